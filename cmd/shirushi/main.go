@@ -9,8 +9,10 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/logging"
 	"github.com/keanuklestil/shirushi/internal/nak"
 	"github.com/keanuklestil/shirushi/internal/relay"
 	"github.com/keanuklestil/shirushi/internal/testing"
@@ -21,13 +23,16 @@ func main() {
 	flag.Parse()
 
 	log.SetFlags(log.Ltime | log.Lmicroseconds)
-	log.Println("Shirushi - Nostr Protocol Explorer")
-	log.Println("===================================")
+	logging.Infof("Shirushi - Nostr Protocol Explorer")
+	logging.Infof("===================================")
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		logging.Fatalf("Failed to load config: %v", err)
+	}
+	if level, err := logging.ParseLevel(cfg.LogLevel); err == nil {
+		logging.SetLevel(level)
 	}
 
 	// Create context with cancellation
@@ -39,7 +44,7 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		log.Println("\nShutting down...")
+		logging.Infof("Shutting down...")
 		cancel()
 	}()
 
@@ -49,22 +54,58 @@ func main() {
 		nakClient = nak.New(cfg.NakPath)
 		version, err := nakClient.Version()
 		if err != nil {
-			log.Printf("[nak] Found at %s (version check failed: %v)", cfg.NakPath, err)
+			logging.Warnf("[nak] Found at %s (version check failed: %v)", cfg.NakPath, err)
 		} else {
-			log.Printf("[nak] Found at %s (%s)", cfg.NakPath, version)
+			logging.Infof("[nak] Found at %s (%s)", cfg.NakPath, version)
 		}
 	} else {
-		log.Println("[nak] CLI not found - some features will be limited")
-		log.Println("[nak] Install from: https://github.com/fiatjaf/nak")
+		logging.Warnf("[nak] CLI not found - some features will be limited")
+		logging.Infof("[nak] Install from: https://github.com/fiatjaf/nak")
 	}
 
 	// Initialize relay pool
-	relayPool := relay.NewPool(cfg.DefaultRelays)
-	log.Printf("[Relays] Default: %v", cfg.DefaultRelays)
+	var readAuthKey string
+	if cfg.ReadAuthEnabled() {
+		readAuthKey = cfg.ReadAuthKey
+	}
+	var pruneAfter time.Duration
+	if cfg.PruneDeadRelays {
+		pruneAfter = cfg.PruneAfter
+	}
+	var maxDiscovered int
+	if cfg.DiscoverRelays {
+		maxDiscovered = cfg.MaxDiscoveredRelays
+	}
+	relayPool := relay.NewPoolWithOptions(cfg.DefaultRelays, relay.PoolOptions{
+		KeepAliveInterval:    cfg.KeepAliveInterval,
+		EventCacheSize:       cfg.EventCacheSize,
+		CacheableKinds:       cfg.CacheableKinds,
+		InfoCacheSize:        cfg.InfoCacheSize,
+		QueryTimeout:         cfg.QueryTimeout,
+		ConnectTimeout:       cfg.ConnectTimeout,
+		InfoFetchTimeout:     cfg.InfoFetchTimeout,
+		ProbeTestKey:         cfg.ProbeTestKey,
+		DrainTimeout:         cfg.DrainTimeout,
+		MaxConcurrentQueries: cfg.MaxConcurrentQueries,
+		ReadAuthKey:          readAuthKey,
+		PruneAfter:           pruneAfter,
+		MaxTrackedKinds:      cfg.MaxTrackedKinds,
+		EOSETimeoutPenalty:   cfg.EOSETimeoutPenalty,
+		MaxDiscoveredRelays:  maxDiscovered,
+		UserAgent:            cfg.UserAgent,
+		Origin:               cfg.Origin,
+		BlockedRelays:        cfg.BlockedRelays,
+		AllowlistOnly:        cfg.AllowlistMode,
+		AllowedRelays:        cfg.AllowedRelays,
+	})
+	logging.Infof("[Relays] Default: %v", cfg.DefaultRelays)
+	if cfg.StartupWait > 0 && len(cfg.DefaultRelays) > 0 {
+		waitForRelays(ctx, relayPool, cfg.StartupWait)
+	}
 
 	// Initialize test runner
 	testRunner := testing.NewRunner(nakClient, relayPool)
-	log.Printf("[Testing] %d NIP tests available", len(testRunner.ListTests()))
+	logging.Infof("[Testing] %d NIP tests available", len(testRunner.ListTests()))
 
 	// Create API handler
 	api := web.NewAPI(cfg, nakClient, relayPool, testRunner)
@@ -75,26 +116,40 @@ func main() {
 	var webFS fs.FS
 	if cfg.Production {
 		webFS, _ = fs.Sub(os.DirFS("web/dist"), ".")
-		log.Println("[Web] Production mode: serving from web/dist/")
+		logging.Infof("[Web] Production mode: serving from web/dist/")
 	} else {
 		webFS, _ = fs.Sub(os.DirFS("web"), ".")
-		log.Println("[Web] Development mode: serving from web/")
+		logging.Infof("[Web] Development mode: serving from web/")
 	}
 	server := web.NewServer(cfg.WebAddr, webFS, api)
 
-	log.Printf("[Web] Dashboard: http://localhost%s", cfg.WebAddr)
-	log.Println()
-	log.Println("Ready! Open the dashboard in your browser.")
+	logging.Infof("[Web] Dashboard: http://localhost%s", cfg.WebAddr)
+	logging.Infof("Ready! Open the dashboard in your browser.")
 
 	// Start server (blocks)
 	go func() {
 		if err := server.Start(); err != nil {
-			log.Fatalf("[Web] Server error: %v", err)
+			logging.Fatalf("[Web] Server error: %v", err)
 		}
 	}()
 
 	// Wait for shutdown
 	<-ctx.Done()
 	relayPool.Close()
-	log.Println("Shutdown complete")
+	logging.Infof("Shutdown complete")
+}
+
+// waitForRelays blocks until a relay connects or timeout passes, logging
+// how startup went. Serving starts either way.
+func waitForRelays(ctx context.Context, pool *relay.Pool, timeout time.Duration) {
+	logging.Infof("[Relays] Waiting up to %s for a relay to connect...", timeout)
+	start := time.Now()
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := pool.WaitForConnection(waitCtx); err != nil {
+		logging.Warnf("[Relays] No relay connected after %s; starting anyway", timeout)
+		return
+	}
+	logging.Infof("[Relays] %d/%d connected after %s", len(pool.GetConnected()), pool.Count(), time.Since(start).Round(time.Millisecond))
 }
@@ -3,10 +3,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"io/fs"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"testing/fstest"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"github.com/keanuklestil/shirushi/internal/config"
 	"github.com/keanuklestil/shirushi/internal/relay"
 	shtesting "github.com/keanuklestil/shirushi/internal/testing"
+	"github.com/keanuklestil/shirushi/internal/types"
 	"github.com/keanuklestil/shirushi/internal/web"
 )
 
@@ -169,6 +172,7 @@ func TestAPIEndpointsAvailable(t *testing.T) {
 		"/api/status",
 		"/api/relays",
 		"/api/nips",
+		"/api/test",
 	}
 
 	for _, endpoint := range endpoints {
@@ -192,6 +196,62 @@ func TestAPIEndpointsAvailable(t *testing.T) {
 	}
 }
 
+// TestTestListDescribesRegisteredTests verifies that the registered NIP tests
+// are listed with their parameter descriptors.
+func TestTestListDescribesRegisteredTests(t *testing.T) {
+	relayPool := relay.NewPool(nil)
+	defer relayPool.Close()
+
+	testRunner := shtesting.NewRunner(nil, relayPool)
+	api := web.NewAPI(&config.Config{}, nil, relayPool, testRunner)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	w := httptest.NewRecorder()
+	api.HandleTestList(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var tests []types.TestDescriptor
+	if err := json.NewDecoder(w.Body).Decode(&tests); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	byID := make(map[string]types.TestDescriptor)
+	for _, test := range tests {
+		byID[test.ID] = test
+	}
+
+	nip01, ok := byID["nip01"]
+	if !ok {
+		t.Fatal("Expected nip01 to be listed")
+	}
+	var signingMode *types.TestParam
+	for i := range nip01.Params {
+		if nip01.Params[i].Name == "signingMode" {
+			signingMode = &nip01.Params[i]
+		}
+	}
+	if signingMode == nil {
+		t.Fatalf("Expected nip01 to describe signingMode, got %+v", nip01.Params)
+	}
+	if signingMode.Type != "enum" || len(signingMode.Options) != 3 || signingMode.Default != "generated" {
+		t.Errorf("Unexpected signingMode descriptor: %+v", signingMode)
+	}
+
+	nip05, ok := byID["nip05"]
+	if !ok {
+		t.Fatal("Expected nip05 to be listed")
+	}
+	if len(nip05.Params) != 1 || nip05.Params[0].Name != "address" || nip05.Params[0].Type != "string" {
+		t.Errorf("Expected nip05 to describe its address parameter, got %+v", nip05.Params)
+	}
+	if nip05.Name == "" || nip05.Description == "" {
+		t.Errorf("Expected nip05 to have a name and description, got %+v", nip05)
+	}
+}
+
 // testFS implements fs.FS for testing
 var _ fs.FS = fstest.MapFS{}
 
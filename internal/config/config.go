@@ -6,7 +6,11 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/logging"
 )
 
 // Config holds all application configuration
@@ -15,6 +19,132 @@ type Config struct {
 	WebAddr       string
 	DefaultRelays []string
 	Production    bool // When true, serve from web/dist/ instead of web/
+
+	// DefaultRelaysFile is a newline-delimited list of default relays, used
+	// when DEFAULT_RELAYS is not set.
+	DefaultRelaysFile string
+
+	// RelayGroupsFile is where user-defined relay groups are persisted
+	RelayGroupsFile string
+
+	// DefaultQueryLimit is the event query limit used when a request sets
+	// none, and MaxQueryLimit caps any requested limit.
+	DefaultQueryLimit int
+	MaxQueryLimit     int
+
+	// KindQueryLimits maps an event kind to its default query limit. It applies
+	// when a query requests exactly one kind without an explicit limit.
+	KindQueryLimits map[int]int
+
+	// KeepAliveInterval is how often idle relay connections are exercised
+	// to keep them open. Zero disables keep-alives.
+	KeepAliveInterval time.Duration
+
+	// EventCacheSize is how many events are cached for lookups by ID.
+	// Zero disables the cache.
+	EventCacheSize int
+
+	// CacheableKinds limits the event cache to these kinds. Empty means all
+	// kinds are cacheable; ephemeral kinds are never cached.
+	CacheableKinds []int
+
+	// InfoCacheSize is how many relays' NIP-11 documents are cached before
+	// the least recently used one is evicted.
+	InfoCacheSize int
+
+	// Relay timeouts: how long queries wait for EOSE, how long connecting may
+	// take, and how long NIP-11 info requests may take.
+	QueryTimeout     time.Duration
+	ConnectTimeout   time.Duration
+	InfoFetchTimeout time.Duration
+
+	// ProbeTestKey is a secret key (hex or nsec) used to publish throwaway
+	// ephemeral events when probing relay write capability. Empty disables
+	// write probes.
+	ProbeTestKey string
+
+	// HTTPCacheMaxAge is the Cache-Control max-age sent with profile and
+	// relay info responses. Zero makes clients revalidate every time.
+	HTTPCacheMaxAge time.Duration
+
+	// MaxConcurrentQueries bounds how many relays a single request queries
+	// in parallel.
+	MaxConcurrentQueries int
+
+	// DrainTimeout bounds how long shutdown waits for in-flight relay queries.
+	DrainTimeout time.Duration
+
+	// StartupWait is how long startup waits for a relay to connect before
+	// reporting ready. Zero starts serving immediately.
+	StartupWait time.Duration
+
+	// NakRateLimit is how many /api/nak requests are allowed per minute.
+	// Zero disables rate limiting.
+	NakRateLimit int
+
+	// NakAllowedCommands restricts /api/nak to these nak subcommands. Empty
+	// allows every subcommand.
+	NakAllowedCommands []string
+
+	// LogLevel is the minimum log level: debug, info, warn or error.
+	LogLevel string
+
+	// ReadAuth enables answering NIP-42 AUTH challenges from relays that
+	// restrict reads, signing with ReadAuthKey (hex or nsec). Both must be
+	// set for queries to authenticate.
+	ReadAuth    bool
+	ReadAuthKey string
+
+	// MaxBodyBytes caps the size of HTTP request bodies. Larger requests are
+	// rejected with 413 Request Entity Too Large.
+	MaxBodyBytes int64
+
+	// PruneDeadRelays enables removing relays from the pool once they have
+	// kept failing to connect for PruneAfter.
+	PruneDeadRelays bool
+	PruneAfter      time.Duration
+
+	// MaxTrackedKinds bounds how many event kinds get their own event rate
+	// history in monitoring. Zero disables per-kind tracking.
+	MaxTrackedKinds int
+
+	// EOSETimeoutPenalty weights the health score penalty for connected
+	// relays whose queries keep timing out without EOSE (0-1; 0 disables).
+	EOSETimeoutPenalty float64
+
+	// DiscoverRelays enables collecting relay hints from the tags of queried
+	// events, remembering up to MaxDiscoveredRelays relays.
+	DiscoverRelays      bool
+	MaxDiscoveredRelays int
+
+	// ImageProxy enables /api/proxy/image, which fetches remote images such
+	// as profile pictures server-side. Images larger than ImageProxyMaxBytes
+	// are refused.
+	ImageProxy         bool
+	ImageProxyMaxBytes int64
+
+	// UserAgent and Origin are sent as headers when connecting to relays,
+	// for relays that gate on them. Origin is empty by default.
+	UserAgent string
+	Origin    string
+
+	// BlockedRelays lists relays never to connect to: full relay URLs, or
+	// hosts such as relay.evil.example or *.evil.example for any subdomain.
+	BlockedRelays []string
+
+	// AllowlistMode restricts every relay connection to AllowedRelays, in
+	// the same format as BlockedRelays, for locked-down deployments.
+	AllowlistMode bool
+	AllowedRelays []string
+
+	// CORSAllowedOrigins lists the origins (e.g. https://dash.example.com)
+	// allowed to call the API from a browser. Empty keeps the API
+	// same-origin only.
+	CORSAllowedOrigins []string
+
+	// APIToken, when set, is required as "Authorization: Bearer <token>" on
+	// every /api/ request. Empty leaves the API open.
+	APIToken string
 }
 
 // RelayPresets defines preset relay groups (all free public relays)
@@ -30,6 +160,28 @@ func Load() (*Config, error) {
 	cfg := &Config{
 		WebAddr:       ":8080",
 		DefaultRelays: []string{"wss://relay.damus.io", "wss://nos.lol"},
+
+		RelayGroupsFile:      defaultRelayGroupsFile(),
+		DefaultQueryLimit:    20,
+		MaxQueryLimit:        500,
+		KeepAliveInterval:    60 * time.Second,
+		EventCacheSize:       1000,
+		InfoCacheSize:        500,
+		QueryTimeout:         10 * time.Second,
+		ConnectTimeout:       10 * time.Second,
+		InfoFetchTimeout:     7 * time.Second,
+		DrainTimeout:         5 * time.Second,
+		MaxConcurrentQueries: 16,
+		HTTPCacheMaxAge:      60 * time.Second,
+		NakRateLimit:         30,
+		LogLevel:             "info",
+		MaxBodyBytes:         1 << 20,
+		PruneAfter:           time.Hour,
+		MaxTrackedKinds:      50,
+		EOSETimeoutPenalty:   0.3,
+		MaxDiscoveredRelays:  200,
+		UserAgent:            "Shirushi",
+		ImageProxyMaxBytes:   5 << 20,
 	}
 
 	// Load .env file if it exists
@@ -49,8 +201,17 @@ func Load() (*Config, error) {
 		cfg.WebAddr = addr
 	}
 
+	// Default relays: DEFAULT_RELAYS, then DEFAULT_RELAYS_FILE, then the
+	// built-in list.
+	cfg.DefaultRelaysFile = os.Getenv("DEFAULT_RELAYS_FILE")
 	if relays := os.Getenv("DEFAULT_RELAYS"); relays != "" {
-		cfg.DefaultRelays = parseRelays(relays)
+		cfg.DefaultRelays = validRelayURLs(parseRelays(relays))
+	} else if cfg.DefaultRelaysFile != "" {
+		relays, err := loadRelaysFile(cfg.DefaultRelaysFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading default relays: %w", err)
+		}
+		cfg.DefaultRelays = validRelayURLs(relays)
 	}
 
 	// Production mode - serve from web/dist/
@@ -58,6 +219,184 @@ func Load() (*Config, error) {
 		cfg.Production = true
 	}
 
+	if groupsFile := os.Getenv("RELAY_GROUPS_FILE"); groupsFile != "" {
+		cfg.RelayGroupsFile = groupsFile
+	}
+
+	if limit := os.Getenv("DEFAULT_QUERY_LIMIT"); limit != "" {
+		if n, err := strconv.Atoi(limit); err == nil && n > 0 {
+			cfg.DefaultQueryLimit = n
+		}
+	}
+
+	if limit := os.Getenv("MAX_QUERY_LIMIT"); limit != "" {
+		if n, err := strconv.Atoi(limit); err == nil && n > 0 {
+			cfg.MaxQueryLimit = n
+		}
+	}
+
+	if kindLimits := os.Getenv("KIND_QUERY_LIMITS"); kindLimits != "" {
+		cfg.KindQueryLimits = parseKindLimits(kindLimits)
+	}
+
+	if keepAlive := os.Getenv("KEEPALIVE_INTERVAL"); keepAlive != "" {
+		if d, ok := parseDuration(keepAlive); ok {
+			cfg.KeepAliveInterval = d
+		}
+	}
+
+	if timeout := os.Getenv("QUERY_TIMEOUT"); timeout != "" {
+		if d, ok := parseDuration(timeout); ok && d > 0 {
+			cfg.QueryTimeout = d
+		}
+	}
+
+	if timeout := os.Getenv("CONNECT_TIMEOUT"); timeout != "" {
+		if d, ok := parseDuration(timeout); ok && d > 0 {
+			cfg.ConnectTimeout = d
+		}
+	}
+
+	if timeout := os.Getenv("INFO_FETCH_TIMEOUT"); timeout != "" {
+		if d, ok := parseDuration(timeout); ok && d > 0 {
+			cfg.InfoFetchTimeout = d
+		}
+	}
+
+	if timeout := os.Getenv("DRAIN_TIMEOUT"); timeout != "" {
+		if d, ok := parseDuration(timeout); ok && d > 0 {
+			cfg.DrainTimeout = d
+		}
+	}
+
+	if wait := os.Getenv("STARTUP_WAIT"); wait != "" {
+		if d, ok := parseDuration(wait); ok {
+			cfg.StartupWait = d
+		}
+	}
+
+	if maxAge := os.Getenv("HTTP_CACHE_MAX_AGE"); maxAge != "" {
+		if d, ok := parseDuration(maxAge); ok {
+			cfg.HTTPCacheMaxAge = d
+		}
+	}
+
+	if maxQueries := os.Getenv("MAX_CONCURRENT_QUERIES"); maxQueries != "" {
+		if n, err := strconv.Atoi(maxQueries); err == nil && n > 0 {
+			cfg.MaxConcurrentQueries = n
+		}
+	}
+
+	if cacheSize := os.Getenv("EVENT_CACHE_SIZE"); cacheSize != "" {
+		if size, err := strconv.Atoi(cacheSize); err == nil && size >= 0 {
+			cfg.EventCacheSize = size
+		}
+	}
+
+	if infoCacheSize := os.Getenv("INFO_CACHE_SIZE"); infoCacheSize != "" {
+		if size, err := strconv.Atoi(infoCacheSize); err == nil && size > 0 {
+			cfg.InfoCacheSize = size
+		}
+	}
+
+	if cacheKinds := os.Getenv("CACHEABLE_KINDS"); cacheKinds != "" {
+		cfg.CacheableKinds = parseKinds(cacheKinds)
+	}
+
+	cfg.ProbeTestKey = os.Getenv("PROBE_TEST_KEY")
+
+	if rateLimit := os.Getenv("NAK_RATE_LIMIT"); rateLimit != "" {
+		if n, err := strconv.Atoi(rateLimit); err == nil && n >= 0 {
+			cfg.NakRateLimit = n
+		}
+	}
+
+	if commands := os.Getenv("NAK_ALLOWED_COMMANDS"); commands != "" {
+		cfg.NakAllowedCommands = parseList(commands)
+	}
+
+	if readAuth := os.Getenv("READ_AUTH"); readAuth == "true" || readAuth == "1" {
+		cfg.ReadAuth = true
+	}
+	cfg.ReadAuthKey = os.Getenv("READ_AUTH_KEY")
+
+	if maxBody := os.Getenv("MAX_BODY_BYTES"); maxBody != "" {
+		if n, err := strconv.ParseInt(maxBody, 10, 64); err == nil && n > 0 {
+			cfg.MaxBodyBytes = n
+		}
+	}
+
+	if prune := os.Getenv("PRUNE_DEAD_RELAYS"); prune == "true" || prune == "1" {
+		cfg.PruneDeadRelays = true
+	}
+
+	if pruneAfter := os.Getenv("PRUNE_AFTER"); pruneAfter != "" {
+		if d, ok := parseDuration(pruneAfter); ok && d > 0 {
+			cfg.PruneAfter = d
+		}
+	}
+
+	if maxKinds := os.Getenv("MAX_TRACKED_KINDS"); maxKinds != "" {
+		if n, err := strconv.Atoi(maxKinds); err == nil && n >= 0 {
+			cfg.MaxTrackedKinds = n
+		}
+	}
+
+	if penalty := os.Getenv("EOSE_TIMEOUT_PENALTY"); penalty != "" {
+		if f, err := strconv.ParseFloat(penalty, 64); err == nil && f >= 0 && f <= 1 {
+			cfg.EOSETimeoutPenalty = f
+		}
+	}
+
+	if discover := os.Getenv("DISCOVER_RELAYS"); discover == "true" || discover == "1" {
+		cfg.DiscoverRelays = true
+	}
+
+	if maxDiscovered := os.Getenv("MAX_DISCOVERED_RELAYS"); maxDiscovered != "" {
+		if n, err := strconv.Atoi(maxDiscovered); err == nil && n > 0 {
+			cfg.MaxDiscoveredRelays = n
+		}
+	}
+
+	if proxy := os.Getenv("IMAGE_PROXY"); proxy == "true" || proxy == "1" {
+		cfg.ImageProxy = true
+	}
+
+	if maxBytes := os.Getenv("IMAGE_PROXY_MAX_BYTES"); maxBytes != "" {
+		if n, err := strconv.ParseInt(maxBytes, 10, 64); err == nil && n > 0 {
+			cfg.ImageProxyMaxBytes = n
+		}
+	}
+
+	if userAgent := os.Getenv("RELAY_USER_AGENT"); userAgent != "" {
+		cfg.UserAgent = userAgent
+	}
+
+	cfg.Origin = os.Getenv("RELAY_ORIGIN")
+
+	if blocked := os.Getenv("BLOCKED_RELAYS"); blocked != "" {
+		cfg.BlockedRelays = parseList(blocked)
+	}
+
+	if mode := os.Getenv("RELAY_ALLOWLIST_MODE"); mode == "true" || mode == "1" {
+		cfg.AllowlistMode = true
+	}
+	if allowed := os.Getenv("ALLOWED_RELAYS"); allowed != "" {
+		cfg.AllowedRelays = parseList(allowed)
+	}
+
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		cfg.CORSAllowedOrigins = parseList(origins)
+	}
+
+	cfg.APIToken = os.Getenv("API_TOKEN")
+
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		if parsed, err := logging.ParseLevel(level); err == nil {
+			cfg.LogLevel = parsed.String()
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -94,6 +433,12 @@ func (c *Config) HasNak() bool {
 	return c.NakPath != ""
 }
 
+// ReadAuthEnabled reports whether queries may authenticate to relays that
+// restrict reads: the flag is on and a key is provided.
+func (c *Config) ReadAuthEnabled() bool {
+	return c.ReadAuth && c.ReadAuthKey != ""
+}
+
 func loadEnvFile(filename string) error {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -137,12 +482,105 @@ func loadEnvFile(filename string) error {
 }
 
 func parseRelays(relaysStr string) []string {
+	return parseList(relaysStr)
+}
+
+// loadRelaysFile reads relay URLs from a file with one relay per line.
+// Blank lines and lines starting with # are ignored.
+func loadRelaysFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
 	var relays []string
-	for _, r := range strings.Split(relaysStr, ",") {
-		r = strings.TrimSpace(r)
-		if r != "" {
-			relays = append(relays, r)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		relays = append(relays, line)
+	}
+	return relays, scanner.Err()
+}
+
+// parseList splits a comma-separated value, dropping empty entries.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validRelayURLs returns the relays that are well-formed ws:// or wss:// URLs,
+// normalized. Invalid entries are logged and skipped.
+func validRelayURLs(relays []string) []string {
+	valid := make([]string, 0, len(relays))
+	for _, r := range relays {
+		normalized, err := NormalizeRelayURL(r)
+		if err != nil {
+			logging.Warnf("[Config] Skipping default relay: %v", err)
+			continue
+		}
+		valid = append(valid, normalized)
+	}
+	return valid
+}
+
+// parseDuration parses a non-negative duration given either as whole seconds
+// ("10") or as a Go duration string ("1500ms", "2m").
+func parseDuration(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// parseKinds parses a comma-separated list of event kinds, skipping invalid entries.
+func parseKinds(kindsStr string) []int {
+	var kinds []int
+	for _, entry := range strings.Split(kindsStr, ",") {
+		kind, err := strconv.Atoi(strings.TrimSpace(entry))
+		if err != nil || kind < 0 {
+			continue
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+// parseKindLimits parses "kind:limit" pairs separated by commas (e.g. "7:200,30023:5").
+// Malformed or non-positive entries are skipped.
+func parseKindLimits(limitsStr string) map[int]int {
+	limits := make(map[int]int)
+	for _, entry := range strings.Split(limitsStr, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		kind, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || kind < 0 {
+			continue
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || limit < 1 {
+			continue
 		}
+		limits[kind] = limit
 	}
-	return relays
+	return limits
 }
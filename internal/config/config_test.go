@@ -3,7 +3,10 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestConfig_ProductionMode(t *testing.T) {
@@ -88,3 +91,601 @@ func TestConfig_DefaultValues(t *testing.T) {
 		t.Errorf("DefaultRelays length = %v, want 2", len(cfg.DefaultRelays))
 	}
 }
+
+func TestConfig_KindQueryLimits(t *testing.T) {
+	os.Setenv("KIND_QUERY_LIMITS", "7:200, 30023:5,bad,1:0,x:3")
+	defer os.Unsetenv("KIND_QUERY_LIMITS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(cfg.KindQueryLimits) != 2 {
+		t.Fatalf("KindQueryLimits = %v, want 2 entries", cfg.KindQueryLimits)
+	}
+	if cfg.KindQueryLimits[7] != 200 {
+		t.Errorf("KindQueryLimits[7] = %d, want 200", cfg.KindQueryLimits[7])
+	}
+	if cfg.KindQueryLimits[30023] != 5 {
+		t.Errorf("KindQueryLimits[30023] = %d, want 5", cfg.KindQueryLimits[30023])
+	}
+}
+
+func TestConfig_KeepAliveInterval(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.KeepAliveInterval != 60*time.Second {
+		t.Errorf("KeepAliveInterval default = %v, want 60s", cfg.KeepAliveInterval)
+	}
+
+	os.Setenv("KEEPALIVE_INTERVAL", "0")
+	defer os.Unsetenv("KEEPALIVE_INTERVAL")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.KeepAliveInterval != 0 {
+		t.Errorf("KeepAliveInterval = %v, want 0 (disabled)", cfg.KeepAliveInterval)
+	}
+}
+
+func TestConfig_EventCache(t *testing.T) {
+	os.Setenv("EVENT_CACHE_SIZE", "250")
+	os.Setenv("CACHEABLE_KINDS", "1, 30023,bad")
+	defer os.Unsetenv("EVENT_CACHE_SIZE")
+	defer os.Unsetenv("CACHEABLE_KINDS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.EventCacheSize != 250 {
+		t.Errorf("EventCacheSize = %d, want 250", cfg.EventCacheSize)
+	}
+	if len(cfg.CacheableKinds) != 2 || cfg.CacheableKinds[0] != 1 || cfg.CacheableKinds[1] != 30023 {
+		t.Errorf("CacheableKinds = %v, want [1 30023]", cfg.CacheableKinds)
+	}
+}
+
+func TestConfig_Timeouts(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.QueryTimeout != 10*time.Second || cfg.ConnectTimeout != 10*time.Second || cfg.InfoFetchTimeout != 7*time.Second {
+		t.Errorf("unexpected default timeouts: query=%v connect=%v info=%v", cfg.QueryTimeout, cfg.ConnectTimeout, cfg.InfoFetchTimeout)
+	}
+
+	os.Setenv("QUERY_TIMEOUT", "30")
+	os.Setenv("CONNECT_TIMEOUT", "1500ms")
+	os.Setenv("INFO_FETCH_TIMEOUT", "0")
+	os.Setenv("DRAIN_TIMEOUT", "2s")
+	os.Setenv("STARTUP_WAIT", "15")
+	defer os.Unsetenv("QUERY_TIMEOUT")
+	defer os.Unsetenv("CONNECT_TIMEOUT")
+	defer os.Unsetenv("INFO_FETCH_TIMEOUT")
+	defer os.Unsetenv("DRAIN_TIMEOUT")
+	defer os.Unsetenv("STARTUP_WAIT")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.QueryTimeout != 30*time.Second {
+		t.Errorf("QueryTimeout = %v, want 30s", cfg.QueryTimeout)
+	}
+	if cfg.ConnectTimeout != 1500*time.Millisecond {
+		t.Errorf("ConnectTimeout = %v, want 1.5s", cfg.ConnectTimeout)
+	}
+	if cfg.InfoFetchTimeout != 7*time.Second {
+		t.Errorf("InfoFetchTimeout = %v, want default 7s for a zero value", cfg.InfoFetchTimeout)
+	}
+	if cfg.HTTPCacheMaxAge != time.Minute {
+		t.Errorf("HTTPCacheMaxAge = %v, want default 1m", cfg.HTTPCacheMaxAge)
+	}
+	if cfg.DrainTimeout != 2*time.Second {
+		t.Errorf("DrainTimeout = %v, want 2s", cfg.DrainTimeout)
+	}
+	if cfg.StartupWait != 15*time.Second {
+		t.Errorf("StartupWait = %v, want 15s", cfg.StartupWait)
+	}
+}
+
+func TestConfig_DefaultRelaysValidation(t *testing.T) {
+	os.Setenv("DEFAULT_RELAYS", "wss://relay.damus.io, https://nos.lol,relay.example.com,wss://,ws://localhost:7777/,wss://bad host")
+	defer os.Unsetenv("DEFAULT_RELAYS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []string{"wss://relay.damus.io", "ws://localhost:7777"}
+	if len(cfg.DefaultRelays) != len(want) {
+		t.Fatalf("DefaultRelays = %v, want %v", cfg.DefaultRelays, want)
+	}
+	for i := range want {
+		if cfg.DefaultRelays[i] != want[i] {
+			t.Errorf("DefaultRelays[%d] = %s, want %s", i, cfg.DefaultRelays[i], want[i])
+		}
+	}
+}
+
+func TestConfig_DefaultRelaysSources(t *testing.T) {
+	relaysFile := filepath.Join(t.TempDir(), "relays.txt")
+	contents := "# production relays\nwss://relay.file.example\n\n  wss://other.file.example/  \nnot a relay\n"
+	if err := os.WriteFile(relaysFile, []byte(contents), 0o644); err != nil {
+		t.Fatalf("failed to write relays file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		env  string
+		file string
+		want []string
+	}{
+		{"built-in defaults", "", "", []string{"wss://relay.damus.io", "wss://nos.lol"}},
+		{"file", "", relaysFile, []string{"wss://relay.file.example", "wss://other.file.example"}},
+		{"env", "wss://relay.env.example", "", []string{"wss://relay.env.example"}},
+		{"env over file", "wss://relay.env.example", relaysFile, []string{"wss://relay.env.example"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("DEFAULT_RELAYS", tt.env)
+			os.Setenv("DEFAULT_RELAYS_FILE", tt.file)
+			defer os.Unsetenv("DEFAULT_RELAYS")
+			defer os.Unsetenv("DEFAULT_RELAYS_FILE")
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.DefaultRelays, tt.want) {
+				t.Errorf("DefaultRelays = %v, want %v", cfg.DefaultRelays, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_DefaultRelaysFileMissing(t *testing.T) {
+	os.Unsetenv("DEFAULT_RELAYS")
+	os.Setenv("DEFAULT_RELAYS_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+	defer os.Unsetenv("DEFAULT_RELAYS_FILE")
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected an error for a missing default relays file")
+	}
+}
+
+func TestConfig_NakLimits(t *testing.T) {
+	os.Unsetenv("NAK_RATE_LIMIT")
+	os.Unsetenv("NAK_ALLOWED_COMMANDS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.NakRateLimit != 30 {
+		t.Errorf("default NakRateLimit = %d, want 30", cfg.NakRateLimit)
+	}
+	if len(cfg.NakAllowedCommands) != 0 {
+		t.Errorf("default NakAllowedCommands = %v, want empty", cfg.NakAllowedCommands)
+	}
+
+	os.Setenv("NAK_RATE_LIMIT", "5")
+	os.Setenv("NAK_ALLOWED_COMMANDS", "decode, encode,,event")
+	defer os.Unsetenv("NAK_RATE_LIMIT")
+	defer os.Unsetenv("NAK_ALLOWED_COMMANDS")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.NakRateLimit != 5 {
+		t.Errorf("NakRateLimit = %d, want 5", cfg.NakRateLimit)
+	}
+	want := []string{"decode", "encode", "event"}
+	if len(cfg.NakAllowedCommands) != len(want) {
+		t.Fatalf("NakAllowedCommands = %v, want %v", cfg.NakAllowedCommands, want)
+	}
+	for i, cmd := range want {
+		if cfg.NakAllowedCommands[i] != cmd {
+			t.Errorf("NakAllowedCommands[%d] = %q, want %q", i, cfg.NakAllowedCommands[i], cmd)
+		}
+	}
+}
+
+func TestConfig_LogLevel(t *testing.T) {
+	os.Unsetenv("LOG_LEVEL")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.LogLevel != "info" {
+		t.Errorf("default LogLevel = %q, want info", cfg.LogLevel)
+	}
+
+	os.Setenv("LOG_LEVEL", "DEBUG")
+	defer os.Unsetenv("LOG_LEVEL")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Errorf("LogLevel = %q, want debug", cfg.LogLevel)
+	}
+
+	os.Setenv("LOG_LEVEL", "verbose")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.LogLevel != "info" {
+		t.Errorf("invalid LOG_LEVEL should keep default, got %q", cfg.LogLevel)
+	}
+}
+
+func TestConfig_MaxConcurrentQueries(t *testing.T) {
+	os.Unsetenv("MAX_CONCURRENT_QUERIES")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxConcurrentQueries != 16 {
+		t.Errorf("default MaxConcurrentQueries = %d, want 16", cfg.MaxConcurrentQueries)
+	}
+
+	os.Setenv("MAX_CONCURRENT_QUERIES", "4")
+	defer os.Unsetenv("MAX_CONCURRENT_QUERIES")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxConcurrentQueries != 4 {
+		t.Errorf("MaxConcurrentQueries = %d, want 4", cfg.MaxConcurrentQueries)
+	}
+}
+
+func TestConfig_InfoCacheSize(t *testing.T) {
+	os.Unsetenv("INFO_CACHE_SIZE")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.InfoCacheSize != 500 {
+		t.Errorf("default InfoCacheSize = %d, want 500", cfg.InfoCacheSize)
+	}
+
+	os.Setenv("INFO_CACHE_SIZE", "50")
+	defer os.Unsetenv("INFO_CACHE_SIZE")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.InfoCacheSize != 50 {
+		t.Errorf("InfoCacheSize = %d, want 50", cfg.InfoCacheSize)
+	}
+}
+
+func TestConfig_QueryLimits(t *testing.T) {
+	os.Unsetenv("DEFAULT_QUERY_LIMIT")
+	os.Unsetenv("MAX_QUERY_LIMIT")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.DefaultQueryLimit != 20 || cfg.MaxQueryLimit != 500 {
+		t.Errorf("default limits = %d/%d, want 20/500", cfg.DefaultQueryLimit, cfg.MaxQueryLimit)
+	}
+
+	os.Setenv("DEFAULT_QUERY_LIMIT", "50")
+	os.Setenv("MAX_QUERY_LIMIT", "200")
+	defer os.Unsetenv("DEFAULT_QUERY_LIMIT")
+	defer os.Unsetenv("MAX_QUERY_LIMIT")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.DefaultQueryLimit != 50 || cfg.MaxQueryLimit != 200 {
+		t.Errorf("limits = %d/%d, want 50/200", cfg.DefaultQueryLimit, cfg.MaxQueryLimit)
+	}
+}
+
+func TestConfig_MaxBodyBytes(t *testing.T) {
+	os.Unsetenv("MAX_BODY_BYTES")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxBodyBytes != 1<<20 {
+		t.Errorf("default MaxBodyBytes = %d, want %d", cfg.MaxBodyBytes, 1<<20)
+	}
+
+	os.Setenv("MAX_BODY_BYTES", "4096")
+	defer os.Unsetenv("MAX_BODY_BYTES")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxBodyBytes != 4096 {
+		t.Errorf("MaxBodyBytes = %d, want 4096", cfg.MaxBodyBytes)
+	}
+}
+
+func TestConfig_ReadAuth(t *testing.T) {
+	os.Unsetenv("READ_AUTH")
+	os.Unsetenv("READ_AUTH_KEY")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ReadAuthEnabled() {
+		t.Error("read auth should be disabled by default")
+	}
+
+	os.Setenv("READ_AUTH", "true")
+	defer os.Unsetenv("READ_AUTH")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.ReadAuth || cfg.ReadAuthEnabled() {
+		t.Error("read auth should stay disabled without a key")
+	}
+
+	os.Setenv("READ_AUTH_KEY", "nsec1example")
+	defer os.Unsetenv("READ_AUTH_KEY")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.ReadAuthEnabled() || cfg.ReadAuthKey != "nsec1example" {
+		t.Errorf("expected read auth enabled with the key, got %v/%q", cfg.ReadAuthEnabled(), cfg.ReadAuthKey)
+	}
+}
+
+func TestConfig_PruneDeadRelays(t *testing.T) {
+	os.Unsetenv("PRUNE_DEAD_RELAYS")
+	os.Unsetenv("PRUNE_AFTER")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.PruneDeadRelays {
+		t.Error("pruning should be disabled by default")
+	}
+	if cfg.PruneAfter != time.Hour {
+		t.Errorf("PruneAfter = %v, want 1h", cfg.PruneAfter)
+	}
+
+	os.Setenv("PRUNE_DEAD_RELAYS", "true")
+	os.Setenv("PRUNE_AFTER", "30m")
+	defer os.Unsetenv("PRUNE_DEAD_RELAYS")
+	defer os.Unsetenv("PRUNE_AFTER")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.PruneDeadRelays || cfg.PruneAfter != 30*time.Minute {
+		t.Errorf("expected pruning after 30m, got %v/%v", cfg.PruneDeadRelays, cfg.PruneAfter)
+	}
+}
+
+func TestConfig_MaxTrackedKinds(t *testing.T) {
+	os.Unsetenv("MAX_TRACKED_KINDS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxTrackedKinds != 50 {
+		t.Errorf("MaxTrackedKinds = %d, want 50", cfg.MaxTrackedKinds)
+	}
+
+	os.Setenv("MAX_TRACKED_KINDS", "0")
+	defer os.Unsetenv("MAX_TRACKED_KINDS")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxTrackedKinds != 0 {
+		t.Errorf("MaxTrackedKinds = %d, want 0 to disable tracking", cfg.MaxTrackedKinds)
+	}
+}
+
+func TestConfig_EOSETimeoutPenalty(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"", 0.3},
+		{"0", 0},
+		{"0.5", 0.5},
+		{"1.5", 0.3},
+		{"-0.1", 0.3},
+		{"heavy", 0.3},
+	}
+
+	for _, tt := range tests {
+		os.Setenv("EOSE_TIMEOUT_PENALTY", tt.value)
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.EOSETimeoutPenalty != tt.want {
+			t.Errorf("EOSE_TIMEOUT_PENALTY=%q: EOSETimeoutPenalty = %v, want %v", tt.value, cfg.EOSETimeoutPenalty, tt.want)
+		}
+	}
+	os.Unsetenv("EOSE_TIMEOUT_PENALTY")
+}
+
+func TestConfig_DiscoverRelays(t *testing.T) {
+	os.Unsetenv("DISCOVER_RELAYS")
+	os.Unsetenv("MAX_DISCOVERED_RELAYS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.DiscoverRelays {
+		t.Error("expected relay discovery to be off by default")
+	}
+	if cfg.MaxDiscoveredRelays != 200 {
+		t.Errorf("MaxDiscoveredRelays = %d, want 200", cfg.MaxDiscoveredRelays)
+	}
+
+	os.Setenv("DISCOVER_RELAYS", "true")
+	os.Setenv("MAX_DISCOVERED_RELAYS", "25")
+	defer os.Unsetenv("DISCOVER_RELAYS")
+	defer os.Unsetenv("MAX_DISCOVERED_RELAYS")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.DiscoverRelays || cfg.MaxDiscoveredRelays != 25 {
+		t.Errorf("expected discovery of up to 25 relays, got %v/%d", cfg.DiscoverRelays, cfg.MaxDiscoveredRelays)
+	}
+}
+
+func TestConfig_ImageProxy(t *testing.T) {
+	os.Unsetenv("IMAGE_PROXY")
+	os.Unsetenv("IMAGE_PROXY_MAX_BYTES")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ImageProxy {
+		t.Error("expected the image proxy to be off by default")
+	}
+	if cfg.ImageProxyMaxBytes != 5<<20 {
+		t.Errorf("ImageProxyMaxBytes = %d, want %d", cfg.ImageProxyMaxBytes, 5<<20)
+	}
+
+	os.Setenv("IMAGE_PROXY", "true")
+	os.Setenv("IMAGE_PROXY_MAX_BYTES", "65536")
+	defer os.Unsetenv("IMAGE_PROXY")
+	defer os.Unsetenv("IMAGE_PROXY_MAX_BYTES")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.ImageProxy || cfg.ImageProxyMaxBytes != 65536 {
+		t.Errorf("expected the proxy enabled with a 64 KiB limit, got %v/%d", cfg.ImageProxy, cfg.ImageProxyMaxBytes)
+	}
+}
+
+func TestConfig_RelayHeaders(t *testing.T) {
+	os.Unsetenv("RELAY_USER_AGENT")
+	os.Unsetenv("RELAY_ORIGIN")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.UserAgent != "Shirushi" || cfg.Origin != "" {
+		t.Errorf("expected default user agent and no origin, got %q/%q", cfg.UserAgent, cfg.Origin)
+	}
+
+	os.Setenv("RELAY_USER_AGENT", "my-monitor/2.0")
+	os.Setenv("RELAY_ORIGIN", "https://monitor.example.com")
+	defer os.Unsetenv("RELAY_USER_AGENT")
+	defer os.Unsetenv("RELAY_ORIGIN")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.UserAgent != "my-monitor/2.0" || cfg.Origin != "https://monitor.example.com" {
+		t.Errorf("got %q/%q, want the configured headers", cfg.UserAgent, cfg.Origin)
+	}
+}
+
+func TestConfig_CORSAllowedOrigins(t *testing.T) {
+	os.Unsetenv("CORS_ALLOWED_ORIGINS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.CORSAllowedOrigins) != 0 {
+		t.Errorf("expected no allowed origins by default, got %v", cfg.CORSAllowedOrigins)
+	}
+
+	os.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com")
+	defer os.Unsetenv("CORS_ALLOWED_ORIGINS")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []string{"https://a.example.com", "https://b.example.com"}
+	if !reflect.DeepEqual(cfg.CORSAllowedOrigins, want) {
+		t.Errorf("CORSAllowedOrigins = %v, want %v", cfg.CORSAllowedOrigins, want)
+	}
+}
+
+func TestConfig_BlockedRelays(t *testing.T) {
+	os.Setenv("BLOCKED_RELAYS", "wss://relay.spam.example, *.evil.example,")
+	defer os.Unsetenv("BLOCKED_RELAYS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []string{"wss://relay.spam.example", "*.evil.example"}
+	if !reflect.DeepEqual(cfg.BlockedRelays, want) {
+		t.Errorf("BlockedRelays = %v, want %v", cfg.BlockedRelays, want)
+	}
+}
+
+func TestConfig_RelayAllowlist(t *testing.T) {
+	os.Unsetenv("RELAY_ALLOWLIST_MODE")
+	os.Unsetenv("ALLOWED_RELAYS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.AllowlistMode || len(cfg.AllowedRelays) != 0 {
+		t.Errorf("expected allowlist mode off by default, got %v %v", cfg.AllowlistMode, cfg.AllowedRelays)
+	}
+
+	os.Setenv("RELAY_ALLOWLIST_MODE", "true")
+	os.Setenv("ALLOWED_RELAYS", "wss://relay.damus.io, *.internal.example")
+	defer os.Unsetenv("RELAY_ALLOWLIST_MODE")
+	defer os.Unsetenv("ALLOWED_RELAYS")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []string{"wss://relay.damus.io", "*.internal.example"}
+	if !cfg.AllowlistMode || !reflect.DeepEqual(cfg.AllowedRelays, want) {
+		t.Errorf("allowlist = %v %v, want on with %v", cfg.AllowlistMode, cfg.AllowedRelays, want)
+	}
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RelayGroup is a user-defined, named set of relay URLs.
+type RelayGroup struct {
+	Name   string   `json:"name"`
+	Relays []string `json:"relays"`
+}
+
+// RelayGroupStore holds user-defined relay groups and persists them to a JSON file.
+// If the store has no path, groups are kept in memory only.
+type RelayGroupStore struct {
+	path   string
+	groups map[string][]string
+	mu     sync.RWMutex
+}
+
+// NewRelayGroupStore creates a store backed by the given file, loading any
+// previously saved groups. A missing file is not an error.
+func NewRelayGroupStore(path string) (*RelayGroupStore, error) {
+	s := &RelayGroupStore{
+		path:   path,
+		groups: make(map[string][]string),
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return s, fmt.Errorf("error reading relay groups: %w", err)
+	}
+
+	var groups []RelayGroup
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return s, fmt.Errorf("error parsing relay groups: %w", err)
+	}
+	for _, g := range groups {
+		s.groups[g.Name] = g.Relays
+	}
+
+	return s, nil
+}
+
+// List returns all saved groups sorted by name.
+func (s *RelayGroupStore) List() []RelayGroup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.listLocked()
+}
+
+// Get returns the relay URLs for a group.
+func (s *RelayGroupStore) Get(name string) ([]string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	relays, ok := s.groups[name]
+	if !ok {
+		return nil, false
+	}
+	result := make([]string, len(relays))
+	copy(result, relays)
+	return result, true
+}
+
+// Set creates or replaces a group and persists the change.
+func (s *RelayGroupStore) Set(name string, relays []string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("group name is required")
+	}
+	if len(relays) == 0 {
+		return fmt.Errorf("group must contain at least one relay")
+	}
+
+	urls := make([]string, 0, len(relays))
+	for _, url := range relays {
+		normalized, err := NormalizeRelayURL(url)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, normalized)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups[name] = urls
+	return s.saveLocked()
+}
+
+// Delete removes a group and persists the change.
+// Returns false if the group did not exist.
+func (s *RelayGroupStore) Delete(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[name]; !ok {
+		return false, nil
+	}
+	delete(s.groups, name)
+	return true, s.saveLocked()
+}
+
+// listLocked returns the groups sorted by name. Caller must hold the mutex.
+func (s *RelayGroupStore) listLocked() []RelayGroup {
+	names := make([]string, 0, len(s.groups))
+	for name := range s.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := make([]RelayGroup, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, RelayGroup{Name: name, Relays: s.groups[name]})
+	}
+	return groups
+}
+
+// saveLocked writes the groups to disk. Caller must hold the write lock.
+func (s *RelayGroupStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("error creating config dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.listLocked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing relay groups: %w", err)
+	}
+	return nil
+}
+
+// defaultRelayGroupsFile returns the default location of the relay groups file.
+func defaultRelayGroupsFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "shirushi", "relay_groups.json")
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRelayGroupStore_PersistsGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shirushi", "relay_groups.json")
+
+	store, err := NewRelayGroupStore(path)
+	if err != nil {
+		t.Fatalf("NewRelayGroupStore() error = %v", err)
+	}
+	if err := store.Set("work", []string{"wss://relay.example.com", "ws://localhost:7777"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("archive", []string{"wss://archive.example.com"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	reloaded, err := NewRelayGroupStore(path)
+	if err != nil {
+		t.Fatalf("NewRelayGroupStore() reload error = %v", err)
+	}
+
+	groups := reloaded.List()
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if groups[0].Name != "archive" || groups[1].Name != "work" {
+		t.Errorf("expected groups sorted by name, got %q and %q", groups[0].Name, groups[1].Name)
+	}
+
+	relays, ok := reloaded.Get("work")
+	if !ok {
+		t.Fatal("expected group 'work' to exist after reload")
+	}
+	if len(relays) != 2 || relays[0] != "wss://relay.example.com" {
+		t.Errorf("unexpected relays for 'work': %v", relays)
+	}
+
+	found, err := reloaded.Delete("work")
+	if err != nil || !found {
+		t.Fatalf("Delete() = %v, %v; want true, nil", found, err)
+	}
+
+	reloaded, _ = NewRelayGroupStore(path)
+	if _, ok := reloaded.Get("work"); ok {
+		t.Error("expected deleted group to stay deleted after reload")
+	}
+}
+
+func TestRelayGroupStore_RejectsInvalidURLs(t *testing.T) {
+	store, _ := NewRelayGroupStore("")
+
+	tests := []struct {
+		name   string
+		relays []string
+	}{
+		{name: "http scheme", relays: []string{"http://relay.example.com"}},
+		{name: "missing scheme", relays: []string{"relay.example.com"}},
+		{name: "one bad url", relays: []string{"wss://ok.example.com", "https://bad.example.com"}},
+		{name: "empty list", relays: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := store.Set("group", tt.relays); err == nil {
+				t.Errorf("Set(%v) expected error, got nil", tt.relays)
+			}
+		})
+	}
+
+	if len(store.List()) != 0 {
+		t.Error("expected no groups to be saved after rejected updates")
+	}
+}
+
+func TestRelayGroupStore_DeleteMissing(t *testing.T) {
+	store, _ := NewRelayGroupStore("")
+
+	found, err := store.Delete("missing")
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if found {
+		t.Error("expected Delete to report missing group")
+	}
+}
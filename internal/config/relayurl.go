@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ValidateRelayURL checks that a relay URL uses the ws:// or wss:// scheme
+// and has a non-empty host.
+func ValidateRelayURL(raw string) error {
+	_, err := NormalizeRelayURL(raw)
+	return err
+}
+
+// NormalizeRelayURL validates a relay URL and returns it in canonical form,
+// with surrounding whitespace and trailing slashes removed, so that
+// "wss://relay.example.com/" and "wss://relay.example.com" refer to the same relay.
+func NormalizeRelayURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "ws://") && !strings.HasPrefix(raw, "wss://") {
+		return "", fmt.Errorf("invalid relay URL %q: must start with ws:// or wss://", raw)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid relay URL %q: %v", raw, err)
+	}
+	if u.Host == "" || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid relay URL %q: missing host", raw)
+	}
+
+	return strings.TrimRight(raw, "/"), nil
+}
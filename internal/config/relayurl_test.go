@@ -0,0 +1,42 @@
+package config
+
+import "testing"
+
+func TestNormalizeRelayURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "wss url", input: "wss://relay.example.com", want: "wss://relay.example.com"},
+		{name: "ws url with port", input: "ws://localhost:7777", want: "ws://localhost:7777"},
+		{name: "trailing slash", input: "wss://relay.example.com/", want: "wss://relay.example.com"},
+		{name: "path kept", input: "wss://relay.example.com/nostr/", want: "wss://relay.example.com/nostr"},
+		{name: "surrounding whitespace", input: "  wss://relay.example.com ", want: "wss://relay.example.com"},
+		{name: "http scheme", input: "http://foo", wantErr: true},
+		{name: "https scheme", input: "https://relay.example.com", wantErr: true},
+		{name: "missing scheme", input: "relay.example.com", wantErr: true},
+		{name: "missing host", input: "wss://", wantErr: true},
+		{name: "port only", input: "wss://:443", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeRelayURL(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NormalizeRelayURL(%q) expected error, got %q", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeRelayURL(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeRelayURL(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
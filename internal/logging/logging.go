@@ -0,0 +1,109 @@
+// Package logging provides a minimal leveled logger on top of the standard
+// log package, so output keeps the flags and destination set up in main.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is a logging severity. Messages below the current level are dropped.
+type Level int32
+
+// Log levels, from most to least verbose.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the lowercase level name as accepted by ParseLevel.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN".
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+var current atomic.Int32
+
+func init() {
+	current.Store(int32(LevelInfo))
+}
+
+// SetLevel sets the minimum level that is logged.
+func SetLevel(l Level) {
+	current.Store(int32(l))
+}
+
+// CurrentLevel returns the minimum level that is logged.
+func CurrentLevel() Level {
+	return Level(current.Load())
+}
+
+// Enabled reports whether messages at level l are logged, so callers can
+// skip building expensive debug output.
+func Enabled(l Level) bool {
+	return l >= CurrentLevel()
+}
+
+// Debugf logs per-query and per-event detail.
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, format, args...)
+}
+
+// Infof logs normal operational messages.
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, format, args...)
+}
+
+// Warnf logs recoverable failures.
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, format, args...)
+}
+
+// Errorf logs failures that need attention.
+func Errorf(format string, args ...interface{}) {
+	logf(LevelError, format, args...)
+}
+
+// Fatalf logs regardless of level and exits, like log.Fatalf.
+func Fatalf(format string, args ...interface{}) {
+	log.Fatalf("ERROR "+format, args...)
+}
+
+// logf writes the message with its level name in front, e.g.
+// "INFO  [Relay] Connected to wss://relay.example.com".
+func logf(l Level, format string, args ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+	log.Output(3, fmt.Sprintf("%-5s %s", strings.ToUpper(l.String()), fmt.Sprintf(format, args...)))
+}
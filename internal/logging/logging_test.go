@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+// captureLog redirects the standard logger into a buffer and restores the
+// logger and level when the test ends.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	out, flags, level := log.Writer(), log.Flags(), CurrentLevel()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+		SetLevel(level)
+	})
+	return &buf
+}
+
+func TestDebugSuppressedAtInfoLevel(t *testing.T) {
+	buf := captureLog(t)
+	SetLevel(LevelInfo)
+
+	Debugf("[Relay] query detail %d", 1)
+	Infof("[Relay] Connected to %s", "wss://relay.example.com")
+
+	out := buf.String()
+	if strings.Contains(out, "query detail") {
+		t.Errorf("debug message logged at info level: %q", out)
+	}
+	if out != "INFO  [Relay] Connected to wss://relay.example.com\n" {
+		t.Errorf("unexpected info output: %q", out)
+	}
+}
+
+func TestDebugLoggedAtDebugLevel(t *testing.T) {
+	buf := captureLog(t)
+	SetLevel(LevelDebug)
+
+	Debugf("[Relay] query detail %d", 1)
+
+	if out := buf.String(); out != "DEBUG [Relay] query detail 1\n" {
+		t.Errorf("unexpected debug output: %q", out)
+	}
+}
+
+func TestWarnLevelSuppressesInfo(t *testing.T) {
+	buf := captureLog(t)
+	SetLevel(LevelWarn)
+
+	Infof("hidden")
+	Warnf("shown")
+	Errorf("also shown")
+
+	if out := buf.String(); out != "WARN  shown\nERROR also shown\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Level
+	}{
+		{"debug", LevelDebug},
+		{"INFO", LevelInfo},
+		{" warn ", LevelWarn},
+		{"warning", LevelWarn},
+		{"error", LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.input)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
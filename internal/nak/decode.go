@@ -16,6 +16,8 @@ type Decoded struct {
 	Relays []string `json:"relays"` // for nevent/nprofile
 	Author string   `json:"author"` // for nevent
 	Kind   int      `json:"kind"`   // for naddr
+
+	Identifier string `json:"identifier,omitempty"` // d tag (for naddr)
 }
 
 // Decode decodes a NIP-19 encoded string.
@@ -0,0 +1,39 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// isAuthRequired reports whether a CLOSED reason asks the client to
+// authenticate (NIP-42) before the relay will serve the subscription.
+func isAuthRequired(reason string) bool {
+	return strings.HasPrefix(reason, "auth-required:")
+}
+
+// canAuthRead reports whether the pool has a key to answer AUTH challenges
+// from relays that restrict reads.
+func (p *Pool) canAuthRead() bool {
+	return p.opts.ReadAuthKey != ""
+}
+
+// authAndResubscribe answers the relay's latest AUTH challenge with an event
+// signed by the read auth key, then opens the subscription again.
+func (p *Pool) authAndResubscribe(ctx context.Context, relay *nostr.Relay, filter nostr.Filter) (*nostr.Subscription, error) {
+	secretKey, err := decodeSecretKey(p.opts.ReadAuthKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid read auth key: %w", err)
+	}
+
+	err = relay.Auth(ctx, func(event *nostr.Event) error {
+		return event.Sign(secretKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return relay.Subscribe(ctx, nostr.Filters{filter})
+}
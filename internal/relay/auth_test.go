@@ -0,0 +1,67 @@
+package relay
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestQueryAuthenticatesToRestrictedRelay(t *testing.T) {
+	fr := newFakeRelay(t, signedEvent(t, "members only"))
+	fr.requireAuth()
+
+	secretKey := nostr.GeneratePrivateKey()
+	pool := NewPoolWithOptions(nil, PoolOptions{ReadAuthKey: secretKey})
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	response, err := pool.QueryEventsAdvancedWithTiming([]int{1}, nil, nil, 10, 0, 0)
+	if err != nil {
+		t.Fatalf("QueryEventsAdvancedWithTiming: %v", err)
+	}
+	if len(response.Events) != 1 {
+		t.Fatalf("expected the restricted event after authenticating, got %d events", len(response.Events))
+	}
+	if len(response.RelayTimings) != 1 || !response.RelayTimings[0].AuthPerformed {
+		t.Errorf("expected the timing to report auth, got %+v", response.RelayTimings)
+	}
+
+	pubkey, _ := nostr.GetPublicKey(secretKey)
+	if authed := fr.authenticated(); len(authed) != 1 || authed[0] != pubkey {
+		t.Errorf("expected the relay to see AUTH from %s, got %v", pubkey, authed)
+	}
+}
+
+func TestQueryWithoutAuthKeyReportsRestrictedRelay(t *testing.T) {
+	fr := newFakeRelay(t, signedEvent(t, "members only"))
+	fr.requireAuth()
+
+	pool := NewPool(nil)
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	response, err := pool.QueryEventsAdvancedWithTiming([]int{1}, nil, nil, 10, 0, 0)
+	if err != nil {
+		t.Fatalf("QueryEventsAdvancedWithTiming: %v", err)
+	}
+	if len(response.Events) != 0 {
+		t.Errorf("expected no events without authenticating, got %d", len(response.Events))
+	}
+	timing := response.RelayTimings[0]
+	if timing.AuthPerformed || !strings.HasPrefix(timing.Error, "closed: auth-required:") {
+		t.Errorf("expected an auth-required error without auth, got %+v", timing)
+	}
+	if len(fr.authenticated()) != 0 {
+		t.Error("expected no AUTH to be sent without a key")
+	}
+}
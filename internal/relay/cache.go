@@ -2,6 +2,7 @@
 package relay
 
 import (
+	"container/list"
 	"sync"
 	"time"
 
@@ -11,9 +12,19 @@ import (
 // DefaultCacheTTL is the default time-to-live for cached relay info.
 const DefaultCacheTTL = 5 * time.Minute
 
+// DefaultInfoCacheSize is the default maximum number of relays kept in the
+// relay info cache.
+const DefaultInfoCacheSize = 500
+
+// DefaultNegativeCacheTTL is how long a failed NIP-11 fetch is remembered
+// before the relay is tried again.
+const DefaultNegativeCacheTTL = 30 * time.Second
+
 // CachedRelayInfo holds relay info with metadata for cache management.
+// For a failed fetch, Info is nil and Err holds the failure.
 type CachedRelayInfo struct {
 	Info      *types.RelayInfo
+	Err       error
 	FetchedAt time.Time
 	ExpiresAt time.Time
 }
@@ -24,84 +35,165 @@ func (c *CachedRelayInfo) IsExpired() bool {
 }
 
 // RelayInfoCache provides thread-safe caching for NIP-11 relay information.
+// Entries expire after their TTL, and once the cache holds maxEntries relays
+// the least recently used entry is evicted to make room for a new one.
 type RelayInfoCache struct {
-	cache map[string]*CachedRelayInfo
-	mu    sync.RWMutex
-	ttl   time.Duration
+	cache      map[string]*list.Element
+	order      *list.List // front is most recently used
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+}
+
+// infoCacheEntry is the value stored in each element of the LRU list.
+// lastSeen is the most recent successfully fetched document; unlike cached it
+// survives expiry and failed fetches, so later fetches can be diffed against it.
+type infoCacheEntry struct {
+	url      string
+	cached   CachedRelayInfo
+	lastSeen *types.RelayInfo
 }
 
-// NewRelayInfoCache creates a new relay info cache with the specified TTL.
+// NewRelayInfoCache creates a new relay info cache with the specified TTL,
+// holding up to DefaultInfoCacheSize relays.
 func NewRelayInfoCache(ttl time.Duration) *RelayInfoCache {
+	return NewRelayInfoCacheWithSize(ttl, DefaultInfoCacheSize)
+}
+
+// NewRelayInfoCacheWithSize creates a new relay info cache with the specified
+// TTL holding up to maxEntries relays.
+func NewRelayInfoCacheWithSize(ttl time.Duration, maxEntries int) *RelayInfoCache {
 	if ttl <= 0 {
 		ttl = DefaultCacheTTL
 	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultInfoCacheSize
+	}
 	return &RelayInfoCache{
-		cache: make(map[string]*CachedRelayInfo),
-		ttl:   ttl,
+		cache:      make(map[string]*list.Element),
+		order:      list.New(),
+		ttl:        ttl,
+		maxEntries: maxEntries,
 	}
 }
 
-// Get retrieves relay info from the cache.
-// Returns nil if not found or expired.
+// Get retrieves relay info from the cache and marks it as recently used.
+// Returns nil if not found, expired, or cached as a failure.
 func (c *RelayInfoCache) Get(url string) *types.RelayInfo {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	entry, exists := c.cache[url]
+	elem, exists := c.cache[url]
 	if !exists {
 		return nil
 	}
 
-	if entry.IsExpired() {
+	entry := elem.Value.(*infoCacheEntry)
+	if entry.cached.IsExpired() {
 		return nil
 	}
 
-	return entry.Info
+	c.order.MoveToFront(elem)
+	return entry.cached.Info
 }
 
 // GetWithMetadata retrieves relay info with cache metadata.
 // Returns nil if not found (does not check expiry).
 func (c *RelayInfoCache) GetWithMetadata(url string) *CachedRelayInfo {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	entry, exists := c.cache[url]
+	elem, exists := c.cache[url]
 	if !exists {
 		return nil
 	}
 
 	// Return a copy to prevent mutations
-	return &CachedRelayInfo{
-		Info:      entry.Info,
-		FetchedAt: entry.FetchedAt,
-		ExpiresAt: entry.ExpiresAt,
-	}
+	cached := elem.Value.(*infoCacheEntry).cached
+	return &cached
 }
 
 // Set stores relay info in the cache.
 func (c *RelayInfoCache) Set(url string, info *types.RelayInfo) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.SetWithTTL(url, info, c.ttl)
+}
 
+// SetWithTTL stores relay info with a custom TTL.
+func (c *RelayInfoCache) SetWithTTL(url string, info *types.RelayInfo, ttl time.Duration) {
 	now := time.Now()
-	c.cache[url] = &CachedRelayInfo{
+	c.store(url, CachedRelayInfo{
 		Info:      info,
 		FetchedAt: now,
-		ExpiresAt: now.Add(c.ttl),
+		ExpiresAt: now.Add(ttl),
+	})
+}
+
+// SetFailure remembers that fetching a relay's info failed, so lookups can
+// skip the relay for ttl instead of retrying immediately. It replaces any
+// info cached for the relay.
+func (c *RelayInfoCache) SetFailure(url string, err error, ttl time.Duration) {
+	now := time.Now()
+	c.store(url, CachedRelayInfo{
+		Err:       err,
+		FetchedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+}
+
+// GetFailure returns the error of an unexpired failed fetch, or nil if the
+// relay has no failure cached.
+func (c *RelayInfoCache) GetFailure(url string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.cache[url]
+	if !exists {
+		return nil
 	}
+	entry := elem.Value.(*infoCacheEntry)
+	if entry.cached.IsExpired() {
+		return nil
+	}
+	return entry.cached.Err
 }
 
-// SetWithTTL stores relay info with a custom TTL.
-func (c *RelayInfoCache) SetWithTTL(url string, info *types.RelayInfo, ttl time.Duration) {
+// store adds or replaces an entry. If the cache is full, expired entries are
+// dropped first and then the least recently used entry.
+func (c *RelayInfoCache) store(url string, cached CachedRelayInfo) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	now := time.Now()
-	c.cache[url] = &CachedRelayInfo{
-		Info:      info,
-		FetchedAt: now,
-		ExpiresAt: now.Add(ttl),
+	if elem, exists := c.cache[url]; exists {
+		entry := elem.Value.(*infoCacheEntry)
+		entry.cached = cached
+		if cached.Info != nil {
+			entry.lastSeen = cached.Info
+		}
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.order.Len() >= c.maxEntries {
+		c.removeExpiredLocked()
+	}
+	for c.order.Len() >= c.maxEntries {
+		c.removeLocked(c.order.Back())
+	}
+	c.cache[url] = c.order.PushFront(&infoCacheEntry{url: url, cached: cached, lastSeen: cached.Info})
+}
+
+// LastSeen returns the most recently fetched info for a relay, even if it has
+// expired or a later fetch failed. Returns nil if the relay's info was never
+// fetched or has been evicted.
+func (c *RelayInfoCache) LastSeen(url string) *types.RelayInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.cache[url]
+	if !exists {
+		return nil
 	}
+	return elem.Value.(*infoCacheEntry).lastSeen
 }
 
 // Delete removes relay info from the cache.
@@ -109,7 +201,9 @@ func (c *RelayInfoCache) Delete(url string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.cache, url)
+	if elem, exists := c.cache[url]; exists {
+		c.removeLocked(elem)
+	}
 }
 
 // Clear removes all entries from the cache.
@@ -117,7 +211,8 @@ func (c *RelayInfoCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.cache = make(map[string]*CachedRelayInfo)
+	c.cache = make(map[string]*list.Element)
+	c.order.Init()
 }
 
 // CleanExpired removes all expired entries from the cache.
@@ -126,32 +221,52 @@ func (c *RelayInfoCache) CleanExpired() int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	return c.removeExpiredLocked()
+}
+
+// removeExpiredLocked removes expired entries and returns how many were
+// removed. c.mu must be held.
+func (c *RelayInfoCache) removeExpiredLocked() int {
 	removed := 0
-	for url, entry := range c.cache {
-		if entry.IsExpired() {
-			delete(c.cache, url)
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*infoCacheEntry).cached.IsExpired() {
+			c.removeLocked(elem)
 			removed++
 		}
+		elem = next
 	}
 	return removed
 }
 
+// removeLocked removes a single entry. c.mu must be held.
+func (c *RelayInfoCache) removeLocked(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.cache, elem.Value.(*infoCacheEntry).url)
+}
+
 // Size returns the number of entries in the cache (including expired).
 func (c *RelayInfoCache) Size() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	return len(c.cache)
+	return c.order.Len()
 }
 
-// URLs returns all cached relay URLs (including expired).
+// MaxEntries returns the maximum number of entries the cache holds.
+func (c *RelayInfoCache) MaxEntries() int {
+	return c.maxEntries
+}
+
+// URLs returns all cached relay URLs (including expired), most recently
+// used first.
 func (c *RelayInfoCache) URLs() []string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	urls := make([]string, 0, len(c.cache))
-	for url := range c.cache {
-		urls = append(urls, url)
+	urls := make([]string, 0, c.order.Len())
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		urls = append(urls, elem.Value.(*infoCacheEntry).url)
 	}
 	return urls
 }
@@ -1,6 +1,7 @@
 package relay
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -343,3 +344,140 @@ func TestRelayInfoCache_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestRelayInfoCache_DefaultMaxEntries(t *testing.T) {
+	if got := NewRelayInfoCache(time.Minute).MaxEntries(); got != DefaultInfoCacheSize {
+		t.Errorf("MaxEntries() = %d, want %d", got, DefaultInfoCacheSize)
+	}
+	if got := NewRelayInfoCacheWithSize(time.Minute, 0).MaxEntries(); got != DefaultInfoCacheSize {
+		t.Errorf("MaxEntries() with zero size = %d, want %d", got, DefaultInfoCacheSize)
+	}
+}
+
+func TestRelayInfoCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewRelayInfoCacheWithSize(5*time.Minute, 2)
+
+	cache.Set("wss://a.relay", &types.RelayInfo{Name: "A"})
+	cache.Set("wss://b.relay", &types.RelayInfo{Name: "B"})
+
+	// Touch A so B becomes the least recently used entry
+	if cache.Get("wss://a.relay") == nil {
+		t.Fatal("expected A to be cached")
+	}
+
+	cache.Set("wss://c.relay", &types.RelayInfo{Name: "C"})
+
+	if cache.Size() != 2 {
+		t.Errorf("expected size 2, got %d", cache.Size())
+	}
+	if cache.Get("wss://b.relay") != nil {
+		t.Error("expected B to be evicted")
+	}
+	if cache.Get("wss://a.relay") == nil || cache.Get("wss://c.relay") == nil {
+		t.Error("expected A and C to remain cached")
+	}
+}
+
+func TestRelayInfoCache_OverwriteDoesNotEvict(t *testing.T) {
+	cache := NewRelayInfoCacheWithSize(5*time.Minute, 2)
+
+	cache.Set("wss://a.relay", &types.RelayInfo{Name: "A"})
+	cache.Set("wss://b.relay", &types.RelayInfo{Name: "B"})
+	cache.Set("wss://a.relay", &types.RelayInfo{Name: "A2"})
+
+	if cache.Size() != 2 {
+		t.Errorf("expected size 2, got %d", cache.Size())
+	}
+	if got := cache.Get("wss://a.relay"); got == nil || got.Name != "A2" {
+		t.Errorf("expected updated A, got %+v", got)
+	}
+	if cache.Get("wss://b.relay") == nil {
+		t.Error("expected B to remain cached")
+	}
+}
+
+func TestRelayInfoCache_EvictsExpiredBeforeLRU(t *testing.T) {
+	cache := NewRelayInfoCacheWithSize(5*time.Minute, 2)
+
+	cache.Set("wss://old.relay", &types.RelayInfo{Name: "Old"})
+	cache.SetWithTTL("wss://short.relay", &types.RelayInfo{Name: "Short"}, 10*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+
+	// The expired entry is newer than Old, but goes first
+	cache.Set("wss://new.relay", &types.RelayInfo{Name: "New"})
+
+	if cache.Size() != 2 {
+		t.Errorf("expected size 2, got %d", cache.Size())
+	}
+	if cache.GetWithMetadata("wss://short.relay") != nil {
+		t.Error("expected expired entry to be evicted")
+	}
+	if cache.Get("wss://old.relay") == nil {
+		t.Error("expected unexpired least recently used entry to survive")
+	}
+}
+
+func TestRelayInfoCache_ExpiredGetDoesNotRefresh(t *testing.T) {
+	cache := NewRelayInfoCacheWithSize(5*time.Minute, 2)
+
+	cache.SetWithTTL("wss://short.relay", &types.RelayInfo{Name: "Short"}, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if cache.Get("wss://short.relay") != nil {
+		t.Fatal("expected expired entry to miss")
+	}
+
+	// Refreshing an expired entry replaces it in place
+	cache.Set("wss://short.relay", &types.RelayInfo{Name: "Fresh"})
+	if got := cache.Get("wss://short.relay"); got == nil || got.Name != "Fresh" {
+		t.Errorf("expected refreshed entry, got %+v", got)
+	}
+	if cache.Size() != 1 {
+		t.Errorf("expected size 1, got %d", cache.Size())
+	}
+}
+
+func TestRelayInfoCache_URLsMostRecentFirst(t *testing.T) {
+	cache := NewRelayInfoCacheWithSize(5*time.Minute, 3)
+
+	cache.Set("wss://a.relay", &types.RelayInfo{})
+	cache.Set("wss://b.relay", &types.RelayInfo{})
+	cache.Set("wss://c.relay", &types.RelayInfo{})
+	cache.Get("wss://a.relay")
+
+	urls := cache.URLs()
+	want := []string{"wss://a.relay", "wss://c.relay", "wss://b.relay"}
+	if len(urls) != len(want) {
+		t.Fatalf("expected %v, got %v", want, urls)
+	}
+	for i := range want {
+		if urls[i] != want[i] {
+			t.Errorf("URLs()[%d] = %s, want %s", i, urls[i], want[i])
+		}
+	}
+}
+
+func TestRelayInfoCache_LastSeenSurvivesExpiryAndFailure(t *testing.T) {
+	cache := NewRelayInfoCacheWithSize(5*time.Minute, 2)
+
+	if cache.LastSeen("wss://a.relay") != nil {
+		t.Fatal("expected no last-seen info before any fetch")
+	}
+
+	cache.SetWithTTL("wss://a.relay", &types.RelayInfo{Name: "A"}, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if got := cache.LastSeen("wss://a.relay"); got == nil || got.Name != "A" {
+		t.Errorf("expected expired info to still be last seen, got %+v", got)
+	}
+
+	cache.SetFailure("wss://a.relay", errors.New("timeout"), time.Minute)
+	if got := cache.LastSeen("wss://a.relay"); got == nil || got.Name != "A" {
+		t.Errorf("expected failure to keep last seen info, got %+v", got)
+	}
+
+	cache.Set("wss://a.relay", &types.RelayInfo{Name: "A2"})
+	if got := cache.LastSeen("wss://a.relay"); got == nil || got.Name != "A2" {
+		t.Errorf("expected last seen info to be replaced, got %+v", got)
+	}
+}
@@ -0,0 +1,80 @@
+package relay
+
+import (
+	"slices"
+
+	"github.com/keanuklestil/shirushi/internal/logging"
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// idConflicts keeps the first copy of each event ID received during a query
+// so later copies from other relays can be compared against it.
+type idConflicts struct {
+	copies map[string]receivedCopy
+}
+
+// receivedCopy is an event and the relay it came from.
+type receivedCopy struct {
+	event *nostr.Event
+	relay string
+}
+
+func newIDConflicts() *idConflicts {
+	return &idConflicts{copies: make(map[string]receivedCopy)}
+}
+
+// check records ev from relay. It reports whether this is the first copy of
+// its ID, and returns a conflict if an earlier copy differs. When exactly one
+// of the two copies hashes to the claimed ID, that copy is kept and replace
+// reports whether it is the new one.
+func (c *idConflicts) check(ev *nostr.Event, relay string) (first bool, conflict *types.EventConflict, replace bool) {
+	kept, exists := c.copies[ev.ID]
+	if !exists {
+		c.copies[ev.ID] = receivedCopy{event: ev, relay: relay}
+		return true, nil, false
+	}
+
+	fields := differingFields(kept.event, ev)
+	if len(fields) == 0 {
+		return false, nil, false
+	}
+
+	replace = kept.event.GetID() != kept.event.ID && ev.GetID() == ev.ID
+	conflict = &types.EventConflict{
+		ID:               ev.ID,
+		Relay:            kept.relay,
+		ConflictingRelay: relay,
+		Fields:           fields,
+	}
+	if replace {
+		c.copies[ev.ID] = receivedCopy{event: ev, relay: relay}
+		conflict.Relay, conflict.ConflictingRelay = relay, kept.relay
+	}
+	logging.Warnf("[Relay] Conflicting copies of event %s from %s and %s (%v differ)", ev.ID, kept.relay, relay, fields)
+	return false, conflict, replace
+}
+
+// differingFields lists the fields in which two copies of an event differ.
+func differingFields(a, b *nostr.Event) []string {
+	var fields []string
+	if a.PubKey != b.PubKey {
+		fields = append(fields, "pubkey")
+	}
+	if a.CreatedAt != b.CreatedAt {
+		fields = append(fields, "created_at")
+	}
+	if a.Kind != b.Kind {
+		fields = append(fields, "kind")
+	}
+	if !slices.EqualFunc(a.Tags, b.Tags, func(x, y nostr.Tag) bool { return slices.Equal(x, y) }) {
+		fields = append(fields, "tags")
+	}
+	if a.Content != b.Content {
+		fields = append(fields, "content")
+	}
+	if a.Sig != b.Sig {
+		fields = append(fields, "sig")
+	}
+	return fields
+}
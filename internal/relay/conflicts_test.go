@@ -0,0 +1,81 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// forgedCopy returns an event with a valid signature over different content
+// that claims genuine's ID, as a malicious relay could serve.
+func forgedCopy(t *testing.T, genuine nostr.Event) nostr.Event {
+	t.Helper()
+	forged := signedEvent(t, "forged: "+genuine.Content)
+	forged.ID = genuine.ID
+	return forged
+}
+
+func TestIDConflictsPrefersCopyMatchingID(t *testing.T) {
+	genuine := signedEvent(t, "hello")
+	forged := forgedCopy(t, genuine)
+
+	detector := newIDConflicts()
+	if first, conflict, _ := detector.check(&forged, "wss://evil.example"); !first || conflict != nil {
+		t.Fatalf("first copy: first = %v, conflict = %+v", first, conflict)
+	}
+	first, conflict, replace := detector.check(&genuine, "wss://good.example")
+	if first || conflict == nil {
+		t.Fatalf("second copy: first = %v, conflict = %+v, want a conflict", first, conflict)
+	}
+	if !replace {
+		t.Error("expected the copy matching its ID to replace the forged one")
+	}
+	if conflict.Relay != "wss://good.example" || conflict.ConflictingRelay != "wss://evil.example" {
+		t.Errorf("conflict relays = %s / %s, want the kept copy's relay first", conflict.Relay, conflict.ConflictingRelay)
+	}
+
+	again := genuine
+	if _, conflict, _ := detector.check(&again, "wss://other.example"); conflict != nil {
+		t.Errorf("identical copy reported as conflict: %+v", conflict)
+	}
+}
+
+func TestQueryEventsByIDsReportsConflicts(t *testing.T) {
+	genuine := signedEvent(t, "hello")
+	good := newFakeRelay(t, genuine)
+	evil := newFakeRelay(t, forgedCopy(t, genuine))
+
+	pool := NewPoolWithOptions(nil, PoolOptions{EventCacheSize: 10})
+	defer pool.Close()
+	pool.Add(good.URL)
+	pool.Add(evil.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 2 }) {
+		t.Fatal("relays never connected")
+	}
+
+	events, conflicts, err := pool.QueryEventsByIDsWithConflicts([]string{genuine.ID})
+	if err != nil {
+		t.Fatalf("QueryEventsByIDsWithConflicts error: %v", err)
+	}
+	if len(events) != 1 || events[0].Content != "hello" {
+		t.Fatalf("events = %+v, want only the genuine copy", events)
+	}
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %+v", conflicts)
+	}
+	conflict := conflicts[0]
+	if conflict.ID != genuine.ID || conflict.Relay != good.URL || conflict.ConflictingRelay != evil.URL {
+		t.Errorf("conflict = %+v, want genuine copy from %s kept over %s", conflict, good.URL, evil.URL)
+	}
+	wantFields := map[string]bool{"pubkey": true, "content": true, "sig": true}
+	for _, field := range conflict.Fields {
+		if !wantFields[field] {
+			t.Errorf("unexpected differing field %q in %v", field, conflict.Fields)
+		}
+	}
+	if _, cached := pool.eventCache.Get(genuine.ID); cached {
+		t.Error("conflicting event should not be cached")
+	}
+}
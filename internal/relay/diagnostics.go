@@ -0,0 +1,33 @@
+package relay
+
+import (
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// Diagnostics reports the pool's relay counts, active work and cache usage.
+func (p *Pool) Diagnostics() *types.PoolDiagnostics {
+	diag := &types.PoolDiagnostics{
+		ActiveQueries:     p.activeCount.Load(),
+		InfoCacheSize:     p.infoCache.Size(),
+		InfoCacheCapacity: p.infoCache.MaxEntries(),
+	}
+
+	p.mu.RLock()
+	diag.Relays = len(p.relays)
+	for _, conn := range p.relays {
+		if conn.Connected {
+			diag.ConnectedRelays++
+		}
+	}
+	p.mu.RUnlock()
+
+	p.subMu.Lock()
+	diag.ActiveSubscriptions = len(p.subs)
+	p.subMu.Unlock()
+
+	if p.eventCache != nil {
+		diag.EventCacheSize = p.eventCache.Len()
+		diag.EventCacheCapacity = p.eventCache.capacity
+	}
+	return diag
+}
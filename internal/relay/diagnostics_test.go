@@ -0,0 +1,37 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func TestPoolDiagnostics(t *testing.T) {
+	fr := newFakeRelay(t)
+	dead := newFakeRelay(t)
+	dead.setRefuse(true)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{EventCacheSize: 10, InfoCacheSize: 20})
+	defer pool.Close()
+	pool.Add(fr.URL)
+	pool.Add(dead.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	subID := pool.Subscribe([]int{1}, nil, func(types.Event) {})
+	defer pool.Unsubscribe(subID)
+
+	diag := pool.Diagnostics()
+	if diag.Relays != 2 || diag.ConnectedRelays != 1 {
+		t.Errorf("relays = %d connected = %d, want 2/1", diag.Relays, diag.ConnectedRelays)
+	}
+	if diag.ActiveSubscriptions != 1 || diag.ActiveQueries < 1 {
+		t.Errorf("subscriptions = %d queries = %d, want 1 and at least 1", diag.ActiveSubscriptions, diag.ActiveQueries)
+	}
+	if diag.EventCacheCapacity != 10 || diag.InfoCacheCapacity != 20 {
+		t.Errorf("cache capacities = %d/%d, want 10/20", diag.EventCacheCapacity, diag.InfoCacheCapacity)
+	}
+}
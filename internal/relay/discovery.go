@@ -0,0 +1,167 @@
+package relay
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// hintTags are the tags whose third element may be a relay hint.
+var hintTags = map[string]bool{"e": true, "p": true, "a": true}
+
+// relayDiscovery collects relay hints seen in the tags of queried events.
+// At most max relays are kept; once full, the relay hinted least recently
+// makes room for a new one.
+type relayDiscovery struct {
+	mu       sync.Mutex
+	relays   map[string]*discoveredRelay
+	fetching map[string]bool
+	max      int
+}
+
+// discoveredRelay tracks how often and when a relay was hinted.
+type discoveredRelay struct {
+	hints     int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// newRelayDiscovery creates a discovery set for up to max relays, or returns
+// nil if max is not positive, which disables discovery.
+func newRelayDiscovery(max int) *relayDiscovery {
+	if max <= 0 {
+		return nil
+	}
+	return &relayDiscovery{
+		relays:   make(map[string]*discoveredRelay),
+		fetching: make(map[string]bool),
+		max:      max,
+	}
+}
+
+// add records a hint for url, evicting the least recently hinted relay if
+// the set is full.
+func (d *relayDiscovery) add(url string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if relay, exists := d.relays[url]; exists {
+		relay.hints++
+		relay.lastSeen = now
+		return
+	}
+
+	if len(d.relays) >= d.max {
+		var oldest string
+		for candidate, relay := range d.relays {
+			if oldest == "" || relay.lastSeen.Before(d.relays[oldest].lastSeen) {
+				oldest = candidate
+			}
+		}
+		delete(d.relays, oldest)
+	}
+	d.relays[url] = &discoveredRelay{hints: 1, firstSeen: now, lastSeen: now}
+}
+
+// collectRelayHints records the relay hints in an event's e, p and a tags
+// when discovery is enabled. Relays already in the pool or that it may not
+// connect to are skipped and nothing is ever connected to.
+func (p *Pool) collectRelayHints(ev *nostr.Event) {
+	if p.discovery == nil || ev == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, tag := range ev.Tags {
+		if len(tag) < 3 || !hintTags[tag[0]] || tag[2] == "" {
+			continue
+		}
+		url, err := config.NormalizeRelayURL(tag[2])
+		if err != nil || p.checkPermitted(url) != nil {
+			continue
+		}
+
+		p.mu.RLock()
+		_, inPool := p.relays[url]
+		p.mu.RUnlock()
+		if !inPool {
+			p.discovery.add(url, now)
+		}
+	}
+}
+
+// DiscoveredRelays returns relays hinted in queried events that are not in
+// the pool, most hinted first. NIP-11 info is included when cached; relays
+// without it have it fetched in the background for later calls.
+func (p *Pool) DiscoveredRelays() []types.DiscoveredRelay {
+	discovered := []types.DiscoveredRelay{}
+	if p.discovery == nil {
+		return discovered
+	}
+
+	p.discovery.mu.Lock()
+	for url, relay := range p.discovery.relays {
+		discovered = append(discovered, types.DiscoveredRelay{
+			URL:       url,
+			HintCount: relay.hints,
+			FirstSeen: relay.firstSeen.Unix(),
+			LastSeen:  relay.lastSeen.Unix(),
+		})
+	}
+	p.discovery.mu.Unlock()
+
+	p.mu.RLock()
+	filtered := discovered[:0]
+	for _, relay := range discovered {
+		if _, inPool := p.relays[relay.URL]; !inPool {
+			filtered = append(filtered, relay)
+		}
+	}
+	p.mu.RUnlock()
+	discovered = filtered
+
+	for i := range discovered {
+		if info := p.infoCache.Get(discovered[i].URL); info != nil {
+			discovered[i].Info = info
+			continue
+		}
+		p.fetchDiscoveredInfo(discovered[i].URL)
+	}
+
+	sort.Slice(discovered, func(i, j int) bool {
+		if discovered[i].HintCount != discovered[j].HintCount {
+			return discovered[i].HintCount > discovered[j].HintCount
+		}
+		return discovered[i].URL < discovered[j].URL
+	})
+	return discovered
+}
+
+// fetchDiscoveredInfo fetches a discovered relay's NIP-11 info into the info
+// cache in the background, unless a fetch is already running or recently
+// failed.
+func (p *Pool) fetchDiscoveredInfo(url string) {
+	if p.infoCache.GetFailure(url) != nil {
+		return
+	}
+
+	p.discovery.mu.Lock()
+	if p.discovery.fetching[url] {
+		p.discovery.mu.Unlock()
+		return
+	}
+	p.discovery.fetching[url] = true
+	p.discovery.mu.Unlock()
+
+	go func() {
+		p.FetchRelayInfoCached(url, false)
+
+		p.discovery.mu.Lock()
+		delete(p.discovery.fetching, url)
+		p.discovery.mu.Unlock()
+	}()
+}
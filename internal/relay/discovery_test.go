@@ -0,0 +1,112 @@
+package relay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip11"
+)
+
+func TestQueryCollectsRelayHints(t *testing.T) {
+	tagged := func(tags nostr.Tags) nostr.Event {
+		ev := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Tags: tags, Content: "reply"}
+		if err := ev.Sign(nostr.GeneratePrivateKey()); err != nil {
+			t.Fatalf("failed to sign event: %v", err)
+		}
+		return ev
+	}
+	pk, _ := nostr.GetPublicKey(nostr.GeneratePrivateKey())
+	fr := newFakeRelay(t,
+		tagged(nostr.Tags{
+			{"e", "aa", "wss://hint.example.com"},
+			{"p", pk, "wss://other.example.com/"},
+		}),
+		tagged(nostr.Tags{
+			{"e", "bb", "wss://hint.example.com/"},
+			{"t", "nostr", "wss://ignored.example.com"},
+			{"p", pk, "not a relay"},
+		}),
+	)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{MaxDiscoveredRelays: 10})
+	defer pool.Close()
+	pool.fetchInfo = sequenceFetch(nip11.RelayInformationDocument{Name: "Hinted"})
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	events, err := pool.QueryEvents("1", "", "10")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	discovered := pool.DiscoveredRelays()
+	if len(discovered) != 2 {
+		t.Fatalf("expected 2 discovered relays, got %+v", discovered)
+	}
+	if discovered[0].URL != "wss://hint.example.com" || discovered[0].HintCount != 2 {
+		t.Errorf("discovered[0] = %+v, want wss://hint.example.com hinted twice", discovered[0])
+	}
+	if discovered[1].URL != "wss://other.example.com" || discovered[1].HintCount != 1 {
+		t.Errorf("discovered[1] = %+v, want wss://other.example.com hinted once", discovered[1])
+	}
+	if pool.Count() != 1 {
+		t.Errorf("expected discovered relays not to be added to the pool, count = %d", pool.Count())
+	}
+
+	if !waitFor(t, 5*time.Second, func() bool {
+		for _, relay := range pool.DiscoveredRelays() {
+			if relay.Info == nil {
+				return false
+			}
+		}
+		return true
+	}) {
+		t.Error("expected NIP-11 info to be fetched for discovered relays")
+	}
+}
+
+func TestRelayDiscoveryIsBounded(t *testing.T) {
+	d := newRelayDiscovery(2)
+	start := time.Now()
+
+	d.add("wss://a.example.com", start)
+	d.add("wss://b.example.com", start.Add(time.Second))
+	d.add("wss://a.example.com", start.Add(2*time.Second))
+	d.add("wss://c.example.com", start.Add(3*time.Second))
+
+	if len(d.relays) != 2 {
+		t.Fatalf("expected 2 relays, got %d", len(d.relays))
+	}
+	if _, ok := d.relays["wss://b.example.com"]; ok {
+		t.Error("expected the least recently hinted relay to be evicted")
+	}
+	if d.relays["wss://a.example.com"].hints != 2 {
+		t.Errorf("a hints = %d, want 2", d.relays["wss://a.example.com"].hints)
+	}
+}
+
+func TestRelayDiscoveryDisabled(t *testing.T) {
+	if newRelayDiscovery(0) != nil {
+		t.Fatal("expected discovery to be disabled with zero max relays")
+	}
+
+	pool := NewPoolWithOptions(nil, PoolOptions{})
+	defer pool.Close()
+	pool.fetchInfo = func(ctx context.Context, url string) (infoDocument, error) {
+		t.Errorf("unexpected info fetch for %s", url)
+		return infoDocument{}, nil
+	}
+
+	pool.collectRelayHints(&nostr.Event{Tags: nostr.Tags{{"e", "aa", "wss://hint.example.com"}}})
+	if discovered := pool.DiscoveredRelays(); len(discovered) != 0 {
+		t.Errorf("expected no discovered relays while disabled, got %+v", discovered)
+	}
+}
@@ -0,0 +1,79 @@
+package relay
+
+import (
+	"errors"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/logging"
+)
+
+// DefaultDrainTimeout is how long Close waits for in-flight queries when
+// PoolOptions leaves DrainTimeout unset.
+const DefaultDrainTimeout = 5 * time.Second
+
+// ErrPoolClosing is returned by queries started after Close has begun.
+var ErrPoolClosing = errors.New("relay pool is closing")
+
+// drainTimeout returns the configured drain timeout or the default.
+func (p *Pool) drainTimeout() time.Duration {
+	if p.opts.DrainTimeout > 0 {
+		return p.opts.DrainTimeout
+	}
+	return DefaultDrainTimeout
+}
+
+// trackQuery registers an in-flight query or subscription goroutine so Close
+// can wait for it. The returned func must be called when the work is done.
+// Once Close has begun no new work is accepted and ErrPoolClosing is returned.
+func (p *Pool) trackQuery() (func(), error) {
+	p.activeMu.Lock()
+	defer p.activeMu.Unlock()
+
+	if p.closing {
+		return nil, ErrPoolClosing
+	}
+	p.activeCount.Add(1)
+	return func() {
+		p.activeMu.Lock()
+		defer p.activeMu.Unlock()
+
+		if p.activeCount.Add(-1) == 0 && p.drained != nil {
+			close(p.drained)
+			p.drained = nil
+		}
+	}, nil
+}
+
+// stopAccepting makes trackQuery refuse new work.
+func (p *Pool) stopAccepting() {
+	p.activeMu.Lock()
+	p.closing = true
+	p.activeMu.Unlock()
+}
+
+// drain waits up to timeout for tracked work to finish and returns how many
+// queries were still running when it gave up. It must be called after
+// stopAccepting, so the count can only go down while it waits.
+func (p *Pool) drain(timeout time.Duration) int {
+	p.activeMu.Lock()
+	active := p.activeCount.Load()
+	if active == 0 {
+		p.activeMu.Unlock()
+		return 0
+	}
+	drained := make(chan struct{})
+	p.drained = drained
+	p.activeMu.Unlock()
+
+	logging.Infof("[Relay] Waiting for %d active queries before closing", active)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		return 0
+	case <-timer.C:
+		return int(p.activeCount.Load())
+	}
+}
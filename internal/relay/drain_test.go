@@ -0,0 +1,125 @@
+package relay
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestCloseWaitsForInFlightQuery(t *testing.T) {
+	fr := newFakeRelay(t, signedEvent(t, "slow"))
+	fr.setNoEOSE(true)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{
+		QueryTimeout: 300 * time.Millisecond,
+		DrainTimeout: 5 * time.Second,
+	})
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	finished := make(chan int, 1)
+	go func() {
+		events, _ := pool.QueryEventsAdvanced([]int{1}, nil, nil, 10, 0, 0)
+		finished <- len(events)
+	}()
+
+	if !waitFor(t, 5*time.Second, func() bool { return pool.activeCount.Load() > 0 }) {
+		t.Fatal("query never started")
+	}
+
+	pool.Close()
+
+	select {
+	case n := <-finished:
+		if n != 1 {
+			t.Errorf("expected the slow query to keep its event, got %d events", n)
+		}
+	default:
+		t.Fatal("Close returned before the in-flight query finished")
+	}
+}
+
+func TestCloseGivesUpAfterDrainTimeout(t *testing.T) {
+	fr := newFakeRelay(t)
+	fr.setNoEOSE(true)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{
+		QueryTimeout: 10 * time.Second,
+		DrainTimeout: 200 * time.Millisecond,
+	})
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	go pool.QueryEventsAdvanced([]int{1}, nil, nil, 10, 0, 0)
+
+	if !waitFor(t, 5*time.Second, func() bool { return pool.activeCount.Load() > 0 }) {
+		t.Fatal("query never started")
+	}
+
+	start := time.Now()
+	pool.Close()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected Close to stop waiting after the drain timeout, took %v", elapsed)
+	}
+}
+
+func TestCloseCancelsSubscriptions(t *testing.T) {
+	fr := newFakeRelay(t)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{DrainTimeout: 10 * time.Second})
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	pool.Subscribe([]int{1}, nil, func(types.Event) {})
+
+	start := time.Now()
+	pool.Close()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected Close to cancel the subscription instead of waiting, took %v", elapsed)
+	}
+	if active := pool.activeCount.Load(); active != 0 {
+		t.Errorf("expected no active goroutines after Close, got %d", active)
+	}
+}
+
+func TestQueriesFailFastAfterClose(t *testing.T) {
+	fr := newFakeRelay(t, signedEvent(t, "stored"))
+
+	pool := NewPoolWithOptions(nil, PoolOptions{DrainTimeout: time.Second})
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	pool.Close()
+
+	if _, err := pool.CountEvents([]int{1}, nil, nil, 10, 0, 0); !errors.Is(err, ErrPoolClosing) {
+		t.Errorf("CountEvents error = %v, want ErrPoolClosing", err)
+	}
+	if _, err := pool.QueryEventsFromRelays(nil, nil, []int{1}, nil, nil, 10); !errors.Is(err, ErrPoolClosing) {
+		t.Errorf("QueryEventsFromRelays error = %v, want ErrPoolClosing", err)
+	}
+
+	response := pool.queryRelays([]string{fr.URL}, nostr.Filter{Kinds: []int{1}})
+	if len(response) != 1 || response[0].timing.Error != ErrPoolClosing.Error() {
+		t.Errorf("expected queryRelays to report the pool closing, got %+v", response)
+	}
+
+	pool.Subscribe([]int{1}, nil, func(types.Event) {})
+	if active := pool.activeCount.Load(); active != 0 {
+		t.Errorf("expected no work to start after Close, got %d active", active)
+	}
+}
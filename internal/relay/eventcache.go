@@ -0,0 +1,97 @@
+package relay
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// DefaultEventCacheSize is the default number of events kept in the event cache.
+const DefaultEventCacheSize = 1000
+
+// EventCache is a thread-safe LRU cache of events keyed by event ID.
+// Ephemeral events are never cached since relays treat them as transient.
+type EventCache struct {
+	capacity int
+	kinds    map[int]bool // nil means every non-ephemeral kind is cacheable
+	order    *list.List   // front is most recently used
+	items    map[string]*list.Element
+	mu       sync.Mutex
+}
+
+// NewEventCache creates an event cache holding up to capacity events.
+// If kinds is non-empty, only those kinds are cached.
+func NewEventCache(capacity int, kinds []int) *EventCache {
+	if capacity <= 0 {
+		capacity = DefaultEventCacheSize
+	}
+	c := &EventCache{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+	if len(kinds) > 0 {
+		c.kinds = make(map[int]bool, len(kinds))
+		for _, kind := range kinds {
+			c.kinds[kind] = true
+		}
+	}
+	return c
+}
+
+// Cacheable reports whether events of the given kind may be cached.
+func (c *EventCache) Cacheable(kind int) bool {
+	if types.ClassifyKind(kind) == types.KindClassEphemeral {
+		return false
+	}
+	if c.kinds != nil {
+		return c.kinds[kind]
+	}
+	return true
+}
+
+// Get returns a cached event by ID.
+func (c *EventCache) Get(id string) (types.Event, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[id]
+	if !ok {
+		return types.Event{}, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(types.Event), true
+}
+
+// Put adds an event to the cache, evicting the least recently used event
+// if the cache is full. Returns false if the event's kind is not cacheable.
+func (c *EventCache) Put(event types.Event) bool {
+	if event.ID == "" || !c.Cacheable(event.Kind) {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[event.ID]; ok {
+		elem.Value = event
+		c.order.MoveToFront(elem)
+		return true
+	}
+
+	c.items[event.ID] = c.order.PushFront(event)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(types.Event).ID)
+	}
+	return true
+}
+
+// Len returns the number of cached events.
+func (c *EventCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,87 @@
+package relay
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func TestEventCacheSkipsEphemeralKinds(t *testing.T) {
+	cache := NewEventCache(10, nil)
+
+	ephemeral := types.Event{ID: "eph1", Kind: 20001, Content: "typing..."}
+	if cache.Put(ephemeral) {
+		t.Error("expected ephemeral event not to be cached")
+	}
+	if _, ok := cache.Get("eph1"); ok {
+		t.Error("expected ephemeral event to be absent from the cache")
+	}
+
+	note := types.Event{ID: "note1", Kind: 1, Content: "hello"}
+	if !cache.Put(note) {
+		t.Error("expected regular event to be cached")
+	}
+	if got, ok := cache.Get("note1"); !ok || got.Content != "hello" {
+		t.Errorf("expected cached note, got %+v (found=%v)", got, ok)
+	}
+}
+
+func TestEventCacheKindAllowlist(t *testing.T) {
+	cache := NewEventCache(10, []int{1, 30023, 20001})
+
+	if !cache.Put(types.Event{ID: "a", Kind: 30023}) {
+		t.Error("expected allowlisted kind 30023 to be cached")
+	}
+	if cache.Put(types.Event{ID: "b", Kind: 7}) {
+		t.Error("expected kind 7 not to be cached when not allowlisted")
+	}
+	// Ephemeral kinds stay uncacheable even if listed
+	if cache.Put(types.Event{ID: "c", Kind: 20001}) {
+		t.Error("expected ephemeral kind to be rejected even when allowlisted")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("expected 1 cached event, got %d", cache.Len())
+	}
+}
+
+func TestEventCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewEventCache(3, nil)
+
+	for i := 0; i < 3; i++ {
+		cache.Put(types.Event{ID: fmt.Sprintf("ev%d", i), Kind: 1})
+	}
+
+	// Touch ev0 so ev1 becomes the least recently used
+	cache.Get("ev0")
+	cache.Put(types.Event{ID: "ev3", Kind: 1})
+
+	if cache.Len() != 3 {
+		t.Fatalf("expected cache size 3, got %d", cache.Len())
+	}
+	if _, ok := cache.Get("ev1"); ok {
+		t.Error("expected ev1 to be evicted")
+	}
+	for _, id := range []string{"ev0", "ev2", "ev3"} {
+		if _, ok := cache.Get(id); !ok {
+			t.Errorf("expected %s to remain cached", id)
+		}
+	}
+}
+
+func TestQueryEventsByIDsServesFromCache(t *testing.T) {
+	pool := &Pool{
+		relays:     make(map[string]*RelayConn),
+		eventCache: NewEventCache(10, nil),
+	}
+	pool.eventCache.Put(types.Event{ID: "cached1", Kind: 1, Content: "from cache"})
+
+	// No relays are connected, so this only succeeds via the cache
+	events, err := pool.QueryEventsByIDs([]string{"cached1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Content != "from cache" {
+		t.Errorf("expected cached event, got %+v", events)
+	}
+}
@@ -0,0 +1,326 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip11"
+)
+
+// fakeRelay is a minimal in-process Nostr relay for tests. It answers every
+// REQ with its stored events followed by EOSE and accepts every EVENT.
+type fakeRelay struct {
+	server *httptest.Server
+	URL    string
+
+	mu       sync.Mutex
+	events   []nostr.Event
+	rawJSON  []string // event JSON sent verbatim after events, in any key order or spacing
+	reqCount int
+	filters  []nostr.Filter // first filter of each REQ received
+	received []nostr.Event  // EVENTs published by clients
+	reject   string         // when set, EVENTs are refused with this reason
+	failNext int            // number of upcoming EVENTs to refuse with failWith
+	failWith string
+	noEOSE   bool                            // when set, stored events are sent but EOSE never is
+	info     *nip11.RelayInformationDocument // served to NIP-11 requests when set
+	reqDelay time.Duration                   // how long to hold each REQ before answering
+	gauge    *concurrencyGauge               // tracks REQs being answered, shared across relays
+	refuse   bool                            // when set, WebSocket upgrades are refused
+	authReq  bool                            // when set, REQs are closed until the connection completes NIP-42 AUTH
+	authedBy []string                        // pubkeys that completed AUTH
+}
+
+// concurrencyGauge records the peak number of concurrent operations.
+type concurrencyGauge struct {
+	mu      sync.Mutex
+	current int
+	peak    int
+}
+
+func (g *concurrencyGauge) enter() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.current++
+	if g.current > g.peak {
+		g.peak = g.current
+	}
+}
+
+func (g *concurrencyGauge) leave() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.current--
+}
+
+func (g *concurrencyGauge) max() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.peak
+}
+
+// newFakeRelay starts a fake relay that is shut down when the test ends.
+func newFakeRelay(t *testing.T, events ...nostr.Event) *fakeRelay {
+	t.Helper()
+
+	fr := &fakeRelay{events: events}
+	upgrader := websocket.Upgrader{}
+
+	fr.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "application/nostr+json" {
+			fr.serveInfo(w, r)
+			return
+		}
+		if fr.refusing() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fr.serve(conn)
+	}))
+	fr.URL = "ws" + strings.TrimPrefix(fr.server.URL, "http")
+
+	t.Cleanup(fr.server.Close)
+	return fr
+}
+
+// serve handles client messages until the connection closes.
+func (fr *fakeRelay) serve(conn *websocket.Conn) {
+	const challenge = "fake-relay-challenge"
+	authed := false
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg []json.RawMessage
+		if err := json.Unmarshal(data, &msg); err != nil || len(msg) < 2 {
+			continue
+		}
+
+		var typ string
+		json.Unmarshal(msg[0], &typ)
+
+		switch typ {
+		case "REQ":
+			var subID string
+			json.Unmarshal(msg[1], &subID)
+			var filter nostr.Filter
+			if len(msg) > 2 {
+				json.Unmarshal(msg[2], &filter)
+			}
+
+			fr.mu.Lock()
+			if fr.authReq && !authed {
+				fr.mu.Unlock()
+				conn.WriteJSON([]interface{}{"AUTH", challenge})
+				conn.WriteJSON([]interface{}{"CLOSED", subID, "auth-required: reads need authentication"})
+				continue
+			}
+			fr.reqCount++
+			fr.filters = append(fr.filters, filter)
+			events := append([]nostr.Event(nil), fr.events...)
+			rawJSON := append([]string(nil), fr.rawJSON...)
+			delay, gauge := fr.reqDelay, fr.gauge
+			fr.mu.Unlock()
+
+			if gauge != nil {
+				gauge.enter()
+			}
+			time.Sleep(delay)
+			if gauge != nil {
+				gauge.leave()
+			}
+
+			for _, ev := range events {
+				conn.WriteJSON([]interface{}{"EVENT", subID, ev})
+			}
+			for _, raw := range rawJSON {
+				prefix, _ := json.Marshal(subID)
+				conn.WriteMessage(websocket.TextMessage, []byte(`["EVENT",`+string(prefix)+`,`+raw+`]`))
+			}
+			if !fr.withholdEOSE() {
+				conn.WriteJSON([]interface{}{"EOSE", subID})
+			}
+		case "AUTH":
+			var ev nostr.Event
+			json.Unmarshal(msg[1], &ev)
+
+			ok, _ := ev.CheckSignature()
+			tag := ev.Tags.GetFirst([]string{"challenge", challenge})
+			if !ok || ev.Kind != nostr.KindClientAuthentication || tag == nil {
+				conn.WriteJSON([]interface{}{"OK", ev.ID, false, "invalid: bad auth event"})
+				continue
+			}
+			authed = true
+			fr.mu.Lock()
+			fr.authedBy = append(fr.authedBy, ev.PubKey)
+			fr.mu.Unlock()
+			conn.WriteJSON([]interface{}{"OK", ev.ID, true, ""})
+		case "EVENT":
+			var ev nostr.Event
+			json.Unmarshal(msg[1], &ev)
+
+			fr.mu.Lock()
+			fr.received = append(fr.received, ev)
+			reject := fr.reject
+			if fr.failNext > 0 {
+				fr.failNext--
+				reject = fr.failWith
+			}
+			fr.mu.Unlock()
+
+			if reject != "" {
+				conn.WriteJSON([]interface{}{"OK", ev.ID, false, reject})
+			} else {
+				conn.WriteJSON([]interface{}{"OK", ev.ID, true, ""})
+			}
+		}
+	}
+}
+
+// serveInfo answers a NIP-11 request with the configured info document.
+func (fr *fakeRelay) serveInfo(w http.ResponseWriter, r *http.Request) {
+	fr.mu.Lock()
+	info := fr.info
+	fr.mu.Unlock()
+
+	if info == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/nostr+json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// setInfo sets the NIP-11 document served by the relay.
+func (fr *fakeRelay) setInfo(info nip11.RelayInformationDocument) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.info = &info
+}
+
+// addRawEvent makes the relay answer REQs with an event written exactly as
+// raw, so tests can check what clients do with non-canonical JSON.
+func (fr *fakeRelay) addRawEvent(raw string) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.rawJSON = append(fr.rawJSON, raw)
+}
+
+// setSlow makes the relay hold each REQ for delay before answering,
+// reporting the time spent to gauge.
+func (fr *fakeRelay) setSlow(delay time.Duration, gauge *concurrencyGauge) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.reqDelay = delay
+	fr.gauge = gauge
+}
+
+// setRefuse makes the relay refuse new connections, simulating an outage.
+func (fr *fakeRelay) setRefuse(v bool) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.refuse = v
+}
+
+func (fr *fakeRelay) refusing() bool {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.refuse
+}
+
+// setReject makes the relay refuse every EVENT with the given reason.
+func (fr *fakeRelay) setReject(reason string) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.reject = reason
+}
+
+// setFailNext makes the relay refuse the next n EVENTs with the given reason.
+func (fr *fakeRelay) setFailNext(n int, reason string) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.failNext = n
+	fr.failWith = reason
+}
+
+// published returns the EVENTs received from clients so far.
+func (fr *fakeRelay) published() []nostr.Event {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return append([]nostr.Event(nil), fr.received...)
+}
+
+// setNoEOSE makes the relay stop sending EOSE, simulating a slow relay.
+func (fr *fakeRelay) setNoEOSE(v bool) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.noEOSE = v
+}
+
+func (fr *fakeRelay) withholdEOSE() bool {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.noEOSE
+}
+
+// signedEvent creates a kind 1 event signed with a fresh key.
+func signedEvent(t *testing.T, content string) nostr.Event {
+	t.Helper()
+	ev := nostr.Event{
+		Kind:      1,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{},
+		Content:   content,
+	}
+	if err := ev.Sign(nostr.GeneratePrivateKey()); err != nil {
+		t.Fatalf("failed to sign event: %v", err)
+	}
+	return ev
+}
+
+// lastFilter returns the filter of the most recent REQ.
+func (fr *fakeRelay) lastFilter() nostr.Filter {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	if len(fr.filters) == 0 {
+		return nostr.Filter{}
+	}
+	return fr.filters[len(fr.filters)-1]
+}
+
+// requests returns the number of REQ messages received so far.
+func (fr *fakeRelay) requests() int {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.reqCount
+}
+
+// requireAuth makes the relay close every REQ until the connection answers
+// its NIP-42 challenge.
+func (fr *fakeRelay) requireAuth() {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.authReq = true
+}
+
+// authenticated returns the pubkeys that completed AUTH so far.
+func (fr *fakeRelay) authenticated() []string {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return append([]string(nil), fr.authedBy...)
+}
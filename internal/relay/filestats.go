@@ -0,0 +1,100 @@
+package relay
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// kindFileMetadata is the NIP-94 file metadata event kind.
+const kindFileMetadata = 1063
+
+// fileSizeBuckets are the size histogram boundaries. The last bucket is
+// unbounded.
+var fileSizeBuckets = []types.SizeBucket{
+	{Label: "<10KB", MinBytes: 0, MaxBytes: 10 << 10},
+	{Label: "10KB-100KB", MinBytes: 10 << 10, MaxBytes: 100 << 10},
+	{Label: "100KB-1MB", MinBytes: 100 << 10, MaxBytes: 1 << 20},
+	{Label: "1MB-10MB", MinBytes: 1 << 20, MaxBytes: 10 << 20},
+	{Label: "10MB-100MB", MinBytes: 10 << 20, MaxBytes: 100 << 20},
+	{Label: ">=100MB", MinBytes: 100 << 20},
+}
+
+// aggregateFileMetadata builds MIME type and size distributions from the
+// kind 1063 events in events, using their m and size tags. Returns nil if
+// there are no file metadata events.
+func aggregateFileMetadata(events []types.Event) *types.FileStats {
+	var stats *types.FileStats
+	mimeCounts := make(map[string]int)
+
+	for _, event := range events {
+		if event.Kind != kindFileMetadata {
+			continue
+		}
+		if stats == nil {
+			stats = &types.FileStats{
+				MimeTypes:     []types.MimeCount{},
+				SizeHistogram: append([]types.SizeBucket(nil), fileSizeBuckets...),
+			}
+		}
+		stats.TotalFiles++
+
+		mime, size, hasSize := fileMetadataTags(event.Tags)
+		if mime == "" {
+			mime = "unknown"
+		}
+		mimeCounts[mime]++
+
+		if !hasSize {
+			stats.UnknownSize++
+			continue
+		}
+		stats.TotalBytes += size
+		for i := range stats.SizeHistogram {
+			bucket := &stats.SizeHistogram[i]
+			if size >= bucket.MinBytes && (bucket.MaxBytes == 0 || size < bucket.MaxBytes) {
+				bucket.Count++
+				break
+			}
+		}
+	}
+
+	if stats == nil {
+		return nil
+	}
+
+	for mime, count := range mimeCounts {
+		stats.MimeTypes = append(stats.MimeTypes, types.MimeCount{MimeType: mime, Count: count})
+	}
+	sort.Slice(stats.MimeTypes, func(i, j int) bool {
+		if stats.MimeTypes[i].Count != stats.MimeTypes[j].Count {
+			return stats.MimeTypes[i].Count > stats.MimeTypes[j].Count
+		}
+		return stats.MimeTypes[i].MimeType < stats.MimeTypes[j].MimeType
+	})
+
+	return stats
+}
+
+// fileMetadataTags returns the lowercased MIME type and the size in bytes
+// from NIP-94 tags. hasSize is false if the size tag is missing or invalid.
+func fileMetadataTags(tags [][]string) (mime string, size int64, hasSize bool) {
+	for _, tag := range tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "m":
+			if mime == "" {
+				mime = strings.ToLower(strings.TrimSpace(tag[1]))
+			}
+		case "size":
+			if n, err := strconv.ParseInt(strings.TrimSpace(tag[1]), 10, 64); err == nil && n >= 0 && !hasSize {
+				size, hasSize = n, true
+			}
+		}
+	}
+	return mime, size, hasSize
+}
@@ -0,0 +1,107 @@
+package relay
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func fileEvent(id, mime, size string) types.Event {
+	tags := [][]string{{"url", "https://example.com/" + id}}
+	if mime != "" {
+		tags = append(tags, []string{"m", mime})
+	}
+	if size != "" {
+		tags = append(tags, []string{"size", size})
+	}
+	return types.Event{ID: id, Kind: 1063, PubKey: "abc", Tags: tags, CreatedAt: 1700000000}
+}
+
+func TestAggregateFileMetadata(t *testing.T) {
+	events := []types.Event{
+		fileEvent("a", "image/png", "2048"),
+		fileEvent("b", "image/png", "524288"),
+		fileEvent("c", "Image/JPEG", "5242880"),
+		fileEvent("d", "video/mp4", "209715200"),
+		fileEvent("e", "", "10240"),
+		fileEvent("f", "image/png", "not-a-number"),
+		{ID: "note", Kind: 1, PubKey: "abc", Tags: [][]string{{"m", "text/plain"}, {"size", "1"}}},
+	}
+
+	stats := aggregateFileMetadata(events)
+	if stats == nil {
+		t.Fatal("expected file stats")
+	}
+
+	if stats.TotalFiles != 6 {
+		t.Errorf("TotalFiles = %d, want 6", stats.TotalFiles)
+	}
+	if stats.UnknownSize != 1 {
+		t.Errorf("UnknownSize = %d, want 1", stats.UnknownSize)
+	}
+	if want := int64(2048 + 524288 + 5242880 + 209715200 + 10240); stats.TotalBytes != want {
+		t.Errorf("TotalBytes = %d, want %d", stats.TotalBytes, want)
+	}
+
+	wantMimes := []types.MimeCount{
+		{MimeType: "image/png", Count: 3},
+		{MimeType: "image/jpeg", Count: 1},
+		{MimeType: "unknown", Count: 1},
+		{MimeType: "video/mp4", Count: 1},
+	}
+	if len(stats.MimeTypes) != len(wantMimes) {
+		t.Fatalf("MimeTypes = %v, want %v", stats.MimeTypes, wantMimes)
+	}
+	for i, want := range wantMimes {
+		if stats.MimeTypes[i] != want {
+			t.Errorf("MimeTypes[%d] = %+v, want %+v", i, stats.MimeTypes[i], want)
+		}
+	}
+
+	wantBuckets := map[string]int{
+		"<10KB":      1,
+		"10KB-100KB": 1,
+		"100KB-1MB":  1,
+		"1MB-10MB":   1,
+		"10MB-100MB": 0,
+		">=100MB":    1,
+	}
+	if len(stats.SizeHistogram) != len(wantBuckets) {
+		t.Fatalf("expected %d buckets, got %d", len(wantBuckets), len(stats.SizeHistogram))
+	}
+	for _, bucket := range stats.SizeHistogram {
+		if bucket.Count != wantBuckets[bucket.Label] {
+			t.Errorf("bucket %s count = %d, want %d", bucket.Label, bucket.Count, wantBuckets[bucket.Label])
+		}
+	}
+}
+
+func TestAggregateFileMetadata_DoesNotShareBuckets(t *testing.T) {
+	aggregateFileMetadata([]types.Event{fileEvent("a", "image/png", "1")})
+	for _, bucket := range fileSizeBuckets {
+		if bucket.Count != 0 {
+			t.Fatalf("expected package buckets to stay empty, got %+v", bucket)
+		}
+	}
+}
+
+func TestAggregateEventData_FileStatsOnlyWithFileMetadata(t *testing.T) {
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	agg := pool.aggregateEventData([]types.Event{{ID: "note", Kind: 1, PubKey: "abc", CreatedAt: 1700000000}}, 0)
+	if agg.FileStats != nil {
+		t.Errorf("expected no file stats without kind 1063 events, got %+v", agg.FileStats)
+	}
+	data, _ := json.Marshal(agg)
+	if strings.Contains(string(data), "file_stats") {
+		t.Errorf("expected file_stats to be omitted, got %s", data)
+	}
+
+	agg = pool.aggregateEventData([]types.Event{fileEvent("a", "image/png", "100")}, 0)
+	if agg.FileStats == nil || agg.FileStats.TotalFiles != 1 {
+		t.Errorf("expected file stats for one file, got %+v", agg.FileStats)
+	}
+}
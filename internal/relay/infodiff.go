@@ -0,0 +1,113 @@
+package relay
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// DiffRelayInfo re-fetches a relay's NIP-11 document and compares it with
+// the last one seen, which it then replaces. Works for relays outside the
+// pool too. Only an invalid URL returns an error; fetch failures are
+// reported in the result and leave the stored document alone.
+func (p *Pool) DiffRelayInfo(url string) (*types.RelayInfoDiff, error) {
+	url, err := config.NormalizeRelayURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := p.lastSeenRelayInfo(url)
+	result := &types.RelayInfoDiff{
+		URL:         url,
+		HasPrevious: previous != nil,
+		Previous:    previous,
+		Changes:     []types.RelayInfoChange{},
+	}
+
+	current, err := p.FetchRelayInfoCached(url, true)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Current = current
+
+	p.mu.Lock()
+	if conn, exists := p.relays[url]; exists {
+		conn.Info = current
+		conn.SupportedNIPs = current.SupportedNIPs
+	}
+	p.mu.Unlock()
+
+	if previous != nil {
+		result.Changes = diffRelayInfo(previous, current)
+	}
+	return result, nil
+}
+
+// lastSeenRelayInfo returns the last document fetched for url, preferring the
+// info cache and falling back to the pool connection.
+func (p *Pool) lastSeenRelayInfo(url string) *types.RelayInfo {
+	if p.infoCache != nil {
+		if info := p.infoCache.LastSeen(url); info != nil {
+			return info
+		}
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if conn, exists := p.relays[url]; exists {
+		return conn.Info
+	}
+	return nil
+}
+
+// diffRelayInfo compares two documents field by field, using their JSON
+// names. Nested objects such as limitation are compared per field; lists
+// such as supported_nips are compared as a whole. Changes are sorted by field.
+func diffRelayInfo(previous, current *types.RelayInfo) []types.RelayInfoChange {
+	changes := []types.RelayInfoChange{}
+	diffFields("", flattenInfo(previous), flattenInfo(current), &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// flattenInfo converts info to its JSON object form. Fields omitted from the
+// JSON, because they are empty, are treated as absent.
+func flattenInfo(info *types.RelayInfo) map[string]interface{} {
+	fields := map[string]interface{}{}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// diffFields appends the differences between two JSON objects to changes,
+// recursing into objects present on both sides.
+func diffFields(prefix string, old, new map[string]interface{}, changes *[]types.RelayInfoChange) {
+	for key, oldValue := range old {
+		field := prefix + key
+		newValue, ok := new[key]
+		if !ok {
+			*changes = append(*changes, types.RelayInfoChange{Field: field, Type: "removed", Old: oldValue})
+			continue
+		}
+		oldObj, oldIsObj := oldValue.(map[string]interface{})
+		newObj, newIsObj := newValue.(map[string]interface{})
+		if oldIsObj && newIsObj {
+			diffFields(field+".", oldObj, newObj, changes)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			*changes = append(*changes, types.RelayInfoChange{Field: field, Type: "changed", Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range new {
+		if _, ok := old[key]; !ok {
+			*changes = append(*changes, types.RelayInfoChange{Field: prefix + key, Type: "added", New: newValue})
+		}
+	}
+}
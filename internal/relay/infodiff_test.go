@@ -0,0 +1,155 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr/nip11"
+)
+
+// sequenceFetch returns a fetch function that serves docs in order, repeating
+// the last one once they run out.
+func sequenceFetch(docs ...nip11.RelayInformationDocument) infoFetchFunc {
+	calls := 0
+	return func(ctx context.Context, url string) (infoDocument, error) {
+		doc := docs[min(calls, len(docs)-1)]
+		calls++
+		return infoDocument{RelayInformationDocument: doc}, nil
+	}
+}
+
+func findChange(changes []types.RelayInfoChange, field string) *types.RelayInfoChange {
+	for i := range changes {
+		if changes[i].Field == field {
+			return &changes[i]
+		}
+	}
+	return nil
+}
+
+func TestDiffRelayInfo_ComparesLimitationAndNIPs(t *testing.T) {
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	pool.fetchInfo = sequenceFetch(
+		nip11.RelayInformationDocument{
+			Name:          "Relay",
+			SupportedNIPs: []int{1, 11},
+			Limitation:    &nip11.RelayLimitationDocument{MaxLimit: 500, MinPowDifficulty: 0},
+		},
+		nip11.RelayInformationDocument{
+			Name:          "Relay",
+			SupportedNIPs: []int{1, 11, 42},
+			Limitation:    &nip11.RelayLimitationDocument{MaxLimit: 500, MinPowDifficulty: 20, PaymentRequired: true},
+		},
+	)
+
+	first, err := pool.DiffRelayInfo("wss://relay.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.HasPrevious || len(first.Changes) != 0 {
+		t.Errorf("first fetch should have nothing to diff against, got %+v", first)
+	}
+
+	diff, err := pool.DiffRelayInfo("wss://relay.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !diff.HasPrevious {
+		t.Fatal("expected the first document to be stored as previous")
+	}
+	if len(diff.Changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", diff.Changes)
+	}
+
+	nips := findChange(diff.Changes, "supported_nips")
+	if nips == nil || nips.Type != "changed" {
+		t.Errorf("expected supported_nips changed, got %+v", nips)
+	}
+	pow := findChange(diff.Changes, "limitation.min_pow_difficulty")
+	if pow == nil || pow.Type != "added" || pow.New != float64(20) {
+		t.Errorf("expected min_pow_difficulty added as 20, got %+v", pow)
+	}
+	paid := findChange(diff.Changes, "limitation.payment_required")
+	if paid == nil || paid.Type != "added" || paid.New != true {
+		t.Errorf("expected payment_required added, got %+v", paid)
+	}
+	if findChange(diff.Changes, "limitation.max_limit") != nil {
+		t.Error("unchanged max_limit should not be reported")
+	}
+}
+
+func TestDiffRelayInfo_RemovedAndChangedFields(t *testing.T) {
+	previous := &types.RelayInfo{
+		Name:       "Old Name",
+		Contact:    "admin@example.com",
+		Limitation: &types.RelayLimitation{MaxLimit: 500, AuthRequired: true},
+	}
+	current := &types.RelayInfo{
+		Name:       "New Name",
+		Limitation: &types.RelayLimitation{MaxLimit: 100},
+	}
+
+	changes := diffRelayInfo(previous, current)
+
+	want := []types.RelayInfoChange{
+		{Field: "contact", Type: "removed", Old: "admin@example.com"},
+		{Field: "limitation.auth_required", Type: "removed", Old: true},
+		{Field: "limitation.max_limit", Type: "changed", Old: float64(500), New: float64(100)},
+		{Field: "name", Type: "changed", Old: "Old Name", New: "New Name"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("changes[%d] = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+}
+
+func TestDiffRelayInfo_KeepsPreviousAcrossFailures(t *testing.T) {
+	withFastInfoRetries(t)
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	pool.fetchInfo = sequenceFetch(nip11.RelayInformationDocument{Name: "Relay"})
+	if _, err := pool.DiffRelayInfo("wss://relay.example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool.fetchInfo = func(ctx context.Context, url string) (infoDocument, error) {
+		return infoDocument{}, errors.New("connection refused")
+	}
+	failed, err := pool.DiffRelayInfo("wss://relay.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if failed.Error == "" {
+		t.Error("expected the fetch failure to be reported")
+	}
+
+	pool.fetchInfo = sequenceFetch(nip11.RelayInformationDocument{Name: "Renamed"})
+	diff, err := pool.DiffRelayInfo("wss://relay.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !diff.HasPrevious || diff.Previous.Name != "Relay" {
+		t.Fatalf("expected previous document to survive the failure, got %+v", diff.Previous)
+	}
+	if change := findChange(diff.Changes, "name"); change == nil || change.New != "Renamed" {
+		t.Errorf("expected name change, got %+v", diff.Changes)
+	}
+}
+
+func TestDiffRelayInfo_RejectsInvalidURL(t *testing.T) {
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	if _, err := pool.DiffRelayInfo("http://not-a-relay"); err == nil {
+		t.Error("expected an error for a non-websocket URL")
+	}
+}
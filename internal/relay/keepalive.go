@@ -0,0 +1,82 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/logging"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// startKeepAliveLocked starts the keep-alive goroutine for a connected relay.
+// It is a no-op if keep-alives are disabled. Caller must hold the pool mutex.
+func (p *Pool) startKeepAliveLocked(conn *RelayConn) {
+	if p.opts.KeepAliveInterval <= 0 || conn.Relay == nil {
+		return
+	}
+
+	conn.stopKeepAliveLocked()
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	done := make(chan struct{})
+	conn.stopKeepAlive = cancel
+	conn.keepAliveDone = done
+
+	go keepAlive(ctx, conn.URL, conn.Relay, p.opts.KeepAliveInterval, p.queryTimeout(), done)
+}
+
+// stopKeepAliveLocked stops the relay's keep-alive goroutine, if running.
+// Caller must hold the pool mutex.
+func (c *RelayConn) stopKeepAliveLocked() {
+	if c.stopKeepAlive != nil {
+		c.stopKeepAlive()
+		c.stopKeepAlive = nil
+	}
+}
+
+// keepAlive periodically exercises the relay connection until ctx is cancelled
+// or the connection closes, giving each request up to timeout to be answered.
+// done is closed when the goroutine exits.
+func keepAlive(ctx context.Context, url string, relay *nostr.Relay, interval, timeout time.Duration, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-relay.Context().Done():
+			return
+		case <-ticker.C:
+			if err := pingRelay(ctx, relay, timeout); err != nil && ctx.Err() == nil {
+				logging.Warnf("[Relay] Keep-alive failed for %s: %v", url, err)
+			}
+		}
+	}
+}
+
+// pingRelay sends a subscription that matches nothing ("limit":0) and waits for
+// the relay to answer with EOSE. This is application-level traffic, so it keeps
+// connections alive through proxies that ignore WebSocket ping frames.
+func pingRelay(ctx context.Context, relay *nostr.Relay, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sub, err := relay.Subscribe(ctx, nostr.Filters{{Kinds: []int{0}, LimitZero: true}})
+	if err != nil {
+		return err
+	}
+	defer sub.Unsub()
+
+	select {
+	case <-sub.EndOfStoredEvents:
+		return nil
+	case reason := <-sub.ClosedReason:
+		return fmt.Errorf("subscription closed: %s", reason)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
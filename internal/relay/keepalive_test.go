@@ -0,0 +1,93 @@
+package relay
+
+import (
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it returns true or the timeout elapses.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return cond()
+}
+
+func TestKeepAliveSendsRequestsAndStopsOnRemove(t *testing.T) {
+	fr := newFakeRelay(t)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{KeepAliveInterval: 20 * time.Millisecond})
+	defer pool.Close()
+
+	if _, err := pool.Add(fr.URL); err != nil {
+		t.Fatalf("unexpected error adding relay: %v", err)
+	}
+
+	var done chan struct{}
+	connected := waitFor(t, 5*time.Second, func() bool {
+		pool.mu.RLock()
+		defer pool.mu.RUnlock()
+		conn := pool.relays[fr.URL]
+		if conn == nil || !conn.Connected {
+			return false
+		}
+		done = conn.keepAliveDone
+		return true
+	})
+	if !connected {
+		t.Fatal("relay never connected")
+	}
+	if done == nil {
+		t.Fatal("expected keep-alive goroutine to be started on connect")
+	}
+
+	if !waitFor(t, 5*time.Second, func() bool { return fr.requests() >= 2 }) {
+		t.Fatalf("expected keep-alive requests, relay saw %d", fr.requests())
+	}
+
+	pool.Remove(fr.URL)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("keep-alive goroutine was not stopped after removal")
+	}
+
+	// No further keep-alive traffic once the goroutine has exited
+	count := fr.requests()
+	time.Sleep(100 * time.Millisecond)
+	if fr.requests() != count {
+		t.Errorf("expected no requests after removal, got %d more", fr.requests()-count)
+	}
+}
+
+func TestKeepAliveDisabled(t *testing.T) {
+	fr := newFakeRelay(t)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{})
+	defer pool.Close()
+
+	pool.Add(fr.URL)
+
+	connected := waitFor(t, 5*time.Second, func() bool {
+		pool.mu.RLock()
+		defer pool.mu.RUnlock()
+		conn := pool.relays[fr.URL]
+		return conn != nil && conn.Connected
+	})
+	if !connected {
+		t.Fatal("relay never connected")
+	}
+
+	pool.mu.RLock()
+	done := pool.relays[fr.URL].keepAliveDone
+	pool.mu.RUnlock()
+	if done != nil {
+		t.Error("expected no keep-alive goroutine when the interval is zero")
+	}
+}
@@ -0,0 +1,129 @@
+package relay
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// DefaultMaxTrackedKinds bounds the per-kind event rate tracker when the pool
+// is created with NewPool.
+const DefaultMaxTrackedKinds = 50
+
+// kindRateTracker keeps an event rate time series for each event kind seen on
+// subscriptions. Once maxKinds kinds are tracked, events of new kinds are only
+// counted as untracked so memory stays bounded.
+type kindRateTracker struct {
+	mu         sync.Mutex
+	kinds      map[int]*kindMetrics
+	maxKinds   int
+	bufferSize int
+	untracked  int64
+	lastSample time.Time
+}
+
+// kindMetrics holds the event counts and rate history for a single kind.
+type kindMetrics struct {
+	total       int64
+	sinceSample int64
+	perSec      float64
+	history     *TimeSeriesRingBuffer
+}
+
+// newKindRateTracker creates a tracker for up to maxKinds kinds, or returns
+// nil if maxKinds is not positive, which disables per-kind tracking.
+func newKindRateTracker(maxKinds, bufferSize int) *kindRateTracker {
+	if maxKinds <= 0 {
+		return nil
+	}
+	return &kindRateTracker{
+		kinds:      make(map[int]*kindMetrics),
+		maxKinds:   maxKinds,
+		bufferSize: bufferSize,
+		lastSample: time.Now(),
+	}
+}
+
+// record counts one event of the given kind.
+func (t *kindRateTracker) record(kind int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	metrics, exists := t.kinds[kind]
+	if !exists {
+		if len(t.kinds) >= t.maxKinds {
+			t.untracked++
+			return
+		}
+		metrics = &kindMetrics{history: NewTimeSeriesRingBuffer(t.bufferSize)}
+		t.kinds[kind] = metrics
+	}
+	metrics.total++
+	metrics.sinceSample++
+}
+
+// sample turns the events counted since the previous sample into a rate per
+// kind and appends it to each kind's history.
+func (t *kindRateTracker) sample(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elapsed := now.Sub(t.lastSample).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	for _, metrics := range t.kinds {
+		metrics.perSec = float64(metrics.sinceSample) / elapsed
+		metrics.sinceSample = 0
+		metrics.history.Add(now.Unix(), metrics.perSec)
+	}
+	t.lastSample = now
+}
+
+// snapshot returns the tracked kinds, busiest first.
+func (t *kindRateTracker) snapshot() *types.KindMonitoringData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	kinds := make([]types.KindRate, 0, len(t.kinds))
+	for kind, metrics := range t.kinds {
+		kinds = append(kinds, types.KindRate{
+			Kind:         kind,
+			TotalEvents:  metrics.total,
+			EventsPerSec: metrics.perSec,
+			RateHistory:  metrics.history.GetAll(),
+		})
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].TotalEvents != kinds[j].TotalEvents {
+			return kinds[i].TotalEvents > kinds[j].TotalEvents
+		}
+		return kinds[i].Kind < kinds[j].Kind
+	})
+
+	return &types.KindMonitoringData{
+		Kinds:           kinds,
+		MaxKinds:        t.maxKinds,
+		UntrackedEvents: t.untracked,
+		Timestamp:       time.Now().Unix(),
+	}
+}
+
+// RecordEventKind records that a subscription received an event of the given
+// kind. It is a no-op when per-kind tracking is disabled.
+func (m *Monitor) RecordEventKind(kind int) {
+	if m.kinds != nil {
+		m.kinds.record(kind)
+	}
+}
+
+// GetKindRates returns the per-kind event rates. Kinds is empty when per-kind
+// tracking is disabled.
+func (m *Monitor) GetKindRates() *types.KindMonitoringData {
+	if m.kinds == nil {
+		return &types.KindMonitoringData{Kinds: []types.KindRate{}, Timestamp: time.Now().Unix()}
+	}
+	return m.kinds.snapshot()
+}
@@ -0,0 +1,130 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestKindRateTrackerCountsPerKind(t *testing.T) {
+	tracker := newKindRateTracker(10, 5)
+	start := tracker.lastSample
+
+	for i := 0; i < 6; i++ {
+		tracker.record(1)
+	}
+	for i := 0; i < 3; i++ {
+		tracker.record(7)
+	}
+	tracker.record(0)
+
+	tracker.sample(start.Add(2 * time.Second))
+	tracker.record(1)
+
+	data := tracker.snapshot()
+	if len(data.Kinds) != 3 {
+		t.Fatalf("expected 3 kinds, got %+v", data.Kinds)
+	}
+
+	want := []struct {
+		kind   int
+		total  int64
+		perSec float64
+	}{
+		{1, 7, 3},
+		{7, 3, 1.5},
+		{0, 1, 0.5},
+	}
+	for i, w := range want {
+		got := data.Kinds[i]
+		if got.Kind != w.kind || got.TotalEvents != w.total || got.EventsPerSec != w.perSec {
+			t.Errorf("kinds[%d] = kind %d total %d rate %v, want kind %d total %d rate %v",
+				i, got.Kind, got.TotalEvents, got.EventsPerSec, w.kind, w.total, w.perSec)
+		}
+		if len(got.RateHistory) != 1 {
+			t.Errorf("kind %d: expected 1 history point, got %d", got.Kind, len(got.RateHistory))
+		}
+	}
+}
+
+func TestKindRateTrackerBoundsTrackedKinds(t *testing.T) {
+	tracker := newKindRateTracker(2, 5)
+
+	tracker.record(1)
+	tracker.record(3)
+	tracker.record(4)
+	tracker.record(5)
+	tracker.record(1)
+
+	data := tracker.snapshot()
+	if len(data.Kinds) != 2 {
+		t.Fatalf("expected 2 tracked kinds, got %+v", data.Kinds)
+	}
+	if data.UntrackedEvents != 2 {
+		t.Errorf("untracked events = %d, want 2", data.UntrackedEvents)
+	}
+	if data.MaxKinds != 2 {
+		t.Errorf("max kinds = %d, want 2", data.MaxKinds)
+	}
+}
+
+func TestKindRateTrackerDisabled(t *testing.T) {
+	if newKindRateTracker(0, 5) != nil {
+		t.Fatal("expected tracking to be disabled with zero max kinds")
+	}
+
+	pool := &Pool{relays: make(map[string]*RelayConn)}
+	m := NewMonitor(pool)
+	m.RecordEventKind(1)
+	if data := m.GetKindRates(); len(data.Kinds) != 0 {
+		t.Errorf("expected no kinds while disabled, got %+v", data.Kinds)
+	}
+}
+
+func TestSubscribeRecordsKindRates(t *testing.T) {
+	sign := func(kind int, content string) nostr.Event {
+		ev := nostr.Event{Kind: kind, CreatedAt: nostr.Now(), Tags: nostr.Tags{}, Content: content}
+		if err := ev.Sign(nostr.GeneratePrivateKey()); err != nil {
+			t.Fatalf("failed to sign event: %v", err)
+		}
+		return ev
+	}
+	fr := newFakeRelay(t,
+		sign(1, "note a"), sign(1, "note b"), sign(1, "note c"),
+		sign(7, "+"), sign(7, "+"),
+		sign(0, "{}"),
+	)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{MaxTrackedKinds: 10})
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	received := make(chan types.Event, 10)
+	subID := pool.Subscribe([]int{0, 1, 7}, nil, func(ev types.Event) { received <- ev })
+	defer pool.Unsubscribe(subID)
+
+	for i := 0; i < 6; i++ {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received only %d of 6 events", i)
+		}
+	}
+
+	counts := make(map[int]int64)
+	for _, k := range pool.KindRates().Kinds {
+		counts[k.Kind] = k.TotalEvents
+	}
+	want := map[int]int64{1: 3, 7: 2, 0: 1}
+	for kind, total := range want {
+		if counts[kind] != total {
+			t.Errorf("kind %d total = %d, want %d (all: %v)", kind, counts[kind], total, counts)
+		}
+	}
+}
@@ -4,6 +4,8 @@ package relay
 import (
 	"context"
 	"log"
+	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -83,6 +85,27 @@ func (rb *TimeSeriesRingBuffer) GetLast(n int) []types.TimeSeriesPoint {
 	return result
 }
 
+// Percentiles returns the given percentiles (0-100) of the stored values,
+// using the nearest-rank method. It returns nil if the buffer is empty.
+func (rb *TimeSeriesRingBuffer) Percentiles(ps ...float64) []float64 {
+	if rb.count == 0 {
+		return nil
+	}
+
+	values := make([]float64, 0, rb.count)
+	for _, point := range rb.GetAll() {
+		values = append(values, point.Value)
+	}
+	sort.Float64s(values)
+
+	result := make([]float64, len(ps))
+	for i, p := range ps {
+		rank := int(math.Ceil(p / 100 * float64(len(values))))
+		result[i] = values[min(max(rank, 1), len(values))-1]
+	}
+	return result
+}
+
 // Len returns the number of elements in the buffer.
 func (rb *TimeSeriesRingBuffer) Len() int {
 	return rb.count
@@ -106,6 +129,7 @@ type Monitor struct {
 	mu             sync.RWMutex
 	interval       time.Duration
 	ringBufferSize int
+	kinds          *kindRateTracker // nil when per-kind tracking is disabled
 }
 
 // relayMetrics holds metrics for a single relay.
@@ -122,6 +146,9 @@ type relayMetrics struct {
 	LastError      string
 	CheckCount     int64
 	SuccessCount   int64
+	// EOSEHistory records recent query outcomes: 1 when the query timed
+	// out without EOSE, 0 when the relay sent EOSE.
+	EOSEHistory *TimeSeriesRingBuffer
 }
 
 // NewMonitor creates a new relay monitor.
@@ -131,6 +158,7 @@ func NewMonitor(pool *Pool) *Monitor {
 		stats:          make(map[string]*relayMetrics),
 		interval:       30 * time.Second,
 		ringBufferSize: DefaultRingBufferSize,
+		kinds:          newKindRateTracker(pool.opts.MaxTrackedKinds, DefaultRingBufferSize),
 	}
 }
 
@@ -141,6 +169,7 @@ func NewMonitorWithBufferSize(pool *Pool, bufferSize int) *Monitor {
 		stats:          make(map[string]*relayMetrics),
 		interval:       30 * time.Second,
 		ringBufferSize: bufferSize,
+		kinds:          newKindRateTracker(pool.opts.MaxTrackedKinds, bufferSize),
 	}
 }
 
@@ -150,6 +179,7 @@ func (m *Monitor) newRelayMetrics(url string) *relayMetrics {
 		URL:            url,
 		LatencyHistory: NewTimeSeriesRingBuffer(m.ringBufferSize),
 		EventHistory:   NewTimeSeriesRingBuffer(m.ringBufferSize),
+		EOSEHistory:    NewTimeSeriesRingBuffer(eoseHistorySize),
 	}
 }
 
@@ -181,6 +211,9 @@ func (m *Monitor) checkAll() {
 
 	// Calculate events per second
 	m.calculateRates()
+	if m.kinds != nil {
+		m.kinds.sample(time.Now())
+	}
 }
 
 // checkRelay checks a single relay's latency.
@@ -197,7 +230,7 @@ func (m *Monitor) checkRelay(url string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	relay, err := nostr.RelayConnect(ctx, url)
+	relay, err := nostr.RelayConnect(ctx, url, m.pool.relayOptions()...)
 	if err != nil {
 		log.Printf("[Monitor] Failed to connect to %s: %v", url, err)
 		m.mu.Lock()
@@ -263,6 +296,24 @@ func (m *Monitor) RecordEvent(url string) {
 	}
 }
 
+// RecordQueryOutcome records whether a query on a relay reached EOSE or timed
+// out waiting for it.
+func (m *Monitor) RecordQueryOutcome(url string, eoseReceived bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metrics, exists := m.stats[url]
+	if !exists {
+		metrics = m.newRelayMetrics(url)
+		m.stats[url] = metrics
+	}
+	var timedOut float64
+	if !eoseReceived {
+		timedOut = 1
+	}
+	metrics.EOSEHistory.Add(time.Now().Unix(), timedOut)
+}
+
 // calculateRates calculates events per second for each relay.
 func (m *Monitor) calculateRates() {
 	m.mu.Lock()
@@ -287,12 +338,18 @@ func (m *Monitor) GetStats() map[string]types.RelayStats {
 
 	result := make(map[string]types.RelayStats)
 	for url, metrics := range m.stats {
-		result[url] = types.RelayStats{
+		stats := types.RelayStats{
 			URL:          url,
 			Latency:      metrics.Latency,
 			EventsPerSec: metrics.EventsPerSec,
 			TotalEvents:  metrics.EventCount,
 		}
+		if p := metrics.LatencyHistory.Percentiles(50, 95, 99); p != nil {
+			stats.LatencyP50 = int64(math.Round(p[0]))
+			stats.LatencyP95 = int64(math.Round(p[1]))
+			stats.LatencyP99 = int64(math.Round(p[2]))
+		}
+		result[url] = stats
 	}
 	return result
 }
@@ -322,7 +379,7 @@ func (m *Monitor) GetRelayHealth(url string) *types.RelayHealth {
 	}
 
 	// Calculate health score
-	healthScore := m.CalculateHealthScore(metrics, connected)
+	factors := m.healthFactors(metrics, connected)
 
 	return &types.RelayHealth{
 		URL:              url,
@@ -332,7 +389,8 @@ func (m *Monitor) GetRelayHealth(url string) *types.RelayHealth {
 		EventsPerSec:     metrics.EventsPerSec,
 		EventRateHistory: metrics.EventHistory.GetAll(),
 		Uptime:           uptime,
-		HealthScore:      healthScore,
+		HealthScore:      healthScore(factors),
+		HealthFactors:    factors,
 		LastSeen:         metrics.LastCheck.Unix(),
 		ErrorCount:       metrics.ErrorCount,
 		LastError:        metrics.LastError,
@@ -365,7 +423,7 @@ func (m *Monitor) GetMonitoringData() *types.MonitoringData {
 		}
 
 		// Calculate health score
-		healthScore := m.CalculateHealthScore(metrics, connected)
+		factors := m.healthFactors(metrics, connected)
 
 		relays = append(relays, types.RelayHealth{
 			URL:              url,
@@ -375,7 +433,8 @@ func (m *Monitor) GetMonitoringData() *types.MonitoringData {
 			EventsPerSec:     metrics.EventsPerSec,
 			EventRateHistory: metrics.EventHistory.GetAll(),
 			Uptime:           uptime,
-			HealthScore:      healthScore,
+			HealthScore:      healthScore(factors),
+			HealthFactors:    factors,
 			LastSeen:         metrics.LastCheck.Unix(),
 			ErrorCount:       metrics.ErrorCount,
 			LastError:        metrics.LastError,
@@ -407,6 +466,21 @@ func (m *Monitor) GetRelayLatency(url string) int64 {
 	return -1
 }
 
+// Health score weights; they add up to 1.
+const (
+	connectionWeight = 0.30
+	latencyWeight    = 0.25
+	uptimeWeight     = 0.25
+	errorWeight      = 0.20
+)
+
+// eoseHistorySize is how many recent query outcomes are kept per relay, and
+// minEOSESamples how many are needed before timeouts count against it.
+const (
+	eoseHistorySize = 20
+	minEOSESamples  = 3
+)
+
 // CalculateHealthScore computes a health score (0-100) for a relay based on
 // connection status, latency, uptime, and error rate.
 //
@@ -415,41 +489,83 @@ func (m *Monitor) GetRelayLatency(url string) int64 {
 //   - Latency score (25%): Based on latency in ms, lower is better
 //   - Uptime percentage (25%): Direct percentage from check history
 //   - Error rate score (20%): Based on recent error count, fewer is better
+//
+// Connected relays whose recent queries time out without EOSE are then
+// penalized in proportion to the pool's EOSETimeoutPenalty.
 func (m *Monitor) CalculateHealthScore(metrics *relayMetrics, connected bool) float64 {
-	// Connection status: 30% weight
+	return healthScore(m.healthFactors(metrics, connected))
+}
+
+// healthFactors scores a relay with the pool's EOSE timeout penalty.
+func (m *Monitor) healthFactors(metrics *relayMetrics, connected bool) types.HealthFactors {
+	return calculateHealthFactors(metrics, connected, m.pool.opts.EOSETimeoutPenalty)
+}
+
+// calculateHealthFactors scores each component of the health score.
+// eosePenalty weights the EOSE timeout penalty; zero disables it.
+func calculateHealthFactors(metrics *relayMetrics, connected bool, eosePenalty float64) types.HealthFactors {
+	// Connection status: 100 if connected, 0 if not
 	var connectionScore float64
 	if connected {
 		connectionScore = 100.0
 	}
 
-	// Latency score: 25% weight
-	// < 100ms = 100, 100-500ms = linear scale 100->50, 500-2000ms = linear 50->0, >2000ms = 0
-	latencyScore := calculateLatencyScore(metrics.Latency)
-
-	// Uptime score: 25% weight (direct percentage)
+	// Uptime: direct percentage of successful checks
 	var uptimeScore float64
 	if metrics.CheckCount > 0 {
 		uptimeScore = float64(metrics.SuccessCount) / float64(metrics.CheckCount) * 100
 	}
 
-	// Error rate score: 20% weight
-	// 0 errors = 100, 1-5 errors = linear 100->50, 6-20 errors = linear 50->0, >20 = 0
-	errorScore := calculateErrorScore(metrics.ErrorCount)
+	var penaltyWeight float64
+	if eosePenalty > 0 {
+		penaltyWeight = -eosePenalty
+	}
 
-	// Weighted average
-	score := (connectionScore * 0.30) +
-		(latencyScore * 0.25) +
-		(uptimeScore * 0.25) +
-		(errorScore * 0.20)
+	return types.HealthFactors{
+		Connection: weightedFactor(connectionScore, connectionWeight),
+		// < 100ms = 100, 100-500ms = linear scale 100->50, 500-2000ms = linear 50->0, >2000ms = 0
+		Latency: weightedFactor(calculateLatencyScore(metrics.Latency), latencyWeight),
+		Uptime:  weightedFactor(uptimeScore, uptimeWeight),
+		// 0 errors = 100, 1-5 errors = linear 100->50, 6-20 errors = linear 50->0, >20 = 0
+		Errors: weightedFactor(calculateErrorScore(metrics.ErrorCount), errorWeight),
+		// Only a connected relay can be a zombie; a disconnected one already
+		// scores nothing for its connection.
+		EOSETimeouts: weightedFactor(eoseTimeoutRate(metrics, connected, eosePenalty), penaltyWeight),
+	}
+}
+
+// eoseTimeoutRate returns the percentage of recent queries that timed out
+// without EOSE, or 0 when the penalty is disabled, the relay is not connected
+// or too few queries have been recorded.
+func eoseTimeoutRate(metrics *relayMetrics, connected bool, eosePenalty float64) float64 {
+	if eosePenalty <= 0 || !connected || metrics.EOSEHistory == nil || metrics.EOSEHistory.Len() < minEOSESamples {
+		return 0
+	}
+	var timedOut float64
+	for _, point := range metrics.EOSEHistory.GetAll() {
+		timedOut += point.Value
+	}
+	return timedOut / float64(metrics.EOSEHistory.Len()) * 100
+}
+
+func weightedFactor(score, weight float64) types.HealthFactor {
+	return types.HealthFactor{Score: score, Weight: weight, Contribution: score * weight}
+}
+
+// healthScore sums the factor contributions, clamped to 0-100.
+func healthScore(f types.HealthFactors) float64 {
+	score := f.Connection.Contribution +
+		f.Latency.Contribution +
+		f.Uptime.Contribution +
+		f.Errors.Contribution +
+		f.EOSETimeouts.Contribution
 
-	// Clamp to 0-100 range
 	if score < 0 {
 		score = 0
 	}
 	if score > 100 {
 		score = 100
 	}
-
 	return score
 }
 
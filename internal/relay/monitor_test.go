@@ -1,7 +1,11 @@
 package relay
 
 import (
+	"math"
 	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
 )
 
 func TestNewTimeSeriesRingBuffer(t *testing.T) {
@@ -264,6 +268,66 @@ func TestTimeSeriesRingBufferSingleItem(t *testing.T) {
 	}
 }
 
+func TestTimeSeriesRingBufferPercentiles(t *testing.T) {
+	rb := NewTimeSeriesRingBuffer(100)
+	if rb.Percentiles(50) != nil {
+		t.Error("expected nil percentiles for an empty buffer")
+	}
+
+	// Latencies 1..100 added out of order.
+	for i := 0; i < 100; i++ {
+		rb.Add(int64(i), float64((i*37)%100+1))
+	}
+
+	got := rb.Percentiles(50, 95, 99, 100)
+	want := []float64{50, 95, 99, 100}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("percentile %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTimeSeriesRingBufferPercentilesUseStoredPointsOnly(t *testing.T) {
+	rb := NewTimeSeriesRingBuffer(4)
+	for _, v := range []float64{1000, 1000, 10, 20, 30, 40} {
+		rb.Add(0, v)
+	}
+
+	got := rb.Percentiles(0, 50, 99)
+	want := []float64{10, 20, 40}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("percentile %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMonitorGetStatsLatencyPercentiles(t *testing.T) {
+	pool := &Pool{
+		relays: make(map[string]*RelayConn),
+	}
+	m := NewMonitor(pool)
+
+	history := NewTimeSeriesRingBuffer(m.ringBufferSize)
+	for _, latency := range []float64{120, 80, 100, 90, 110, 95, 105, 85, 115, 900} {
+		history.Add(0, latency)
+	}
+	m.mu.Lock()
+	m.stats["wss://relay1.com"] = &relayMetrics{
+		URL:            "wss://relay1.com",
+		Latency:        900,
+		LatencyHistory: history,
+		EventHistory:   NewTimeSeriesRingBuffer(m.ringBufferSize),
+	}
+	m.mu.Unlock()
+
+	s := m.GetStats()["wss://relay1.com"]
+	if s.LatencyP50 != 100 || s.LatencyP95 != 900 || s.LatencyP99 != 900 {
+		t.Errorf("percentiles = p50 %d p95 %d p99 %d, want 100/900/900", s.LatencyP50, s.LatencyP95, s.LatencyP99)
+	}
+}
+
 func TestNewMonitorWithBufferSize(t *testing.T) {
 	pool := &Pool{
 		relays: make(map[string]*RelayConn),
@@ -757,3 +821,150 @@ func TestGetMonitoringDataIncludesHealthScore(t *testing.T) {
 		t.Errorf("expected high health score (>=95), got %f", data.Relays[0].HealthScore)
 	}
 }
+
+func TestHealthFactorsRecombineIntoScore(t *testing.T) {
+	pool := &Pool{
+		relays: make(map[string]*RelayConn),
+	}
+	m := NewMonitor(pool)
+
+	cases := []struct {
+		metrics   *relayMetrics
+		connected bool
+	}{
+		{&relayMetrics{Latency: 50, CheckCount: 100, SuccessCount: 100}, true},
+		{&relayMetrics{Latency: 200, CheckCount: 100, SuccessCount: 95, ErrorCount: 2}, true},
+		{&relayMetrics{Latency: 1500, CheckCount: 100, SuccessCount: 50, ErrorCount: 15}, false},
+		{&relayMetrics{}, true},
+	}
+
+	for _, c := range cases {
+		factors := calculateHealthFactors(c.metrics, c.connected, 0)
+		sum := factors.Connection.Contribution + factors.Latency.Contribution +
+			factors.Uptime.Contribution + factors.Errors.Contribution +
+			factors.EOSETimeouts.Contribution
+
+		score := m.CalculateHealthScore(c.metrics, c.connected)
+		if math.Abs(sum-score) > 0.001 {
+			t.Errorf("factors sum to %f, score is %f (%+v)", sum, score, factors)
+		}
+
+		weights := factors.Connection.Weight + factors.Latency.Weight +
+			factors.Uptime.Weight + factors.Errors.Weight
+		if math.Abs(weights-1) > 0.001 {
+			t.Errorf("weights sum to %f, want 1", weights)
+		}
+	}
+}
+
+func TestEOSETimeoutPenalty(t *testing.T) {
+	pool := &Pool{
+		relays: make(map[string]*RelayConn),
+		opts:   PoolOptions{EOSETimeoutPenalty: 0.3},
+	}
+	m := NewMonitor(pool)
+
+	newMetrics := func(timeouts, answered int) *relayMetrics {
+		metrics := m.newRelayMetrics("wss://relay.example.com")
+		metrics.Latency = 50
+		metrics.CheckCount = 10
+		metrics.SuccessCount = 10
+		for i := 0; i < timeouts; i++ {
+			metrics.EOSEHistory.Add(int64(i), 1)
+		}
+		for i := 0; i < answered; i++ {
+			metrics.EOSEHistory.Add(int64(i), 0)
+		}
+		return metrics
+	}
+
+	responsive := m.CalculateHealthScore(newMetrics(0, 5), true)
+	zombie := m.CalculateHealthScore(newMetrics(5, 0), true)
+	flaky := m.CalculateHealthScore(newMetrics(1, 3), true)
+
+	if math.Abs(responsive-zombie-30) > 0.001 {
+		t.Errorf("expected a relay timing out every query to lose 30 points, got %f vs %f", zombie, responsive)
+	}
+	if !(zombie < flaky && flaky < responsive) {
+		t.Errorf("expected zombie < flaky < responsive, got %f, %f, %f", zombie, flaky, responsive)
+	}
+	if few := m.CalculateHealthScore(newMetrics(2, 0), true); few != responsive {
+		t.Errorf("expected no penalty below %d queries, got %f vs %f", minEOSESamples, few, responsive)
+	}
+	if offline := calculateHealthFactors(newMetrics(5, 0), false, 0.3); offline.EOSETimeouts.Contribution != 0 {
+		t.Errorf("expected no penalty for a disconnected relay, got %+v", offline.EOSETimeouts)
+	}
+	if disabled := calculateHealthFactors(newMetrics(5, 0), true, 0); disabled.EOSETimeouts != (types.HealthFactor{}) {
+		t.Errorf("expected no penalty when disabled, got %+v", disabled.EOSETimeouts)
+	}
+}
+
+func TestUnresponsiveRelayScoresLower(t *testing.T) {
+	responsive := newFakeRelay(t, signedEvent(t, "from responsive"))
+	unresponsive := newFakeRelay(t, signedEvent(t, "from unresponsive"))
+	unresponsive.setNoEOSE(true)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{QueryTimeout: 200 * time.Millisecond, EOSETimeoutPenalty: 0.3})
+	defer pool.Close()
+	pool.Add(responsive.URL)
+	pool.Add(unresponsive.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 2 }) {
+		t.Fatal("relays never connected")
+	}
+
+	for i := 0; i < minEOSESamples; i++ {
+		response, err := pool.QueryEventsAdvancedWithTiming([]int{1}, nil, nil, 10, 0, 0)
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		for _, timing := range response.RelayTimings {
+			if want := timing.URL == responsive.URL; timing.EOSEReceived != want {
+				t.Errorf("%s: EOSEReceived = %v, want %v", timing.URL, timing.EOSEReceived, want)
+			}
+		}
+	}
+
+	good := pool.monitor.GetRelayHealth(responsive.URL)
+	zombie := pool.monitor.GetRelayHealth(unresponsive.URL)
+	if good == nil || zombie == nil {
+		t.Fatal("expected health data for both relays")
+	}
+	if !zombie.Connected {
+		t.Fatal("expected the unresponsive relay to still look connected")
+	}
+	if zombie.HealthScore >= good.HealthScore {
+		t.Errorf("expected the unresponsive relay to score lower, got %f vs %f", zombie.HealthScore, good.HealthScore)
+	}
+	if zombie.HealthFactors.EOSETimeouts.Score != 100 {
+		t.Errorf("expected every query on the unresponsive relay to count as a timeout, got %+v", zombie.HealthFactors.EOSETimeouts)
+	}
+}
+
+func TestGetMonitoringDataIncludesHealthFactors(t *testing.T) {
+	pool := &Pool{
+		relays: map[string]*RelayConn{
+			"wss://relay.example.com": {URL: "wss://relay.example.com", Connected: true},
+		},
+	}
+	m := NewMonitor(pool)
+	m.stats["wss://relay.example.com"] = m.newRelayMetrics("wss://relay.example.com")
+	m.stats["wss://relay.example.com"].Latency = 300
+	m.stats["wss://relay.example.com"].CheckCount = 10
+	m.stats["wss://relay.example.com"].SuccessCount = 9
+	m.stats["wss://relay.example.com"].ErrorCount = 1
+
+	data := m.GetMonitoringData()
+	if len(data.Relays) != 1 {
+		t.Fatalf("expected 1 relay, got %d", len(data.Relays))
+	}
+	relay := data.Relays[0]
+	if relay.HealthFactors.Connection.Score != 100 || relay.HealthFactors.Uptime.Score != 90 {
+		t.Errorf("unexpected factors %+v", relay.HealthFactors)
+	}
+	f := relay.HealthFactors
+	sum := f.Connection.Contribution + f.Latency.Contribution + f.Uptime.Contribution + f.Errors.Contribution
+	if math.Abs(sum-relay.HealthScore) > 0.001 {
+		t.Errorf("factors sum to %f, health score is %f", sum, relay.HealthScore)
+	}
+}
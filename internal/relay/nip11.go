@@ -0,0 +1,129 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip11"
+)
+
+// infoFetchAttempts is how many times a NIP-11 fetch is tried before giving up.
+const infoFetchAttempts = 3
+
+// infoRetryBackoff is the delay before the first retry; each later retry
+// waits one backoff longer than the previous one.
+var infoRetryBackoff = 250 * time.Millisecond
+
+// infoDocument is a NIP-11 document including the fields go-nostr's
+// nip11.RelayInformationDocument does not decode.
+type infoDocument struct {
+	nip11.RelayInformationDocument
+	Retention []retentionDocument `json:"retention,omitempty"`
+}
+
+// retentionDocument is one entry of the NIP-11 retention list. Kinds mixes
+// single kinds and [start, end] ranges; a null or missing time means the
+// events are kept indefinitely.
+type retentionDocument struct {
+	Kinds []json.RawMessage `json:"kinds,omitempty"`
+	Time  *int64            `json:"time,omitempty"`
+	Count *int              `json:"count,omitempty"`
+}
+
+// infoFetchFunc fetches a relay's NIP-11 document. It is fetchInfoDocument
+// outside of tests.
+type infoFetchFunc func(ctx context.Context, url string) (infoDocument, error)
+
+// fetchInfoDocument fetches a relay's NIP-11 document over HTTP, the same way
+// nip11.Fetch does, but keeps the retention policy.
+func fetchInfoDocument(ctx context.Context, url string) (infoDocument, error) {
+	url = nostr.NormalizeURL(url)
+	info := infoDocument{RelayInformationDocument: nip11.RelayInformationDocument{URL: url}}
+	if !strings.HasPrefix(url, "ws") {
+		return info, fmt.Errorf("invalid relay URL: %s", url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http"+url[2:], nil)
+	if err != nil {
+		return info, fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Accept", "application/nostr+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return info, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return info, fmt.Errorf("invalid json: %w", err)
+	}
+	info.URL = url
+	return info, nil
+}
+
+// convertRetention converts NIP-11 retention entries, skipping kind values
+// that are neither a number nor a two-element range.
+func convertRetention(entries []retentionDocument) []types.RelayRetention {
+	if len(entries) == 0 {
+		return nil
+	}
+	retention := make([]types.RelayRetention, 0, len(entries))
+	for _, entry := range entries {
+		r := types.RelayRetention{Time: entry.Time, Count: entry.Count}
+		for _, raw := range entry.Kinds {
+			var kind int
+			if err := json.Unmarshal(raw, &kind); err == nil {
+				r.Kinds = append(r.Kinds, kind)
+				continue
+			}
+			var kindRange []int
+			if err := json.Unmarshal(raw, &kindRange); err == nil && len(kindRange) == 2 {
+				r.KindRanges = append(r.KindRanges, [2]int{kindRange[0], kindRange[1]})
+			}
+		}
+		retention = append(retention, r)
+	}
+	return retention
+}
+
+// fetchNIP11 fetches a relay's NIP-11 document, retrying transient failures
+// with a short linear backoff. Each attempt gets the full fetch timeout.
+func (p *Pool) fetchNIP11(url string) (infoDocument, error) {
+	fetch := p.fetchInfo
+	if fetch == nil {
+		fetch = fetchInfoDocument
+	}
+
+	var info infoDocument
+	var err error
+	for attempt := 1; attempt <= infoFetchAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(p.ctx, p.infoFetchTimeout())
+		info, err = fetch(ctx, url)
+		cancel()
+		if err == nil || attempt == infoFetchAttempts {
+			break
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return info, err
+		case <-time.After(time.Duration(attempt) * infoRetryBackoff):
+		}
+	}
+	return info, err
+}
+
+// cacheInfoFailure remembers a failed fetch so lookups don't immediately
+// retry the relay.
+func (p *Pool) cacheInfoFailure(url string, err error) {
+	if p.infoCache != nil {
+		p.infoCache.SetFailure(url, err, DefaultNegativeCacheTTL)
+	}
+}
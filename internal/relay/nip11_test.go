@@ -0,0 +1,198 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr/nip11"
+)
+
+// flakyFetch returns a fetch function that fails the first failures calls and
+// then succeeds, counting every call.
+func flakyFetch(failures int32, calls *atomic.Int32) infoFetchFunc {
+	return func(ctx context.Context, url string) (infoDocument, error) {
+		if calls.Add(1) <= failures {
+			return infoDocument{}, errors.New("503 service unavailable")
+		}
+		return infoDocument{RelayInformationDocument: nip11.RelayInformationDocument{Name: "Flaky Relay"}}, nil
+	}
+}
+
+func withFastInfoRetries(t *testing.T) {
+	t.Helper()
+	old := infoRetryBackoff
+	infoRetryBackoff = time.Millisecond
+	t.Cleanup(func() { infoRetryBackoff = old })
+}
+
+func TestFetchRelayInfoCached_RetriesTransientFailures(t *testing.T) {
+	withFastInfoRetries(t)
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	var calls atomic.Int32
+	pool.fetchInfo = flakyFetch(2, &calls)
+
+	info, err := pool.FetchRelayInfoCached("wss://flaky.example.com", false)
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if info.Name != "Flaky Relay" {
+		t.Errorf("expected name 'Flaky Relay', got %q", info.Name)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestFetchRelayInfoCached_CachesFailure(t *testing.T) {
+	withFastInfoRetries(t)
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	var calls atomic.Int32
+	pool.fetchInfo = flakyFetch(100, &calls)
+
+	if _, err := pool.FetchRelayInfoCached("wss://down.example.com", false); err == nil {
+		t.Fatal("expected error after all attempts fail")
+	}
+	if got := calls.Load(); got != infoFetchAttempts {
+		t.Errorf("expected %d attempts, got %d", infoFetchAttempts, got)
+	}
+
+	// The failure is cached, so the next lookup doesn't hit the network
+	if _, err := pool.FetchRelayInfoCached("wss://down.example.com", false); err == nil {
+		t.Fatal("expected cached error")
+	}
+	if got := calls.Load(); got != infoFetchAttempts {
+		t.Errorf("expected no new attempts while failure is cached, got %d total", got)
+	}
+
+	// Forcing a refresh tries again
+	pool.FetchRelayInfoCached("wss://down.example.com", true)
+	if got := calls.Load(); got != 2*infoFetchAttempts {
+		t.Errorf("expected force refresh to retry, got %d total attempts", got)
+	}
+}
+
+func TestFetchRelayInfoCached_RetriesAfterFailureExpires(t *testing.T) {
+	withFastInfoRetries(t)
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	var calls atomic.Int32
+	pool.fetchInfo = flakyFetch(0, &calls)
+	pool.infoCache.SetFailure("wss://recovered.example.com", errors.New("timeout"), 10*time.Millisecond)
+
+	if _, err := pool.FetchRelayInfoCached("wss://recovered.example.com", false); err == nil {
+		t.Fatal("expected cached failure before it expires")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	info, err := pool.FetchRelayInfoCached("wss://recovered.example.com", false)
+	if err != nil {
+		t.Fatalf("expected fetch after failure expired, got %v", err)
+	}
+	if info.Name != "Flaky Relay" || calls.Load() != 1 {
+		t.Errorf("expected one fresh fetch, got %d calls and %+v", calls.Load(), info)
+	}
+}
+
+func TestRelayInfoCache_SuccessReplacesFailure(t *testing.T) {
+	cache := NewRelayInfoCache(time.Minute)
+	cache.SetFailure("wss://relay.example.com", errors.New("boom"), time.Minute)
+
+	if cache.Get("wss://relay.example.com") != nil {
+		t.Error("expected failed entry to have no info")
+	}
+	if cache.GetFailure("wss://relay.example.com") == nil {
+		t.Error("expected cached failure")
+	}
+
+	cache.Set("wss://relay.example.com", &types.RelayInfo{Name: "Recovered"})
+	if cache.GetFailure("wss://relay.example.com") != nil {
+		t.Error("expected success to clear the failure")
+	}
+}
+
+func TestFetchInfoDocument_DecodesRetentionAndPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/nostr+json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/nostr+json")
+		w.Write([]byte(`{
+			"name": "Policy Relay",
+			"supported_nips": [1, 11],
+			"retention": [
+				{"kinds": [0, 1, [5, 7], [40, 49]], "time": 3600},
+				{"kinds": [[30000, 39999]], "count": 1000},
+				{"time": null}
+			],
+			"relay_countries": ["CA"],
+			"language_tags": ["en"],
+			"posting_policy": "https://relay.example.com/policy.html"
+		}`))
+	}))
+	defer server.Close()
+
+	doc, err := fetchInfoDocument(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool := NewPool(nil)
+	defer pool.Close()
+	info := pool.convertNIP11Info(&doc)
+
+	if info.Name != "Policy Relay" {
+		t.Errorf("expected name 'Policy Relay', got %q", info.Name)
+	}
+	if len(info.Retention) != 3 {
+		t.Fatalf("expected 3 retention entries, got %+v", info.Retention)
+	}
+	first := info.Retention[0]
+	if len(first.Kinds) != 2 || first.Kinds[0] != 0 || first.Kinds[1] != 1 {
+		t.Errorf("expected single kinds [0 1], got %v", first.Kinds)
+	}
+	if len(first.KindRanges) != 2 || first.KindRanges[0] != [2]int{5, 7} || first.KindRanges[1] != [2]int{40, 49} {
+		t.Errorf("expected kind ranges [5-7 40-49], got %v", first.KindRanges)
+	}
+	if first.Time == nil || *first.Time != 3600 {
+		t.Errorf("expected time 3600, got %v", first.Time)
+	}
+	if info.Retention[1].Count == nil || *info.Retention[1].Count != 1000 {
+		t.Errorf("expected count 1000, got %+v", info.Retention[1])
+	}
+	if info.Retention[2].Time != nil {
+		t.Errorf("expected a null time to mean no limit, got %v", *info.Retention[2].Time)
+	}
+	if len(info.RelayCountries) != 1 || info.RelayCountries[0] != "CA" {
+		t.Errorf("expected relay_countries [CA], got %v", info.RelayCountries)
+	}
+	if len(info.LanguageTags) != 1 || info.LanguageTags[0] != "en" {
+		t.Errorf("expected language_tags [en], got %v", info.LanguageTags)
+	}
+	if info.PostingPolicy != "https://relay.example.com/policy.html" {
+		t.Errorf("unexpected posting_policy %q", info.PostingPolicy)
+	}
+}
+
+func TestConvertNIP11Info_OmitsMissingPolicyFields(t *testing.T) {
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	info := pool.convertNIP11Info(&infoDocument{RelayInformationDocument: nip11.RelayInformationDocument{Name: "Plain"}})
+	if info.Retention != nil || info.RelayCountries != nil || info.LanguageTags != nil || info.PostingPolicy != "" {
+		t.Errorf("expected no policy fields, got %+v", info)
+	}
+}
@@ -5,14 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"net/http"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/logging"
 	"github.com/keanuklestil/shirushi/internal/types"
 	"github.com/nbd-wtf/go-nostr"
-	"github.com/nbd-wtf/go-nostr/nip11"
 )
 
 // StatusChangeCallback is called when a relay's connection status changes.
@@ -23,19 +26,208 @@ type StatusChangeCallback func(url string, connected bool, err string)
 // RelayInfoCallback is called when NIP-11 relay info is fetched for a relay.
 type RelayInfoCallback func(url string, info *types.RelayInfo)
 
+// DefaultKeepAliveInterval is how often idle relay connections are kept active
+// when the pool is created with NewPool.
+const DefaultKeepAliveInterval = 60 * time.Second
+
+// Default timeouts used when PoolOptions leaves them unset.
+const (
+	DefaultQueryTimeout     = 10 * time.Second
+	DefaultConnectTimeout   = 10 * time.Second
+	DefaultInfoFetchTimeout = 7 * time.Second
+)
+
+// DefaultMaxConcurrentQueries bounds how many relays one request queries in
+// parallel when PoolOptions leaves MaxConcurrentQueries unset.
+const DefaultMaxConcurrentQueries = 16
+
+// DefaultUserAgent is sent as the User-Agent header when connecting to relays
+// if PoolOptions leaves UserAgent unset.
+const DefaultUserAgent = "Shirushi"
+
+// PoolOptions configures optional pool behaviour.
+type PoolOptions struct {
+	// KeepAliveInterval is how often each connected relay is sent a minimal
+	// subscription so intermediaries don't drop the idle connection.
+	// Zero disables keep-alives.
+	KeepAliveInterval time.Duration
+
+	// EventCacheSize is the number of events kept in the event cache used by
+	// lookups by ID. Zero disables the cache.
+	EventCacheSize int
+
+	// CacheableKinds restricts the event cache to these kinds. If empty, all
+	// kinds except ephemeral ones are cached.
+	CacheableKinds []int
+
+	// InfoCacheSize is the maximum number of relays kept in the NIP-11 info
+	// cache; the least recently used entry is evicted beyond that. Zero uses
+	// DefaultInfoCacheSize.
+	InfoCacheSize int
+
+	// QueryTimeout bounds how long queries wait for relays to reach EOSE and
+	// how long publishes wait for a relay's OK. Events received before the
+	// timeout are still returned.
+	QueryTimeout time.Duration
+
+	// ConnectTimeout bounds how long connecting to a relay may take.
+	ConnectTimeout time.Duration
+
+	// InfoFetchTimeout bounds NIP-11 relay information requests.
+	InfoFetchTimeout time.Duration
+
+	// ProbeTestKey is the secret key (hex or nsec) used to sign write probes.
+	// If empty, ProbeCapabilities never publishes anything.
+	ProbeTestKey string
+
+	// DrainTimeout bounds how long Close waits for in-flight queries and
+	// subscriptions to finish before closing connections.
+	DrainTimeout time.Duration
+
+	// MaxConcurrentQueries bounds how many relays a single request queries
+	// or publishes to at once; the rest wait for a free slot.
+	MaxConcurrentQueries int
+
+	// ReadAuthKey is the secret key (hex or nsec) used to answer NIP-42 AUTH
+	// challenges from relays that refuse to serve events until the client
+	// authenticates. If empty, such relays just return nothing.
+	ReadAuthKey string
+
+	// PruneAfter is how long a relay may keep failing to connect before it
+	// is removed from the pool. Zero disables pruning.
+	PruneAfter time.Duration
+
+	// MaxTrackedKinds bounds how many event kinds get their own event rate
+	// time series in monitoring. Zero disables per-kind tracking.
+	MaxTrackedKinds int
+
+	// MaxDiscoveredRelays bounds how many relays hinted in the tags of
+	// queried events are remembered for discovery. Zero disables discovery.
+	MaxDiscoveredRelays int
+
+	// UserAgent and Origin are sent as headers on the websocket handshake
+	// when connecting to relays, for relays that gate on them. An empty
+	// UserAgent uses DefaultUserAgent; an empty Origin sends none.
+	UserAgent string
+	Origin    string
+
+	// BlockedRelays lists relay URLs, hosts or *.domain host wildcards that
+	// are never added to the pool, recorded as discovered or queried for
+	// hints and outbox routing.
+	BlockedRelays []string
+
+	// AllowlistOnly restricts the pool to AllowedRelays, in the same format
+	// as BlockedRelays: no other relay is added, probed, discovered or
+	// queried. With AllowlistOnly set and no AllowedRelays, nothing is.
+	AllowlistOnly bool
+	AllowedRelays []string
+
+	// EOSETimeoutPenalty weights the health score penalty for connected
+	// relays whose recent queries time out without EOSE. A relay that timed
+	// out every recent query loses EOSETimeoutPenalty * 100 points. Zero
+	// disables the penalty.
+	EOSETimeoutPenalty float64
+}
+
+// queryTimeout returns the configured query timeout or the default.
+func (p *Pool) queryTimeout() time.Duration {
+	if p.opts.QueryTimeout > 0 {
+		return p.opts.QueryTimeout
+	}
+	return DefaultQueryTimeout
+}
+
+// connectTimeout returns the configured connect timeout or the default.
+func (p *Pool) connectTimeout() time.Duration {
+	if p.opts.ConnectTimeout > 0 {
+		return p.opts.ConnectTimeout
+	}
+	return DefaultConnectTimeout
+}
+
+// maxConcurrentQueries returns the configured per-request relay concurrency
+// or the default.
+func (p *Pool) maxConcurrentQueries() int {
+	if p.opts.MaxConcurrentQueries > 0 {
+		return p.opts.MaxConcurrentQueries
+	}
+	return DefaultMaxConcurrentQueries
+}
+
+// infoFetchTimeout returns the configured NIP-11 fetch timeout or the default.
+func (p *Pool) infoFetchTimeout() time.Duration {
+	if p.opts.InfoFetchTimeout > 0 {
+		return p.opts.InfoFetchTimeout
+	}
+	return DefaultInfoFetchTimeout
+}
+
+// userAgent returns the configured relay User-Agent or the default.
+func (p *Pool) userAgent() string {
+	if p.opts.UserAgent != "" {
+		return p.opts.UserAgent
+	}
+	return DefaultUserAgent
+}
+
+// requestHeader is a nostr.RelayOption that sets the headers sent on the
+// websocket handshake.
+type requestHeader http.Header
+
+func (h requestHeader) ApplyRelayOption(r *nostr.Relay) {
+	r.RequestHeader = http.Header(h)
+}
+
+// relayOptions returns the options used for every relay connection the pool
+// opens itself.
+func (p *Pool) relayOptions() []nostr.RelayOption {
+	header := http.Header{}
+	header.Set("User-Agent", p.userAgent())
+	if p.opts.Origin != "" {
+		header.Set("Origin", p.opts.Origin)
+	}
+	return []nostr.RelayOption{requestHeader(header)}
+}
+
 // Pool manages connections to multiple Nostr relays.
 type Pool struct {
 	relays         map[string]*RelayConn
+	opts           PoolOptions
 	mu             sync.RWMutex
 	pool           *nostr.SimplePool
 	monitor        *Monitor
 	infoCache      *RelayInfoCache
+	eventCache     *EventCache
+	discovery      *relayDiscovery
+	blocklist      *relayMatcher
+	allowlist      *relayMatcher
 	ctx            context.Context
 	cancel         context.CancelFunc
 	subCounter     int
+	subs           map[string]context.CancelFunc
 	subMu          sync.Mutex
 	onStatusChange StatusChangeCallback
 	onRelayInfo    func(url string, info *types.RelayInfo)
+	onRelayPruned  func(pruned types.PrunedRelay)
+	fetchInfo      infoFetchFunc
+
+	// connected is closed, under mu, when a relay connects, waking
+	// WaitForConnection; it is nil while nobody is waiting.
+	connected chan struct{}
+
+	// pruned is the log of relays removed by the dead-relay pruner.
+	pruned []types.PrunedRelay
+
+	// stateHistory holds each relay's recent connection state changes.
+	stateHistory map[string][]types.RelayStateChange
+
+	// activeCount counts in-flight queries and subscriptions for Close.
+	// activeMu guards closing, which is set once Close begins, and drained,
+	// which drain waits on.
+	activeMu    sync.Mutex
+	activeCount atomic.Int64
+	closing     bool
+	drained     chan struct{}
 }
 
 // RelayConn represents a connection to a single relay.
@@ -47,38 +239,78 @@ type RelayConn struct {
 	AddedAt       time.Time
 	Info          *types.RelayInfo
 	SupportedNIPs []int
+
+	// failingSince is when the relay started failing to connect; zero
+	// while it is connected or has not been tried yet.
+	failingSince time.Time
+
+	stopKeepAlive context.CancelFunc
+	keepAliveDone chan struct{}
 }
 
-// NewPool creates a new relay pool.
+// NewPool creates a new relay pool with default options.
 func NewPool(defaultRelays []string) *Pool {
+	return NewPoolWithOptions(defaultRelays, PoolOptions{
+		KeepAliveInterval: DefaultKeepAliveInterval,
+		EventCacheSize:    DefaultEventCacheSize,
+		MaxTrackedKinds:   DefaultMaxTrackedKinds,
+	})
+}
+
+// NewPoolWithOptions creates a new relay pool with the given options.
+func NewPoolWithOptions(defaultRelays []string, opts PoolOptions) *Pool {
 	ctx, cancel := context.WithCancel(context.Background())
 	p := &Pool{
 		relays:    make(map[string]*RelayConn),
+		opts:      opts,
 		pool:      nostr.NewSimplePool(ctx),
-		infoCache: NewRelayInfoCache(DefaultCacheTTL),
+		infoCache: NewRelayInfoCacheWithSize(DefaultCacheTTL, opts.InfoCacheSize),
+		fetchInfo: fetchInfoDocument,
+		discovery: newRelayDiscovery(opts.MaxDiscoveredRelays),
+		blocklist: newRelayMatcher(opts.BlockedRelays),
+		allowlist: newRelayMatcher(opts.AllowedRelays),
 		ctx:       ctx,
 		cancel:    cancel,
 	}
 	p.monitor = NewMonitor(p)
+	if opts.EventCacheSize > 0 {
+		p.eventCache = NewEventCache(opts.EventCacheSize, opts.CacheableKinds)
+	}
 
 	// Add default relays
 	for _, url := range defaultRelays {
-		p.Add(url)
+		if _, err := p.Add(url); err != nil {
+			logging.Warnf("[Relay] Not adding default relay: %v", err)
+		}
 	}
 
 	// Start monitoring
 	go p.monitor.Start()
 
+	if opts.PruneAfter > 0 {
+		go p.pruneDeadRelays()
+	}
+
 	return p
 }
 
 // Add adds a relay to the pool.
-func (p *Pool) Add(url string) error {
+// The URL must use the ws:// or wss:// scheme; trailing slashes are ignored.
+// Returns false if the relay was already present, in which case the call is a no-op.
+func (p *Pool) Add(url string) (bool, error) {
+	url, err := config.NormalizeRelayURL(url)
+	if err != nil {
+		return false, err
+	}
+	if err := p.checkPermitted(url); err != nil {
+		return false, err
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	if _, exists := p.relays[url]; exists {
-		return nil // Already added
+		return false, nil // Already added
 	}
 
 	conn := &RelayConn{
@@ -91,7 +323,7 @@ func (p *Pool) Add(url string) error {
 	// Connect in background
 	go p.connect(url)
 
-	return nil
+	return true, nil
 }
 
 // SetOnStatusChange sets the callback function that is invoked when a relay's
@@ -128,12 +360,14 @@ func (p *Pool) notifyRelayInfo(url string, info *types.RelayInfo) {
 	}
 }
 
-// notifyStatusChange invokes the status change callback if set.
+// notifyStatusChange records the change in the relay's state history and
+// invokes the status change callback if set.
 // Must be called without holding the mutex.
 func (p *Pool) notifyStatusChange(url string, connected bool, errMsg string) {
-	p.mu.RLock()
+	p.mu.Lock()
+	p.recordStateChangeLocked(url, connected, errMsg)
 	callback := p.onStatusChange
-	p.mu.RUnlock()
+	p.mu.Unlock()
 
 	if callback != nil {
 		callback(url, connected, errMsg)
@@ -142,10 +376,10 @@ func (p *Pool) notifyStatusChange(url string, connected bool, errMsg string) {
 
 // connect attempts to connect to a relay.
 func (p *Pool) connect(url string) {
-	ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(p.ctx, p.connectTimeout())
 	defer cancel()
 
-	relay, err := nostr.RelayConnect(ctx, url)
+	relay, err := nostr.RelayConnect(ctx, url, p.relayOptions()...)
 
 	p.mu.Lock()
 	conn, exists := p.relays[url]
@@ -157,7 +391,10 @@ func (p *Pool) connect(url string) {
 	if err != nil {
 		conn.Connected = false
 		conn.Error = err.Error()
-		log.Printf("[Relay] Failed to connect to %s: %v", url, err)
+		if conn.failingSince.IsZero() {
+			conn.failingSince = time.Now()
+		}
+		logging.Warnf("[Relay] Failed to connect to %s: %v", url, err)
 		p.mu.Unlock()
 		p.notifyStatusChange(url, false, err.Error())
 		return
@@ -166,7 +403,13 @@ func (p *Pool) connect(url string) {
 	conn.Relay = relay
 	conn.Connected = true
 	conn.Error = ""
-	log.Printf("[Relay] Connected to %s", url)
+	conn.failingSince = time.Time{}
+	p.startKeepAliveLocked(conn)
+	if p.connected != nil {
+		close(p.connected)
+		p.connected = nil
+	}
+	logging.Infof("[Relay] Connected to %s", url)
 	p.mu.Unlock()
 
 	p.notifyStatusChange(url, true, "")
@@ -177,12 +420,10 @@ func (p *Pool) connect(url string) {
 
 // fetchRelayInfo fetches NIP-11 relay information document.
 func (p *Pool) fetchRelayInfo(url string) {
-	ctx, cancel := context.WithTimeout(p.ctx, 7*time.Second)
-	defer cancel()
-
-	info, err := nip11.Fetch(ctx, url)
+	info, err := p.fetchNIP11(url)
 	if err != nil {
-		log.Printf("[Relay] Failed to fetch NIP-11 info for %s: %v", url, err)
+		logging.Warnf("[Relay] Failed to fetch NIP-11 info for %s: %v", url, err)
+		p.cacheInfoFailure(url, err)
 		return
 	}
 
@@ -203,7 +444,7 @@ func (p *Pool) fetchRelayInfo(url string) {
 	conn.Info = relayInfo
 	conn.SupportedNIPs = info.SupportedNIPs
 
-	log.Printf("[Relay] Fetched NIP-11 info for %s: %s (supports %d NIPs)", url, info.Name, len(info.SupportedNIPs))
+	logging.Infof("[Relay] Fetched NIP-11 info for %s: %s (supports %d NIPs)", url, info.Name, len(info.SupportedNIPs))
 
 	p.mu.Unlock()
 
@@ -218,6 +459,10 @@ func (p *Pool) fetchRelayInfo(url string) {
 
 // Remove removes a relay from the pool.
 func (p *Pool) Remove(url string) {
+	if normalized, err := config.NormalizeRelayURL(url); err == nil {
+		url = normalized
+	}
+
 	p.mu.Lock()
 	conn, exists := p.relays[url]
 	if !exists {
@@ -226,12 +471,13 @@ func (p *Pool) Remove(url string) {
 	}
 
 	wasConnected := conn.Connected
+	conn.stopKeepAliveLocked()
 	if conn.Relay != nil {
 		conn.Relay.Close()
 	}
 
 	delete(p.relays, url)
-	log.Printf("[Relay] Removed %s", url)
+	logging.Infof("[Relay] Removed %s", url)
 	p.mu.Unlock()
 
 	// Notify if the relay was connected (now disconnected due to removal)
@@ -240,6 +486,44 @@ func (p *Pool) Remove(url string) {
 	}
 }
 
+// Reconnect drops a relay's current connection and dials it again right away.
+// The relay keeps its place in the pool, its AddedAt time and its monitoring
+// history. Returns the relay's status after the attempt.
+func (p *Pool) Reconnect(url string) (*types.RelayStatus, error) {
+	url, err := config.NormalizeRelayURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	conn, exists := p.relays[url]
+	if !exists {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("relay not in pool: %s", url)
+	}
+	conn.stopKeepAliveLocked()
+	if conn.Relay != nil {
+		conn.Relay.Close()
+		conn.Relay = nil
+	}
+	conn.Connected = false
+	p.mu.Unlock()
+
+	// Queries go through the shared pool, so drop its connection too.
+	p.releaseRelays([]string{url})
+	p.connect(url)
+
+	stats := p.monitor.GetStats()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	conn, exists = p.relays[url]
+	if !exists {
+		return nil, fmt.Errorf("relay not in pool: %s", url)
+	}
+	status := relayStatus(url, conn, stats)
+	return &status, nil
+}
+
 // List returns all relays with their status.
 func (p *Pool) List() []types.RelayStatus {
 	p.mu.RLock()
@@ -248,22 +532,27 @@ func (p *Pool) List() []types.RelayStatus {
 	stats := p.monitor.GetStats()
 	var list []types.RelayStatus
 	for url, conn := range p.relays {
-		status := types.RelayStatus{
-			URL:           url,
-			Connected:     conn.Connected,
-			Error:         conn.Error,
-			SupportedNIPs: conn.SupportedNIPs,
-			RelayInfo:     conn.Info,
-		}
-		if s, ok := stats[url]; ok {
-			status.Latency = s.Latency
-			status.EventsPS = s.EventsPerSec
-		}
-		list = append(list, status)
+		list = append(list, relayStatus(url, conn, stats))
 	}
 	return list
 }
 
+// relayStatus builds the status reported for a relay connection.
+func relayStatus(url string, conn *RelayConn, stats map[string]types.RelayStats) types.RelayStatus {
+	status := types.RelayStatus{
+		URL:           url,
+		Connected:     conn.Connected,
+		Error:         conn.Error,
+		SupportedNIPs: conn.SupportedNIPs,
+		RelayInfo:     conn.Info,
+	}
+	if s, ok := stats[url]; ok {
+		status.Latency = s.Latency
+		status.EventsPS = s.EventsPerSec
+	}
+	return status
+}
+
 // Stats returns statistics for all relays.
 func (p *Pool) Stats() map[string]types.RelayStats {
 	return p.monitor.GetStats()
@@ -290,6 +579,33 @@ func (p *Pool) GetConnected() []string {
 	return urls
 }
 
+// WaitForConnection blocks until at least one relay is connected, ctx is
+// done, or the pool is closed. It returns nil once a relay is connected.
+func (p *Pool) WaitForConnection(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		for _, conn := range p.relays {
+			if conn.Connected {
+				p.mu.Unlock()
+				return nil
+			}
+		}
+		if p.connected == nil {
+			p.connected = make(chan struct{})
+		}
+		connected := p.connected
+		p.mu.Unlock()
+
+		select {
+		case <-connected:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.ctx.Done():
+			return p.ctx.Err()
+		}
+	}
+}
+
 // getRelaysForQuery returns the list of relays to use for a query.
 // If selectedRelays is provided and non-empty, only those relays are returned (if connected).
 // Otherwise, all connected relays are returned.
@@ -317,6 +633,12 @@ func (p *Pool) getRelaysForQuery(selectedRelays []string) []string {
 
 // QueryEvents queries events from connected relays.
 func (p *Pool) QueryEvents(kindStr, author, limitStr string) ([]types.Event, error) {
+	done, err := p.trackQuery()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	relays := p.GetConnected()
 	if len(relays) == 0 {
 		return nil, fmt.Errorf("no connected relays")
@@ -346,23 +668,15 @@ func (p *Pool) QueryEvents(kindStr, author, limitStr string) ([]types.Event, err
 	}
 	filter.Limit = limit
 
-	ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
 	defer cancel()
 
 	var events []types.Event
 	ch := p.pool.SubManyEose(ctx, relays, nostr.Filters{filter})
 
 	for ev := range ch {
-		events = append(events, types.Event{
-			ID:        ev.Event.ID,
-			Kind:      ev.Event.Kind,
-			PubKey:    ev.Event.PubKey,
-			Content:   ev.Event.Content,
-			CreatedAt: int64(ev.Event.CreatedAt),
-			Tags:      convertTags(ev.Event.Tags),
-			Sig:       ev.Event.Sig,
-			Relay:     ev.Relay.URL,
-		})
+		p.collectRelayHints(ev.Event)
+		events = append(events, convertEvent(ev.Event, ev.Relay.URL))
 	}
 
 	return events, nil
@@ -402,116 +716,60 @@ func (p *Pool) QueryEventsWithTiming(kindStr, author, limitStr string) (*types.E
 	filter.Limit = limit
 
 	// Query each relay individually to track per-relay timing
-	type relayResult struct {
-		timing types.RelayFetchTiming
-		events []types.Event
-	}
-
-	var wg sync.WaitGroup
-	resultsChan := make(chan relayResult, len(relays))
+	events, timings := mergeRelayResults(p.queryRelays(relays, filter))
 
-	for _, relayURL := range relays {
-		wg.Add(1)
-		go func(url string) {
-			defer wg.Done()
-
-			result := relayResult{
-				timing: types.RelayFetchTiming{
-					URL:       url,
-					Connected: true,
-				},
-				events: make([]types.Event, 0),
-			}
-
-			start := time.Now()
-			var firstEventTime time.Time
-
-			ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
-			defer cancel()
-
-			// Get the relay connection
-			relay, err := p.pool.EnsureRelay(url)
-			if err != nil {
-				result.timing.Error = fmt.Sprintf("connection error: %v", err)
-				result.timing.LatencyMs = time.Since(start).Milliseconds()
-				result.timing.Connected = false
-				resultsChan <- result
-				return
-			}
+	response := &types.EventsQueryResponse{
+		Events:       events,
+		RelayTimings: timings,
+	}
 
-			sub, err := relay.Subscribe(ctx, nostr.Filters{filter})
-			if err != nil {
-				result.timing.Error = fmt.Sprintf("subscribe error: %v", err)
-				result.timing.LatencyMs = time.Since(start).Milliseconds()
-				resultsChan <- result
-				return
-			}
-			defer sub.Unsub()
+	response.TotalTimeMs = time.Since(totalStart).Milliseconds()
 
-			// Collect events until EOSE or timeout
-		eventLoop:
-			for {
-				select {
-				case ev := <-sub.Events:
-					if ev != nil {
-						if firstEventTime.IsZero() {
-							firstEventTime = time.Now()
-						}
-						result.events = append(result.events, types.Event{
-							ID:        ev.ID,
-							Kind:      ev.Kind,
-							PubKey:    ev.PubKey,
-							Content:   ev.Content,
-							CreatedAt: int64(ev.CreatedAt),
-							Tags:      convertTags(ev.Tags),
-							Sig:       ev.Sig,
-							Relay:     url,
-						})
-					}
-				case <-sub.EndOfStoredEvents:
-					break eventLoop
-				case <-ctx.Done():
-					result.timing.Error = "timeout"
-					break eventLoop
-				}
-			}
+	return response, nil
+}
 
-			result.timing.LatencyMs = time.Since(start).Milliseconds()
-			result.timing.EventCount = len(result.events)
-			if !firstEventTime.IsZero() {
-				result.timing.FirstEventMs = firstEventTime.Sub(start).Milliseconds()
-			}
-			resultsChan <- result
-		}(relayURL)
+// convertEvent converts a nostr.Event received from the given relay to a types.Event.
+func convertEvent(ev *nostr.Event, relayURL string) types.Event {
+	return types.Event{
+		ID:             ev.ID,
+		Kind:           ev.Kind,
+		PubKey:         ev.PubKey,
+		Content:        ev.Content,
+		CreatedAt:      int64(ev.CreatedAt),
+		Tags:           convertTags(ev.Tags),
+		Sig:            ev.Sig,
+		Relay:          relayURL,
+		ContentWarning: contentWarning(ev.Tags),
+		Emojis:         emojis(ev.Tags),
 	}
+}
 
-	// Close channel when all goroutines complete
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
-
-	// Collect results
-	response := &types.EventsQueryResponse{
-		Events:       make([]types.Event, 0),
-		RelayTimings: make([]types.RelayFetchTiming, 0, len(relays)),
+// contentWarning returns the reason from an event's NIP-36 content-warning
+// tag, or "" if it has none or gives no reason.
+func contentWarning(tags nostr.Tags) string {
+	for _, tag := range tags {
+		if len(tag) >= 2 && tag[0] == "content-warning" {
+			return tag[1]
+		}
 	}
+	return ""
+}
 
-	seenEvents := make(map[string]bool)
-	for result := range resultsChan {
-		response.RelayTimings = append(response.RelayTimings, result.timing)
-		// Deduplicate events by ID
-		for _, ev := range result.events {
-			if !seenEvents[ev.ID] {
-				seenEvents[ev.ID] = true
-				response.Events = append(response.Events, ev)
-			}
+// emojis maps the shortcodes of an event's NIP-30 emoji tags to their image
+// URLs. Tags without both a shortcode and a URL are skipped. Returns nil if
+// there are none.
+func emojis(tags nostr.Tags) map[string]string {
+	var result map[string]string
+	for _, tag := range tags {
+		if len(tag) < 3 || tag[0] != "emoji" || tag[1] == "" || tag[2] == "" {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
 		}
+		result[tag[1]] = tag[2]
 	}
-
-	response.TotalTimeMs = time.Since(totalStart).Milliseconds()
-
-	return response, nil
+	return result
 }
 
 // convertTags converts nostr.Tags to [][]string
@@ -563,6 +821,7 @@ func buildFilter(kinds []int, authors []string, tags map[string][]string, limit
 
 // QueryEventsAdvanced queries events from connected relays with advanced filter options.
 // If selectedRelays is provided and non-empty, only those relays are queried (must be connected).
+// Each relay is queried separately so its limit can be clamped to its NIP-11 max_limit.
 func (p *Pool) QueryEventsAdvanced(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) ([]types.Event, error) {
 	relays := p.getRelaysForQuery(selectedRelays)
 	if len(relays) == 0 {
@@ -570,34 +829,103 @@ func (p *Pool) QueryEventsAdvanced(kinds []int, authors []string, tags map[strin
 	}
 
 	filter := buildFilter(kinds, authors, tags, limit, since, until)
+	events, _ := mergeRelayResults(p.queryRelays(relays, filter))
+
+	return events, nil
+}
+
+// QueryEventsFromRelays queries connected relays plus the given relay URLs
+// (typically NIP-19 relay hints), connecting to the extra relays on demand
+// without adding them to the pool. Invalid hint URLs and relays the pool may
+// not connect to are ignored.
+func (p *Pool) QueryEventsFromRelays(relayURLs []string, ids []string, kinds []int, authors []string, tags map[string][]string, limit int) ([]types.Event, error) {
+	done, err := p.trackQuery()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	relays := p.GetConnected()
+	seen := make(map[string]bool, len(relays))
+	for _, url := range relays {
+		seen[url] = true
+	}
+	for _, url := range relayURLs {
+		normalized, err := config.NormalizeRelayURL(url)
+		if err != nil || seen[normalized] || p.checkPermitted(normalized) != nil {
+			continue
+		}
+		seen[normalized] = true
+		relays = append(relays, normalized)
+	}
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("no connected relays")
+	}
+
+	filter := buildFilter(kinds, authors, tags, limit, 0, 0)
+	if len(ids) > 0 {
+		filter.IDs = ids
+	}
 
-	ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
 	defer cancel()
 
 	var events []types.Event
+	eventSeen := make(map[string]bool)
 	ch := p.pool.SubManyEose(ctx, relays, nostr.Filters{filter})
 
 	for ev := range ch {
-		events = append(events, types.Event{
-			ID:        ev.Event.ID,
-			Kind:      ev.Event.Kind,
-			PubKey:    ev.Event.PubKey,
-			Content:   ev.Event.Content,
-			CreatedAt: int64(ev.Event.CreatedAt),
-			Tags:      convertTags(ev.Event.Tags),
-			Sig:       ev.Event.Sig,
-			Relay:     ev.Relay.URL,
-		})
+		if eventSeen[ev.Event.ID] {
+			continue
+		}
+		eventSeen[ev.Event.ID] = true
+		p.collectRelayHints(ev.Event)
+		events = append(events, convertEvent(ev.Event, ev.Relay.URL))
 	}
 
 	return events, nil
 }
 
-// QueryEventsAdvancedWithTiming queries events with advanced filter options and returns per-relay timing data.
-// If selectedRelays is provided and non-empty, only those relays are queried (must be connected).
-func (p *Pool) QueryEventsAdvancedWithTiming(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) (*types.EventsQueryResponse, error) {
-	totalStart := time.Now()
+// QueryEventFromHints fetches an event by ID from the given relay hints only,
+// connecting to each on demand without adding it to the pool. Hints that are
+// invalid, not permitted or already connected are skipped, since callers query the connected
+// relays first. Returns nil if no hinted relay has the event; the event's
+// Relay field names the hint that served it.
+func (p *Pool) QueryEventFromHints(eventID string, hints []string) *types.Event {
+	connected := make(map[string]bool)
+	for _, url := range p.GetConnected() {
+		connected[url] = true
+	}
+
+	var relays []string
+	for _, url := range hints {
+		normalized, err := config.NormalizeRelayURL(url)
+		if err != nil || connected[normalized] || p.checkPermitted(normalized) != nil {
+			continue
+		}
+		connected[normalized] = true
+		relays = append(relays, normalized)
+	}
+	if len(relays) == 0 {
+		return nil
+	}
+
+	filter := nostr.Filter{IDs: []string{eventID}, Limit: 1}
+	events, _ := mergeRelayResults(p.queryRelays(relays, filter))
+	for _, event := range events {
+		if event.ID == eventID {
+			return &event
+		}
+	}
+	return nil
+}
 
+// QueryEventsAdvancedPartial is like QueryEventsAdvanced but queries each relay
+// individually so that per-relay failures are not hidden. When some relays fail
+// and others succeed, the events received are returned along with a
+// *types.PartialError listing the failed relays. If every relay fails, only an
+// error is returned.
+func (p *Pool) QueryEventsAdvancedPartial(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) ([]types.Event, error) {
 	relays := p.getRelaysForQuery(selectedRelays)
 	if len(relays) == 0 {
 		return nil, fmt.Errorf("no connected relays")
@@ -605,120 +933,115 @@ func (p *Pool) QueryEventsAdvancedWithTiming(kinds []int, authors []string, tags
 
 	filter := buildFilter(kinds, authors, tags, limit, since, until)
 
-	// Query each relay individually to track per-relay timing
-	type relayResult struct {
-		timing types.RelayFetchTiming
-		events []types.Event
+	events, timings := mergeRelayResults(p.queryRelays(relays, filter))
+
+	failed := relayFailures(timings)
+	if len(failed) == 0 {
+		return events, nil
+	}
+	partial := &types.PartialError{Events: events, Failed: failed}
+	if len(failed) == len(timings) {
+		return nil, fmt.Errorf("all relays failed: %s", strings.Join(partial.Warnings(), "; "))
 	}
+	return events, partial
+}
 
-	var wg sync.WaitGroup
-	resultsChan := make(chan relayResult, len(relays))
+// QueryEventsAdvancedWithTiming queries events with advanced filter options and returns per-relay timing data.
+// If selectedRelays is provided and non-empty, only those relays are queried (must be connected).
+// The limit applies to each relay, so the merged result may hold more events than limit;
+// the web layer trims it to the newest limit events unless the caller asks for a per-relay limit.
+func (p *Pool) QueryEventsAdvancedWithTiming(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) (*types.EventsQueryResponse, error) {
+	relays := p.getRelaysForQuery(selectedRelays)
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("no connected relays")
+	}
+	return p.timedQuery(relays, false, buildFilter(kinds, authors, tags, limit, since, until)), nil
+}
 
-	for _, relayURL := range relays {
-		wg.Add(1)
-		go func(url string) {
-			defer wg.Done()
+// QueryEventsGlobalLimitWithTiming is like QueryEventsAdvancedWithTiming but
+// applies limit to the merged result. Events are interleaved round-robin
+// across relays before truncating so each relay contributes proportionally.
+func (p *Pool) QueryEventsGlobalLimitWithTiming(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) (*types.EventsQueryResponse, error) {
+	relays := p.getRelaysForQuery(selectedRelays)
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("no connected relays")
+	}
+	return p.timedQuery(relays, true, buildFilter(kinds, authors, tags, limit, since, until)), nil
+}
 
-			result := relayResult{
-				timing: types.RelayFetchTiming{
-					URL:       url,
-					Connected: true,
-				},
-				events: make([]types.Event, 0),
-			}
+// QueryEventsOnRelaysWithTiming queries the given relays whether or not they
+// are in the pool, returning per-relay timing data. Relays outside the pool
+// are connected to for this query only. Invalid relay URLs and relays the
+// pool may not connect to are skipped.
+func (p *Pool) QueryEventsOnRelaysWithTiming(relayURLs []string, kinds []int, authors []string, tags map[string][]string, limit int, since, until int64) (*types.EventsQueryResponse, error) {
+	var relays, temporary []string
+	seen := make(map[string]bool)
 
-			start := time.Now()
-			var firstEventTime time.Time
+	p.mu.RLock()
+	for _, url := range relayURLs {
+		normalized, err := config.NormalizeRelayURL(url)
+		if err != nil || seen[normalized] || p.checkPermitted(normalized) != nil {
+			continue
+		}
+		seen[normalized] = true
+		relays = append(relays, normalized)
+		if _, inPool := p.relays[normalized]; !inPool {
+			temporary = append(temporary, normalized)
+		}
+	}
+	p.mu.RUnlock()
 
-			ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
-			defer cancel()
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("no valid relays to query")
+	}
+	defer p.releaseRelays(temporary)
 
-			// Get the relay connection
-			relay, err := p.pool.EnsureRelay(url)
-			if err != nil {
-				result.timing.Error = fmt.Sprintf("connection error: %v", err)
-				result.timing.LatencyMs = time.Since(start).Milliseconds()
-				result.timing.Connected = false
-				resultsChan <- result
-				return
-			}
+	return p.timedQuery(relays, false, buildFilter(kinds, authors, tags, limit, since, until)), nil
+}
 
-			sub, err := relay.Subscribe(ctx, nostr.Filters{filter})
-			if err != nil {
-				result.timing.Error = fmt.Sprintf("subscribe error: %v", err)
-				result.timing.LatencyMs = time.Since(start).Milliseconds()
-				resultsChan <- result
-				return
-			}
-			defer sub.Unsub()
+// releaseRelays closes connections opened for relays outside the pool.
+func (p *Pool) releaseRelays(urls []string) {
+	for _, url := range urls {
+		if relay, ok := p.pool.Relays.LoadAndDelete(nostr.NormalizeURL(url)); ok && relay != nil {
+			relay.Close()
+		}
+	}
+}
 
-			// Collect events until EOSE or timeout
-		eventLoop:
-			for {
-				select {
-				case ev := <-sub.Events:
-					if ev != nil {
-						if firstEventTime.IsZero() {
-							firstEventTime = time.Now()
-						}
-						result.events = append(result.events, types.Event{
-							ID:        ev.ID,
-							Kind:      ev.Kind,
-							PubKey:    ev.PubKey,
-							Content:   ev.Content,
-							CreatedAt: int64(ev.CreatedAt),
-							Tags:      convertTags(ev.Tags),
-							Sig:       ev.Sig,
-							Relay:     url,
-						})
-					}
-				case <-sub.EndOfStoredEvents:
-					break eventLoop
-				case <-ctx.Done():
-					result.timing.Error = "timeout"
-					break eventLoop
-				}
-			}
+// timedQuery queries each relay individually to track per-relay timing and
+// merges the results. With globalLimit, the filter's limit is applied to the
+// merged result rather than to each relay.
+func (p *Pool) timedQuery(relays []string, globalLimit bool, filter nostr.Filter) *types.EventsQueryResponse {
+	totalStart := time.Now()
 
-			result.timing.LatencyMs = time.Since(start).Milliseconds()
-			result.timing.EventCount = len(result.events)
-			if !firstEventTime.IsZero() {
-				result.timing.FirstEventMs = firstEventTime.Sub(start).Milliseconds()
-			}
-			resultsChan <- result
-		}(relayURL)
-	}
+	results := p.queryRelays(relays, filter)
 
-	// Close channel when all goroutines complete
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
+	var events []types.Event
+	var timings []types.RelayFetchTiming
+	if globalLimit {
+		events, timings = interleaveRelayResults(results, filter.Limit)
+	} else {
+		events, timings = mergeRelayResults(results)
+	}
 
-	// Collect results
 	response := &types.EventsQueryResponse{
-		Events:       make([]types.Event, 0),
-		RelayTimings: make([]types.RelayFetchTiming, 0, len(relays)),
+		Events:       events,
+		RelayTimings: timings,
 	}
 
-	seenEvents := make(map[string]bool)
-	for result := range resultsChan {
-		response.RelayTimings = append(response.RelayTimings, result.timing)
-		// Deduplicate events by ID
-		for _, ev := range result.events {
-			if !seenEvents[ev.ID] {
-				seenEvents[ev.ID] = true
-				response.Events = append(response.Events, ev)
-			}
-		}
+	// Only warn about failures when some relays still succeeded
+	if failed := relayFailures(timings); len(failed) > 0 && len(failed) < len(timings) {
+		response.Warnings = (&types.PartialError{Failed: failed}).Warnings()
 	}
 
 	response.TotalTimeMs = time.Since(totalStart).Milliseconds()
 
-	return response, nil
+	return response
 }
 
 // Subscribe creates a subscription to events matching the filter.
+// The subscription runs until Unsubscribe is called or the pool is closed;
+// one requested after Close has begun receives no events.
 func (p *Pool) Subscribe(kinds []int, authors []string, callback func(types.Event)) string {
 	p.subMu.Lock()
 	p.subCounter++
@@ -730,6 +1053,22 @@ func (p *Pool) Subscribe(kinds []int, authors []string, callback func(types.Even
 		return subID // Return ID but subscription won't work without relays
 	}
 
+	ctx, cancel := context.WithCancel(p.ctx)
+	p.subMu.Lock()
+	if p.subs == nil {
+		p.subs = make(map[string]context.CancelFunc)
+	}
+	p.subs[subID] = cancel
+	p.subMu.Unlock()
+
+	// Registering before tracking means Close either sees the subscription
+	// and cancels it, or has already begun and trackQuery refuses it.
+	done, err := p.trackQuery()
+	if err != nil {
+		p.Unsubscribe(subID)
+		return subID
+	}
+
 	filter := nostr.Filter{}
 	if len(kinds) > 0 {
 		filter.Kinds = kinds
@@ -739,77 +1078,154 @@ func (p *Pool) Subscribe(kinds []int, authors []string, callback func(types.Even
 	}
 
 	go func() {
-		ch := p.pool.SubMany(p.ctx, relays, nostr.Filters{filter})
+		defer done()
+		defer p.Unsubscribe(subID)
+		ch := p.pool.SubMany(ctx, relays, nostr.Filters{filter})
 		for ev := range ch {
 			p.monitor.RecordEvent(ev.Relay.URL)
-			callback(types.Event{
-				ID:        ev.Event.ID,
-				Kind:      ev.Event.Kind,
-				PubKey:    ev.Event.PubKey,
-				Content:   ev.Event.Content,
-				CreatedAt: int64(ev.Event.CreatedAt),
-				Tags:      convertTags(ev.Event.Tags),
-				Sig:       ev.Event.Sig,
-				Relay:     ev.Relay.URL,
-			})
+			p.monitor.RecordEventKind(ev.Kind)
+			callback(convertEvent(ev.Event, ev.Relay.URL))
 		}
 	}()
 
 	return subID
 }
 
+// Unsubscribe stops a subscription created by Subscribe.
+// Returns false if the subscription does not exist or has already ended.
+func (p *Pool) Unsubscribe(subID string) bool {
+	p.subMu.Lock()
+	cancel, exists := p.subs[subID]
+	delete(p.subs, subID)
+	p.subMu.Unlock()
+
+	if !exists {
+		return false
+	}
+	cancel()
+	return true
+}
+
 // MonitoringData returns aggregated monitoring data for all relays.
 func (p *Pool) MonitoringData() *types.MonitoringData {
 	return p.monitor.GetMonitoringData()
 }
 
+// KindRates returns the per-kind event rates seen on subscriptions.
+func (p *Pool) KindRates() *types.KindMonitoringData {
+	return p.monitor.GetKindRates()
+}
+
 // QueryEventsByIDs fetches events by their IDs from connected relays.
+// Events found in the event cache are returned without querying relays.
 func (p *Pool) QueryEventsByIDs(ids []string) ([]types.Event, error) {
+	events, _, err := p.QueryEventsByIDsWithConflicts(ids)
+	return events, err
+}
+
+// QueryEventsByIDsWithConflicts is QueryEventsByIDs that also reports IDs for
+// which relays returned differing events. Every copy is compared rather than
+// keeping the first; when one copy of a conflicting pair hashes to its ID it
+// is the one returned. Conflicting events are not cached.
+func (p *Pool) QueryEventsByIDsWithConflicts(ids []string) ([]types.Event, []types.EventConflict, error) {
+	done, err := p.trackQuery()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer done()
+
+	var events []types.Event
+	missing := ids
+	if p.eventCache != nil {
+		missing = make([]string, 0, len(ids))
+		for _, id := range ids {
+			if ev, ok := p.eventCache.Get(id); ok {
+				events = append(events, ev)
+			} else {
+				missing = append(missing, id)
+			}
+		}
+		if len(ids) > 0 && len(missing) == 0 {
+			return events, nil, nil
+		}
+	}
+
 	relays := p.GetConnected()
 	if len(relays) == 0 {
-		return nil, fmt.Errorf("no connected relays")
+		return nil, nil, fmt.Errorf("no connected relays")
 	}
 
 	if len(ids) == 0 {
-		return []types.Event{}, nil
+		return []types.Event{}, nil, nil
 	}
 
 	filter := nostr.Filter{
-		IDs:   ids,
-		Limit: len(ids),
+		IDs:   missing,
+		Limit: len(missing),
 	}
 
-	ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
 	defer cancel()
 
-	var events []types.Event
-	seen := make(map[string]bool)
-	ch := p.pool.SubManyEose(ctx, relays, nostr.Filters{filter})
+	cached := len(events)
+	detector := newIDConflicts()
+	index := make(map[string]int)
+	var conflicts []types.EventConflict
+	ch := p.pool.SubManyEoseNonUnique(ctx, relays, nostr.Filters{filter})
 
 	for ev := range ch {
-		if !seen[ev.Event.ID] {
-			seen[ev.Event.ID] = true
-			events = append(events, types.Event{
-				ID:        ev.Event.ID,
-				Kind:      ev.Event.Kind,
-				PubKey:    ev.Event.PubKey,
-				Content:   ev.Event.Content,
-				CreatedAt: int64(ev.Event.CreatedAt),
-				Tags:      convertTags(ev.Event.Tags),
-				Sig:       ev.Event.Sig,
-				Relay:     ev.Relay.URL,
-			})
+		first, conflict, replace := detector.check(ev.Event, ev.Relay.URL)
+		if conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		}
+		switch {
+		case first:
+			p.collectRelayHints(ev.Event)
+			index[ev.Event.ID] = len(events)
+			events = append(events, convertEvent(ev.Event, ev.Relay.URL))
+		case replace:
+			events[index[ev.Event.ID]] = convertEvent(ev.Event, ev.Relay.URL)
 		}
 	}
 
-	return events, nil
+	if p.eventCache != nil {
+		conflicted := make(map[string]bool, len(conflicts))
+		for _, conflict := range conflicts {
+			conflicted[conflict.ID] = true
+		}
+		for _, event := range events[cached:] {
+			if !conflicted[event.ID] {
+				p.eventCache.Put(event)
+			}
+		}
+	}
+
+	return events, conflicts, nil
 }
 
+// DefaultReplyLimit is the per-relay reply limit QueryEventReplies uses when
+// the caller passes a limit of 0.
+const DefaultReplyLimit = 100
+
 // QueryEventReplies fetches events that reference (reply to) a given event ID.
-func (p *Pool) QueryEventReplies(eventID string) ([]types.Event, error) {
-	relays := p.GetConnected()
+// limit caps the replies requested from each relay (DefaultReplyLimit when 0)
+// and until, when non-zero, only returns replies created at or before it so
+// callers can page back through busy threads. The returned bool reports
+// whether any relay returned a full page, meaning older replies may remain.
+// If selectedRelays is provided and non-empty, only those relays are queried (must be connected).
+func (p *Pool) QueryEventReplies(eventID string, limit int, until int64, selectedRelays ...string) ([]types.Event, bool, error) {
+	done, err := p.trackQuery()
+	if err != nil {
+		return nil, false, err
+	}
+	defer done()
+
+	relays := p.getRelaysForQuery(selectedRelays)
 	if len(relays) == 0 {
-		return nil, fmt.Errorf("no connected relays")
+		return nil, false, fmt.Errorf("no connected relays")
+	}
+	if limit <= 0 {
+		limit = DefaultReplyLimit
 	}
 
 	// Query for kind 1 events with e-tags referencing this event ID
@@ -818,39 +1234,50 @@ func (p *Pool) QueryEventReplies(eventID string) ([]types.Event, error) {
 		Tags: nostr.TagMap{
 			"e": []string{eventID},
 		},
-		Limit: 100,
+		Limit: limit,
+	}
+	if until > 0 {
+		ts := nostr.Timestamp(until)
+		filter.Until = &ts
 	}
 
-	ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
 	defer cancel()
 
 	var events []types.Event
 	seen := make(map[string]bool)
+	perRelay := make(map[string]int)
 	ch := p.pool.SubManyEose(ctx, relays, nostr.Filters{filter})
 
 	for ev := range ch {
+		perRelay[ev.Relay.URL]++
 		if !seen[ev.Event.ID] {
 			seen[ev.Event.ID] = true
-			events = append(events, types.Event{
-				ID:        ev.Event.ID,
-				Kind:      ev.Event.Kind,
-				PubKey:    ev.Event.PubKey,
-				Content:   ev.Event.Content,
-				CreatedAt: int64(ev.Event.CreatedAt),
-				Tags:      convertTags(ev.Event.Tags),
-				Sig:       ev.Event.Sig,
-				Relay:     ev.Relay.URL,
-			})
+			p.collectRelayHints(ev.Event)
+			events = append(events, convertEvent(ev.Event, ev.Relay.URL))
 		}
 	}
 
-	return events, nil
+	limitHit := false
+	for _, count := range perRelay {
+		if count >= limit {
+			limitHit = true
+			break
+		}
+	}
+
+	return events, limitHit, nil
 }
 
 // QueryEventFromAllRelays fetches an event by ID from all connected relays,
 // returning individual results for each relay (whether found, latency, errors).
 func (p *Pool) QueryEventFromAllRelays(eventID string) *types.EventFetchAllRelaysResponse {
 	relays := p.GetConnected()
+	if done, err := p.trackQuery(); err != nil {
+		relays = nil
+	} else {
+		defer done()
+	}
 	response := &types.EventFetchAllRelaysResponse{
 		EventID:     eventID,
 		Results:     make([]types.EventRelayResult, 0, len(relays)),
@@ -870,11 +1297,14 @@ func (p *Pool) QueryEventFromAllRelays(eventID string) *types.EventFetchAllRelay
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	resultsChan := make(chan types.EventRelayResult, len(relays))
+	slots := p.newQuerySlots()
 
 	for _, relayURL := range relays {
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
+			slots.acquire()
+			defer slots.release()
 
 			result := types.EventRelayResult{
 				URL:   url,
@@ -882,7 +1312,7 @@ func (p *Pool) QueryEventFromAllRelays(eventID string) *types.EventFetchAllRelay
 			}
 
 			start := time.Now()
-			ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+			ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
 			defer cancel()
 
 			// Get a single relay from the pool for this specific query
@@ -908,16 +1338,8 @@ func (p *Pool) QueryEventFromAllRelays(eventID string) *types.EventFetchAllRelay
 			case ev := <-sub.Events:
 				if ev != nil {
 					result.Found = true
-					result.Event = &types.Event{
-						ID:        ev.ID,
-						Kind:      ev.Kind,
-						PubKey:    ev.PubKey,
-						Content:   ev.Content,
-						CreatedAt: int64(ev.CreatedAt),
-						Tags:      convertTags(ev.Tags),
-						Sig:       ev.Sig,
-						Relay:     url,
-					}
+					event := convertEvent(ev, url)
+					result.Event = &event
 				}
 			case <-sub.EndOfStoredEvents:
 				// No event found, result.Found remains false
@@ -955,6 +1377,11 @@ func (p *Pool) QueryBatchEventsByIDs(ids []string) *types.BatchQueryResponse {
 	totalStart := time.Now()
 
 	relays := p.GetConnected()
+	if done, err := p.trackQuery(); err != nil {
+		relays = nil
+	} else {
+		defer done()
+	}
 	response := &types.BatchQueryResponse{
 		Results:      make([]types.BatchEventResult, 0, len(ids)),
 		TotalQueried: len(ids),
@@ -996,12 +1423,15 @@ func (p *Pool) QueryBatchEventsByIDs(ids []string) *types.BatchQueryResponse {
 
 	// Query each relay individually to track per-relay availability
 	var wg sync.WaitGroup
+	slots := p.newQuerySlots()
 	for _, relayURL := range relays {
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
+			slots.acquire()
+			defer slots.release()
 
-			ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+			ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
 			defer cancel()
 
 			relay, err := p.pool.EnsureRelay(url)
@@ -1024,16 +1454,8 @@ func (p *Pool) QueryBatchEventsByIDs(ids []string) *types.BatchQueryResponse {
 						if info, exists := eventResults[ev.ID]; exists {
 							info.foundOn[url] = true
 							if info.event == nil {
-								info.event = &types.Event{
-									ID:        ev.ID,
-									Kind:      ev.Kind,
-									PubKey:    ev.PubKey,
-									Content:   ev.Content,
-									CreatedAt: int64(ev.CreatedAt),
-									Tags:      convertTags(ev.Tags),
-									Sig:       ev.Sig,
-									Relay:     url,
-								}
+								event := convertEvent(ev, url)
+								info.event = &event
 							}
 						}
 						eventMu.Unlock()
@@ -1108,10 +1530,7 @@ func (p *Pool) RefreshRelayInfo(url string) error {
 	}
 
 	// Fetch in foreground for immediate result
-	ctx, cancel := context.WithTimeout(p.ctx, 7*time.Second)
-	defer cancel()
-
-	info, err := nip11.Fetch(ctx, url)
+	info, err := p.fetchNIP11(url)
 	if err != nil {
 		return fmt.Errorf("failed to fetch NIP-11 info: %w", err)
 	}
@@ -1162,22 +1581,24 @@ func (p *Pool) GetCachedRelayInfoWithMetadata(url string) *CachedRelayInfo {
 
 // FetchRelayInfoCached fetches and caches relay info for any relay URL.
 // This can be used to get info for relays not in the pool.
-// If info is already cached and not expired, returns cached info.
-// Set forceRefresh to true to bypass cache and fetch fresh info.
+// If info is already cached and not expired, returns cached info. A recent
+// failed fetch is also cached briefly and returned as an error without
+// retrying. Set forceRefresh to true to bypass cache and fetch fresh info.
 func (p *Pool) FetchRelayInfoCached(url string, forceRefresh bool) (*types.RelayInfo, error) {
 	// Check cache first (unless force refresh)
 	if !forceRefresh && p.infoCache != nil {
 		if info := p.infoCache.Get(url); info != nil {
 			return info, nil
 		}
+		if err := p.infoCache.GetFailure(url); err != nil {
+			return nil, fmt.Errorf("failed to fetch NIP-11 info: %w", err)
+		}
 	}
 
-	// Fetch from network
-	ctx, cancel := context.WithTimeout(p.ctx, 7*time.Second)
-	defer cancel()
-
-	info, err := nip11.Fetch(ctx, url)
+	// Fetch from network, retrying transient failures
+	info, err := p.fetchNIP11(url)
 	if err != nil {
+		p.cacheInfoFailure(url, err)
 		return nil, fmt.Errorf("failed to fetch NIP-11 info: %w", err)
 	}
 
@@ -1191,18 +1612,22 @@ func (p *Pool) FetchRelayInfoCached(url string, forceRefresh bool) (*types.Relay
 	return relayInfo, nil
 }
 
-// convertNIP11Info converts nip11.RelayInformationDocument to types.RelayInfo.
-func (p *Pool) convertNIP11Info(info *nip11.RelayInformationDocument) *types.RelayInfo {
+// convertNIP11Info converts a fetched NIP-11 document to types.RelayInfo.
+func (p *Pool) convertNIP11Info(info *infoDocument) *types.RelayInfo {
 	relayInfo := &types.RelayInfo{
-		Name:          info.Name,
-		Description:   info.Description,
-		PubKey:        info.PubKey,
-		Contact:       info.Contact,
-		SupportedNIPs: info.SupportedNIPs,
-		Software:      info.Software,
-		Version:       info.Version,
-		Icon:          info.Icon,
-		PaymentsURL:   info.PaymentsURL,
+		Name:           info.Name,
+		Description:    info.Description,
+		PubKey:         info.PubKey,
+		Contact:        info.Contact,
+		SupportedNIPs:  info.SupportedNIPs,
+		Software:       info.Software,
+		Version:        info.Version,
+		Icon:           info.Icon,
+		PaymentsURL:    info.PaymentsURL,
+		Retention:      convertRetention(info.Retention),
+		RelayCountries: info.RelayCountries,
+		LanguageTags:   info.LanguageTags,
+		PostingPolicy:  info.PostingPolicy,
 	}
 
 	if info.Limitation != nil {
@@ -1255,6 +1680,14 @@ func (p *Pool) InfoCache() *RelayInfoCache {
 // If relayURLs is empty, publishes to all connected relays.
 // Returns results for each relay attempted.
 func (p *Pool) PublishEvent(event *nostr.Event, relayURLs []string) []types.PublishResult {
+	return p.PublishEventWithRetries(event, relayURLs, 0)
+}
+
+// PublishEventWithRetries publishes an event like PublishEvent, retrying each
+// relay that fails with a transient error up to retries more times. Permanent
+// rejections (blocked, invalid, ...) are not retried. Each result records how
+// many attempts were made.
+func (p *Pool) PublishEventWithRetries(event *nostr.Event, relayURLs []string, retries int) []types.PublishResult {
 	// If no specific relays provided, use all connected relays
 	if len(relayURLs) == 0 {
 		relayURLs = p.GetConnected()
@@ -1271,45 +1704,29 @@ func (p *Pool) PublishEvent(event *nostr.Event, relayURLs []string) []types.Publ
 	results := make([]types.PublishResult, 0, len(relayURLs))
 	var wg sync.WaitGroup
 	var resultsMu sync.Mutex
+	slots := p.newQuerySlots()
 
 	for _, url := range relayURLs {
 		wg.Add(1)
 		go func(relayURL string) {
 			defer wg.Done()
-
-			result := types.PublishResult{URL: relayURL}
-
-			p.mu.RLock()
-			conn, exists := p.relays[relayURL]
-			p.mu.RUnlock()
-
-			if !exists {
-				result.Success = false
-				result.Error = "relay not in pool"
-				resultsMu.Lock()
-				results = append(results, result)
-				resultsMu.Unlock()
-				return
-			}
-
-			if !conn.Connected || conn.Relay == nil {
-				result.Success = false
-				result.Error = "relay not connected"
-				resultsMu.Lock()
-				results = append(results, result)
-				resultsMu.Unlock()
-				return
-			}
-
-			ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
-			defer cancel()
-
-			err := conn.Relay.Publish(ctx, *event)
-			if err != nil {
-				result.Success = false
-				result.Error = err.Error()
-			} else {
-				result.Success = true
+			slots.acquire()
+			defer slots.release()
+
+			var result types.PublishResult
+			for attempt := 1; ; attempt++ {
+				result = p.publishToRelay(event, relayURL)
+				result.Attempts = attempt
+				if result.Success || attempt > retries || !isTransientPublishError(result.Error) {
+					break
+				}
+				select {
+				case <-time.After(publishRetryDelay):
+				case <-p.ctx.Done():
+				}
+				if p.ctx.Err() != nil {
+					break
+				}
 			}
 
 			resultsMu.Lock()
@@ -1322,9 +1739,51 @@ func (p *Pool) PublishEvent(event *nostr.Event, relayURLs []string) []types.Publ
 	return results
 }
 
+// publishToRelay makes a single attempt to publish an event to a pool relay.
+func (p *Pool) publishToRelay(event *nostr.Event, relayURL string) types.PublishResult {
+	result := types.PublishResult{URL: relayURL}
+
+	p.mu.RLock()
+	conn, exists := p.relays[relayURL]
+	var relay *nostr.Relay
+	connected := false
+	if exists {
+		relay = conn.Relay
+		connected = conn.Connected
+	}
+	p.mu.RUnlock()
+
+	if !exists {
+		result.Error = "relay not in pool"
+		return result
+	}
+
+	if !connected || relay == nil {
+		result.Error = "relay not connected"
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
+	defer cancel()
+
+	if err := relay.Publish(ctx, *event); err != nil {
+		result.Error = err.Error()
+		result.Reason = okReason(err)
+	} else {
+		result.Success = true
+	}
+	return result
+}
+
 // PublishEventJSON publishes a signed event (as JSON bytes) to the specified relays.
 // This is a convenience method that parses the JSON and publishes the event.
 func (p *Pool) PublishEventJSON(eventJSON []byte, relayURLs []string) (string, []types.PublishResult) {
+	return p.PublishEventJSONWithRetries(eventJSON, relayURLs, 0)
+}
+
+// PublishEventJSONWithRetries parses and publishes an event like
+// PublishEventJSON, retrying transient relay failures up to retries times.
+func (p *Pool) PublishEventJSONWithRetries(eventJSON []byte, relayURLs []string, retries int) (string, []types.PublishResult) {
 	var event nostr.Event
 	if err := json.Unmarshal(eventJSON, &event); err != nil {
 		return "", []types.PublishResult{{
@@ -1334,7 +1793,7 @@ func (p *Pool) PublishEventJSON(eventJSON []byte, relayURLs []string) (string, [
 		}}
 	}
 
-	results := p.PublishEvent(&event, relayURLs)
+	results := p.PublishEventWithRetries(&event, relayURLs, retries)
 	return event.ID, results
 }
 
@@ -1349,7 +1808,107 @@ func (p *Pool) AggregateEvents(kinds []int, authors []string, tags map[string][]
 		return nil, err
 	}
 
-	return p.aggregateEventData(events, time.Since(totalStart).Milliseconds()), nil
+	agg := p.aggregateEventData(events, time.Since(totalStart).Milliseconds())
+	agg.Propagation = aggregatePropagation(events, len(p.getRelaysForQuery(selectedRelays)))
+	return agg, nil
+}
+
+// CountEvents counts events matching the filter on each relay.
+// Relays advertising NIP-45 are sent a COUNT request; the others fall back to
+// fetching events up to the limit and counting them, which marks the result
+// approximate. The overall count is the highest count reported by any relay.
+func (p *Pool) CountEvents(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) (*types.EventCountResponse, error) {
+	done, err := p.trackQuery()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	totalStart := time.Now()
+
+	relays := p.getRelaysForQuery(selectedRelays)
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("no connected relays")
+	}
+
+	filter := buildFilter(kinds, authors, tags, limit, since, until)
+
+	response := &types.EventCountResponse{
+		PerRelay: make(map[string]types.RelayEventCount, len(relays)),
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	slots := p.newQuerySlots()
+	for _, relayURL := range relays {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			slots.acquire()
+			defer slots.release()
+			result := p.countOnRelay(url, filter)
+			mu.Lock()
+			response.PerRelay[url] = result
+			mu.Unlock()
+		}(relayURL)
+	}
+	wg.Wait()
+
+	for _, result := range response.PerRelay {
+		if result.Count > response.Count {
+			response.Count = result.Count
+		}
+		if result.Approximate && result.Error == "" {
+			response.Approximate = true
+		}
+	}
+
+	response.TotalTimeMs = time.Since(totalStart).Milliseconds()
+
+	return response, nil
+}
+
+// countOnRelay counts events on a single relay, using NIP-45 COUNT when the
+// relay supports it and counting fetched events otherwise.
+func (p *Pool) countOnRelay(url string, filter nostr.Filter) types.RelayEventCount {
+	if p.supportsNIP(url, 45) {
+		relay, err := p.pool.EnsureRelay(url)
+		if err != nil {
+			return types.RelayEventCount{Error: fmt.Sprintf("connection error: %v", err)}
+		}
+
+		ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
+		defer cancel()
+
+		// COUNT requests are not bounded by the fetch limit
+		countFilter := filter
+		countFilter.Limit = 0
+		count, err := relay.Count(ctx, nostr.Filters{countFilter})
+		if err == nil {
+			return types.RelayEventCount{Count: count}
+		}
+	}
+
+	result := p.queryRelay(url, filter)
+	return types.RelayEventCount{
+		Count:       int64(len(result.events)),
+		Approximate: true,
+		Error:       result.timing.Error,
+	}
+}
+
+// supportsNIP reports whether the relay's NIP-11 document lists the given NIP.
+func (p *Pool) supportsNIP(url string, nip int) bool {
+	info := p.GetRelayInfo(url)
+	if info == nil {
+		return false
+	}
+	for _, n := range info.SupportedNIPs {
+		if n == nip {
+			return true
+		}
+	}
+	return false
 }
 
 // aggregateEventData computes aggregation statistics from a slice of events.
@@ -1433,9 +1992,10 @@ func (p *Pool) aggregateEventData(events []types.Event, queryTimeMs int64) *type
 	// Convert kind counts to sorted slice
 	for kind, count := range kindCounts {
 		agg.KindCounts = append(agg.KindCounts, types.KindCount{
-			Kind:  kind,
-			Count: count,
-			Label: getKindLabel(kind),
+			Kind:     kind,
+			Count:    count,
+			Label:    types.KindLabel(kind),
+			Category: types.KindCategory(kind),
 		})
 	}
 	// Sort by count descending
@@ -1501,47 +2061,9 @@ func (p *Pool) aggregateEventData(events []types.Event, queryTimeMs int64) *type
 		EmptyCount: emptyContent,
 	}
 
-	return agg
-}
+	agg.FileStats = aggregateFileMetadata(events)
 
-// getKindLabel returns a human-readable label for an event kind.
-func getKindLabel(kind int) string {
-	labels := map[int]string{
-		0:     "Metadata",
-		1:     "Short Text Note",
-		2:     "Recommend Relay",
-		3:     "Contacts",
-		4:     "Encrypted DM",
-		5:     "Deletion",
-		6:     "Repost",
-		7:     "Reaction",
-		8:     "Badge Award",
-		9:     "Group Chat",
-		10:    "Group Chat Threaded",
-		40:    "Channel Creation",
-		41:    "Channel Metadata",
-		42:    "Channel Message",
-		43:    "Channel Hide",
-		44:    "Channel Mute",
-		1063:  "File Metadata",
-		1984:  "Report",
-		9734:  "Zap Request",
-		9735:  "Zap Receipt",
-		10000: "Mute List",
-		10001: "Pin List",
-		10002: "Relay List",
-		30000: "Categorized People",
-		30001: "Categorized Bookmarks",
-		30008: "Profile Badges",
-		30009: "Badge Definition",
-		30023: "Long-form Content",
-		30024: "Draft Long-form",
-		30078: "App-specific Data",
-	}
-	if label, ok := labels[kind]; ok {
-		return label
-	}
-	return fmt.Sprintf("Kind %d", kind)
+	return agg
 }
 
 // Sort helpers
@@ -1637,13 +2159,28 @@ func computeTimeDistribution(events []types.Event, earliest, latest int64) []typ
 	return buckets
 }
 
-// Close closes all relay connections.
+// Close closes all relay connections. Live subscriptions are cancelled and
+// in-flight queries are given up to the drain timeout to finish first; queries
+// started once Close has begun fail with ErrPoolClosing.
 func (p *Pool) Close() {
+	p.stopAccepting()
+
+	p.subMu.Lock()
+	for _, cancel := range p.subs {
+		cancel()
+	}
+	p.subMu.Unlock()
+
+	if remaining := p.drain(p.drainTimeout()); remaining > 0 {
+		logging.Warnf("[Relay] Closing with %d queries still active", remaining)
+	}
+
 	p.cancel()
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	for _, conn := range p.relays {
+		conn.stopKeepAliveLocked()
 		if conn.Relay != nil {
 			conn.Relay.Close()
 		}
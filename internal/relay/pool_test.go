@@ -1,11 +1,21 @@
 package relay
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip11"
 )
 
 func TestSetOnStatusChange(t *testing.T) {
@@ -1201,28 +1211,6 @@ func TestAggregateEventData_WithEvents(t *testing.T) {
 	}
 }
 
-func TestGetKindLabel(t *testing.T) {
-	testCases := []struct {
-		kind     int
-		expected string
-	}{
-		{0, "Metadata"},
-		{1, "Short Text Note"},
-		{3, "Contacts"},
-		{7, "Reaction"},
-		{9735, "Zap Receipt"},
-		{30023, "Long-form Content"},
-		{99999, "Kind 99999"}, // Unknown kind
-	}
-
-	for _, tc := range testCases {
-		result := getKindLabel(tc.kind)
-		if result != tc.expected {
-			t.Errorf("getKindLabel(%d): expected '%s', got '%s'", tc.kind, tc.expected, result)
-		}
-	}
-}
-
 func TestComputeTimeDistribution_Empty(t *testing.T) {
 	events := []types.Event{}
 	result := computeTimeDistribution(events, 0, 0)
@@ -1308,3 +1296,782 @@ func TestSortHelpers(t *testing.T) {
 		t.Error("sortRelayCounts did not sort correctly")
 	}
 }
+
+func TestAddReportsAlreadyPresent(t *testing.T) {
+	pool := &Pool{
+		relays: map[string]*RelayConn{
+			"wss://relay.example.com": {URL: "wss://relay.example.com", Connected: true},
+		},
+	}
+
+	added, err := pool.Add("wss://relay.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if added {
+		t.Error("expected Add to report the relay as already present")
+	}
+	if pool.Count() != 1 {
+		t.Errorf("expected pool to still have 1 relay, got %d", pool.Count())
+	}
+}
+
+func TestAddRejectsMalformedURLs(t *testing.T) {
+	pool := &Pool{
+		relays: make(map[string]*RelayConn),
+	}
+
+	for _, url := range []string{"http://foo", "relay.example.com", "wss://", ""} {
+		added, err := pool.Add(url)
+		if err == nil {
+			t.Errorf("expected error for %q", url)
+		}
+		if added {
+			t.Errorf("expected %q not to be added", url)
+		}
+	}
+
+	if pool.Count() != 0 {
+		t.Errorf("expected no relays to be added, got %d", pool.Count())
+	}
+}
+
+func TestAddNormalizesTrailingSlash(t *testing.T) {
+	pool := &Pool{
+		relays: map[string]*RelayConn{
+			"wss://relay.example.com": {URL: "wss://relay.example.com", Connected: true},
+		},
+	}
+
+	added, err := pool.Add("wss://relay.example.com/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if added {
+		t.Error("expected trailing-slash URL to match the existing relay")
+	}
+	if pool.Count() != 1 {
+		t.Errorf("expected 1 relay, got %d", pool.Count())
+	}
+}
+
+func TestMergeRelayResultsAggregatesSeenOn(t *testing.T) {
+	base := time.Now()
+	ev := func(id, relay string) types.Event {
+		return types.Event{ID: id, Kind: 1, Content: "hello", Relay: relay}
+	}
+
+	results := []relayQueryResult{
+		{
+			timing: types.RelayFetchTiming{URL: "wss://slow.example.com", EventCount: 2},
+			events: []seenEvent{
+				{event: ev("aaa", "wss://slow.example.com"), receivedAt: base.Add(300 * time.Millisecond)},
+				{event: ev("bbb", "wss://slow.example.com"), receivedAt: base.Add(350 * time.Millisecond)},
+			},
+		},
+		{
+			timing: types.RelayFetchTiming{URL: "wss://fast.example.com", EventCount: 1},
+			events: []seenEvent{
+				{event: ev("aaa", "wss://fast.example.com"), receivedAt: base.Add(50 * time.Millisecond)},
+			},
+		},
+	}
+
+	events, timings := mergeRelayResults(results)
+
+	if len(timings) != 2 {
+		t.Fatalf("expected 2 timings, got %d", len(timings))
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 deduplicated events, got %d", len(events))
+	}
+
+	first := events[0]
+	if first.ID != "aaa" {
+		t.Fatalf("expected first event aaa, got %s", first.ID)
+	}
+	if first.Relay != "wss://fast.example.com" {
+		t.Errorf("expected earliest-seen copy from fast relay, got %s", first.Relay)
+	}
+	want := []string{"wss://fast.example.com", "wss://slow.example.com"}
+	if len(first.SeenOn) != len(want) {
+		t.Fatalf("expected SeenOn %v, got %v", want, first.SeenOn)
+	}
+	for i := range want {
+		if first.SeenOn[i] != want[i] {
+			t.Errorf("SeenOn[%d] = %s, want %s", i, first.SeenOn[i], want[i])
+		}
+	}
+
+	if len(events[1].SeenOn) != 1 || events[1].SeenOn[0] != "wss://slow.example.com" {
+		t.Errorf("expected bbb seen only on slow relay, got %v", events[1].SeenOn)
+	}
+}
+
+func TestMergeRelayResultsSortsTimingsByURL(t *testing.T) {
+	// Results arrive in goroutine completion order, not URL order.
+	results := []relayQueryResult{
+		{timing: types.RelayFetchTiming{URL: "wss://c.example.com", LatencyMs: 30, FirstEventMs: 25}},
+		{timing: types.RelayFetchTiming{URL: "wss://a.example.com", LatencyMs: 10, FirstEventMs: 5}},
+		{timing: types.RelayFetchTiming{URL: "wss://b.example.com", LatencyMs: 20, FirstEventMs: 15}},
+	}
+
+	_, timings := mergeRelayResults(results)
+
+	want := []types.RelayFetchTiming{
+		{URL: "wss://a.example.com", LatencyMs: 10, FirstEventMs: 5},
+		{URL: "wss://b.example.com", LatencyMs: 20, FirstEventMs: 15},
+		{URL: "wss://c.example.com", LatencyMs: 30, FirstEventMs: 25},
+	}
+	if len(timings) != len(want) {
+		t.Fatalf("expected %d timings, got %d", len(want), len(timings))
+	}
+	for i := range want {
+		if timings[i] != want[i] {
+			t.Errorf("timings[%d] = %+v, want %+v", i, timings[i], want[i])
+		}
+	}
+}
+
+func TestInterleaveRelayResultsBalancesGlobalLimit(t *testing.T) {
+	base := time.Now()
+	relayEvents := func(relay, prefix string, n int, offset time.Duration) relayQueryResult {
+		result := relayQueryResult{timing: types.RelayFetchTiming{URL: relay, EventCount: n}}
+		for i := 0; i < n; i++ {
+			result.events = append(result.events, seenEvent{
+				event:      types.Event{ID: fmt.Sprintf("%s-%d", prefix, i), Kind: 1, Relay: relay},
+				receivedAt: base.Add(offset + time.Duration(i)*time.Millisecond),
+			})
+		}
+		return result
+	}
+
+	// The fast relay finishes first and would fill the whole limit on its own.
+	results := []relayQueryResult{
+		relayEvents("wss://fast.example.com", "fast", 10, 0),
+		relayEvents("wss://slow.example.com", "slow", 10, 500*time.Millisecond),
+	}
+
+	events, timings := interleaveRelayResults(results, 10)
+
+	if len(timings) != 2 {
+		t.Fatalf("expected 2 timings, got %d", len(timings))
+	}
+	if len(events) != 10 {
+		t.Fatalf("expected 10 events after global limit, got %d", len(events))
+	}
+
+	perRelay := make(map[string]int)
+	for _, event := range events {
+		perRelay[event.Relay]++
+	}
+	if perRelay["wss://fast.example.com"] != 5 || perRelay["wss://slow.example.com"] != 5 {
+		t.Errorf("expected 5 events from each relay, got %v", perRelay)
+	}
+}
+
+func TestInterleaveRelayResultsSkipsDuplicates(t *testing.T) {
+	base := time.Now()
+	ev := func(id, relay string, at time.Duration) seenEvent {
+		return seenEvent{event: types.Event{ID: id, Kind: 1, Relay: relay}, receivedAt: base.Add(at)}
+	}
+
+	results := []relayQueryResult{
+		{events: []seenEvent{ev("shared", "wss://a.example.com", 0), ev("a-only", "wss://a.example.com", 1)}},
+		{events: []seenEvent{ev("shared", "wss://b.example.com", 5), ev("b-only", "wss://b.example.com", 6)}},
+	}
+
+	events, _ := interleaveRelayResults(results, 2)
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].ID != "shared" || events[1].ID != "b-only" {
+		t.Errorf("expected [shared b-only], got [%s %s]", events[0].ID, events[1].ID)
+	}
+	if len(events[0].SeenOn) != 2 {
+		t.Errorf("expected shared event to keep both relays in SeenOn, got %v", events[0].SeenOn)
+	}
+}
+
+func TestSupportsNIP(t *testing.T) {
+	pool := &Pool{
+		relays: map[string]*RelayConn{
+			"wss://nip45.example.com": {
+				URL:  "wss://nip45.example.com",
+				Info: &types.RelayInfo{SupportedNIPs: []int{1, 11, 45}},
+			},
+			"wss://legacy.example.com": {
+				URL:  "wss://legacy.example.com",
+				Info: &types.RelayInfo{SupportedNIPs: []int{1, 11}},
+			},
+			"wss://noinfo.example.com": {URL: "wss://noinfo.example.com"},
+		},
+	}
+
+	if !pool.supportsNIP("wss://nip45.example.com", 45) {
+		t.Error("expected relay advertising NIP-45 to support COUNT")
+	}
+	if pool.supportsNIP("wss://legacy.example.com", 45) {
+		t.Error("expected relay without NIP-45 not to support COUNT")
+	}
+	if pool.supportsNIP("wss://noinfo.example.com", 45) {
+		t.Error("expected relay without NIP-11 info not to support COUNT")
+	}
+}
+
+func TestUnsubscribeStopsSubscription(t *testing.T) {
+	fr := newFakeRelay(t)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{})
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	subID := pool.Subscribe([]int{1}, nil, func(types.Event) {})
+
+	if !pool.Unsubscribe(subID) {
+		t.Fatalf("expected Unsubscribe(%s) to succeed", subID)
+	}
+	if pool.Unsubscribe(subID) {
+		t.Error("expected second Unsubscribe to report the subscription is gone")
+	}
+	if pool.Unsubscribe("sub-unknown") {
+		t.Error("expected Unsubscribe of an unknown ID to return false")
+	}
+}
+
+func TestQueryTimeoutReturnsPartialResults(t *testing.T) {
+	fr := newFakeRelay(t, signedEvent(t, "first"), signedEvent(t, "second"))
+	fr.setNoEOSE(true)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{QueryTimeout: 200 * time.Millisecond})
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	start := time.Now()
+	response, err := pool.QueryEventsAdvancedWithTiming([]int{1}, nil, nil, 10, 0, 0)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("expected query to stop at the configured timeout, took %v", elapsed)
+	}
+	if len(response.Events) != 2 {
+		t.Errorf("expected the 2 events received before the timeout, got %d", len(response.Events))
+	}
+	if len(response.RelayTimings) != 1 || response.RelayTimings[0].Error != "timeout" {
+		t.Errorf("expected relay timing to report a timeout, got %+v", response.RelayTimings)
+	}
+}
+
+func TestQueryEventsAdvancedPartial(t *testing.T) {
+	healthy := newFakeRelay(t, signedEvent(t, "from healthy"))
+	slow := newFakeRelay(t, signedEvent(t, "from slow"))
+	slow.setNoEOSE(true)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{QueryTimeout: 200 * time.Millisecond})
+	defer pool.Close()
+	pool.Add(healthy.URL)
+	pool.Add(slow.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 2 }) {
+		t.Fatal("relays never connected")
+	}
+
+	events, err := pool.QueryEventsAdvancedPartial([]int{1}, nil, nil, 10, 0, 0)
+
+	var partial *types.PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected *types.PartialError, got %v", err)
+	}
+	if len(events) != 2 || len(partial.Events) != 2 {
+		t.Errorf("expected 2 events, got %d (error carries %d)", len(events), len(partial.Events))
+	}
+	if len(partial.Failed) != 1 || partial.Failed[0].URL != slow.URL || partial.Failed[0].Reason != "timeout" {
+		t.Errorf("expected only the slow relay to fail with timeout, got %+v", partial.Failed)
+	}
+
+	// Once every relay fails there are no partial results to return
+	healthy.setNoEOSE(true)
+	events, err = pool.QueryEventsAdvancedPartial([]int{1}, nil, nil, 10, 0, 0)
+	if err == nil || errors.As(err, &partial) {
+		t.Errorf("expected a plain error when all relays fail, got %v", err)
+	}
+	if events != nil {
+		t.Errorf("expected no events when all relays fail, got %d", len(events))
+	}
+}
+
+func TestQueryEventsOnRelaysWithTimingUsesRelaysOutsidePool(t *testing.T) {
+	outside := newFakeRelay(t, signedEvent(t, "from outbox relay"))
+
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	response, err := pool.QueryEventsOnRelaysWithTiming([]string{outside.URL, "not-a-relay"}, []int{1}, nil, nil, 10, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(response.Events) != 1 {
+		t.Errorf("expected 1 event from the outside relay, got %d", len(response.Events))
+	}
+	if len(response.RelayTimings) != 1 || response.RelayTimings[0].URL != outside.URL {
+		t.Errorf("expected only the valid relay to be queried, got %+v", response.RelayTimings)
+	}
+	if len(pool.List()) != 0 {
+		t.Errorf("expected the relay not to be added to the pool, got %d relays", len(pool.List()))
+	}
+	if _, ok := pool.pool.Relays.Load(nostr.NormalizeURL(outside.URL)); ok {
+		t.Error("expected the temporary connection to be released")
+	}
+
+	if _, err := pool.QueryEventsOnRelaysWithTiming([]string{"https://example.com"}, nil, nil, nil, 10, 0, 0); err == nil {
+		t.Error("expected an error when no relay URL is valid")
+	}
+}
+
+func TestPoolTimeoutDefaults(t *testing.T) {
+	pool := &Pool{}
+	if pool.queryTimeout() != DefaultQueryTimeout {
+		t.Errorf("queryTimeout() = %v, want %v", pool.queryTimeout(), DefaultQueryTimeout)
+	}
+	if pool.connectTimeout() != DefaultConnectTimeout {
+		t.Errorf("connectTimeout() = %v, want %v", pool.connectTimeout(), DefaultConnectTimeout)
+	}
+	if pool.infoFetchTimeout() != DefaultInfoFetchTimeout {
+		t.Errorf("infoFetchTimeout() = %v, want %v", pool.infoFetchTimeout(), DefaultInfoFetchTimeout)
+	}
+}
+
+func TestQueryRelaysBoundsConcurrency(t *testing.T) {
+	const relayCount = 6
+	const maxConcurrent = 2
+
+	gauge := &concurrencyGauge{}
+	pool := NewPoolWithOptions(nil, PoolOptions{MaxConcurrentQueries: maxConcurrent})
+	defer pool.Close()
+
+	// The relays stay out of the pool so the monitor never queries them.
+	relays := make([]*fakeRelay, relayCount)
+	urls := make([]string, relayCount)
+	for i := range relays {
+		relays[i] = newFakeRelay(t, signedEvent(t, fmt.Sprintf("event %d", i)))
+		relays[i].setSlow(100*time.Millisecond, gauge)
+		urls[i] = relays[i].URL
+	}
+
+	response, err := pool.QueryEventsOnRelaysWithTiming(urls, []int{1}, nil, nil, 10, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(response.Events) != relayCount {
+		t.Errorf("expected %d events, got %d", relayCount, len(response.Events))
+	}
+	if peak := gauge.max(); peak > maxConcurrent {
+		t.Errorf("expected at most %d relays queried at once, saw %d", maxConcurrent, peak)
+	}
+	for _, fr := range relays {
+		if fr.requests() == 0 {
+			t.Errorf("relay %s was never queried", fr.URL)
+		}
+	}
+}
+
+func TestQueryClampsLimitToRelayMaxLimit(t *testing.T) {
+	limited := newFakeRelay(t, signedEvent(t, "limited"))
+	limited.setInfo(nip11.RelayInformationDocument{
+		Name:       "Limited",
+		Limitation: &nip11.RelayLimitationDocument{MaxLimit: 5},
+	})
+	open := newFakeRelay(t, signedEvent(t, "open"))
+
+	pool := NewPoolWithOptions(nil, PoolOptions{})
+	defer pool.Close()
+	pool.Add(limited.URL)
+	pool.Add(open.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool {
+		return len(pool.GetConnected()) == 2 && pool.GetRelayInfo(limited.URL) != nil
+	}) {
+		t.Fatal("relays never connected or NIP-11 info was never fetched")
+	}
+
+	response, err := pool.QueryEventsAdvancedWithTiming([]int{1}, nil, nil, 50, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, timing := range response.RelayTimings {
+		want := 0
+		if timing.URL == limited.URL {
+			want = 5
+		}
+		if timing.ClampedLimit != want {
+			t.Errorf("%s: ClampedLimit = %d, want %d", timing.URL, timing.ClampedLimit, want)
+		}
+	}
+	if got := limited.lastFilter().Limit; got != 5 {
+		t.Errorf("limited relay received limit %d, want 5", got)
+	}
+	if got := open.lastFilter().Limit; got != 50 {
+		t.Errorf("unlimited relay received limit %d, want 50", got)
+	}
+
+	if _, err := pool.QueryEventsAdvanced([]int{1}, nil, nil, 50, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := limited.lastFilter().Limit; got != 5 {
+		t.Errorf("QueryEventsAdvanced sent limit %d to the limited relay, want 5", got)
+	}
+}
+
+func TestReconnectRestoresDisconnectedRelay(t *testing.T) {
+	fr := newFakeRelay(t)
+	fr.setRefuse(true)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{ConnectTimeout: time.Second})
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	var addedAt time.Time
+	if !waitFor(t, 5*time.Second, func() bool {
+		pool.mu.RLock()
+		defer pool.mu.RUnlock()
+		conn := pool.relays[fr.URL]
+		addedAt = conn.AddedAt
+		return conn.Error != ""
+	}) {
+		t.Fatal("expected the first connection attempt to fail")
+	}
+	if len(pool.GetConnected()) != 0 {
+		t.Fatal("relay should start disconnected")
+	}
+
+	fr.setRefuse(false)
+	status, err := pool.Reconnect(fr.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !status.Connected || status.Error != "" {
+		t.Errorf("expected a connected status, got %+v", status)
+	}
+
+	pool.mu.RLock()
+	conn := pool.relays[fr.URL]
+	pool.mu.RUnlock()
+	if !conn.AddedAt.Equal(addedAt) {
+		t.Errorf("AddedAt changed from %v to %v", addedAt, conn.AddedAt)
+	}
+
+	if _, err := pool.Reconnect("wss://not-in-pool.example.com"); err == nil {
+		t.Error("expected an error for a relay that is not in the pool")
+	}
+}
+
+func TestConvertEvent_ContentWarning(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tags     nostr.Tags
+		expected string
+	}{
+		{"no tag", nostr.Tags{{"t", "nostr"}}, ""},
+		{"with reason", nostr.Tags{{"t", "nostr"}, {"content-warning", "spoilers"}}, "spoilers"},
+		{"without reason", nostr.Tags{{"content-warning"}}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			event := convertEvent(&nostr.Event{ID: "abc", Kind: 1, Tags: tc.tags}, "wss://relay.example.com")
+			if event.ContentWarning != tc.expected {
+				t.Errorf("ContentWarning = %q, want %q", event.ContentWarning, tc.expected)
+			}
+		})
+	}
+}
+
+func TestConvertEvent_ContentWarningOmittedFromJSON(t *testing.T) {
+	event := convertEvent(&nostr.Event{ID: "abc", Kind: 1}, "")
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	if strings.Contains(string(data), "content_warning") {
+		t.Errorf("expected content_warning to be omitted, got %s", data)
+	}
+}
+
+func TestConvertEvent_Emojis(t *testing.T) {
+	event := convertEvent(&nostr.Event{
+		ID:      "abc",
+		Kind:    1,
+		Content: "hello :soapbox: :gleasonator:",
+		Tags: nostr.Tags{
+			{"emoji", "soapbox", "https://example.com/soapbox.png"},
+			{"emoji", "gleasonator", "https://example.com/gleasonator.png"},
+			{"emoji", "broken"},
+			{"emoji"},
+			{"t", "emoji"},
+		},
+	}, "")
+
+	expected := map[string]string{
+		"soapbox":     "https://example.com/soapbox.png",
+		"gleasonator": "https://example.com/gleasonator.png",
+	}
+	if len(event.Emojis) != len(expected) {
+		t.Fatalf("expected %d emojis, got %v", len(expected), event.Emojis)
+	}
+	for shortcode, url := range expected {
+		if event.Emojis[shortcode] != url {
+			t.Errorf("Emojis[%q] = %q, want %q", shortcode, event.Emojis[shortcode], url)
+		}
+	}
+}
+
+func TestConvertEvent_NoEmojisOmittedFromJSON(t *testing.T) {
+	event := convertEvent(&nostr.Event{ID: "abc", Kind: 1, Tags: nostr.Tags{{"emoji", "broken"}}}, "")
+	if event.Emojis != nil {
+		t.Errorf("expected nil Emojis, got %v", event.Emojis)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	if strings.Contains(string(data), "emojis") {
+		t.Errorf("expected emojis to be omitted, got %s", data)
+	}
+}
+
+func TestQueryEventRepliesScopedToSelectedRelays(t *testing.T) {
+	rootID := strings.Repeat("a", 64)
+	reply := func(content string) nostr.Event {
+		ev := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Tags: nostr.Tags{{"e", rootID}}, Content: content}
+		if err := ev.Sign(nostr.GeneratePrivateKey()); err != nil {
+			t.Fatalf("failed to sign event: %v", err)
+		}
+		return ev
+	}
+	scoped := newFakeRelay(t, reply("from scoped"))
+	other := newFakeRelay(t, reply("from other"))
+
+	pool := NewPool(nil)
+	defer pool.Close()
+	pool.Add(scoped.URL)
+	pool.Add(other.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 2 }) {
+		t.Fatal("relays never connected")
+	}
+
+	before := other.requests()
+	replies, _, err := pool.QueryEventReplies(rootID, 0, 0, scoped.URL)
+	if err != nil {
+		t.Fatalf("QueryEventReplies: %v", err)
+	}
+	if len(replies) != 1 || replies[0].Relay != scoped.URL {
+		t.Errorf("expected only the scoped relay's reply, got %+v", replies)
+	}
+	if other.requests() != before {
+		t.Errorf("expected the other relay not to be queried, it saw %d new requests", other.requests()-before)
+	}
+
+	replies, _, err = pool.QueryEventReplies(rootID, 0, 0)
+	if err != nil {
+		t.Fatalf("QueryEventReplies: %v", err)
+	}
+	if len(replies) != 2 {
+		t.Errorf("expected replies from both relays without scoping, got %d", len(replies))
+	}
+}
+
+func TestQueryEventRepliesReportsLimitHitAndPagesWithUntil(t *testing.T) {
+	rootID := strings.Repeat("b", 64)
+	var stored []nostr.Event
+	for i := 0; i < 3; i++ {
+		ev := nostr.Event{Kind: 1, CreatedAt: nostr.Timestamp(1700000000 + i), Tags: nostr.Tags{{"e", rootID}}, Content: fmt.Sprint(i)}
+		if err := ev.Sign(nostr.GeneratePrivateKey()); err != nil {
+			t.Fatalf("failed to sign event: %v", err)
+		}
+		stored = append(stored, ev)
+	}
+	fr := newFakeRelay(t, stored...)
+
+	pool := NewPool(nil)
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	replies, limitHit, err := pool.QueryEventReplies(rootID, 3, 0)
+	if err != nil {
+		t.Fatalf("QueryEventReplies: %v", err)
+	}
+	if len(replies) != 3 || !limitHit {
+		t.Errorf("expected a full page to report the limit hit, got %d replies, limitHit=%v", len(replies), limitHit)
+	}
+	if filter := fr.lastFilter(); filter.Limit != 3 || filter.Until != nil {
+		t.Errorf("expected limit 3 and no until, got limit %d until %v", filter.Limit, filter.Until)
+	}
+
+	_, limitHit, err = pool.QueryEventReplies(rootID, 0, 1700000001)
+	if err != nil {
+		t.Fatalf("QueryEventReplies: %v", err)
+	}
+	if limitHit {
+		t.Error("expected a partial page not to report the limit hit")
+	}
+	filter := fr.lastFilter()
+	if filter.Limit != DefaultReplyLimit {
+		t.Errorf("expected the default limit %d, got %d", DefaultReplyLimit, filter.Limit)
+	}
+	if filter.Until == nil || *filter.Until != 1700000001 {
+		t.Errorf("expected until 1700000001, got %v", filter.Until)
+	}
+}
+
+func TestQueryEventFromHintsReachesRelaysOutsideThePool(t *testing.T) {
+	event := signedEvent(t, "only on the hint")
+	connected := newFakeRelay(t)
+	hinted := newFakeRelay(t, event)
+
+	pool := NewPool(nil)
+	defer pool.Close()
+	pool.Add(connected.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	before := connected.requests()
+	found := pool.QueryEventFromHints(event.ID, []string{connected.URL, hinted.URL, "not a relay"})
+	if found == nil {
+		t.Fatal("expected the event to be found on the hinted relay")
+	}
+	if found.Relay != hinted.URL {
+		t.Errorf("expected the event to be served by %s, got %s", hinted.URL, found.Relay)
+	}
+	if connected.requests() != before {
+		t.Errorf("expected the connected relay to be skipped, it saw %d new requests", connected.requests()-before)
+	}
+	if len(pool.List()) != 1 {
+		t.Errorf("expected the hinted relay not to be added to the pool, got %d relays", len(pool.List()))
+	}
+
+	if pool.QueryEventFromHints(strings.Repeat("c", 64), []string{hinted.URL}) != nil {
+		t.Error("expected nil for an event no hint has")
+	}
+}
+
+// headerRelay is a websocket server that records the handshake headers of
+// each connection and then keeps the connection open.
+func headerRelay(t *testing.T) (string, <-chan http.Header) {
+	t.Helper()
+	headers := make(chan http.Header, 4)
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		headers <- r.Header.Clone()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), headers
+}
+
+func TestConnectSendsUserAgentAndOrigin(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          PoolOptions
+		wantUserAgent string
+		wantOrigin    string
+	}{
+		{"defaults", PoolOptions{}, DefaultUserAgent, ""},
+		{"configured", PoolOptions{UserAgent: "Shirushi-Test/1.0", Origin: "https://shirushi.example.com"}, "Shirushi-Test/1.0", "https://shirushi.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, headers := headerRelay(t)
+
+			pool := NewPoolWithOptions(nil, tt.opts)
+			defer pool.Close()
+			pool.Add(url)
+
+			select {
+			case header := <-headers:
+				if got := header.Get("User-Agent"); got != tt.wantUserAgent {
+					t.Errorf("User-Agent = %q, want %q", got, tt.wantUserAgent)
+				}
+				if got := header.Get("Origin"); got != tt.wantOrigin {
+					t.Errorf("Origin = %q, want %q", got, tt.wantOrigin)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("relay never received a connection")
+			}
+		})
+	}
+}
+
+func TestWaitForConnectionReturnsOnceRelayConnects(t *testing.T) {
+	fr := newFakeRelay(t)
+	fr.setRefuse(true)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{ConnectTimeout: time.Second})
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	done := make(chan error, 1)
+	go func() { done <- pool.WaitForConnection(context.Background()) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("WaitForConnection returned %v before any relay connected", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	fr.setRefuse(false)
+	if _, err := pool.Reconnect(fr.URL); err != nil {
+		t.Fatalf("Reconnect failed: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WaitForConnection error = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitForConnection did not return after the relay connected")
+	}
+}
+
+func TestWaitForConnectionTimesOut(t *testing.T) {
+	pool := NewPoolWithOptions(nil, PoolOptions{})
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := pool.WaitForConnection(ctx); err != context.DeadlineExceeded {
+		t.Errorf("WaitForConnection error = %v, want context.DeadlineExceeded", err)
+	}
+}
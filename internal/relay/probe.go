@@ -0,0 +1,155 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// probeTimeout bounds the connection attempt when probing a relay.
+const probeTimeout = 5 * time.Second
+
+// probeEventKind is the ephemeral kind published by write probes. Relays do
+// not store ephemeral events, so probing leaves nothing behind.
+const probeEventKind = 29999
+
+// ProbeRelay checks whether a relay is reachable and fetches its NIP-11 info
+// without adding it to the pool. The connection is closed once the handshake
+// completes. Only an invalid URL returns an error; connection failures are
+// reported in the result.
+func (p *Pool) ProbeRelay(url string) (*types.RelayTestResult, error) {
+	url, err := config.NormalizeRelayURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkPermitted(url); err != nil {
+		return nil, err
+	}
+
+	result := &types.RelayTestResult{URL: url}
+
+	ctx, cancel := context.WithTimeout(p.ctx, probeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	relay, err := nostr.RelayConnect(ctx, url, p.relayOptions()...)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	relay.Close()
+	result.Reachable = true
+
+	info, err := p.FetchRelayInfoCached(url, false)
+	if err != nil {
+		result.InfoError = err.Error()
+	} else {
+		result.Info = info
+	}
+
+	return result, nil
+}
+
+// ProbeCapabilities checks whether a relay serves reads and accepts writes
+// without adding it to the pool. Reads are confirmed with a minimal REQ that
+// must reach EOSE. Writes are confirmed by publishing a throwaway ephemeral
+// event, but only when a probe test key is configured. Only an invalid URL
+// returns an error.
+func (p *Pool) ProbeCapabilities(url string) (*types.RelayCapabilities, error) {
+	url, err := config.NormalizeRelayURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkPermitted(url); err != nil {
+		return nil, err
+	}
+
+	result := &types.RelayCapabilities{URL: url}
+
+	ctx, cancel := context.WithTimeout(p.ctx, probeTimeout)
+	defer cancel()
+
+	relay, err := nostr.RelayConnect(ctx, url, p.relayOptions()...)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer relay.Close()
+
+	result.CanRead, result.ReadError = probeRead(ctx, relay)
+
+	if p.opts.ProbeTestKey != "" {
+		result.WriteTested = true
+		if err := p.probeWrite(ctx, relay); err != nil {
+			result.WriteError = err.Error()
+		} else {
+			result.CanWrite = true
+		}
+	}
+
+	return result, nil
+}
+
+// probeRead sends a REQ for a single kind 1 event and waits for EOSE.
+func probeRead(ctx context.Context, relay *nostr.Relay) (bool, string) {
+	sub, err := relay.Subscribe(ctx, nostr.Filters{{Kinds: []int{1}, Limit: 1}})
+	if err != nil {
+		return false, err.Error()
+	}
+	defer sub.Unsub()
+
+	for {
+		select {
+		case <-sub.Events:
+		case <-sub.EndOfStoredEvents:
+			return true, ""
+		case reason := <-sub.ClosedReason:
+			return false, "subscription closed: " + reason
+		case <-ctx.Done():
+			return false, "timeout waiting for EOSE"
+		}
+	}
+}
+
+// probeWrite publishes a signed ephemeral event and waits for the relay's OK.
+func (p *Pool) probeWrite(ctx context.Context, relay *nostr.Relay) error {
+	secretKey, err := decodeSecretKey(p.opts.ProbeTestKey)
+	if err != nil {
+		return fmt.Errorf("invalid probe test key: %w", err)
+	}
+
+	event := nostr.Event{
+		Kind:      probeEventKind,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{},
+		Content:   "shirushi write probe",
+	}
+	if err := event.Sign(secretKey); err != nil {
+		return fmt.Errorf("failed to sign probe event: %w", err)
+	}
+
+	return relay.Publish(ctx, event)
+}
+
+// decodeSecretKey accepts a secret key as hex or nsec and returns it as hex.
+func decodeSecretKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if !strings.HasPrefix(key, "nsec") {
+		return key, nil
+	}
+	prefix, value, err := nip19.Decode(key)
+	if err != nil {
+		return "", err
+	}
+	if prefix != "nsec" {
+		return "", fmt.Errorf("expected nsec, got %s", prefix)
+	}
+	return value.(string), nil
+}
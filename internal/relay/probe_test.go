@@ -0,0 +1,136 @@
+package relay
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip11"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+func TestProbeRelayReachable(t *testing.T) {
+	fr := newFakeRelay(t)
+	fr.setInfo(nip11.RelayInformationDocument{Name: "Fake Relay", SupportedNIPs: []int{1, 11}})
+
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	result, err := pool.ProbeRelay(fr.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Reachable {
+		t.Fatalf("expected relay to be reachable, got error %q", result.Error)
+	}
+	if result.Info == nil || result.Info.Name != "Fake Relay" {
+		t.Errorf("expected NIP-11 info to be fetched, got %+v (info error %q)", result.Info, result.InfoError)
+	}
+	if len(pool.List()) != 0 {
+		t.Errorf("expected probe not to add the relay to the pool, got %d relays", len(pool.List()))
+	}
+}
+
+func TestProbeRelayUnreachable(t *testing.T) {
+	fr := newFakeRelay(t)
+	url := fr.URL
+	fr.server.Close()
+
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	result, err := pool.ProbeRelay(url)
+	if err != nil {
+		t.Fatalf("expected connection failure in the result, got error %v", err)
+	}
+	if result.Reachable {
+		t.Error("expected relay to be unreachable")
+	}
+	if result.Error == "" {
+		t.Error("expected a connection error message")
+	}
+}
+
+func TestProbeRelayInvalidURL(t *testing.T) {
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	_, err := pool.ProbeRelay("https://relay.example.com")
+	if err == nil || !strings.Contains(err.Error(), "ws://") {
+		t.Errorf("expected invalid URL error, got %v", err)
+	}
+}
+
+func TestProbeCapabilitiesReadOnlyWithoutKey(t *testing.T) {
+	fr := newFakeRelay(t)
+
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	caps, err := pool.ProbeCapabilities(fr.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !caps.CanRead {
+		t.Errorf("expected relay to be readable, got read error %q", caps.ReadError)
+	}
+	if caps.WriteTested || caps.CanWrite {
+		t.Errorf("expected writes not to be tested without a key, got %+v", caps)
+	}
+	if got := fr.published(); len(got) != 0 {
+		t.Errorf("expected nothing to be published without a key, got %d events", len(got))
+	}
+}
+
+func TestProbeCapabilitiesWrite(t *testing.T) {
+	fr := newFakeRelay(t)
+	nsec, _ := nip19.EncodePrivateKey(nostr.GeneratePrivateKey())
+
+	pool := NewPoolWithOptions(nil, PoolOptions{ProbeTestKey: nsec})
+	defer pool.Close()
+
+	caps, err := pool.ProbeCapabilities(fr.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !caps.WriteTested || !caps.CanWrite {
+		t.Fatalf("expected write probe to succeed, got %+v", caps)
+	}
+
+	published := fr.published()
+	if len(published) != 1 || published[0].Kind != probeEventKind {
+		t.Fatalf("expected one ephemeral probe event, got %+v", published)
+	}
+
+	fr.setReject("restricted: paid relay")
+	caps, err = pool.ProbeCapabilities(fr.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if caps.CanWrite {
+		t.Error("expected write probe to fail on a rejecting relay")
+	}
+	if !strings.Contains(caps.WriteError, "paid relay") {
+		t.Errorf("expected write error to carry the relay's reason, got %q", caps.WriteError)
+	}
+	if !caps.CanRead {
+		t.Error("expected reads to still succeed")
+	}
+}
+
+func TestProbeCapabilitiesUnreachable(t *testing.T) {
+	fr := newFakeRelay(t)
+	url := fr.URL
+	fr.server.Close()
+
+	pool := NewPool(nil)
+	defer pool.Close()
+
+	caps, err := pool.ProbeCapabilities(url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if caps.CanRead || caps.CanWrite || caps.Error == "" {
+		t.Errorf("expected connection error, got %+v", caps)
+	}
+}
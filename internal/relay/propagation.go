@@ -0,0 +1,44 @@
+package relay
+
+import (
+	"sort"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// aggregatePropagation counts how many relays returned each event, using the
+// SeenOn provenance recorded by per-relay queries, and groups events by that
+// count. relaysQueried is how many relays the query went to. Returns nil if
+// no event carries provenance, since the relay counts would be unknown.
+func aggregatePropagation(events []types.Event, relaysQueried int) *types.PropagationStats {
+	eventsByReach := make(map[int]int)
+	hasProvenance := false
+
+	for _, event := range events {
+		reach := len(event.SeenOn)
+		if reach > 0 {
+			hasProvenance = true
+		} else if event.Relay != "" {
+			reach = 1
+		}
+		eventsByReach[reach]++
+	}
+	if !hasProvenance {
+		return nil
+	}
+
+	stats := &types.PropagationStats{
+		RelaysQueried: relaysQueried,
+		Histogram:     make([]types.PropagationBucket, 0, len(eventsByReach)),
+	}
+	for relays, count := range eventsByReach {
+		stats.Histogram = append(stats.Histogram, types.PropagationBucket{Relays: relays, Events: count})
+		if relays > stats.MaxRelays {
+			stats.MaxRelays = relays
+		}
+	}
+	sort.Slice(stats.Histogram, func(i, j int) bool {
+		return stats.Histogram[i].Relays < stats.Histogram[j].Relays
+	})
+	return stats
+}
@@ -0,0 +1,83 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestAggregatePropagation(t *testing.T) {
+	events := []types.Event{
+		{ID: "a", Relay: "wss://one", SeenOn: []string{"wss://one"}},
+		{ID: "b", Relay: "wss://one", SeenOn: []string{"wss://one"}},
+		{ID: "c", Relay: "wss://one", SeenOn: []string{"wss://one", "wss://two", "wss://three"}},
+		{ID: "d", Relay: "wss://two", SeenOn: []string{"wss://two", "wss://three"}},
+	}
+
+	stats := aggregatePropagation(events, 3)
+	if stats == nil {
+		t.Fatal("expected propagation stats")
+	}
+	if stats.RelaysQueried != 3 || stats.MaxRelays != 3 {
+		t.Errorf("RelaysQueried/MaxRelays = %d/%d, want 3/3", stats.RelaysQueried, stats.MaxRelays)
+	}
+
+	want := []types.PropagationBucket{{Relays: 1, Events: 2}, {Relays: 2, Events: 1}, {Relays: 3, Events: 1}}
+	if len(stats.Histogram) != len(want) {
+		t.Fatalf("Histogram = %+v, want %+v", stats.Histogram, want)
+	}
+	for i := range want {
+		if stats.Histogram[i] != want[i] {
+			t.Errorf("Histogram[%d] = %+v, want %+v", i, stats.Histogram[i], want[i])
+		}
+	}
+}
+
+func TestAggregatePropagation_NilWithoutProvenance(t *testing.T) {
+	events := []types.Event{{ID: "a", Relay: "wss://one"}}
+	if stats := aggregatePropagation(events, 1); stats != nil {
+		t.Errorf("expected nil without SeenOn data, got %+v", stats)
+	}
+}
+
+func TestAggregateEventsReportsPropagation(t *testing.T) {
+	everywhere := signedEvent(t, "on every relay")
+	twice := signedEvent(t, "on two relays")
+	once := signedEvent(t, "on one relay")
+
+	first := newFakeRelay(t, everywhere, twice, once)
+	second := newFakeRelay(t, everywhere, twice)
+	third := newFakeRelay(t, everywhere)
+
+	pool := NewPool(nil)
+	defer pool.Close()
+	for _, fr := range []*fakeRelay{first, second, third} {
+		pool.Add(fr.URL)
+	}
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 3 }) {
+		t.Fatal("relays never connected")
+	}
+
+	agg, err := pool.AggregateEvents([]int{nostr.KindTextNote}, nil, nil, 10, 0, 0)
+	if err != nil {
+		t.Fatalf("AggregateEvents: %v", err)
+	}
+	if agg.Propagation == nil {
+		t.Fatal("expected propagation stats")
+	}
+	if agg.Propagation.RelaysQueried != 3 {
+		t.Errorf("RelaysQueried = %d, want 3", agg.Propagation.RelaysQueried)
+	}
+
+	want := []types.PropagationBucket{{Relays: 1, Events: 1}, {Relays: 2, Events: 1}, {Relays: 3, Events: 1}}
+	if len(agg.Propagation.Histogram) != len(want) {
+		t.Fatalf("Histogram = %+v, want %+v", agg.Propagation.Histogram, want)
+	}
+	for i := range want {
+		if agg.Propagation.Histogram[i] != want[i] {
+			t.Errorf("Histogram[%d] = %+v, want %+v", i, agg.Propagation.Histogram[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package relay
+
+import (
+	"sync"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/logging"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// maxPruneInterval caps how often the pruner retries failing relays, so a long
+// PruneAfter still gets regular reconnection attempts.
+const maxPruneInterval = time.Minute
+
+// maxPrunedRelays is how many entries the pruned relays log keeps.
+const maxPrunedRelays = 100
+
+// SetOnRelayPruned sets the callback function that is invoked when the
+// dead-relay pruner removes a relay from the pool.
+func (p *Pool) SetOnRelayPruned(callback func(pruned types.PrunedRelay)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onRelayPruned = callback
+}
+
+// PrunedRelays returns the relays removed by the dead-relay pruner, oldest
+// first, with their final stats.
+func (p *Pool) PrunedRelays() []types.PrunedRelay {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pruned := make([]types.PrunedRelay, len(p.pruned))
+	copy(pruned, p.pruned)
+	return pruned
+}
+
+// pruneInterval returns how often the pruner checks for dead relays.
+func (p *Pool) pruneInterval() time.Duration {
+	return max(min(p.opts.PruneAfter/4, maxPruneInterval), time.Millisecond)
+}
+
+// pruneDeadRelays periodically retries relays that are disconnected with an
+// error and removes those that have kept failing for longer than PruneAfter.
+// It runs until the pool is closed.
+func (p *Pool) pruneDeadRelays() {
+	ticker := time.NewTicker(p.pruneInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.pruneOnce()
+		}
+	}
+}
+
+// pruneOnce retries every failing relay once and prunes the ones that are
+// still failing after PruneAfter.
+func (p *Pool) pruneOnce() {
+	now := time.Now()
+
+	p.mu.Lock()
+	var failing []string
+	for url, conn := range p.relays {
+		if conn.Connected || conn.Error == "" {
+			continue
+		}
+		// Relays marked down by the monitor rather than by connect start
+		// their clock when the pruner first sees them.
+		if conn.failingSince.IsZero() {
+			conn.failingSince = now
+		}
+		failing = append(failing, url)
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, url := range failing {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			p.Reconnect(url)
+			p.pruneIfDead(url)
+		}(url)
+	}
+	wg.Wait()
+}
+
+// pruneIfDead removes the relay if it is still failing and has been failing
+// for at least PruneAfter, recording it in the pruned relays log.
+func (p *Pool) pruneIfDead(url string) {
+	now := time.Now()
+
+	p.mu.RLock()
+	conn, exists := p.relays[url]
+	if !exists || conn.Connected || conn.failingSince.IsZero() || now.Sub(conn.failingSince) < p.opts.PruneAfter {
+		p.mu.RUnlock()
+		return
+	}
+	pruned := types.PrunedRelay{
+		URL:          url,
+		Error:        conn.Error,
+		AddedAt:      conn.AddedAt.Unix(),
+		FailingSince: conn.failingSince.Unix(),
+		PrunedAt:     now.Unix(),
+	}
+	p.mu.RUnlock()
+
+	pruned.Health = p.monitor.GetRelayHealth(url)
+	p.Remove(url)
+	logging.Warnf("[Relay] Pruned %s after failing since %s: %s", url, time.Unix(pruned.FailingSince, 0).Format(time.RFC3339), pruned.Error)
+
+	p.mu.Lock()
+	p.pruned = append(p.pruned, pruned)
+	if len(p.pruned) > maxPrunedRelays {
+		p.pruned = p.pruned[len(p.pruned)-maxPrunedRelays:]
+	}
+	callback := p.onRelayPruned
+	p.mu.Unlock()
+
+	if callback != nil {
+		callback(pruned)
+	}
+}
@@ -0,0 +1,77 @@
+package relay
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func TestPruneRemovesRelayThatNeverConnects(t *testing.T) {
+	dead := newFakeRelay(t)
+	dead.setRefuse(true)
+	alive := newFakeRelay(t)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{PruneAfter: 100 * time.Millisecond})
+	defer pool.Close()
+
+	var mu sync.Mutex
+	var notified []types.PrunedRelay
+	pool.SetOnRelayPruned(func(pruned types.PrunedRelay) {
+		mu.Lock()
+		defer mu.Unlock()
+		notified = append(notified, pruned)
+	})
+
+	pool.Add(dead.URL)
+	pool.Add(alive.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.PrunedRelays()) == 1 }) {
+		t.Fatal("dead relay was never pruned")
+	}
+
+	pruned := pool.PrunedRelays()[0]
+	if pruned.URL != dead.URL {
+		t.Errorf("pruned URL = %q, want %q", pruned.URL, dead.URL)
+	}
+	if pruned.Error == "" {
+		t.Error("expected the last connection error to be recorded")
+	}
+	if pruned.FailingSince == 0 || pruned.PrunedAt < pruned.FailingSince {
+		t.Errorf("unexpected timestamps: failing_since=%d pruned_at=%d", pruned.FailingSince, pruned.PrunedAt)
+	}
+
+	list := pool.List()
+	if len(list) != 1 || list[0].URL != alive.URL {
+		t.Errorf("pool relays = %+v, want only %s", list, alive.URL)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(notified) != 1 || notified[0].URL != dead.URL {
+		t.Errorf("pruned notifications = %+v, want one for %s", notified, dead.URL)
+	}
+}
+
+func TestPruneDisabledKeepsFailingRelays(t *testing.T) {
+	dead := newFakeRelay(t)
+	dead.setRefuse(true)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{})
+	defer pool.Close()
+
+	pool.Add(dead.URL)
+	waitFor(t, 5*time.Second, func() bool {
+		list := pool.List()
+		return len(list) == 1 && list[0].Error != ""
+	})
+	time.Sleep(100 * time.Millisecond)
+
+	if pool.Count() != 1 {
+		t.Errorf("relay count = %d, want 1 with pruning disabled", pool.Count())
+	}
+	if pruned := pool.PrunedRelays(); len(pruned) != 0 {
+		t.Errorf("pruned relays = %+v, want none", pruned)
+	}
+}
@@ -0,0 +1,74 @@
+package relay
+
+import (
+	"strings"
+	"time"
+)
+
+// publishRetryDelay is how long to wait before retrying a transient publish failure.
+const publishRetryDelay = 500 * time.Millisecond
+
+// permanentPublishPrefixes are NIP-01 OK reasons (and pool errors) that will
+// not change on retry.
+var permanentPublishPrefixes = []string{
+	"blocked:",
+	"invalid:",
+	"pow:",
+	"restricted:",
+	"duplicate:",
+	"auth-required:",
+	"relay not in pool",
+}
+
+// transientPublishHints mark failures that may succeed when retried: relay-side
+// rate limits and errors, and problems with the connection itself.
+var transientPublishHints = []string{
+	"rate-limited:",
+	"error:",
+	"timeout",
+	"deadline exceeded",
+	"given up waiting",
+	"not connected",
+	"connection",
+	"closed",
+	"eof",
+}
+
+// okReasonPrefix is how go-nostr prefixes the reason of a rejecting OK message
+// in the error Publish returns.
+const okReasonPrefix = "msg: "
+
+// okReason returns the relay's OK reason carried by a Publish error, or "" if
+// the error didn't come from an OK message (a timeout or closed connection).
+func okReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	reason, found := strings.CutPrefix(err.Error(), okReasonPrefix)
+	if !found {
+		return ""
+	}
+	return reason
+}
+
+// isTransientPublishError reports whether a failed publish is worth retrying,
+// based on the relay's OK reason or the connection error.
+func isTransientPublishError(errMsg string) bool {
+	msg := strings.ToLower(strings.TrimSpace(errMsg))
+	msg = strings.TrimPrefix(msg, okReasonPrefix)
+	if msg == "" {
+		return false
+	}
+
+	for _, prefix := range permanentPublishPrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return false
+		}
+	}
+	for _, hint := range transientPublishHints {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package relay
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsTransientPublishError(t *testing.T) {
+	testCases := []struct {
+		err  string
+		want bool
+	}{
+		{"msg: rate-limited: slow down", true},
+		{"msg: error: could not save event", true},
+		{"relay not connected", true},
+		{"context deadline exceeded", true},
+		{"given up waiting for an OK", true},
+		{"msg: blocked: you are banned", false},
+		{"msg: invalid: bad signature", false},
+		{"msg: pow: difficulty 20 required", false},
+		{"msg: duplicate: already have this event", false},
+		{"msg: restricted: not a member", false},
+		{"relay not in pool", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.err, func(t *testing.T) {
+			if got := isTransientPublishError(tc.err); got != tc.want {
+				t.Errorf("isTransientPublishError(%q) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPublishRetriesTransientFailure(t *testing.T) {
+	fr := newFakeRelay(t)
+	fr.setFailNext(1, "error: database busy")
+
+	pool := NewPool(nil)
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	event := signedEvent(t, "retry me")
+	results := pool.PublishEventWithRetries(&event, nil, 2)
+
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if !results[0].Success {
+		t.Fatalf("expected publish to succeed after retry, got error %q", results[0].Error)
+	}
+	if results[0].Attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", results[0].Attempts)
+	}
+	if got := len(fr.published()); got != 2 {
+		t.Errorf("expected relay to receive 2 EVENTs, got %d", got)
+	}
+}
+
+func TestPublishDoesNotRetryPermanentRejection(t *testing.T) {
+	fr := newFakeRelay(t)
+	fr.setReject("blocked: pubkey banned")
+
+	pool := NewPool(nil)
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	event := signedEvent(t, "rejected")
+	results := pool.PublishEventWithRetries(&event, nil, 3)
+
+	if len(results) != 1 || results[0].Success {
+		t.Fatalf("expected a failed result, got %+v", results)
+	}
+	if results[0].Attempts != 1 {
+		t.Errorf("expected permanent rejection not to be retried, got %d attempts", results[0].Attempts)
+	}
+}
+
+func TestPublishReportsOKReason(t *testing.T) {
+	fr := newFakeRelay(t)
+	fr.setReject("blocked: pubkey not allowed")
+
+	pool := NewPool(nil)
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	event := signedEvent(t, "rejected")
+	results := pool.PublishEvent(&event, nil)
+
+	if len(results) != 1 || results[0].Success {
+		t.Fatalf("expected a failed result, got %+v", results)
+	}
+	if results[0].Reason != "blocked: pubkey not allowed" {
+		t.Errorf("Reason = %q, want the relay's OK message", results[0].Reason)
+	}
+
+	// Accepted events carry no rejection reason
+	fr.setReject("")
+	results = pool.PublishEvent(&event, nil)
+	if len(results) != 1 || !results[0].Success || results[0].Reason != "" {
+		t.Errorf("expected success without a reason, got %+v", results)
+	}
+}
+
+func TestOKReason(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{errors.New("msg: rate-limited: slow down"), "rate-limited: slow down"},
+		{errors.New("context deadline exceeded"), ""},
+	}
+	for _, tc := range cases {
+		if got := okReason(tc.err); got != tc.want {
+			t.Errorf("okReason(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/logging"
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// seenEvent is an event received from a relay along with when it arrived.
+type seenEvent struct {
+	event      types.Event
+	receivedAt time.Time
+}
+
+// relayQueryResult holds the outcome of querying a single relay.
+type relayQueryResult struct {
+	timing types.RelayFetchTiming
+	events []seenEvent
+}
+
+// querySlots is a counting semaphore bounding how many relays one request
+// talks to at once.
+type querySlots chan struct{}
+
+// newQuerySlots returns a semaphore sized to the pool's concurrency limit.
+// Each fan-out creates its own, so the limit applies per request.
+func (p *Pool) newQuerySlots() querySlots {
+	return make(querySlots, p.maxConcurrentQueries())
+}
+
+func (s querySlots) acquire() { s <- struct{}{} }
+func (s querySlots) release() { <-s }
+
+// queryRelays queries each relay individually with the same filter, collecting
+// events until EOSE or timeout, and returns one result per relay in completion order.
+// At most maxConcurrentQueries relays are queried at once.
+func (p *Pool) queryRelays(relays []string, filter nostr.Filter) []relayQueryResult {
+	done, err := p.trackQuery()
+	if err != nil {
+		results := make([]relayQueryResult, 0, len(relays))
+		for _, url := range relays {
+			results = append(results, relayQueryResult{timing: types.RelayFetchTiming{URL: url, Error: err.Error()}})
+		}
+		return results
+	}
+	defer done()
+
+	var wg sync.WaitGroup
+	resultsChan := make(chan relayQueryResult, len(relays))
+	slots := p.newQuerySlots()
+
+	for _, relayURL := range relays {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			slots.acquire()
+			defer slots.release()
+			resultsChan <- p.queryRelay(url, filter)
+		}(relayURL)
+	}
+
+	// Close channel when all goroutines complete
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	results := make([]relayQueryResult, 0, len(relays))
+	for result := range resultsChan {
+		results = append(results, result)
+	}
+	return results
+}
+
+// queryRelay runs a single filter against one relay and records timing data.
+// The filter's limit is clamped to the relay's advertised max_limit, if any.
+func (p *Pool) queryRelay(url string, filter nostr.Filter) relayQueryResult {
+	filter, clamped := p.clampLimit(url, filter)
+	result := relayQueryResult{
+		timing: types.RelayFetchTiming{
+			URL:          url,
+			Connected:    true,
+			ClampedLimit: clamped,
+		},
+		events: make([]seenEvent, 0),
+	}
+
+	start := time.Now()
+	var firstEventTime time.Time
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
+	defer cancel()
+
+	// Get the relay connection
+	relay, err := p.pool.EnsureRelay(url)
+	if err != nil {
+		result.timing.Error = fmt.Sprintf("connection error: %v", err)
+		result.timing.LatencyMs = time.Since(start).Milliseconds()
+		result.timing.Connected = false
+		return result
+	}
+
+	sub, err := relay.Subscribe(ctx, nostr.Filters{filter})
+	if err != nil {
+		result.timing.Error = fmt.Sprintf("subscribe error: %v", err)
+		result.timing.LatencyMs = time.Since(start).Milliseconds()
+		return result
+	}
+	defer func() { sub.Unsub() }()
+
+	// Collect events until EOSE or timeout
+eventLoop:
+	for {
+		select {
+		case ev := <-sub.Events:
+			if ev != nil {
+				now := time.Now()
+				if firstEventTime.IsZero() {
+					firstEventTime = now
+				}
+				p.collectRelayHints(ev)
+				result.events = append(result.events, seenEvent{
+					event:      convertEvent(ev, url),
+					receivedAt: now,
+				})
+			}
+		case <-sub.EndOfStoredEvents:
+			result.timing.EOSEReceived = true
+			break eventLoop
+		case reason := <-sub.ClosedReason:
+			// A relay restricting reads closes the subscription until the
+			// client authenticates; answer its challenge once and retry.
+			if isAuthRequired(reason) && p.canAuthRead() && !result.timing.AuthPerformed {
+				resub, err := p.authAndResubscribe(ctx, relay, filter)
+				if err == nil {
+					sub.Unsub()
+					sub = resub
+					result.timing.AuthPerformed = true
+					continue
+				}
+				result.timing.Error = fmt.Sprintf("auth failed: %v", err)
+				break eventLoop
+			}
+			result.timing.Error = "closed: " + reason
+			break eventLoop
+		case <-ctx.Done():
+			result.timing.Error = "timeout"
+			break eventLoop
+		}
+	}
+
+	result.timing.LatencyMs = time.Since(start).Milliseconds()
+	result.timing.EventCount = len(result.events)
+	if result.timing.EOSEReceived || (result.timing.Error == "timeout" && p.ctx.Err() == nil) {
+		p.monitor.RecordQueryOutcome(url, result.timing.EOSEReceived)
+	}
+	if !firstEventTime.IsZero() {
+		result.timing.FirstEventMs = firstEventTime.Sub(start).Milliseconds()
+	}
+	logging.Debugf("[Relay] Query on %s returned %d events in %dms (filter %v)", url, result.timing.EventCount, result.timing.LatencyMs, filter)
+	return result
+}
+
+// clampLimit lowers filter.Limit to the relay's NIP-11 max_limit when the
+// cached info advertises a smaller one. It returns the filter to send and the
+// clamped limit, or 0 if the limit was left alone.
+func (p *Pool) clampLimit(url string, filter nostr.Filter) (nostr.Filter, int) {
+	info := p.GetRelayInfo(url)
+	if info == nil || info.Limitation == nil {
+		return filter, 0
+	}
+	maxLimit := info.Limitation.MaxLimit
+	if maxLimit <= 0 || filter.Limit <= maxLimit {
+		return filter, 0
+	}
+	filter.Limit = maxLimit
+	return filter, maxLimit
+}
+
+// mergeRelayResults deduplicates events across relay results. For each event ID
+// the earliest-received copy is kept, and SeenOn lists every relay that returned
+// it in the order they delivered it. Events keep the order in which they were
+// first encountered in the results. Timings are sorted by relay URL so their
+// order doesn't depend on which relay answered first.
+func mergeRelayResults(results []relayQueryResult) ([]types.Event, []types.RelayFetchTiming) {
+	timings := make([]types.RelayFetchTiming, 0, len(results))
+
+	type mergedEvent struct {
+		first     seenEvent
+		sightings []seenEvent
+	}
+	merged := make(map[string]*mergedEvent)
+	var order []string
+
+	for _, result := range results {
+		timings = append(timings, result.timing)
+		for _, se := range result.events {
+			m, exists := merged[se.event.ID]
+			if !exists {
+				m = &mergedEvent{first: se}
+				merged[se.event.ID] = m
+				order = append(order, se.event.ID)
+			} else if se.receivedAt.Before(m.first.receivedAt) {
+				m.first = se
+			}
+			m.sightings = append(m.sightings, se)
+		}
+	}
+
+	events := make([]types.Event, 0, len(order))
+	for _, id := range order {
+		m := merged[id]
+		sort.SliceStable(m.sightings, func(i, j int) bool {
+			return m.sightings[i].receivedAt.Before(m.sightings[j].receivedAt)
+		})
+
+		event := m.first.event
+		event.SeenOn = make([]string, 0, len(m.sightings))
+		seenRelays := make(map[string]bool)
+		for _, s := range m.sightings {
+			if !seenRelays[s.event.Relay] {
+				seenRelays[s.event.Relay] = true
+				event.SeenOn = append(event.SeenOn, s.event.Relay)
+			}
+		}
+		events = append(events, event)
+	}
+
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].URL < timings[j].URL
+	})
+
+	return events, timings
+}
+
+// interleaveRelayResults merges relay results like mergeRelayResults, then
+// applies limit to the merged set by taking events round-robin across relays
+// so that fast relays cannot crowd out unique events from slower ones. Each
+// turn a relay contributes its next event not already taken from another relay.
+func interleaveRelayResults(results []relayQueryResult, limit int) ([]types.Event, []types.RelayFetchTiming) {
+	events, timings := mergeRelayResults(results)
+	if limit <= 0 || len(events) <= limit {
+		return events, timings
+	}
+
+	byID := make(map[string]types.Event, len(events))
+	for _, event := range events {
+		byID[event.ID] = event
+	}
+
+	picked := make(map[string]bool, limit)
+	cursors := make([]int, len(results))
+	interleaved := make([]types.Event, 0, limit)
+
+	for len(interleaved) < limit {
+		progressed := false
+		for i, result := range results {
+			if len(interleaved) >= limit {
+				break
+			}
+			for cursors[i] < len(result.events) {
+				id := result.events[cursors[i]].event.ID
+				cursors[i]++
+				if !picked[id] {
+					picked[id] = true
+					interleaved = append(interleaved, byID[id])
+					progressed = true
+					break
+				}
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	return interleaved, timings
+}
+
+// relayFailures lists the relays whose query ended in an error, sorted by URL.
+func relayFailures(timings []types.RelayFetchTiming) []types.RelayFailure {
+	var failed []types.RelayFailure
+	for _, timing := range timings {
+		if timing.Error != "" {
+			failed = append(failed, types.RelayFailure{URL: timing.URL, Reason: timing.Error})
+		}
+	}
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].URL < failed[j].URL
+	})
+	return failed
+}
@@ -0,0 +1,91 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+	"github.com/keanuklestil/shirushi/internal/logging"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// rawSubID is the subscription ID RawEvents uses on its own connection.
+const rawSubID = "raw"
+
+// RawEvents fetches the given events from relayURL again and returns each
+// one's JSON exactly as the relay sent it, keyed by event ID, so key order,
+// whitespace, escaping and fields Event doesn't model are preserved.
+//
+// go-nostr parses relay messages without keeping their bytes, so this opens
+// a separate plain WebSocket connection for a single REQ by ID; callers should
+// only use it when raw output was asked for. Events the relay no longer
+// returns, or that fail ID or signature checks, are missing from the result,
+// as is everything when the relay can't be reached within the query timeout.
+func (p *Pool) RawEvents(relayURL string, ids []string) map[string]json.RawMessage {
+	raw := make(map[string]json.RawMessage, len(ids))
+	if len(ids) == 0 || p.checkPermitted(relayURL) != nil {
+		return raw
+	}
+	done, err := p.trackQuery()
+	if err != nil {
+		return raw
+	}
+	defer done()
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout())
+	defer cancel()
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, relayURL, nil)
+	if err != nil {
+		logging.Debugf("[Relay] Raw fetch from %s failed: %v", relayURL, err)
+		return raw
+	}
+	defer conn.Close()
+
+	// Unblock ReadMessage when the timeout passes or the pool closes.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := conn.WriteJSON([]interface{}{"REQ", rawSubID, nostr.Filter{IDs: ids}}); err != nil {
+		logging.Debugf("[Relay] Raw fetch from %s failed: %v", relayURL, err)
+		return raw
+	}
+	defer conn.WriteJSON([]interface{}{"CLOSE", rawSubID})
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	for len(raw) < len(wanted) {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return raw
+		}
+
+		var frame []json.RawMessage
+		if err := json.Unmarshal(message, &frame); err != nil || len(frame) < 2 {
+			continue
+		}
+		var label string
+		json.Unmarshal(frame[0], &label)
+
+		switch label {
+		case "EVENT":
+			if len(frame) < 3 {
+				continue
+			}
+			var ev nostr.Event
+			if err := json.Unmarshal(frame[2], &ev); err != nil || !wanted[ev.ID] || ev.GetID() != ev.ID {
+				continue
+			}
+			if ok, _ := ev.CheckSignature(); !ok {
+				continue
+			}
+			raw[ev.ID] = frame[2]
+		case "EOSE", "CLOSED":
+			return raw
+		}
+	}
+	return raw
+}
@@ -0,0 +1,48 @@
+package relay
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestRawEventsKeepsRelayBytes(t *testing.T) {
+	ev := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Tags: nostr.Tags{}, Content: "café / done"}
+	if err := ev.Sign(nostr.GeneratePrivateKey()); err != nil {
+		t.Fatalf("failed to sign event: %v", err)
+	}
+
+	// Reversed key order, extra spacing, escapes go-nostr would not produce
+	// and a field Event doesn't model.
+	raw := fmt.Sprintf(`{ "sig": %q,"content": "café \/ done", "tags": [ ],
+  "kind": 1, "created_at": %d, "pubkey": %q, "id": %q, "x_note": {"b": 1, "a": 2} }`,
+		ev.Sig, ev.CreatedAt, ev.PubKey, ev.ID)
+
+	fr := newFakeRelay(t)
+	fr.addRawEvent(raw)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{})
+	defer pool.Close()
+
+	got := pool.RawEvents(fr.URL, []string{ev.ID})
+	if string(got[ev.ID]) != raw {
+		t.Errorf("raw JSON = %s\nwant the relay's bytes %s", got[ev.ID], raw)
+	}
+}
+
+func TestRawEventsSkipsUnverifiedEvents(t *testing.T) {
+	ev := signedEvent(t, "original")
+	tampered := fmt.Sprintf(`{"id":%q,"pubkey":%q,"created_at":%d,"kind":1,"tags":[],"content":"edited","sig":%q}`,
+		ev.ID, ev.PubKey, ev.CreatedAt, ev.Sig)
+
+	fr := newFakeRelay(t)
+	fr.addRawEvent(tampered)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{})
+	defer pool.Close()
+
+	if got := pool.RawEvents(fr.URL, []string{ev.ID}); len(got) != 0 {
+		t.Errorf("expected a tampered event to be skipped, got %v", got)
+	}
+}
@@ -0,0 +1,90 @@
+package relay
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// relayMatcher matches relay URLs against an operator's blocklist or
+// allowlist. Entries are full relay URLs, matched exactly, or hosts, matching
+// the host on any scheme and path. A host of the form *.example.com matches
+// every subdomain of example.com but not example.com itself.
+type relayMatcher struct {
+	urls     map[string]bool
+	hosts    map[string]bool
+	suffixes []string
+}
+
+// newRelayMatcher builds a matcher from entries, or returns nil if there are
+// none, which matches nothing.
+func newRelayMatcher(entries []string) *relayMatcher {
+	b := &relayMatcher{urls: make(map[string]bool), hosts: make(map[string]bool)}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		host, isURL := strings.CutPrefix(entry, "wss://")
+		if !isURL {
+			host, isURL = strings.CutPrefix(entry, "ws://")
+		}
+		if isURL && !strings.Contains(host, "*") {
+			b.urls[strings.TrimRight(entry, "/")] = true
+			continue
+		}
+
+		host, _, _ = strings.Cut(host, "/")
+		if suffix, ok := strings.CutPrefix(host, "*."); ok {
+			b.suffixes = append(b.suffixes, "."+suffix)
+		} else {
+			b.hosts[host] = true
+		}
+	}
+	if len(b.urls) == 0 && len(b.hosts) == 0 && len(b.suffixes) == 0 {
+		return nil
+	}
+	return b
+}
+
+// matches reports whether relayURL, already normalized, matches an entry.
+func (b *relayMatcher) matches(relayURL string) bool {
+	if b == nil {
+		return false
+	}
+	lower := strings.ToLower(relayURL)
+	if b.urls[lower] {
+		return true
+	}
+
+	u, err := url.Parse(lower)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if b.hosts[host] {
+		return true
+	}
+	for _, suffix := range b.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPermitted returns an error if the pool may not connect to relayURL,
+// already normalized, because it is blocked or, in allowlist mode, not
+// allowed.
+func (p *Pool) checkPermitted(relayURL string) error {
+	if p.blocklist.matches(relayURL) {
+		return fmt.Errorf("%w: %s", types.ErrRelayBlocked, relayURL)
+	}
+	if p.opts.AllowlistOnly && !p.allowlist.matches(relayURL) {
+		return fmt.Errorf("%w: %s", types.ErrRelayNotAllowed, relayURL)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package relay
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestRelayBlocklistMatches(t *testing.T) {
+	blocklist := newRelayMatcher([]string{
+		"wss://relay.spam.example/",
+		"bad.example",
+		"*.evil.example",
+		"wss://*.worse.example",
+	})
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"wss://relay.spam.example", true},
+		{"wss://Relay.Spam.Example", true},
+		{"ws://relay.spam.example", false},
+		{"wss://relay.spam.example/other", false},
+		{"wss://bad.example", true},
+		{"ws://bad.example:7777/path", true},
+		{"wss://sub.bad.example", false},
+		{"wss://relay.evil.example", true},
+		{"wss://deep.relay.evil.example", true},
+		{"wss://evil.example", false},
+		{"wss://notevil.example", false},
+		{"wss://a.worse.example", true},
+		{"wss://relay.damus.io", false},
+	}
+	for _, tt := range tests {
+		if got := blocklist.matches(tt.url); got != tt.want {
+			t.Errorf("blocks(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	if newRelayMatcher([]string{" ", ""}) != nil {
+		t.Error("expected an empty blocklist to be nil")
+	}
+	if (*relayMatcher)(nil).matches("wss://relay.spam.example") {
+		t.Error("expected a nil blocklist to block nothing")
+	}
+}
+
+func TestAddRejectsBlockedRelays(t *testing.T) {
+	pool := NewPoolWithOptions([]string{"wss://relay.evil.example"}, PoolOptions{
+		BlockedRelays: []string{"wss://relay.spam.example", "*.evil.example"},
+	})
+	defer pool.Close()
+
+	if pool.Count() != 0 {
+		t.Errorf("expected blocked default relay to be skipped, count = %d", pool.Count())
+	}
+
+	for _, url := range []string{"wss://relay.spam.example/", "wss://other.evil.example"} {
+		added, err := pool.Add(url)
+		if added || !errors.Is(err, types.ErrRelayBlocked) {
+			t.Errorf("Add(%s) = %v, %v; want ErrRelayBlocked", url, added, err)
+		}
+	}
+	if pool.Count() != 0 {
+		t.Errorf("expected no relays in the pool, count = %d", pool.Count())
+	}
+}
+
+func TestBlockedRelaysAreNotDiscovered(t *testing.T) {
+	pool := NewPoolWithOptions(nil, PoolOptions{
+		MaxDiscoveredRelays: 10,
+		BlockedRelays:       []string{"*.evil.example"},
+	})
+	defer pool.Close()
+
+	pool.collectRelayHints(&nostr.Event{Tags: nostr.Tags{
+		{"e", "aa", "wss://relay.evil.example"},
+		{"p", "bb", "wss://hint.example.com"},
+	}})
+
+	pool.discovery.mu.Lock()
+	defer pool.discovery.mu.Unlock()
+	if len(pool.discovery.relays) != 1 || pool.discovery.relays["wss://hint.example.com"] == nil {
+		t.Errorf("discovered = %v, want only wss://hint.example.com", pool.discovery.relays)
+	}
+}
+
+func TestQueryEventsOnRelaysSkipsBlocked(t *testing.T) {
+	pool := NewPoolWithOptions(nil, PoolOptions{BlockedRelays: []string{"*.evil.example"}})
+	defer pool.Close()
+
+	if _, err := pool.QueryEventsOnRelaysWithTiming([]string{"wss://outbox.evil.example"}, []int{1}, nil, nil, 10, 0, 0); err == nil {
+		t.Error("expected an error when every outbox relay is blocked")
+	}
+	if event := pool.QueryEventFromHints("aa", []string{"wss://hint.evil.example"}); event != nil {
+		t.Errorf("expected no event from a blocked hint, got %+v", event)
+	}
+}
+
+func TestAllowlistMode(t *testing.T) {
+	pool := NewPoolWithOptions(nil, PoolOptions{
+		AllowlistOnly: true,
+		AllowedRelays: []string{"ws://127.0.0.1:1", "*.corp.example"},
+		BlockedRelays: []string{"wss://bad.corp.example"},
+	})
+	defer pool.Close()
+
+	if added, err := pool.Add("ws://127.0.0.1:1/"); !added || err != nil {
+		t.Errorf("Add(approved) = %v, %v; want added", added, err)
+	}
+
+	rejected := []struct {
+		url  string
+		want error
+	}{
+		{"wss://relay.damus.io", types.ErrRelayNotAllowed},
+		{"ws://127.0.0.1:2", types.ErrRelayNotAllowed},
+		{"wss://bad.corp.example", types.ErrRelayBlocked},
+	}
+	for _, tt := range rejected {
+		if added, err := pool.Add(tt.url); added || !errors.Is(err, tt.want) {
+			t.Errorf("Add(%s) = %v, %v; want %v", tt.url, added, err, tt.want)
+		}
+	}
+	if pool.Count() != 1 {
+		t.Errorf("expected only the approved relay in the pool, count = %d", pool.Count())
+	}
+
+	if _, err := pool.ProbeRelay("wss://relay.damus.io"); !errors.Is(err, types.ErrRelayNotAllowed) {
+		t.Errorf("ProbeRelay(unapproved) error = %v, want ErrRelayNotAllowed", err)
+	}
+	if event := pool.QueryEventFromHints("aa", []string{"wss://relay.damus.io"}); event != nil {
+		t.Errorf("expected no event from an unapproved hint, got %+v", event)
+	}
+	if _, err := pool.QueryEventsOnRelaysWithTiming([]string{"wss://relay.damus.io"}, []int{1}, nil, nil, 10, 0, 0); err == nil {
+		t.Error("expected an error when no outbox relay is approved")
+	}
+}
+
+func TestAllowlistModeWithEmptyListAllowsNothing(t *testing.T) {
+	locked := NewPoolWithOptions(nil, PoolOptions{AllowlistOnly: true})
+	defer locked.Close()
+	if _, err := locked.Add("ws://127.0.0.1:1"); !errors.Is(err, types.ErrRelayNotAllowed) {
+		t.Errorf("Add error = %v, want ErrRelayNotAllowed", err)
+	}
+
+	open := NewPoolWithOptions(nil, PoolOptions{})
+	defer open.Close()
+	if added, err := open.Add("ws://127.0.0.1:1"); !added || err != nil {
+		t.Errorf("Add with allowlist mode off = %v, %v; want added", added, err)
+	}
+}
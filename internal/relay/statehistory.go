@@ -0,0 +1,52 @@
+package relay
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// maxStateChanges is how many connection state changes are kept per relay;
+// older ones are dropped first.
+const maxStateChanges = 50
+
+// recordStateChangeLocked appends a connection state change to the relay's
+// history. Must be called with p.mu held.
+func (p *Pool) recordStateChangeLocked(url string, connected bool, errMsg string) {
+	if p.stateHistory == nil {
+		p.stateHistory = make(map[string][]types.RelayStateChange)
+	}
+	changes := append(p.stateHistory[url], types.RelayStateChange{
+		Timestamp: time.Now().UnixMilli(),
+		Connected: connected,
+		Error:     errMsg,
+	})
+	if len(changes) > maxStateChanges {
+		changes = append([]types.RelayStateChange(nil), changes[len(changes)-maxStateChanges:]...)
+	}
+	p.stateHistory[url] = changes
+}
+
+// StateHistory returns a relay's recent connection state changes, oldest
+// first. History is kept after a relay is removed, so its last changes can
+// still be inspected; relays never seen by the pool are an error.
+func (p *Pool) StateHistory(url string) (*types.RelayStateHistory, error) {
+	url, err := config.NormalizeRelayURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	changes, recorded := p.stateHistory[url]
+	if _, inPool := p.relays[url]; !inPool && !recorded {
+		return nil, fmt.Errorf("relay not in pool: %s", url)
+	}
+
+	history := &types.RelayStateHistory{URL: url, Changes: make([]types.RelayStateChange, len(changes))}
+	copy(history.Changes, changes)
+	return history, nil
+}
@@ -0,0 +1,96 @@
+package relay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateHistoryRecordsTransitionsInOrder(t *testing.T) {
+	fr := newFakeRelay(t)
+
+	pool := NewPoolWithOptions(nil, PoolOptions{})
+	defer pool.Close()
+	pool.Add(fr.URL)
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(pool.GetConnected()) == 1 }) {
+		t.Fatal("relay never connected")
+	}
+
+	fr.setRefuse(true)
+	if _, err := pool.Reconnect(fr.URL); err != nil {
+		t.Fatalf("reconnect failed: %v", err)
+	}
+	fr.setRefuse(false)
+	if _, err := pool.Reconnect(fr.URL); err != nil {
+		t.Fatalf("reconnect failed: %v", err)
+	}
+	pool.Remove(fr.URL)
+
+	history, err := pool.StateHistory(fr.URL)
+	if err != nil {
+		t.Fatalf("expected history to outlive removal: %v", err)
+	}
+
+	want := []bool{true, false, true, false}
+	if len(history.Changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), history.Changes)
+	}
+	for i, connected := range want {
+		if history.Changes[i].Connected != connected {
+			t.Errorf("changes[%d].Connected = %v, want %v", i, history.Changes[i].Connected, connected)
+		}
+		if i > 0 && history.Changes[i].Timestamp < history.Changes[i-1].Timestamp {
+			t.Errorf("changes[%d] is older than the change before it", i)
+		}
+	}
+	if history.Changes[0].Error != "" || history.Changes[2].Error != "" {
+		t.Errorf("expected no error on connect, got %+v", history.Changes)
+	}
+	if history.Changes[1].Error == "" {
+		t.Error("expected the refused connection to record its error")
+	}
+	if history.Changes[3].Error != "removed" {
+		t.Errorf("expected removal to be recorded, got %q", history.Changes[3].Error)
+	}
+}
+
+func TestStateHistoryIsBounded(t *testing.T) {
+	pool := &Pool{relays: make(map[string]*RelayConn)}
+	url := "wss://flapping.example.com"
+
+	for i := 0; i < maxStateChanges+10; i++ {
+		pool.notifyStatusChange(url, i%2 == 0, "")
+	}
+
+	history, err := pool.StateHistory(url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history.Changes) != maxStateChanges {
+		t.Fatalf("expected %d changes, got %d", maxStateChanges, len(history.Changes))
+	}
+	// The 10 oldest changes were dropped, so the log starts on a connect.
+	if !history.Changes[0].Connected || history.Changes[1].Connected {
+		t.Errorf("expected the oldest changes to be dropped, got %+v", history.Changes[:2])
+	}
+}
+
+func TestStateHistoryUnknownRelay(t *testing.T) {
+	pool := &Pool{relays: make(map[string]*RelayConn)}
+
+	if _, err := pool.StateHistory("wss://unknown.example.com"); err == nil {
+		t.Error("expected an error for a relay the pool has never seen")
+	}
+	if _, err := pool.StateHistory("not a relay"); err == nil {
+		t.Error("expected an error for an invalid relay URL")
+	}
+
+	pool.relays["wss://quiet.example.com"] = &RelayConn{URL: "wss://quiet.example.com"}
+	history, err := pool.StateHistory("wss://quiet.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if history.Changes == nil || len(history.Changes) != 0 {
+		t.Errorf("expected an empty history, got %+v", history.Changes)
+	}
+}
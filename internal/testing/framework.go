@@ -4,6 +4,8 @@ package testing
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/keanuklestil/shirushi/internal/nak"
 	"github.com/keanuklestil/shirushi/internal/types"
@@ -20,6 +22,7 @@ type NIPTest interface {
 	ID() string
 	Name() string
 	Description() string
+	Params() []types.TestParam
 	Run(ctx context.Context, params map[string]interface{}) (*types.TestResult, error)
 }
 
@@ -55,16 +58,77 @@ func (r *Runner) Register(test NIPTest) {
 	r.tests[test.ID()] = test
 }
 
-// RunTest executes a specific NIP test.
+// RunTest executes a specific NIP test. If ctx is cancelled before the test
+// finishes, RunTest returns at once with the steps completed so far followed
+// by an aborted step, rather than waiting for calls that ignore ctx.
 func (r *Runner) RunTest(ctx context.Context, nipID string, params map[string]interface{}) (*types.TestResult, error) {
 	test, exists := r.tests[nipID]
 	if !exists {
 		return nil, fmt.Errorf("unknown NIP test: %s", nipID)
 	}
+	if err := ctx.Err(); err != nil {
+		return cancelledResult(nipID, nil, err), nil
+	}
+
+	var mu sync.Mutex
+	var completed []types.TestStep
+	onStep, _ := ctx.Value(stepReporterKey{}).(func(types.TestStep))
+	ctx = context.WithValue(ctx, stepReporterKey{}, func(step types.TestStep) {
+		mu.Lock()
+		completed = append(completed, step)
+		mu.Unlock()
+		if onStep != nil {
+			onStep(step)
+		}
+	})
 
-	return test.Run(ctx, params)
+	type outcome struct {
+		result *types.TestResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := test.Run(ctx, params)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.result, out.err
+	case <-ctx.Done():
+		mu.Lock()
+		steps := append([]types.TestStep(nil), completed...)
+		mu.Unlock()
+		return cancelledResult(nipID, steps, ctx.Err()), nil
+	}
+}
+
+// cancelledResult builds the partial result of a test stopped by err.
+func cancelledResult(nipID string, steps []types.TestStep, err error) *types.TestResult {
+	aborted := makeStep("Cancelled", false, "", "test cancelled: "+err.Error())
+	aborted.Aborted = true
+	return &types.TestResult{
+		NIPID:     nipID,
+		Success:   false,
+		Message:   "Test cancelled",
+		Steps:     append(steps, aborted),
+		Cancelled: true,
+	}
+}
+
+// RunTestStreaming executes a specific NIP test like RunTest, additionally
+// calling onStep with each step as soon as the test records it.
+func (r *Runner) RunTestStreaming(ctx context.Context, nipID string, params map[string]interface{}, onStep func(types.TestStep)) (*types.TestResult, error) {
+	if onStep != nil {
+		ctx = context.WithValue(ctx, stepReporterKey{}, onStep)
+	}
+	return r.RunTest(ctx, nipID, params)
 }
 
+// stepReporterKey is the context key for the callback that addStep reports
+// each step to.
+type stepReporterKey struct{}
+
 // ListTests returns all available tests.
 func (r *Runner) ListTests() []NIPTestInfo {
 	var tests []NIPTestInfo
@@ -78,6 +142,26 @@ func (r *Runner) ListTests() []NIPTestInfo {
 	return tests
 }
 
+// DescribeTests returns every registered test with its parameters, sorted by
+// ID.
+func (r *Runner) DescribeTests() []types.TestDescriptor {
+	descriptors := make([]types.TestDescriptor, 0, len(r.tests))
+	for _, test := range r.tests {
+		params := test.Params()
+		if params == nil {
+			params = []types.TestParam{}
+		}
+		descriptors = append(descriptors, types.TestDescriptor{
+			ID:          test.ID(),
+			Name:        test.Name(),
+			Description: test.Description(),
+			Params:      params,
+		})
+	}
+	sort.Slice(descriptors, func(i, j int) bool { return descriptors[i].ID < descriptors[j].ID })
+	return descriptors
+}
+
 // NIPTestInfo provides information about a NIP test.
 type NIPTestInfo struct {
 	ID          string `json:"id"`
@@ -105,6 +189,16 @@ func makeResult(nipID string, success bool, steps []types.TestStep) *types.TestR
 	}
 }
 
+// addStep appends a step to steps and reports it to the step callback
+// carried by ctx, if any. Steps finished after ctx is cancelled are not
+// reported, since RunTest has already returned the partial result.
+func addStep(ctx context.Context, steps []types.TestStep, step types.TestStep) []types.TestStep {
+	if onStep, ok := ctx.Value(stepReporterKey{}).(func(types.TestStep)); ok && ctx.Err() == nil {
+		onStep(step)
+	}
+	return append(steps, step)
+}
+
 // makeStep creates a test step.
 func makeStep(name string, success bool, output, errMsg string) types.TestStep {
 	return types.TestStep{
@@ -0,0 +1,138 @@
+package testing
+
+import (
+	"context"
+	gotesting "testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// blockingTest completes one step and then blocks on a call that ignores its
+// context, like a nak subprocess would, until released.
+type blockingTest struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingTest) ID() string                { return "nip99" }
+func (t *blockingTest) Name() string              { return "NIP-99: Blocking" }
+func (t *blockingTest) Description() string       { return "Blocks until released" }
+func (t *blockingTest) Params() []types.TestParam { return nil }
+
+func (t *blockingTest) Run(ctx context.Context, params map[string]interface{}) (*types.TestResult, error) {
+	var steps []types.TestStep
+	steps = addStep(ctx, steps, makeStep("First step", true, "done", ""))
+	close(t.started)
+	<-t.release
+	steps = addStep(ctx, steps, makeStep("Second step", true, "done", ""))
+	return makeResult(t.ID(), true, steps), nil
+}
+
+func newBlockingRunner() (*Runner, *blockingTest) {
+	test := &blockingTest{started: make(chan struct{}), release: make(chan struct{})}
+	r := &Runner{tests: make(map[string]NIPTest)}
+	r.Register(test)
+	return r, test
+}
+
+func TestRunTest_CancelReturnsPartialResult(t *gotesting.T) {
+	r, test := newBlockingRunner()
+	defer close(test.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-test.started
+		cancel()
+	}()
+
+	start := time.Now()
+	result, err := r.RunTest(ctx, "nip99", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected RunTest to return promptly after cancel, took %v", elapsed)
+	}
+
+	if !result.Cancelled || result.Success {
+		t.Errorf("expected a cancelled, unsuccessful result, got %+v", result)
+	}
+	if len(result.Steps) != 2 {
+		t.Fatalf("expected the completed step and the aborted step, got %+v", result.Steps)
+	}
+	if result.Steps[0].Name != "First step" || !result.Steps[0].Success || result.Steps[0].Aborted {
+		t.Errorf("expected the first step to be kept as completed, got %+v", result.Steps[0])
+	}
+	if !result.Steps[1].Aborted || result.Steps[1].Success {
+		t.Errorf("expected the last step to be flagged as aborted, got %+v", result.Steps[1])
+	}
+}
+
+func TestRunTest_AlreadyCancelledContextRunsNothing(t *gotesting.T) {
+	r, test := newBlockingRunner()
+	defer close(test.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := r.RunTest(ctx, "nip99", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-test.started:
+		t.Error("expected the test not to start with a cancelled context")
+	default:
+	}
+	if !result.Cancelled || len(result.Steps) != 1 || !result.Steps[0].Aborted {
+		t.Errorf("expected only an aborted step, got %+v", result)
+	}
+}
+
+func TestRunTestStreaming_StopsReportingAfterCancel(t *gotesting.T) {
+	r, test := newBlockingRunner()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reported := make(chan types.TestStep, 4)
+	go func() {
+		<-test.started
+		cancel()
+	}()
+
+	result, err := r.RunTestStreaming(ctx, "nip99", nil, func(step types.TestStep) {
+		reported <- step
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Cancelled {
+		t.Fatalf("expected a cancelled result, got %+v", result)
+	}
+
+	// Let the abandoned test finish; its late step must not be streamed.
+	close(test.release)
+	time.Sleep(20 * time.Millisecond)
+	close(reported)
+
+	var names []string
+	for step := range reported {
+		names = append(names, step.Name)
+	}
+	if len(names) != 1 || names[0] != "First step" {
+		t.Errorf("expected only the first step to be streamed, got %v", names)
+	}
+}
+
+func TestRunTest_CompletesWithoutCancel(t *gotesting.T) {
+	r, test := newBlockingRunner()
+	close(test.release)
+
+	result, err := r.RunTest(context.Background(), "nip99", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Cancelled || !result.Success || len(result.Steps) != 2 {
+		t.Errorf("expected a complete successful result, got %+v", result)
+	}
+}
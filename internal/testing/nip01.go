@@ -39,6 +39,39 @@ func (t *NIP01Test) Description() string {
 	return "Test basic protocol: create event, sign, publish, query, verify"
 }
 
+// Params returns the parameters the test accepts.
+func (t *NIP01Test) Params() []types.TestParam {
+	return []types.TestParam{
+		{
+			Name:        "signingMode",
+			Label:       "Signing mode",
+			Description: "Sign with a generated key, a provided nsec, or an event pre-signed by a NIP-07 extension",
+			Type:        "enum",
+			Default:     "generated",
+			Options:     []string{"generated", "provided", "extension"},
+		},
+		{
+			Name:        "privateKey",
+			Label:       "Private key",
+			Description: "nsec to sign with; required when signingMode is provided",
+			Type:        "string",
+		},
+		{
+			Name:        "signedEvent",
+			Label:       "Signed event",
+			Description: "Event JSON signed by an extension; required when signingMode is extension",
+			Type:        "string",
+		},
+		{
+			Name:        "content",
+			Label:       "Content",
+			Description: "Content of the test note",
+			Type:        "string",
+			Default:     "Shirushi NIP-01 test event",
+		},
+	}
+}
+
 // Run executes the NIP-01 test.
 func (t *NIP01Test) Run(ctx context.Context, params map[string]interface{}) (*types.TestResult, error) {
 	var steps []types.TestStep
@@ -46,7 +79,7 @@ func (t *NIP01Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 
 	// Check if nak is available
 	if t.nak == nil {
-		steps = append(steps, makeStep("Check nak CLI", false, "", "nak CLI not available - install from https://github.com/fiatjaf/nak"))
+		steps = addStep(ctx, steps, makeStep("Check nak CLI", false, "", "nak CLI not available - install from https://github.com/fiatjaf/nak"))
 		return makeResult(t.ID(), false, steps), nil
 	}
 
@@ -65,7 +98,7 @@ func (t *NIP01Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 		// User provided their private key (nsec)
 		nsec, ok := params["privateKey"].(string)
 		if !ok || nsec == "" {
-			steps = append(steps, makeStep("Get user keypair", false, "", "Private key (nsec) not provided"))
+			steps = addStep(ctx, steps, makeStep("Get user keypair", false, "", "Private key (nsec) not provided"))
 			return makeResult(t.ID(), false, steps), nil
 		}
 		privateKey = nsec
@@ -73,17 +106,17 @@ func (t *NIP01Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 		// Derive public key from private key
 		pubkey, err := t.nak.PublicKeyFromPrivate(nsec)
 		if err != nil {
-			steps = append(steps, makeStep("Get user keypair", false, "", "Failed to derive public key: "+err.Error()))
+			steps = addStep(ctx, steps, makeStep("Get user keypair", false, "", "Failed to derive public key: "+err.Error()))
 			return makeResult(t.ID(), false, steps), nil
 		}
 		publicKey = pubkey
-		steps = append(steps, makeStep("Use provided keypair", true, fmt.Sprintf("npub: %s...", publicKey[:20]), ""))
+		steps = addStep(ctx, steps, makeStep("Use provided keypair", true, fmt.Sprintf("npub: %s...", publicKey[:20]), ""))
 
 	case "extension":
 		// Event was signed client-side via NIP-07 extension
 		signedEventJSON, ok := params["signedEvent"].(string)
 		if !ok || signedEventJSON == "" {
-			steps = append(steps, makeStep("Get signed event", false, "", "Pre-signed event not provided"))
+			steps = addStep(ctx, steps, makeStep("Get signed event", false, "", "Pre-signed event not provided"))
 			return makeResult(t.ID(), false, steps), nil
 		}
 
@@ -94,22 +127,22 @@ func (t *NIP01Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 			if err != nil {
 				errMsg = err.Error()
 			}
-			steps = append(steps, makeStep("Verify extension-signed event", false, "", errMsg))
+			steps = addStep(ctx, steps, makeStep("Verify extension-signed event", false, "", errMsg))
 			return makeResult(t.ID(), false, steps), nil
 		}
-		steps = append(steps, makeStep("Verify extension-signed event", true, "Signature valid", ""))
+		steps = addStep(ctx, steps, makeStep("Verify extension-signed event", true, "Signature valid", ""))
 
 		// Publish the pre-signed event
 		relays := t.relayPool.GetConnected()
 		if len(relays) > 0 {
 			err = t.nak.Publish(signedEventJSON, relays[0])
 			if err != nil {
-				steps = append(steps, makeStep("Publish extension-signed event", false, "", err.Error()))
+				steps = addStep(ctx, steps, makeStep("Publish extension-signed event", false, "", err.Error()))
 			} else {
-				steps = append(steps, makeStep("Publish extension-signed event", true, fmt.Sprintf("Published to %s", relays[0]), ""))
+				steps = addStep(ctx, steps, makeStep("Publish extension-signed event", true, fmt.Sprintf("Published to %s", relays[0]), ""))
 			}
 		} else {
-			steps = append(steps, makeStep("Publish extension-signed event", false, "", "No connected relays"))
+			steps = addStep(ctx, steps, makeStep("Publish extension-signed event", false, "", "No connected relays"))
 		}
 
 		return makeResult(t.ID(), success, steps), nil
@@ -118,12 +151,12 @@ func (t *NIP01Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 		// Generate a new keypair for testing
 		keypair, err := t.nak.GenerateKey()
 		if err != nil {
-			steps = append(steps, makeStep("Generate keypair", false, "", err.Error()))
+			steps = addStep(ctx, steps, makeStep("Generate keypair", false, "", err.Error()))
 			return makeResult(t.ID(), false, steps), nil
 		}
 		privateKey = keypair.PrivateKey
 		publicKey = keypair.PublicKey
-		steps = append(steps, makeStep("Generate keypair", true, fmt.Sprintf("npub: %s...", publicKey[:20]), ""))
+		steps = addStep(ctx, steps, makeStep("Generate keypair", true, fmt.Sprintf("npub: %s...", publicKey[:20]), ""))
 	}
 
 	// Step 2: Create event
@@ -138,10 +171,10 @@ func (t *NIP01Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 		PrivateKey: privateKey,
 	})
 	if err != nil {
-		steps = append(steps, makeStep("Create event", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Create event", false, "", err.Error()))
 		return makeResult(t.ID(), false, steps), nil
 	}
-	steps = append(steps, makeStep("Create event", true, fmt.Sprintf("ID: %s...", event.ID[:16]), ""))
+	steps = addStep(ctx, steps, makeStep("Create event", true, fmt.Sprintf("ID: %s...", event.ID[:16]), ""))
 
 	// Step 3: Verify signature
 	eventJSON, _ := json.Marshal(event)
@@ -151,10 +184,10 @@ func (t *NIP01Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 		if err != nil {
 			errMsg = err.Error()
 		}
-		steps = append(steps, makeStep("Verify signature", false, "", errMsg))
+		steps = addStep(ctx, steps, makeStep("Verify signature", false, "", errMsg))
 		success = false
 	} else {
-		steps = append(steps, makeStep("Verify signature", true, "Signature valid", ""))
+		steps = addStep(ctx, steps, makeStep("Verify signature", true, "Signature valid", ""))
 	}
 
 	// Step 4: Publish to relay (optional)
@@ -163,23 +196,23 @@ func (t *NIP01Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 		targetRelay := relays[0]
 		err = t.nak.Publish(string(eventJSON), targetRelay)
 		if err != nil {
-			steps = append(steps, makeStep("Publish to relay", false, "", err.Error()))
+			steps = addStep(ctx, steps, makeStep("Publish to relay", false, "", err.Error()))
 			// Don't fail the whole test if publish fails
 		} else {
-			steps = append(steps, makeStep("Publish to relay", true, fmt.Sprintf("Published to %s", targetRelay), ""))
+			steps = addStep(ctx, steps, makeStep("Publish to relay", true, fmt.Sprintf("Published to %s", targetRelay), ""))
 
 			// Step 5: Query back
 			events, err := t.relayPool.QueryEvents(fmt.Sprintf("%d", event.Kind), event.PubKey, "1")
 			if err != nil {
-				steps = append(steps, makeStep("Query event back", false, "", err.Error()))
+				steps = addStep(ctx, steps, makeStep("Query event back", false, "", err.Error()))
 			} else if len(events) == 0 {
-				steps = append(steps, makeStep("Query event back", false, "", "Event not found"))
+				steps = addStep(ctx, steps, makeStep("Query event back", false, "", "Event not found"))
 			} else {
-				steps = append(steps, makeStep("Query event back", true, fmt.Sprintf("Found %d events", len(events)), ""))
+				steps = addStep(ctx, steps, makeStep("Query event back", true, fmt.Sprintf("Found %d events", len(events)), ""))
 			}
 		}
 	} else {
-		steps = append(steps, makeStep("Publish to relay", false, "", "No connected relays"))
+		steps = addStep(ctx, steps, makeStep("Publish to relay", false, "", "No connected relays"))
 	}
 
 	return makeResult(t.ID(), success, steps), nil
@@ -38,6 +38,18 @@ func (t *NIP02Test) Description() string {
 	return "Fetch and parse contact list (kind 3) for a pubkey"
 }
 
+// Params returns the parameters the test accepts.
+func (t *NIP02Test) Params() []types.TestParam {
+	return []types.TestParam{
+		{
+			Name:        "pubkey",
+			Label:       "Public key",
+			Description: "Hex pubkey or npub whose follow list is fetched",
+			Type:        "string",
+		},
+	}
+}
+
 // Run executes the NIP-02 test.
 func (t *NIP02Test) Run(ctx context.Context, params map[string]interface{}) (*types.TestResult, error) {
 	var steps []types.TestStep
@@ -52,44 +64,45 @@ func (t *NIP02Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 	// If npub provided, decode it
 	if len(pubkey) > 0 && pubkey[:4] == "npub" {
 		if t.nak == nil {
-			steps = append(steps, makeStep("Decode npub", false, "", "nak CLI not available - provide hex pubkey instead"))
+			steps = addStep(ctx, steps, makeStep("Decode npub", false, "", "nak CLI not available - provide hex pubkey instead"))
 			return makeResult(t.ID(), false, steps), nil
 		}
 		decoded, err := t.nak.Decode(pubkey)
 		if err != nil {
-			steps = append(steps, makeStep("Decode npub", false, "", err.Error()))
+			steps = addStep(ctx, steps, makeStep("Decode npub", false, "", err.Error()))
 			return makeResult(t.ID(), false, steps), nil
 		}
 		pubkey = decoded.Hex
-		steps = append(steps, makeStep("Decode npub", true, fmt.Sprintf("Hex: %s...", pubkey[:16]), ""))
+		steps = addStep(ctx, steps, makeStep("Decode npub", true, fmt.Sprintf("Hex: %s...", pubkey[:16]), ""))
 	}
 
 	// Use a known pubkey if none provided (fiatjaf)
 	if pubkey == "" {
 		pubkey = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
-		steps = append(steps, makeStep("Using default pubkey", true, "fiatjaf (for testing)", ""))
+		steps = addStep(ctx, steps, makeStep("Using default pubkey", true, "fiatjaf (for testing)", ""))
 	}
 
 	// Step 1: Query for kind 3 events
 	relays := t.relayPool.GetConnected()
 	if len(relays) == 0 {
-		steps = append(steps, makeStep("Query contact list", false, "", "No connected relays"))
+		steps = addStep(ctx, steps, makeStep("Query contact list", false, "", "No connected relays"))
 		return makeResult(t.ID(), false, steps), nil
 	}
 
 	events, err := t.relayPool.QueryEvents("3", pubkey, "1")
 	if err != nil {
-		steps = append(steps, makeStep("Query contact list", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Query contact list", false, "", err.Error()))
 		return makeResult(t.ID(), false, steps), nil
 	}
 
-	if len(events) == 0 {
-		steps = append(steps, makeStep("Query contact list", false, "", "No contact list found"))
+	latest := types.LatestReplaceable(events)
+	if latest == nil {
+		steps = addStep(ctx, steps, makeStep("Query contact list", false, "", "No contact list found"))
 		return makeResult(t.ID(), false, steps), nil
 	}
 
-	event := events[0]
-	steps = append(steps, makeStep("Query contact list", true, fmt.Sprintf("Found kind 3 event: %s...", event.ID[:16]), ""))
+	event := *latest
+	steps = addStep(ctx, steps, makeStep("Query contact list", true, fmt.Sprintf("Found kind 3 event: %s...", event.ID[:16]), ""))
 
 	// Step 2: Parse tags to extract follows
 	follows := []string{}
@@ -100,10 +113,10 @@ func (t *NIP02Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 	}
 
 	if len(follows) == 0 {
-		steps = append(steps, makeStep("Parse follows", false, "", "No follows found in contact list"))
+		steps = addStep(ctx, steps, makeStep("Parse follows", false, "", "No follows found in contact list"))
 		success = false
 	} else {
-		steps = append(steps, makeStep("Parse follows", true, fmt.Sprintf("Found %d follows", len(follows)), ""))
+		steps = addStep(ctx, steps, makeStep("Parse follows", true, fmt.Sprintf("Found %d follows", len(follows)), ""))
 
 		// Show first few follows
 		displayCount := 3
@@ -115,11 +128,11 @@ func (t *NIP02Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 			if t.nak != nil {
 				npub, err := t.nak.Encode("npub", follows[i])
 				if err == nil {
-					steps = append(steps, makeStep(fmt.Sprintf("Follow #%d", i+1), true, npub, ""))
+					steps = addStep(ctx, steps, makeStep(fmt.Sprintf("Follow #%d", i+1), true, npub, ""))
 					continue
 				}
 			}
-			steps = append(steps, makeStep(fmt.Sprintf("Follow #%d", i+1), true, follows[i][:16]+"...", ""))
+			steps = addStep(ctx, steps, makeStep(fmt.Sprintf("Follow #%d", i+1), true, follows[i][:16]+"...", ""))
 		}
 	}
 
@@ -34,6 +34,18 @@ func (t *NIP19Test) Description() string {
 	return "Test encode/decode roundtrip for npub, nsec, note"
 }
 
+// Params returns the parameters the test accepts.
+func (t *NIP19Test) Params() []types.TestParam {
+	return []types.TestParam{
+		{
+			Name:        "input",
+			Label:       "npub",
+			Description: "npub to decode and re-encode",
+			Type:        "string",
+		},
+	}
+}
+
 // Run executes the NIP-19 test.
 func (t *NIP19Test) Run(ctx context.Context, params map[string]interface{}) (*types.TestResult, error) {
 	var steps []types.TestStep
@@ -41,7 +53,7 @@ func (t *NIP19Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 
 	// Check if nak is available
 	if t.nak == nil {
-		steps = append(steps, makeStep("Check nak CLI", false, "", "nak CLI not available - install from https://github.com/fiatjaf/nak"))
+		steps = addStep(ctx, steps, makeStep("Check nak CLI", false, "", "nak CLI not available - install from https://github.com/fiatjaf/nak"))
 		return makeResult(t.ID(), false, steps), nil
 	}
 
@@ -53,62 +65,62 @@ func (t *NIP19Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 
 	decoded, err := t.nak.Decode(testNpub)
 	if err != nil {
-		steps = append(steps, makeStep("Decode npub", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Decode npub", false, "", err.Error()))
 		success = false
 	} else {
 		hexPreview := decoded.Hex
 		if len(hexPreview) > 16 {
 			hexPreview = hexPreview[:16]
 		}
-		steps = append(steps, makeStep("Decode npub", true, fmt.Sprintf("Type: %s, Hex: %s...", decoded.Type, hexPreview), ""))
+		steps = addStep(ctx, steps, makeStep("Decode npub", true, fmt.Sprintf("Type: %s, Hex: %s...", decoded.Type, hexPreview), ""))
 	}
 
 	// Test 2: Re-encode and verify roundtrip
 	if decoded != nil && decoded.Type == "npub" {
 		reencoded, err := t.nak.Encode("npub", decoded.Hex)
 		if err != nil {
-			steps = append(steps, makeStep("Re-encode npub", false, "", err.Error()))
+			steps = addStep(ctx, steps, makeStep("Re-encode npub", false, "", err.Error()))
 			success = false
 		} else if reencoded != testNpub {
-			steps = append(steps, makeStep("Verify roundtrip", false, "", "Roundtrip mismatch"))
+			steps = addStep(ctx, steps, makeStep("Verify roundtrip", false, "", "Roundtrip mismatch"))
 			success = false
 		} else {
-			steps = append(steps, makeStep("Verify roundtrip", true, "Input matches output", ""))
+			steps = addStep(ctx, steps, makeStep("Verify roundtrip", true, "Input matches output", ""))
 		}
 	}
 
 	// Test 3: Generate keypair and test nsec roundtrip
 	keypair, err := t.nak.GenerateKey()
 	if err != nil {
-		steps = append(steps, makeStep("Generate keypair", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Generate keypair", false, "", err.Error()))
 		return makeResult(t.ID(), false, steps), nil
 	}
 	nsecPreview := keypair.PrivateKey
 	if len(nsecPreview) > 12 {
 		nsecPreview = nsecPreview[:12]
 	}
-	steps = append(steps, makeStep("Generate keypair", true, fmt.Sprintf("nsec: %s...", nsecPreview), ""))
+	steps = addStep(ctx, steps, makeStep("Generate keypair", true, fmt.Sprintf("nsec: %s...", nsecPreview), ""))
 
 	// Test 4: Decode nsec
 	decodedNsec, err := t.nak.Decode(keypair.PrivateKey)
 	if err != nil {
-		steps = append(steps, makeStep("Decode nsec", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Decode nsec", false, "", err.Error()))
 		success = false
 	} else {
-		steps = append(steps, makeStep("Decode nsec", true, fmt.Sprintf("Type: %s", decodedNsec.Type), ""))
+		steps = addStep(ctx, steps, makeStep("Decode nsec", true, fmt.Sprintf("Type: %s", decodedNsec.Type), ""))
 	}
 
 	// Test 5: Re-encode nsec
 	if decodedNsec != nil {
 		reencodedNsec, err := t.nak.Encode("nsec", decodedNsec.Hex)
 		if err != nil {
-			steps = append(steps, makeStep("Re-encode nsec", false, "", err.Error()))
+			steps = addStep(ctx, steps, makeStep("Re-encode nsec", false, "", err.Error()))
 			success = false
 		} else if reencodedNsec != keypair.PrivateKey {
-			steps = append(steps, makeStep("Verify nsec roundtrip", false, "", "Roundtrip mismatch"))
+			steps = addStep(ctx, steps, makeStep("Verify nsec roundtrip", false, "", "Roundtrip mismatch"))
 			success = false
 		} else {
-			steps = append(steps, makeStep("Verify nsec roundtrip", true, "Input matches output", ""))
+			steps = addStep(ctx, steps, makeStep("Verify nsec roundtrip", true, "Input matches output", ""))
 		}
 	}
 
@@ -116,25 +128,25 @@ func (t *NIP19Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 	testEventID := "5c83da77af1dec6d7289834998ad7aafbd9e2191396d75ec3cc27f5a77226f36"
 	neventEncoded, err := t.nak.Encode("nevent", testEventID)
 	if err != nil {
-		steps = append(steps, makeStep("Encode nevent", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Encode nevent", false, "", err.Error()))
 		success = false
 	} else {
 		neventPreview := neventEncoded
 		if len(neventPreview) > 20 {
 			neventPreview = neventPreview[:20]
 		}
-		steps = append(steps, makeStep("Encode nevent", true, fmt.Sprintf("nevent: %s...", neventPreview), ""))
+		steps = addStep(ctx, steps, makeStep("Encode nevent", true, fmt.Sprintf("nevent: %s...", neventPreview), ""))
 
 		// Decode it back
 		decodedEvent, err := t.nak.Decode(neventEncoded)
 		if err != nil {
-			steps = append(steps, makeStep("Decode nevent", false, "", err.Error()))
+			steps = addStep(ctx, steps, makeStep("Decode nevent", false, "", err.Error()))
 			success = false
 		} else if decodedEvent.Hex != testEventID {
-			steps = append(steps, makeStep("Verify nevent roundtrip", false, "", "Roundtrip mismatch"))
+			steps = addStep(ctx, steps, makeStep("Verify nevent roundtrip", false, "", "Roundtrip mismatch"))
 			success = false
 		} else {
-			steps = append(steps, makeStep("Verify nevent roundtrip", true, "Input matches output", ""))
+			steps = addStep(ctx, steps, makeStep("Verify nevent roundtrip", true, "Input matches output", ""))
 		}
 	}
 
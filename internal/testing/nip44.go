@@ -34,6 +34,18 @@ func (t *NIP44Test) Description() string {
 	return "Test encryption/decryption roundtrip using NIP-44"
 }
 
+// Params returns the parameters the test accepts.
+func (t *NIP44Test) Params() []types.TestParam {
+	return []types.TestParam{
+		{
+			Name:        "message",
+			Label:       "Message",
+			Description: "Plaintext to encrypt and decrypt",
+			Type:        "string",
+		},
+	}
+}
+
 // Run executes the NIP-44 test.
 func (t *NIP44Test) Run(ctx context.Context, params map[string]interface{}) (*types.TestResult, error) {
 	var steps []types.TestStep
@@ -41,25 +53,25 @@ func (t *NIP44Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 
 	// Check if nak is available
 	if t.nak == nil {
-		steps = append(steps, makeStep("Check nak CLI", false, "", "nak CLI not available - install from https://github.com/fiatjaf/nak"))
+		steps = addStep(ctx, steps, makeStep("Check nak CLI", false, "", "nak CLI not available - install from https://github.com/fiatjaf/nak"))
 		return makeResult(t.ID(), false, steps), nil
 	}
 
 	// Step 1: Generate sender keypair
 	sender, err := t.nak.GenerateKey()
 	if err != nil {
-		steps = append(steps, makeStep("Generate sender keypair", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Generate sender keypair", false, "", err.Error()))
 		return makeResult(t.ID(), false, steps), nil
 	}
-	steps = append(steps, makeStep("Generate sender keypair", true, fmt.Sprintf("npub: %s...", sender.PublicKey[:20]), ""))
+	steps = addStep(ctx, steps, makeStep("Generate sender keypair", true, fmt.Sprintf("npub: %s...", sender.PublicKey[:20]), ""))
 
 	// Step 2: Generate receiver keypair
 	receiver, err := t.nak.GenerateKey()
 	if err != nil {
-		steps = append(steps, makeStep("Generate receiver keypair", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Generate receiver keypair", false, "", err.Error()))
 		return makeResult(t.ID(), false, steps), nil
 	}
-	steps = append(steps, makeStep("Generate receiver keypair", true, fmt.Sprintf("npub: %s...", receiver.PublicKey[:20]), ""))
+	steps = addStep(ctx, steps, makeStep("Generate receiver keypair", true, fmt.Sprintf("npub: %s...", receiver.PublicKey[:20]), ""))
 
 	// Step 3: Encrypt a message
 	plaintext := "Hello, this is a secret message for NIP-44 testing!"
@@ -71,27 +83,27 @@ func (t *NIP44Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 	encrypted, err := t.nak.Run("encrypt", "--sec", sender.PrivateKey, "-p", receiver.PublicKey, plaintext)
 	if err != nil {
 		// NIP-44 encryption might not be available in all nak versions
-		steps = append(steps, makeStep("Encrypt message", false, "", fmt.Sprintf("nak encrypt failed: %v (NIP-44 may not be supported)", err)))
+		steps = addStep(ctx, steps, makeStep("Encrypt message", false, "", fmt.Sprintf("nak encrypt failed: %v (NIP-44 may not be supported)", err)))
 		// Don't fail completely, just note the limitation
-		steps = append(steps, makeStep("NIP-44 Status", true, "nak CLI may not support NIP-44 encryption yet", ""))
+		steps = addStep(ctx, steps, makeStep("NIP-44 Status", true, "nak CLI may not support NIP-44 encryption yet", ""))
 		return makeResult(t.ID(), success, steps), nil
 	}
-	steps = append(steps, makeStep("Encrypt message", true, fmt.Sprintf("Ciphertext: %s...", truncate(encrypted, 32)), ""))
+	steps = addStep(ctx, steps, makeStep("Encrypt message", true, fmt.Sprintf("Ciphertext: %s...", truncate(encrypted, 32)), ""))
 
 	// Step 4: Decrypt the message
 	decrypted, err := t.nak.Run("decrypt", "--sec", receiver.PrivateKey, "-p", sender.PublicKey, encrypted)
 	if err != nil {
-		steps = append(steps, makeStep("Decrypt message", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Decrypt message", false, "", err.Error()))
 		success = false
 	} else {
-		steps = append(steps, makeStep("Decrypt message", true, fmt.Sprintf("Plaintext: %s", truncate(decrypted, 40)), ""))
+		steps = addStep(ctx, steps, makeStep("Decrypt message", true, fmt.Sprintf("Plaintext: %s", truncate(decrypted, 40)), ""))
 	}
 
 	// Step 5: Verify roundtrip
 	if decrypted == plaintext {
-		steps = append(steps, makeStep("Verify roundtrip", true, "Decrypted text matches original", ""))
+		steps = addStep(ctx, steps, makeStep("Verify roundtrip", true, "Decrypted text matches original", ""))
 	} else {
-		steps = append(steps, makeStep("Verify roundtrip", false, "", "Decrypted text does not match original"))
+		steps = addStep(ctx, steps, makeStep("Verify roundtrip", false, "", "Decrypted text does not match original"))
 		success = false
 	}
 
@@ -40,6 +40,11 @@ func (t *NIP90Test) Description() string {
 	return "Discover DVMs, submit job request, monitor feedback"
 }
 
+// Params returns nil; the test takes no parameters.
+func (t *NIP90Test) Params() []types.TestParam {
+	return nil
+}
+
 // Run executes the NIP-90 test.
 func (t *NIP90Test) Run(ctx context.Context, params map[string]interface{}) (*types.TestResult, error) {
 	var steps []types.TestStep
@@ -48,18 +53,18 @@ func (t *NIP90Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 	// Step 1: Query for DVM announcements (kind 31990)
 	relays := t.relayPool.GetConnected()
 	if len(relays) == 0 {
-		steps = append(steps, makeStep("Discover DVMs", false, "", "No connected relays"))
+		steps = addStep(ctx, steps, makeStep("Discover DVMs", false, "", "No connected relays"))
 		return makeResult(t.ID(), false, steps), nil
 	}
 
 	// Query for DVM kind announcements
 	events, err := t.relayPool.QueryEvents("31990", "", "10")
 	if err != nil {
-		steps = append(steps, makeStep("Discover DVMs", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Discover DVMs", false, "", err.Error()))
 	} else if len(events) == 0 {
-		steps = append(steps, makeStep("Discover DVMs", true, "No DVM announcements found", ""))
+		steps = addStep(ctx, steps, makeStep("Discover DVMs", true, "No DVM announcements found", ""))
 	} else {
-		steps = append(steps, makeStep("Discover DVMs", true, fmt.Sprintf("Found %d DVM announcements", len(events)), ""))
+		steps = addStep(ctx, steps, makeStep("Discover DVMs", true, fmt.Sprintf("Found %d DVM announcements", len(events)), ""))
 
 		// Parse DVM info
 		for i, dvm := range events {
@@ -87,38 +92,38 @@ func (t *NIP90Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 			if len(supportedKinds) > 0 {
 				kindsStr = strings.Join(supportedKinds, ", ")
 			}
-			steps = append(steps, makeStep(fmt.Sprintf("DVM #%d: %s", i+1, name), true, fmt.Sprintf("Kinds: %s", kindsStr), ""))
+			steps = addStep(ctx, steps, makeStep(fmt.Sprintf("DVM #%d: %s", i+1, name), true, fmt.Sprintf("Kinds: %s", kindsStr), ""))
 		}
 	}
 
 	// Step 2: Query for recent job requests (kinds 5000-5999)
 	jobEvents, err := t.relayPool.QueryEvents("5050", "", "5") // Text generation
 	if err != nil {
-		steps = append(steps, makeStep("Find job requests", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Find job requests", false, "", err.Error()))
 	} else if len(jobEvents) == 0 {
-		steps = append(steps, makeStep("Find job requests", true, "No recent job requests found", ""))
+		steps = addStep(ctx, steps, makeStep("Find job requests", true, "No recent job requests found", ""))
 	} else {
-		steps = append(steps, makeStep("Find job requests", true, fmt.Sprintf("Found %d job requests (kind 5050)", len(jobEvents)), ""))
+		steps = addStep(ctx, steps, makeStep("Find job requests", true, fmt.Sprintf("Found %d job requests (kind 5050)", len(jobEvents)), ""))
 	}
 
 	// Step 3: Query for job results (kinds 6000-6999)
 	resultEvents, err := t.relayPool.QueryEvents("6050", "", "5")
 	if err != nil {
-		steps = append(steps, makeStep("Find job results", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Find job results", false, "", err.Error()))
 	} else if len(resultEvents) == 0 {
-		steps = append(steps, makeStep("Find job results", true, "No recent job results found", ""))
+		steps = addStep(ctx, steps, makeStep("Find job results", true, "No recent job results found", ""))
 	} else {
-		steps = append(steps, makeStep("Find job results", true, fmt.Sprintf("Found %d job results (kind 6050)", len(resultEvents)), ""))
+		steps = addStep(ctx, steps, makeStep("Find job results", true, fmt.Sprintf("Found %d job results (kind 6050)", len(resultEvents)), ""))
 	}
 
 	// Step 4: Query for feedback (kind 7000)
 	feedbackEvents, err := t.relayPool.QueryEvents("7000", "", "5")
 	if err != nil {
-		steps = append(steps, makeStep("Find feedback events", false, "", err.Error()))
+		steps = addStep(ctx, steps, makeStep("Find feedback events", false, "", err.Error()))
 	} else if len(feedbackEvents) == 0 {
-		steps = append(steps, makeStep("Find feedback events", true, "No feedback events found", ""))
+		steps = addStep(ctx, steps, makeStep("Find feedback events", true, "No feedback events found", ""))
 	} else {
-		steps = append(steps, makeStep("Find feedback events", true, fmt.Sprintf("Found %d feedback events", len(feedbackEvents)), ""))
+		steps = addStep(ctx, steps, makeStep("Find feedback events", true, fmt.Sprintf("Found %d feedback events", len(feedbackEvents)), ""))
 
 		// Parse feedback status
 		for i, fb := range feedbackEvents {
@@ -132,12 +137,12 @@ func (t *NIP90Test) Run(ctx context.Context, params map[string]interface{}) (*ty
 					break
 				}
 			}
-			steps = append(steps, makeStep(fmt.Sprintf("Feedback #%d", i+1), true, fmt.Sprintf("Status: %s", status), ""))
+			steps = addStep(ctx, steps, makeStep(fmt.Sprintf("Feedback #%d", i+1), true, fmt.Sprintf("Status: %s", status), ""))
 		}
 	}
 
 	// Step 5: Note about job submission
-	steps = append(steps, makeStep("Submit test job", true, "Job submission available via API", ""))
+	steps = addStep(ctx, steps, makeStep("Submit test job", true, "Job submission available via API", ""))
 
 	return makeResult(t.ID(), success, steps), nil
 }
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RelayFailure describes why a single relay failed during a query.
+type RelayFailure struct {
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// PartialError is returned by a query when at least one relay failed but
+// others succeeded. Events holds the results that were received.
+type PartialError struct {
+	Events []Event
+	Failed []RelayFailure
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d relay(s) failed: %s", len(e.Failed), strings.Join(e.Warnings(), "; "))
+}
+
+// Warnings returns one human-readable message per failed relay.
+func (e *PartialError) Warnings() []string {
+	warnings := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		warnings = append(warnings, fmt.Sprintf("relay %s failed: %s", f.URL, f.Reason))
+	}
+	return warnings
+}
+
+// ErrRelayBlocked is returned when adding a relay that matches the configured
+// blocklist.
+var ErrRelayBlocked = errors.New("relay is blocked")
+
+// ErrRelayNotAllowed is returned when adding a relay that is not on the
+// allowlist while allowlist mode is on.
+var ErrRelayNotAllowed = errors.New("relay is not on the allowlist")
@@ -0,0 +1,23 @@
+package types
+
+import "testing"
+
+func TestPartialError(t *testing.T) {
+	err := &PartialError{
+		Events: []Event{{ID: "abc"}},
+		Failed: []RelayFailure{
+			{URL: "wss://a.example.com", Reason: "timeout"},
+			{URL: "wss://b.example.com", Reason: "connection error: refused"},
+		},
+	}
+
+	want := "2 relay(s) failed: relay wss://a.example.com failed: timeout; relay wss://b.example.com failed: connection error: refused"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	warnings := err.Warnings()
+	if len(warnings) != 2 || warnings[0] != "relay wss://a.example.com failed: timeout" {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
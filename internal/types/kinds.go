@@ -0,0 +1,189 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+)
+
+// KindInfo describes a known event kind.
+type KindInfo struct {
+	Kind     int    `json:"kind"`
+	Label    string `json:"label"`
+	Category string `json:"category"`
+}
+
+// kindLabels maps well-known event kinds to human-readable labels.
+var kindLabels = map[int]string{
+	0:     "Metadata",
+	1:     "Short Text Note",
+	2:     "Recommend Relay",
+	3:     "Contacts",
+	4:     "Encrypted DM",
+	5:     "Deletion",
+	6:     "Repost",
+	7:     "Reaction",
+	8:     "Badge Award",
+	9:     "Group Chat",
+	10:    "Group Chat Threaded",
+	11:    "Thread",
+	13:    "Seal",
+	14:    "Direct Message",
+	16:    "Generic Repost",
+	20:    "Picture",
+	40:    "Channel Creation",
+	41:    "Channel Metadata",
+	42:    "Channel Message",
+	43:    "Channel Hide",
+	44:    "Channel Mute",
+	1021:  "Bid",
+	1022:  "Bid Confirmation",
+	1040:  "OpenTimestamps",
+	1059:  "Gift Wrap",
+	1063:  "File Metadata",
+	1111:  "Comment",
+	1311:  "Live Chat Message",
+	1617:  "Patches",
+	1621:  "Issues",
+	1971:  "Problem Tracker",
+	1984:  "Report",
+	1985:  "Label",
+	4550:  "Community Post Approval",
+	5000:  "Job Request",
+	6000:  "Job Result",
+	7000:  "Job Feedback",
+	9041:  "Zap Goal",
+	9321:  "Nutzap",
+	9734:  "Zap Request",
+	9735:  "Zap Receipt",
+	9802:  "Highlights",
+	10000: "Mute List",
+	10001: "Pin List",
+	10002: "Relay List",
+	10003: "Bookmark List",
+	10004: "Communities List",
+	10005: "Public Chats List",
+	10006: "Blocked Relays List",
+	10007: "Search Relays List",
+	10015: "Interests List",
+	10030: "User Emoji List",
+	10050: "DM Relays List",
+	10096: "File Storage Server List",
+	13194: "Wallet Info",
+	22242: "Client Authentication",
+	23194: "Wallet Request",
+	23195: "Wallet Response",
+	24133: "Nostr Connect",
+	27235: "HTTP Auth",
+	30000: "Categorized People",
+	30001: "Categorized Bookmarks",
+	30002: "Relay Sets",
+	30003: "Bookmark Sets",
+	30008: "Profile Badges",
+	30009: "Badge Definition",
+	30017: "Marketplace Stall",
+	30018: "Marketplace Product",
+	30023: "Long-form Content",
+	30024: "Draft Long-form",
+	30030: "Emoji Set",
+	30078: "App-specific Data",
+	30311: "Live Event",
+	30315: "User Status",
+	30402: "Classified Listing",
+	30617: "Repository Announcement",
+	31922: "Date-Based Calendar Event",
+	31923: "Time-Based Calendar Event",
+	31924: "Calendar",
+	31925: "Calendar Event RSVP",
+	31989: "Handler Recommendation",
+	31990: "Handler Information",
+	34550: "Community Definition",
+}
+
+// KindLabel returns a human-readable label for an event kind.
+// Unknown kinds are labelled "Kind N".
+func KindLabel(kind int) string {
+	if label, ok := kindLabels[kind]; ok {
+		return label
+	}
+	return fmt.Sprintf("Kind %d", kind)
+}
+
+// KnownKinds returns all kinds with a known label, sorted by kind number.
+func KnownKinds() []KindInfo {
+	kinds := make([]KindInfo, 0, len(kindLabels))
+	for kind, label := range kindLabels {
+		kinds = append(kinds, KindInfo{Kind: kind, Label: label, Category: KindCategory(kind)})
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].Kind < kinds[j].Kind
+	})
+	return kinds
+}
+
+// KindClass is the NIP-01 storage class of an event kind.
+type KindClass string
+
+const (
+	// KindClassRegular events are stored by relays indefinitely.
+	KindClassRegular KindClass = "regular"
+	// KindClassReplaceable events keep only the latest per pubkey and kind.
+	KindClassReplaceable KindClass = "replaceable"
+	// KindClassEphemeral events are not stored by relays.
+	KindClassEphemeral KindClass = "ephemeral"
+	// KindClassAddressable events keep only the latest per pubkey, kind and d tag.
+	KindClassAddressable KindClass = "addressable"
+)
+
+// ClassifyKind returns the NIP-01 storage class for an event kind.
+func ClassifyKind(kind int) KindClass {
+	switch {
+	case kind == 0 || kind == 3 || (kind >= 10000 && kind < 20000):
+		return KindClassReplaceable
+	case kind >= 20000 && kind < 30000:
+		return KindClassEphemeral
+	case kind >= 30000 && kind < 40000:
+		return KindClassAddressable
+	default:
+		return KindClassRegular
+	}
+}
+
+// MaxKind is the largest event kind NIP-01 allows.
+const MaxKind = 65535
+
+// KindCategory describes how relays handle a kind under NIP-01: "regular",
+// "replaceable" (0, 3 and 10000-19999), "ephemeral" (20000-29999) or
+// "parameterized-replaceable" (30000-39999). Kinds outside 0-65535 are
+// "invalid".
+func KindCategory(kind int) string {
+	if kind < 0 || kind > MaxKind {
+		return "invalid"
+	}
+	if class := ClassifyKind(kind); class != KindClassAddressable {
+		return string(class)
+	}
+	return "parameterized-replaceable"
+}
+
+// Supersedes reports whether replaceable event a replaces b under NIP-01:
+// the newer created_at wins, and on a tie the lexicographically smallest ID.
+func Supersedes(a, b Event) bool {
+	if a.CreatedAt != b.CreatedAt {
+		return a.CreatedAt > b.CreatedAt
+	}
+	return a.ID < b.ID
+}
+
+// LatestReplaceable returns the current version among several versions of a
+// replaceable or addressable event, or nil if there are none. Relays may
+// return stale versions alongside the newest, so callers should not rely on
+// result order.
+func LatestReplaceable(events []Event) *Event {
+	var latest *Event
+	for i := range events {
+		if latest == nil || Supersedes(events[i], *latest) {
+			latest = &events[i]
+		}
+	}
+	return latest
+}
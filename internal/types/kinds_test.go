@@ -0,0 +1,139 @@
+package types
+
+import "testing"
+
+func TestKindLabel(t *testing.T) {
+	testCases := []struct {
+		kind     int
+		expected string
+	}{
+		{0, "Metadata"},
+		{1, "Short Text Note"},
+		{3, "Contacts"},
+		{7, "Reaction"},
+		{1059, "Gift Wrap"},
+		{9321, "Nutzap"},
+		{9735, "Zap Receipt"},
+		{10002, "Relay List"},
+		{30023, "Long-form Content"},
+		{30311, "Live Event"},
+		{31922, "Date-Based Calendar Event"},
+		{31923, "Time-Based Calendar Event"},
+		{99999, "Kind 99999"}, // Unknown kind
+	}
+
+	for _, tc := range testCases {
+		result := KindLabel(tc.kind)
+		if result != tc.expected {
+			t.Errorf("KindLabel(%d): expected '%s', got '%s'", tc.kind, tc.expected, result)
+		}
+	}
+}
+
+func TestKnownKinds_Sorted(t *testing.T) {
+	kinds := KnownKinds()
+	if len(kinds) != len(kindLabels) {
+		t.Fatalf("expected %d kinds, got %d", len(kindLabels), len(kinds))
+	}
+	for i := 1; i < len(kinds); i++ {
+		if kinds[i-1].Kind >= kinds[i].Kind {
+			t.Fatalf("kinds not sorted: %d before %d", kinds[i-1].Kind, kinds[i].Kind)
+		}
+	}
+	for _, k := range kinds {
+		if k.Label != KindLabel(k.Kind) {
+			t.Errorf("kind %d label mismatch: %q vs %q", k.Kind, k.Label, KindLabel(k.Kind))
+		}
+		if k.Category != KindCategory(k.Kind) {
+			t.Errorf("kind %d category = %q, want %q", k.Kind, k.Category, KindCategory(k.Kind))
+		}
+	}
+}
+
+func TestClassifyKind(t *testing.T) {
+	testCases := []struct {
+		kind     int
+		expected KindClass
+	}{
+		{0, KindClassReplaceable},
+		{1, KindClassRegular},
+		{3, KindClassReplaceable},
+		{7, KindClassRegular},
+		{9735, KindClassRegular},
+		{10002, KindClassReplaceable},
+		{19999, KindClassReplaceable},
+		{20000, KindClassEphemeral},
+		{22242, KindClassEphemeral},
+		{29999, KindClassEphemeral},
+		{30023, KindClassAddressable},
+		{39999, KindClassAddressable},
+		{40000, KindClassRegular},
+	}
+
+	for _, tc := range testCases {
+		if got := ClassifyKind(tc.kind); got != tc.expected {
+			t.Errorf("ClassifyKind(%d) = %s, want %s", tc.kind, got, tc.expected)
+		}
+	}
+}
+
+func TestKindCategory(t *testing.T) {
+	testCases := []struct {
+		kind     int
+		expected string
+	}{
+		{-1, "invalid"},
+		{0, "replaceable"},
+		{1, "regular"},
+		{2, "regular"},
+		{3, "replaceable"},
+		{4, "regular"},
+		{9999, "regular"},
+		{10000, "replaceable"},
+		{19999, "replaceable"},
+		{20000, "ephemeral"},
+		{29999, "ephemeral"},
+		{30000, "parameterized-replaceable"},
+		{39999, "parameterized-replaceable"},
+		{40000, "regular"},
+		{65535, "regular"},
+		{65536, "invalid"},
+	}
+
+	for _, tc := range testCases {
+		if got := KindCategory(tc.kind); got != tc.expected {
+			t.Errorf("KindCategory(%d) = %s, want %s", tc.kind, got, tc.expected)
+		}
+	}
+}
+
+func TestLatestReplaceable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		events   []Event
+		expected string
+	}{
+		{"empty", nil, ""},
+		{"single", []Event{{ID: "a", CreatedAt: 100}}, "a"},
+		{"newest first", []Event{{ID: "c", CreatedAt: 300}, {ID: "a", CreatedAt: 100}, {ID: "b", CreatedAt: 200}}, "c"},
+		{"newest last", []Event{{ID: "a", CreatedAt: 100}, {ID: "b", CreatedAt: 200}, {ID: "c", CreatedAt: 300}}, "c"},
+		{"newest in middle", []Event{{ID: "b", CreatedAt: 200}, {ID: "c", CreatedAt: 300}, {ID: "a", CreatedAt: 100}}, "c"},
+		{"tie keeps smallest id", []Event{{ID: "ff", CreatedAt: 300}, {ID: "0a", CreatedAt: 300}, {ID: "ab", CreatedAt: 300}}, "0a"},
+		{"tie does not beat newer", []Event{{ID: "00", CreatedAt: 200}, {ID: "ff", CreatedAt: 300}}, "ff"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			latest := LatestReplaceable(tc.events)
+			if tc.expected == "" {
+				if latest != nil {
+					t.Errorf("expected nil, got %s", latest.ID)
+				}
+				return
+			}
+			if latest == nil || latest.ID != tc.expected {
+				t.Errorf("expected %s, got %+v", tc.expected, latest)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 // Package types provides shared types used across packages.
 package types
 
+import "encoding/json"
+
 // Event represents a Nostr event for the UI.
 type Event struct {
 	ID        string     `json:"id"`
@@ -11,6 +13,18 @@ type Event struct {
 	Tags      [][]string `json:"tags"`
 	Sig       string     `json:"sig,omitempty"`
 	Relay     string     `json:"relay,omitempty"`
+	// SeenOn lists every relay that returned this event, earliest first.
+	// Only populated by queries that fan out to relays individually.
+	SeenOn []string `json:"seen_on,omitempty"`
+	// ContentWarning is the reason given by a NIP-36 content-warning tag.
+	ContentWarning string `json:"content_warning,omitempty"`
+	// Emojis maps NIP-30 custom emoji shortcodes used in the content to
+	// image URLs.
+	Emojis map[string]string `json:"emojis,omitempty"`
+	// Raw is the event's JSON exactly as the relay named by Relay sends it,
+	// including fields Event doesn't model. It is only fetched for responses
+	// that ask for it.
+	Raw json.RawMessage `json:"raw,omitempty"`
 }
 
 // RelayStatus represents the status of a relay.
@@ -37,6 +51,23 @@ type RelayInfo struct {
 	Limitation    *RelayLimitation `json:"limitation,omitempty"`
 	PaymentsURL   string           `json:"payments_url,omitempty"`
 	Fees          *RelayFees       `json:"fees,omitempty"`
+
+	Retention      []RelayRetention `json:"retention,omitempty"`
+	RelayCountries []string         `json:"relay_countries,omitempty"`
+	LanguageTags   []string         `json:"language_tags,omitempty"`
+	PostingPolicy  string           `json:"posting_policy,omitempty"`
+}
+
+// RelayRetention is one entry of a relay's NIP-11 retention policy. The entry
+// applies to Kinds and KindRanges (inclusive [start, end] pairs), or to all
+// other kinds when both are empty. Time is how many seconds events are kept,
+// nil meaning indefinitely and 0 meaning not stored; Count caps how many are
+// kept.
+type RelayRetention struct {
+	Kinds      []int    `json:"kinds,omitempty"`
+	KindRanges [][2]int `json:"kind_ranges,omitempty"`
+	Time       *int64   `json:"time,omitempty"`
+	Count      *int     `json:"count,omitempty"`
 }
 
 // RelayLimitation represents the limitation section of NIP-11.
@@ -71,16 +102,81 @@ type RelayFees struct {
 type RelayStats struct {
 	URL          string  `json:"url"`
 	Latency      int64   `json:"latency_ms"`
+	LatencyP50   int64   `json:"latency_p50_ms"`
+	LatencyP95   int64   `json:"latency_p95_ms"`
+	LatencyP99   int64   `json:"latency_p99_ms"`
 	EventsPerSec float64 `json:"events_per_sec"`
 	TotalEvents  int64   `json:"total_events"`
 }
 
+// PoolDiagnostics summarizes the relay pool for the diagnostics report.
+// ActiveQueries counts in-flight queries and subscriptions together.
+type PoolDiagnostics struct {
+	Relays              int   `json:"relays"`
+	ConnectedRelays     int   `json:"connected_relays"`
+	ActiveSubscriptions int   `json:"active_subscriptions"`
+	ActiveQueries       int64 `json:"active_queries"`
+	EventCacheSize      int   `json:"event_cache_size"`
+	EventCacheCapacity  int   `json:"event_cache_capacity"`
+	InfoCacheSize       int   `json:"info_cache_size"`
+	InfoCacheCapacity   int   `json:"info_cache_capacity"`
+}
+
+// RelayStateChange is one entry in a relay's connection history. Timestamp
+// is in Unix milliseconds so quick reconnects stay distinguishable.
+type RelayStateChange struct {
+	Timestamp int64  `json:"timestamp"`
+	Connected bool   `json:"connected"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RelayStateHistory lists a relay's recent connection state changes, oldest
+// first.
+type RelayStateHistory struct {
+	URL     string             `json:"url"`
+	Changes []RelayStateChange `json:"changes"`
+}
+
+// PrunedRelay records a relay that was removed from the pool after failing to
+// connect for too long. Health holds its last monitoring stats, if any.
+type PrunedRelay struct {
+	URL          string       `json:"url"`
+	Error        string       `json:"error,omitempty"`
+	AddedAt      int64        `json:"added_at"`
+	FailingSince int64        `json:"failing_since"`
+	PrunedAt     int64        `json:"pruned_at"`
+	Health       *RelayHealth `json:"health,omitempty"`
+}
+
+// EventConflict records two relays returning different events under the same
+// ID, which only a faulty or malicious relay can cause. Relay is where the
+// kept copy came from; Fields lists what differs between the copies.
+type EventConflict struct {
+	ID               string   `json:"id"`
+	Relay            string   `json:"relay"`
+	ConflictingRelay string   `json:"conflicting_relay"`
+	Fields           []string `json:"fields"`
+}
+
+// DiscoveredRelay is a relay hinted in the e, p or a tags of queried events
+// that is not in the pool. Info is set once its NIP-11 document is cached.
+type DiscoveredRelay struct {
+	URL       string     `json:"url"`
+	HintCount int        `json:"hint_count"`
+	FirstSeen int64      `json:"first_seen"`
+	LastSeen  int64      `json:"last_seen"`
+	Info      *RelayInfo `json:"info,omitempty"`
+}
+
 // TestResult represents the result of a NIP test.
+// Cancelled is set when the test was stopped before finishing, in which case
+// Steps holds the steps completed so far followed by the aborted step.
 type TestResult struct {
-	NIPID   string     `json:"nip_id"`
-	Success bool       `json:"success"`
-	Message string     `json:"message"`
-	Steps   []TestStep `json:"steps"`
+	NIPID     string     `json:"nip_id"`
+	Success   bool       `json:"success"`
+	Message   string     `json:"message"`
+	Steps     []TestStep `json:"steps"`
+	Cancelled bool       `json:"cancelled,omitempty"`
 }
 
 // TestHistoryEntry represents a test result with timestamp for history tracking.
@@ -91,11 +187,33 @@ type TestHistoryEntry struct {
 }
 
 // TestStep represents a single step in a test.
+// Aborted marks the step that was interrupted by cancellation.
 type TestStep struct {
 	Name    string `json:"name"`
 	Success bool   `json:"success"`
 	Output  string `json:"output,omitempty"`
 	Error   string `json:"error,omitempty"`
+	Aborted bool   `json:"aborted,omitempty"`
+}
+
+// TestParam describes one parameter a NIP test accepts, so a UI can render an
+// input for it. Type is "string" or "enum"; Options lists the enum values.
+type TestParam struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Default     string   `json:"default,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// TestDescriptor describes a registered NIP test and its parameters.
+type TestDescriptor struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Params      []TestParam `json:"params"`
 }
 
 // ExampleEvent represents an example Nostr event for documentation purposes.
@@ -136,6 +254,35 @@ type Profile struct {
 	FollowerHint int    `json:"follower_hint,omitempty"`
 }
 
+// ProfileFeed is a profile's metadata together with its latest notes, newest
+// first. ProfileFound is false when only notes could be found, in which case
+// Profile holds just the pubkey.
+type ProfileFeed struct {
+	Profile      Profile `json:"profile"`
+	ProfileFound bool    `json:"profile_found"`
+	Notes        []Event `json:"notes"`
+}
+
+// ProfileVersion is one kind 0 metadata event in a profile's history.
+// Changed lists the fields that differ from the previous version and is
+// omitted for the oldest one.
+type ProfileVersion struct {
+	EventID   string   `json:"event_id"`
+	CreatedAt int64    `json:"created_at"`
+	Profile   Profile  `json:"profile"`
+	Changed   []string `json:"changed,omitempty"`
+}
+
+// ProfileHistory lists the metadata versions relays returned for a pubkey,
+// oldest first. Relays usually keep only the latest replaceable event, so
+// the history is best effort and Notice says so.
+type ProfileHistory struct {
+	PubKey     string           `json:"pubkey"`
+	Versions   []ProfileVersion `json:"versions"`
+	BestEffort bool             `json:"best_effort"`
+	Notice     string           `json:"notice"`
+}
+
 // FollowListEntry represents a single entry in a follow list.
 type FollowListEntry struct {
 	PubKey  string   `json:"pubkey"`
@@ -160,9 +307,20 @@ type ZapStats struct {
 	AvgSats     int64      `json:"avg_sats"`
 	TopZap      int64      `json:"top_zap"`
 	RecentZaps  []ZapEvent `json:"recent_zaps,omitempty"`
+	Skipped     int        `json:"skipped"` // Malformed receipts that were ignored
 	LastUpdated int64      `json:"last_updated,omitempty"`
 }
 
+// RelayList is a user's NIP-65 relay list (kind 10002).
+type RelayList struct {
+	PubKey    string   `json:"pubkey"`
+	Read      []string `json:"read"`  // Relays the user reads from only
+	Write     []string `json:"write"` // Relays the user writes to only
+	Both      []string `json:"both"`  // Relays used for reading and writing
+	CreatedAt int64    `json:"created_at"`
+	EventID   string   `json:"event_id,omitempty"`
+}
+
 // ZapEvent represents a single zap receipt.
 type ZapEvent struct {
 	EventID   string `json:"event_id"`
@@ -189,11 +347,34 @@ type RelayHealth struct {
 	EventRateHistory []TimeSeriesPoint `json:"event_rate_history,omitempty"`
 	Uptime           float64           `json:"uptime_percent"`
 	HealthScore      float64           `json:"health_score"`
+	HealthFactors    HealthFactors     `json:"health_factors"`
 	LastSeen         int64             `json:"last_seen"`
 	ErrorCount       int               `json:"error_count"`
 	LastError        string            `json:"last_error,omitempty"`
 }
 
+// HealthFactor is one weighted component of a relay's health score.
+// Score is 0-100; Contribution is Score * Weight.
+type HealthFactor struct {
+	Score        float64 `json:"score"`
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"`
+}
+
+// HealthFactors breaks a health score into its components. The
+// contributions add up to the health score. Event rate is reported
+// separately and is not part of the score.
+type HealthFactors struct {
+	Connection HealthFactor `json:"connection"`
+	Latency    HealthFactor `json:"latency"`
+	Uptime     HealthFactor `json:"uptime"`
+	Errors     HealthFactor `json:"errors"`
+	// EOSETimeouts is a penalty for a connected relay whose recent queries
+	// timed out without EOSE. Its Score is the percentage of such queries
+	// and its Weight is negative, so its Contribution lowers the score.
+	EOSETimeouts HealthFactor `json:"eose_timeouts"`
+}
+
 // MonitoringData represents aggregated monitoring data for all relays.
 type MonitoringData struct {
 	Relays           []RelayHealth     `json:"relays"`
@@ -205,18 +386,38 @@ type MonitoringData struct {
 	Timestamp        int64             `json:"timestamp"`
 }
 
+// KindRate is the event rate history for a single event kind, as seen on
+// live subscriptions.
+type KindRate struct {
+	Kind         int               `json:"kind"`
+	TotalEvents  int64             `json:"total_events"`
+	EventsPerSec float64           `json:"events_per_sec"`
+	RateHistory  []TimeSeriesPoint `json:"rate_history,omitempty"`
+}
+
+// KindMonitoringData reports per-kind event rates, busiest kind first. At
+// most MaxKinds kinds are tracked; events of further kinds are only counted
+// in UntrackedEvents.
+type KindMonitoringData struct {
+	Kinds           []KindRate `json:"kinds"`
+	MaxKinds        int        `json:"max_kinds"`
+	UntrackedEvents int64      `json:"untracked_events"`
+	Timestamp       int64      `json:"timestamp"`
+}
+
 // RelayHealthSummary represents a lightweight health summary for a relay
 // without time-series history data.
 type RelayHealthSummary struct {
-	URL          string  `json:"url"`
-	Connected    bool    `json:"connected"`
-	Latency      int64   `json:"latency_ms"`
-	EventsPerSec float64 `json:"events_per_sec"`
-	Uptime       float64 `json:"uptime_percent"`
-	HealthScore  float64 `json:"health_score"`
-	LastSeen     int64   `json:"last_seen"`
-	ErrorCount   int     `json:"error_count"`
-	LastError    string  `json:"last_error,omitempty"`
+	URL           string        `json:"url"`
+	Connected     bool          `json:"connected"`
+	Latency       int64         `json:"latency_ms"`
+	EventsPerSec  float64       `json:"events_per_sec"`
+	Uptime        float64       `json:"uptime_percent"`
+	HealthScore   float64       `json:"health_score"`
+	HealthFactors HealthFactors `json:"health_factors"`
+	LastSeen      int64         `json:"last_seen"`
+	ErrorCount    int           `json:"error_count"`
+	LastError     string        `json:"last_error,omitempty"`
 }
 
 // HealthSummary represents a lightweight health summary for all relays.
@@ -230,10 +431,13 @@ type HealthSummary struct {
 }
 
 // ThreadEvent represents an event in a thread with its position/depth info.
+// Orphaned is set when the event's chain of parents couldn't be fetched back
+// to the root, so its depth is unknown and reported as 0.
 type ThreadEvent struct {
 	Event
 	Depth      int    `json:"depth"`
 	IsRoot     bool   `json:"is_root"`
+	Orphaned   bool   `json:"orphaned,omitempty"`
 	ParentID   string `json:"parent_id,omitempty"`
 	RootID     string `json:"root_id,omitempty"`
 	ReplyCount int    `json:"reply_count"`
@@ -246,6 +450,27 @@ type Thread struct {
 	TotalSize int           `json:"total_size"`
 	MaxDepth  int           `json:"max_depth"`
 	TargetID  string        `json:"target_id"`
+	// Participants lists the distinct authors of the thread's events.
+	// Only the pubkey is set unless profile resolution was requested.
+	Participants []Profile `json:"participants,omitempty"`
+	// MoreReplies is set when a relay returned a full page of replies, so
+	// older replies may be available by passing NextUntil as until.
+	MoreReplies bool  `json:"more_replies,omitempty"`
+	NextUntil   int64 `json:"next_until,omitempty"`
+}
+
+// ThreadSummary is a lightweight view of a thread: the root event and how
+// many events were found at each depth, without the reply bodies.
+// DepthCounts[0] is 1 when the root was fetched; DepthCounts[1] counts direct
+// replies to the root, and so on. Orphaned events are counted separately.
+type ThreadSummary struct {
+	RootEvent   *ThreadEvent `json:"root_event,omitempty"`
+	TargetID    string       `json:"target_id"`
+	TotalSize   int          `json:"total_size"`
+	MaxDepth    int          `json:"max_depth"`
+	DepthCounts []int        `json:"depth_counts"`
+	Orphaned    int          `json:"orphaned,omitempty"`
+	MoreReplies bool         `json:"more_replies,omitempty"`
 }
 
 // PublishResult represents the result of publishing an event to a relay.
@@ -253,6 +478,10 @@ type PublishResult struct {
 	URL     string `json:"url"`
 	Success bool   `json:"success"`
 	Error   string `json:"error,omitempty"`
+	// Reason is the message from the relay's NIP-01 OK response when it
+	// rejected the event, such as "blocked: pubkey not allowed".
+	Reason   string `json:"reason,omitempty"`
+	Attempts int    `json:"attempts,omitempty"` // Publish attempts made, including retries
 }
 
 // PublishResponse represents the response from publishing an event.
@@ -286,6 +515,13 @@ type RelayFetchTiming struct {
 	Error        string `json:"error,omitempty"`
 	Connected    bool   `json:"connected"`
 	FirstEventMs int64  `json:"first_event_ms,omitempty"` // Time to first event (0 if no events)
+	ClampedLimit int    `json:"clamped_limit,omitempty"`  // Limit actually sent when lowered to the relay's max_limit
+	// AuthPerformed is set when the relay demanded NIP-42 AUTH before serving
+	// events and the query authenticated to read them.
+	AuthPerformed bool `json:"auth_performed,omitempty"`
+	// EOSEReceived is set when the relay signalled the end of stored events
+	// before the query timed out.
+	EOSEReceived bool `json:"eose_received"`
 }
 
 // EventsQueryResponse represents the response from querying events with timing data.
@@ -293,6 +529,68 @@ type EventsQueryResponse struct {
 	Events       []Event            `json:"events"`
 	RelayTimings []RelayFetchTiming `json:"relay_timings"`
 	TotalTimeMs  int64              `json:"total_time_ms"`
+	Warnings     []string           `json:"warnings,omitempty"` // Relays that failed while others succeeded
+}
+
+// RelayTestResult is the outcome of probing a relay without adding it to the pool.
+type RelayTestResult struct {
+	URL       string     `json:"url"`
+	Reachable bool       `json:"reachable"`
+	LatencyMs int64      `json:"latency_ms"` // WebSocket handshake time
+	Info      *RelayInfo `json:"info,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	InfoError string     `json:"info_error,omitempty"`
+}
+
+// RelayInfoChange is a single NIP-11 field that differs between two fetches.
+// Field is a dotted path such as "limitation.min_pow_difficulty". Type is
+// "added", "removed" or "changed"; Old and New hold the JSON values.
+type RelayInfoChange struct {
+	Field string      `json:"field"`
+	Type  string      `json:"type"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+// RelayInfoDiff compares a freshly fetched NIP-11 document with the one seen
+// before it. HasPrevious is false when no earlier document was stored, in
+// which case Changes is empty. Error is set when the fetch failed.
+type RelayInfoDiff struct {
+	URL         string            `json:"url"`
+	HasPrevious bool              `json:"has_previous"`
+	Previous    *RelayInfo        `json:"previous,omitempty"`
+	Current     *RelayInfo        `json:"current,omitempty"`
+	Changes     []RelayInfoChange `json:"changes"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// RelayCapabilities reports whether a relay serves reads and accepts writes.
+// Writes are only probed when a test key is configured.
+type RelayCapabilities struct {
+	URL         string `json:"url"`
+	CanRead     bool   `json:"can_read"`
+	CanWrite    bool   `json:"can_write"`
+	WriteTested bool   `json:"write_tested"`
+	ReadError   string `json:"read_error,omitempty"`
+	WriteError  string `json:"write_error,omitempty"`
+	Error       string `json:"error,omitempty"` // Set when the relay could not be reached
+}
+
+// RelayEventCount is a single relay's contribution to an event count.
+// Approximate is set when the relay does not support NIP-45 and the count
+// was obtained by fetching events up to the query limit.
+type RelayEventCount struct {
+	Count       int64  `json:"count"`
+	Approximate bool   `json:"approximate"`
+	Error       string `json:"error,omitempty"`
+}
+
+// EventCountResponse represents the response from counting events across relays.
+type EventCountResponse struct {
+	Count       int64                      `json:"count"`
+	Approximate bool                       `json:"approximate"`
+	PerRelay    map[string]RelayEventCount `json:"per_relay"`
+	TotalTimeMs int64                      `json:"total_time_ms"`
 }
 
 // BatchEventResult represents the result of fetching a single event in a batch query.
@@ -325,13 +623,20 @@ type EventAggregation struct {
 	EarliestEvent int64                 `json:"earliest_event"`
 	LatestEvent   int64                 `json:"latest_event"`
 	TotalTimeMs   int64                 `json:"total_time_ms"`
+	// FileStats is only set when the result contains NIP-94 file metadata
+	// (kind 1063) events.
+	FileStats *FileStats `json:"file_stats,omitempty"`
+	// Propagation is only set when the events were queried relay by relay,
+	// so it is known which relays returned each one.
+	Propagation *PropagationStats `json:"propagation,omitempty"`
 }
 
 // KindCount represents event count per kind.
 type KindCount struct {
-	Kind  int    `json:"kind"`
-	Count int    `json:"count"`
-	Label string `json:"label"`
+	Kind     int    `json:"kind"`
+	Count    int    `json:"count"`
+	Label    string `json:"label"`
+	Category string `json:"category"`
 }
 
 // AuthorCount represents event count per author.
@@ -358,6 +663,47 @@ type TimeBucket struct {
 	Count     int   `json:"count"`
 }
 
+// PropagationStats summarizes how widely events are replicated: how many of
+// the queried relays returned each one.
+type PropagationStats struct {
+	RelaysQueried int `json:"relays_queried"`
+	MaxRelays     int `json:"max_relays"`
+	// Histogram has one bucket per relay count seen, ascending.
+	Histogram []PropagationBucket `json:"histogram"`
+}
+
+// PropagationBucket is the number of events returned by exactly Relays relays.
+type PropagationBucket struct {
+	Relays int `json:"relays"`
+	Events int `json:"events"`
+}
+
+// FileStats summarizes NIP-94 file metadata events.
+type FileStats struct {
+	TotalFiles int `json:"total_files"`
+	// TotalBytes sums the sizes of the files that declare one; UnknownSize
+	// counts those that don't.
+	TotalBytes    int64        `json:"total_bytes"`
+	UnknownSize   int          `json:"unknown_size"`
+	MimeTypes     []MimeCount  `json:"mime_types"`
+	SizeHistogram []SizeBucket `json:"size_histogram"`
+}
+
+// MimeCount is the number of files with a given MIME type.
+type MimeCount struct {
+	MimeType string `json:"mime_type"`
+	Count    int    `json:"count"`
+}
+
+// SizeBucket counts files whose size is at least MinBytes and below MaxBytes.
+// MaxBytes is zero for the last, unbounded bucket.
+type SizeBucket struct {
+	Label    string `json:"label"`
+	MinBytes int64  `json:"min_bytes"`
+	MaxBytes int64  `json:"max_bytes,omitempty"`
+	Count    int    `json:"count"`
+}
+
 // ContentStats represents statistics about event content.
 type ContentStats struct {
 	AvgLength  int `json:"avg_length"`
@@ -1059,6 +1059,86 @@ func TestRelayInfoWithoutFees(t *testing.T) {
 	}
 }
 
+func TestRelayInfoPolicyFieldsJSONSerialization(t *testing.T) {
+	hour := int64(3600)
+	zero := int64(0)
+	count := 1000
+	relayInfo := RelayInfo{
+		Name: "Policy Relay",
+		Retention: []RelayRetention{
+			{Kinds: []int{0, 1}, KindRanges: [][2]int{{40, 49}}, Time: &hour},
+			{KindRanges: [][2]int{{30000, 39999}}, Count: &count},
+			{Kinds: []int{4}, Time: &zero},
+			{}, // no time limit
+		},
+		RelayCountries: []string{"CA", "US"},
+		LanguageTags:   []string{"en", "en-419"},
+		PostingPolicy:  "https://relay.example.com/policy.html",
+	}
+
+	data, err := json.Marshal(relayInfo)
+	if err != nil {
+		t.Fatalf("failed to marshal RelayInfo: %v", err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("failed to unmarshal to map: %v", err)
+	}
+	for _, field := range []string{"retention", "relay_countries", "language_tags", "posting_policy"} {
+		if _, exists := m[field]; !exists {
+			t.Errorf("expected %s in JSON", field)
+		}
+	}
+
+	retention := m["retention"].([]interface{})
+	notStored := retention[2].(map[string]interface{})
+	if notStored["time"] != float64(0) {
+		t.Errorf("a zero retention time should be kept, got %v", notStored["time"])
+	}
+	if _, exists := retention[3].(map[string]interface{})["time"]; exists {
+		t.Error("an unlimited retention time should be omitted")
+	}
+
+	var decoded RelayInfo
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal RelayInfo: %v", err)
+	}
+	if len(decoded.Retention) != 4 {
+		t.Fatalf("expected 4 retention entries, got %d", len(decoded.Retention))
+	}
+	first := decoded.Retention[0]
+	if len(first.Kinds) != 2 || first.KindRanges[0] != [2]int{40, 49} || *first.Time != 3600 {
+		t.Errorf("unexpected first retention entry: %+v", first)
+	}
+	if decoded.Retention[1].Count == nil || *decoded.Retention[1].Count != 1000 {
+		t.Errorf("expected count 1000, got %+v", decoded.Retention[1])
+	}
+	if decoded.PostingPolicy != relayInfo.PostingPolicy {
+		t.Errorf("PostingPolicy mismatch: got %s, want %s", decoded.PostingPolicy, relayInfo.PostingPolicy)
+	}
+	if len(decoded.RelayCountries) != 2 || len(decoded.LanguageTags) != 2 {
+		t.Errorf("expected countries and language tags to round-trip, got %v %v", decoded.RelayCountries, decoded.LanguageTags)
+	}
+}
+
+func TestRelayInfoPolicyFieldsOmitEmpty(t *testing.T) {
+	data, err := json.Marshal(RelayInfo{Name: "Plain Relay"})
+	if err != nil {
+		t.Fatalf("failed to marshal RelayInfo: %v", err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("failed to unmarshal to map: %v", err)
+	}
+	for _, field := range []string{"retention", "relay_countries", "language_tags", "posting_policy"} {
+		if _, exists := m[field]; exists {
+			t.Errorf("empty %s should be omitted", field)
+		}
+	}
+}
+
 func TestRelayLimitationRestrictedWrites(t *testing.T) {
 	limitation := RelayLimitation{
 		PaymentRequired:  true,
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/keanuklestil/shirushi/internal/nak"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// HandleAddressableEvent resolves a NIP-33 addressable event, such as a
+// long-form article (kind 30023) or app data (kind 30078), to its latest
+// version.
+// Query params:
+// - kind: event kind, 30000-39999 (required)
+// - pubkey: author as hex or npub (required)
+// - d: the event's d tag identifier (required, may be empty)
+func (a *API) HandleAddressableEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+
+	kind, err := strconv.Atoi(query.Get("kind"))
+	if err != nil || types.ClassifyKind(kind) != types.KindClassAddressable {
+		writeError(w, http.StatusBadRequest, "kind must be an addressable kind (30000-39999)")
+		return
+	}
+
+	if query.Get("pubkey") == "" {
+		writeError(w, http.StatusBadRequest, "pubkey query parameter required")
+		return
+	}
+	pubkey, status, err := a.resolvePubkey(query.Get("pubkey"))
+	if err != nil {
+		writeErrorCode(w, status, pubkeyErrorCode(status), err.Error())
+		return
+	}
+	pubkey = strings.ToLower(pubkey)
+
+	if !query.Has("d") {
+		writeError(w, http.StatusBadRequest, "d query parameter required")
+		return
+	}
+
+	event, err := a.fetchAddressable(kind, pubkey, query.Get("d"), nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query event: "+err.Error())
+		return
+	}
+	if event == nil {
+		writeErrorCode(w, http.StatusNotFound, codeEventNotFound, "event not found")
+		return
+	}
+
+	writeJSON(w, event)
+}
+
+// lookupNaddr resolves an naddr to the latest version of the addressable
+// event it points to, using its relay hints. The naddr is decoded with nak
+// when available and natively otherwise.
+func (a *API) lookupNaddr(w http.ResponseWriter, naddr string, includeRaw bool) {
+	var decoded *nak.Decoded
+	var err error
+	if a.nak != nil {
+		decoded, err = a.nak.Decode(naddr)
+	} else {
+		decoded, err = decodeNIP19Native(naddr)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to decode naddr: %v", err))
+		return
+	}
+	if decoded.Type != "naddr" || len(decoded.Pubkey) != 64 || types.ClassifyKind(decoded.Kind) != types.KindClassAddressable {
+		writeError(w, http.StatusBadRequest, "naddr does not point to an addressable event")
+		return
+	}
+
+	event, err := a.fetchAddressable(decoded.Kind, strings.ToLower(decoded.Pubkey), decoded.Identifier, decoded.Relays)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to query event: %v", err))
+		return
+	}
+	if event == nil {
+		writeErrorCode(w, http.StatusNotFound, codeEventNotFound, "event not found")
+		return
+	}
+
+	if includeRaw {
+		events := []types.Event{*event}
+		a.attachRawEvents(events)
+		event = &events[0]
+	}
+	writeJSON(w, event)
+}
+
+// fetchAddressable queries the connected relays, plus any relay hints, for
+// the addressable event identified by kind, pubkey and d tag, and returns its
+// latest version. Returns nil if no version was found.
+func (a *API) fetchAddressable(kind int, pubkey, identifier string, relays []string) (*types.Event, error) {
+	tags := map[string][]string{"d": {identifier}}
+	events, err := a.relayPool.QueryEventsFromRelays(relays, nil, []int{kind}, []string{pubkey}, tags, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	// Relays don't always apply the d tag filter exactly, so check it here
+	matched := make([]types.Event, 0, len(events))
+	for _, event := range events {
+		if event.Kind == kind && event.PubKey == pubkey && dTag(event) == identifier {
+			matched = append(matched, event)
+		}
+	}
+	return types.LatestReplaceable(matched), nil
+}
+
+// dTag returns the value of an event's first d tag, or "" if it has none.
+func dTag(event types.Event) string {
+	for _, tag := range event.Tags {
+		if len(tag) >= 2 && tag[0] == "d" {
+			return tag[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/nak"
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+func TestHandleAddressableEvent_ReturnsLatest(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			{ID: "new", Kind: 30023, PubKey: decodeAuthor, CreatedAt: 1700005000, Tags: [][]string{{"d", "my-article"}}},
+			{ID: "old", Kind: 30023, PubKey: decodeAuthor, CreatedAt: 1700000000, Tags: [][]string{{"d", "my-article"}}},
+			{ID: "other", Kind: 30023, PubKey: decodeAuthor, CreatedAt: 1700009000, Tags: [][]string{{"d", "other-article"}}},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/addr?kind=30023&pubkey="+decodeAuthor+"&d=my-article", nil)
+	w := httptest.NewRecorder()
+	api.HandleAddressableEvent(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var event types.Event
+	if err := json.NewDecoder(w.Body).Decode(&event); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if event.ID != "new" {
+		t.Errorf("expected latest version 'new', got %s", event.ID)
+	}
+	if d := pool.lastTags["d"]; len(d) != 1 || d[0] != "my-article" {
+		t.Errorf("expected d tag filter my-article, got %v", pool.lastTags)
+	}
+	if len(pool.lastAuthors) != 1 || pool.lastAuthors[0] != decodeAuthor {
+		t.Errorf("expected author filter %s, got %v", decodeAuthor, pool.lastAuthors)
+	}
+}
+
+func TestHandleAddressableEvent_EmptyIdentifier(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			{ID: "tagged", Kind: 30078, PubKey: decodeAuthor, CreatedAt: 1700005000, Tags: [][]string{{"d", "app"}}},
+			{ID: "untagged", Kind: 30078, PubKey: decodeAuthor, CreatedAt: 1700000000},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/addr?kind=30078&pubkey="+decodeAuthor+"&d=", nil)
+	w := httptest.NewRecorder()
+	api.HandleAddressableEvent(w, req)
+
+	var event types.Event
+	if err := json.NewDecoder(w.Body).Decode(&event); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if event.ID != "untagged" {
+		t.Errorf("expected event without d tag, got %s", event.ID)
+	}
+}
+
+func TestHandleAddressableEvent_NotFound(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/addr?kind=30023&pubkey="+decodeAuthor+"&d=missing", nil)
+	w := httptest.NewRecorder()
+	api.HandleAddressableEvent(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleAddressableEvent_InvalidParams(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{"missing kind", "pubkey=" + decodeAuthor + "&d=x"},
+		{"non-numeric kind", "kind=abc&pubkey=" + decodeAuthor + "&d=x"},
+		{"regular kind", "kind=1&pubkey=" + decodeAuthor + "&d=x"},
+		{"replaceable kind", "kind=10002&pubkey=" + decodeAuthor + "&d=x"},
+		{"kind above range", "kind=40000&pubkey=" + decodeAuthor + "&d=x"},
+		{"missing pubkey", "kind=30023&d=x"},
+		{"invalid pubkey", "kind=30023&pubkey=abc&d=x"},
+		{"missing d", "kind=30023&pubkey=" + decodeAuthor},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/events/addr?"+tc.query, nil)
+			w := httptest.NewRecorder()
+			api.HandleAddressableEvent(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandleAddressableEvent_MethodNotAllowed(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/events/addr", nil)
+	w := httptest.NewRecorder()
+	api.HandleAddressableEvent(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestHandleEventLookup_Naddr(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			{ID: "old", Kind: 30023, PubKey: decodeAuthor, CreatedAt: 1700000000, Tags: [][]string{{"d", "my-article"}}},
+			{ID: "new", Kind: 30023, PubKey: decodeAuthor, CreatedAt: 1700005000, Tags: [][]string{{"d", "my-article"}, {"title", "My Article"}}},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	naddr, _ := nip19.EncodeEntity(decodeAuthor, 30023, "my-article", []string{"wss://hint.example.com"})
+	req := httptest.NewRequest(http.MethodGet, "/api/events/lookup?id="+naddr, nil)
+	w := httptest.NewRecorder()
+	api.HandleEventLookup(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var event types.Event
+	if err := json.NewDecoder(w.Body).Decode(&event); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if event.ID != "new" {
+		t.Errorf("expected latest version 'new', got %s", event.ID)
+	}
+	if len(pool.lastHintRelays) != 1 || pool.lastHintRelays[0] != "wss://hint.example.com" {
+		t.Errorf("expected relay hints to be used, got %v", pool.lastHintRelays)
+	}
+	if d := pool.lastTags["d"]; len(d) != 1 || d[0] != "my-article" {
+		t.Errorf("expected d tag filter my-article, got %v", pool.lastTags)
+	}
+}
+
+func TestHandleEventLookup_NaddrWithNak(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			{ID: "article", Kind: 30023, PubKey: decodeAuthor, CreatedAt: 1700000000, Tags: [][]string{{"d", "from-nak"}}},
+		},
+	}
+	nakClient := &mockNakClient{
+		decoded: &nak.Decoded{Type: "naddr", Pubkey: decodeAuthor, Kind: 30023, Identifier: "from-nak"},
+	}
+	api := NewAPI(&config.Config{}, nakClient, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/lookup?id=naddr1xyz", nil)
+	w := httptest.NewRecorder()
+	api.HandleEventLookup(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if d := pool.lastTags["d"]; len(d) != 1 || d[0] != "from-nak" {
+		t.Errorf("expected identifier decoded by nak, got %v", pool.lastTags)
+	}
+}
+
+func TestHandleEventLookup_NaddrNotFound(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	naddr, _ := nip19.EncodeEntity(decodeAuthor, 30023, "missing", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/events/lookup?id="+naddr, nil)
+	w := httptest.NewRecorder()
+	api.HandleEventLookup(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleEventLookup_InvalidNaddr(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/lookup?id=naddr1invalid", nil)
+	w := httptest.NewRecorder()
+	api.HandleEventLookup(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
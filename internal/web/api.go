@@ -4,23 +4,28 @@ package web
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/logging"
 	"github.com/keanuklestil/shirushi/internal/nak"
 	"github.com/keanuklestil/shirushi/internal/types"
 )
 
 // RelayPool defines the interface for relay pool operations
 type RelayPool interface {
-	Add(url string) error
+	Add(url string) (bool, error)
 	Remove(url string)
+	Reconnect(url string) (*types.RelayStatus, error)
 	List() []types.RelayStatus
 	Stats() map[string]types.RelayStats
 	Count() int
@@ -28,24 +33,45 @@ type RelayPool interface {
 	QueryEvents(kindStr, author, limitStr string) ([]types.Event, error)
 	QueryEventsWithTiming(kindStr, author, limitStr string) (*types.EventsQueryResponse, error)
 	QueryEventsAdvanced(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) ([]types.Event, error)
+	QueryEventsAdvancedPartial(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) ([]types.Event, error)
 	QueryEventsAdvancedWithTiming(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) (*types.EventsQueryResponse, error)
+	QueryEventsOnRelaysWithTiming(relayURLs []string, kinds []int, authors []string, tags map[string][]string, limit int, since, until int64) (*types.EventsQueryResponse, error)
+	QueryEventsGlobalLimitWithTiming(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) (*types.EventsQueryResponse, error)
 	QueryEventsByIDs(ids []string) ([]types.Event, error)
+	QueryEventsByIDsWithConflicts(ids []string) ([]types.Event, []types.EventConflict, error)
+	QueryEventsFromRelays(relayURLs []string, ids []string, kinds []int, authors []string, tags map[string][]string, limit int) ([]types.Event, error)
+	QueryEventFromHints(eventID string, hints []string) *types.Event
+	RawEvents(relayURL string, ids []string) map[string]json.RawMessage
 	QueryBatchEventsByIDs(ids []string) *types.BatchQueryResponse
-	QueryEventReplies(eventID string) ([]types.Event, error)
+	QueryEventReplies(eventID string, limit int, until int64, selectedRelays ...string) ([]types.Event, bool, error)
 	QueryEventFromAllRelays(eventID string) *types.EventFetchAllRelaysResponse
 	AggregateEvents(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) (*types.EventAggregation, error)
+	CountEvents(kinds []int, authors []string, tags map[string][]string, limit int, since, until int64, selectedRelays ...string) (*types.EventCountResponse, error)
 	Subscribe(kinds []int, authors []string, callback func(types.Event)) string
+	Unsubscribe(subID string) bool
 	MonitoringData() *types.MonitoringData
+	KindRates() *types.KindMonitoringData
 	GetRelayInfo(url string) *types.RelayInfo
+	ProbeRelay(url string) (*types.RelayTestResult, error)
+	ProbeCapabilities(url string) (*types.RelayCapabilities, error)
 	RefreshRelayInfo(url string) error
+	DiffRelayInfo(url string) (*types.RelayInfoDiff, error)
 	SetStatusCallback(callback func(url string, connected bool, err string))
 	SetOnRelayInfo(callback func(url string, info *types.RelayInfo))
+	SetOnRelayPruned(callback func(pruned types.PrunedRelay))
+	PrunedRelays() []types.PrunedRelay
+	StateHistory(url string) (*types.RelayStateHistory, error)
+	DiscoveredRelays() []types.DiscoveredRelay
+	Diagnostics() *types.PoolDiagnostics
 	PublishEventJSON(eventJSON []byte, relayURLs []string) (string, []types.PublishResult)
+	PublishEventJSONWithRetries(eventJSON []byte, relayURLs []string, retries int) (string, []types.PublishResult)
 }
 
 // TestRunner defines the interface for running NIP tests
 type TestRunner interface {
 	RunTest(ctx context.Context, nipID string, params map[string]interface{}) (*types.TestResult, error)
+	RunTestStreaming(ctx context.Context, nipID string, params map[string]interface{}, onStep func(types.TestStep)) (*types.TestResult, error)
+	DescribeTests() []types.TestDescriptor
 }
 
 // NakClient defines the interface for nak CLI operations
@@ -56,6 +82,7 @@ type NakClient interface {
 	CreateEvent(opts nak.CreateEventOptions) (*nak.Event, error)
 	Verify(eventJSON string) (bool, error)
 	Run(args ...string) (string, error)
+	Version() (string, error)
 }
 
 // API handles REST API requests.
@@ -67,6 +94,12 @@ type API struct {
 	hub              *Hub
 	testHistory      []types.TestHistoryEntry
 	testHistoryMutex sync.RWMutex
+	relayGroups      *config.RelayGroupStore
+	nakLimiter       *tokenBucket // nil when /api/nak is not rate limited
+	imageProxy       *imageProxy  // nil when the image proxy is disabled
+	httpMetrics      *httpMetrics
+	testRuns         atomic.Int64
+	startedAt        time.Time
 }
 
 // NewAPI creates a new API handler.
@@ -74,12 +107,32 @@ type API struct {
 const maxTestHistoryEntries = 100
 
 func NewAPI(cfg *config.Config, nakClient NakClient, relayPool RelayPool, testRunner TestRunner) *API {
+	relayGroups, err := config.NewRelayGroupStore(cfg.RelayGroupsFile)
+	if err != nil {
+		logging.Warnf("[Web] Failed to load relay groups: %v", err)
+	}
+
+	var nakLimiter *tokenBucket
+	if cfg.NakRateLimit > 0 {
+		nakLimiter = newTokenBucket(cfg.NakRateLimit)
+	}
+
+	var proxy *imageProxy
+	if cfg.ImageProxy {
+		proxy = newImageProxy(cfg.ImageProxyMaxBytes)
+	}
+
 	return &API{
 		cfg:         cfg,
 		nak:         nakClient,
 		relayPool:   relayPool,
 		testRunner:  testRunner,
 		testHistory: make([]types.TestHistoryEntry, 0),
+		relayGroups: relayGroups,
+		nakLimiter:  nakLimiter,
+		imageProxy:  proxy,
+		httpMetrics: newHTTPMetrics(),
+		startedAt:   time.Now(),
 	}
 }
 
@@ -111,19 +164,27 @@ func (a *API) HandleRelays(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, relays)
 
 	case http.MethodPost:
+		if !requireJSON(w, r, false) {
+			return
+		}
 		var req struct {
 			URL string `json:"url"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+			writeBodyError(w, err, "invalid request body")
 			return
 		}
 		if req.URL == "" {
 			writeError(w, http.StatusBadRequest, "url is required")
 			return
 		}
-		if err := a.relayPool.Add(req.URL); err != nil {
-			writeError(w, http.StatusInternalServerError, err.Error())
+		added, err := a.relayPool.Add(req.URL)
+		if err != nil {
+			writeRelayURLError(w, err)
+			return
+		}
+		if !added {
+			writeJSON(w, map[string]string{"status": "already_present", "url": req.URL})
 			return
 		}
 		writeJSON(w, map[string]string{"status": "added", "url": req.URL})
@@ -180,15 +241,16 @@ func (a *API) HandleMonitoringHealth(w http.ResponseWriter, r *http.Request) {
 	relayHealthSummaries := make([]types.RelayHealthSummary, len(data.Relays))
 	for i, relay := range data.Relays {
 		relayHealthSummaries[i] = types.RelayHealthSummary{
-			URL:          relay.URL,
-			Connected:    relay.Connected,
-			Latency:      relay.Latency,
-			EventsPerSec: relay.EventsPerSec,
-			Uptime:       relay.Uptime,
-			HealthScore:  relay.HealthScore,
-			LastSeen:     relay.LastSeen,
-			ErrorCount:   relay.ErrorCount,
-			LastError:    relay.LastError,
+			URL:           relay.URL,
+			Connected:     relay.Connected,
+			Latency:       relay.Latency,
+			EventsPerSec:  relay.EventsPerSec,
+			Uptime:        relay.Uptime,
+			HealthScore:   relay.HealthScore,
+			HealthFactors: relay.HealthFactors,
+			LastSeen:      relay.LastSeen,
+			ErrorCount:    relay.ErrorCount,
+			LastError:     relay.LastError,
 		}
 	}
 
@@ -204,6 +266,16 @@ func (a *API) HandleMonitoringHealth(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, summary)
 }
 
+// HandleMonitoringKinds returns the event rate history for each event kind
+// seen on live subscriptions, busiest kind first.
+func (a *API) HandleMonitoringKinds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, a.relayPool.KindRates())
+}
+
 // HandleRelayPresets returns available relay presets.
 func (a *API) HandleRelayPresets(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -213,6 +285,48 @@ func (a *API) HandleRelayPresets(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, config.RelayPresets)
 }
 
+// HandleRelayGroups manages user-defined relay groups.
+// GET lists saved groups, POST creates or replaces a group from {"name": ..., "relays": [...]},
+// and DELETE removes the group given by the name query parameter.
+func (a *API) HandleRelayGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, a.relayGroups.List())
+
+	case http.MethodPost:
+		var req config.RelayGroup
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeBodyError(w, err, "invalid request body")
+			return
+		}
+		if err := a.relayGroups.Set(req.Name, req.Relays); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, map[string]string{"status": "saved", "name": strings.TrimSpace(req.Name)})
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			writeError(w, http.StatusBadRequest, "name query parameter required")
+			return
+		}
+		found, err := a.relayGroups.Delete(name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "group not found")
+			return
+		}
+		writeJSON(w, map[string]string{"status": "deleted", "name": name})
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
 // HandleRelayInfo returns NIP-11 info for a specific relay.
 // Path: /api/relays/info?url=wss://...
 func (a *API) HandleRelayInfo(w http.ResponseWriter, r *http.Request) {
@@ -241,7 +355,213 @@ func (a *API) HandleRelayInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, info)
+	if r.Method == http.MethodPost {
+		writeJSON(w, info)
+		return
+	}
+	writeCachedJSON(w, r, info, a.cfg.HTTPCacheMaxAge)
+}
+
+// HandleRelayInfoDiff re-fetches a relay's NIP-11 info and returns the
+// fields that changed since the previous fetch, so policy changes such as a
+// relay becoming paid stand out.
+// Path: /api/relays/info/diff?url=wss://...
+func (a *API) HandleRelayInfoDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		writeError(w, http.StatusBadRequest, "url query parameter required")
+		return
+	}
+
+	diff, err := a.relayPool.DiffRelayInfo(url)
+	if err != nil {
+		writeErrorCode(w, http.StatusBadRequest, codeInvalidRelayURL, err.Error())
+		return
+	}
+
+	writeJSON(w, diff)
+}
+
+// HandleRelayReconnect drops and re-dials a relay already in the pool,
+// keeping its monitoring history, and returns its new status.
+// Path: POST /api/relays/reconnect?url=wss://...
+func (a *API) HandleRelayReconnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		writeError(w, http.StatusBadRequest, "url query parameter required")
+		return
+	}
+	if _, err := config.NormalizeRelayURL(url); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, codeInvalidRelayURL, err.Error())
+		return
+	}
+
+	status, err := a.relayPool.Reconnect(url)
+	if err != nil {
+		writeErrorCode(w, http.StatusNotFound, codeRelayNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, status)
+}
+
+// HandleRelaysPruned lists relays the dead-relay pruner removed from the pool,
+// oldest first, with their final stats.
+func (a *API) HandleRelaysPruned(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, a.relayPool.PrunedRelays())
+}
+
+// HandleRelayHistory returns a relay's recent connection state changes,
+// oldest first, to help diagnose flapping relays.
+// Path: GET /api/relays/history?url=wss://...
+func (a *API) HandleRelayHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		writeError(w, http.StatusBadRequest, "url query parameter required")
+		return
+	}
+	if _, err := config.NormalizeRelayURL(url); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, codeInvalidRelayURL, err.Error())
+		return
+	}
+
+	history, err := a.relayPool.StateHistory(url)
+	if err != nil {
+		writeErrorCode(w, http.StatusNotFound, codeRelayNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, history)
+}
+
+// HandleRelaysDiscovered lists relays hinted in the tags of queried events
+// that are not in the pool, most hinted first. Nothing is connected to.
+func (a *API) HandleRelaysDiscovered(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, a.relayPool.DiscoveredRelays())
+}
+
+// HandleRelayTest probes a relay without adding it to the pool, reporting
+// whether it is reachable, its handshake latency and its NIP-11 info.
+// Path: /api/relays/test?url=wss://...
+func (a *API) HandleRelayTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		writeError(w, http.StatusBadRequest, "url query parameter required")
+		return
+	}
+
+	result, err := a.relayPool.ProbeRelay(url)
+	if err != nil {
+		writeRelayURLError(w, err)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// HandleRelayCapabilities checks whether a relay serves reads and accepts
+// writes. Writes are only probed when a probe test key is configured.
+// Path: /api/relays/capabilities?url=wss://...
+func (a *API) HandleRelayCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		writeError(w, http.StatusBadRequest, "url query parameter required")
+		return
+	}
+
+	caps, err := a.relayPool.ProbeCapabilities(url)
+	if err != nil {
+		writeRelayURLError(w, err)
+		return
+	}
+
+	writeJSON(w, caps)
+}
+
+// HandleRelayEvents returns events authored by a relay's operator pubkey,
+// as declared in the relay's NIP-11 info. Requires a url query param and
+// accepts the same filter params as HandleEvents (authors is ignored).
+func (a *API) HandleRelayEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		writeError(w, http.StatusBadRequest, "url query parameter required")
+		return
+	}
+	url, err := config.NormalizeRelayURL(url)
+	if err != nil {
+		writeRelayURLError(w, err)
+		return
+	}
+
+	info := a.relayPool.GetRelayInfo(url)
+	if info == nil {
+		writeError(w, http.StatusNotFound, "relay info not available")
+		return
+	}
+	if info.PubKey == "" {
+		writeError(w, http.StatusNotFound, "relay does not declare a pubkey")
+		return
+	}
+
+	params, err := a.parseEventQueryParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params.Authors = []string{info.PubKey}
+
+	events, err := a.relayPool.QueryEventsAdvanced(params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until, params.Relays...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if events == nil {
+		events = []types.Event{}
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"relay":  url,
+		"pubkey": info.PubKey,
+		"events": events,
+	})
 }
 
 // EventQueryParams holds the parsed query parameters for event queries.
@@ -253,18 +573,194 @@ type EventQueryParams struct {
 	Since   int64
 	Until   int64
 	Relays  []string
+
+	// GlobalLimit applies Limit to the merged result instead of to each relay
+	GlobalLimit bool
+
+	// PerRelayLimit returns everything each relay sent for Limit, so the
+	// merged result may hold up to Limit events per relay. Otherwise the
+	// merged result is trimmed to the newest Limit events after filtering.
+	PerRelayLimit bool
+
+	// LatestPerAuthor collapses the result to the newest event per pubkey
+	LatestPerAuthor bool
+
+	// Outbox queries the single author's NIP-65 write relays instead of the pool
+	Outbox bool
+
+	// RequireNIP restricts the query to connected relays whose NIP-11 info
+	// lists this NIP; zero means no restriction
+	RequireNIP int
+
+	// Warnings are notes about the request itself, such as asking stored
+	// queries for ephemeral kinds, returned alongside relay warnings
+	Warnings []string
+
+	// IncludeRaw attaches each event's wire JSON under "raw"
+	IncludeRaw bool
+
+	// RequireAllTags keeps only events carrying every requested tag value,
+	// instead of any of them
+	RequireAllTags bool
+
+	// MuteAuthors and MuteWords drop events by these pubkeys or whose content
+	// contains any of these words (case-insensitive)
+	MuteAuthors []string
+	MuteWords   []string
+}
+
+// postFilter applies the client-side filters relays cannot express: mutes
+// first, then require_all_tags, then latest_per_author. Unless the limit is
+// per relay, the result is then trimmed to the newest Limit events.
+func (params *EventQueryParams) postFilter(events []types.Event) []types.Event {
+	if len(params.MuteAuthors) > 0 || len(params.MuteWords) > 0 {
+		events = filterMuted(events, params.MuteAuthors, params.MuteWords)
+	}
+	if params.RequireAllTags {
+		events = filterAllTags(events, params.Tags)
+	}
+	if params.LatestPerAuthor {
+		events = latestPerAuthor(events, params.Limit)
+	} else if !params.PerRelayLimit && !params.GlobalLimit {
+		events = newestEvents(events, params.Limit)
+	}
+	return events
+}
+
+// applyPostFilters applies params' client-side filters and, when include_raw was
+// asked for, attaches each remaining event's raw JSON.
+func (a *API) applyPostFilters(params *EventQueryParams, events []types.Event) []types.Event {
+	events = params.postFilter(events)
+	if params.IncludeRaw {
+		a.attachRawEvents(events)
+	}
+	return events
+}
+
+// attachRawEvents sets each event's raw field to its JSON exactly as the
+// relay that served it sends it. The pool keeps no message bytes, so events
+// are fetched again by ID, one request per relay in parallel; events a relay
+// no longer returns are left without raw.
+func (a *API) attachRawEvents(events []types.Event) {
+	byRelay := make(map[string][]string)
+	for _, event := range events {
+		if event.Relay != "" {
+			byRelay[event.Relay] = append(byRelay[event.Relay], event.ID)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	raw := make(map[string]map[string]json.RawMessage, len(byRelay))
+	for url, ids := range byRelay {
+		wg.Add(1)
+		go func(url string, ids []string) {
+			defer wg.Done()
+			fetched := a.relayPool.RawEvents(url, ids)
+			mu.Lock()
+			raw[url] = fetched
+			mu.Unlock()
+		}(url, ids)
+	}
+	wg.Wait()
+
+	for i := range events {
+		events[i].Raw = raw[events[i].Relay][events[i].ID]
+	}
+}
+
+// filterMuted drops events by any of the muted authors or whose content
+// contains any of the muted words, ignoring case.
+func filterMuted(events []types.Event, authors, words []string) []types.Event {
+	muted := make(map[string]bool, len(authors))
+	for _, author := range authors {
+		muted[strings.ToLower(author)] = true
+	}
+	lowerWords := make([]string, 0, len(words))
+	for _, word := range words {
+		lowerWords = append(lowerWords, strings.ToLower(word))
+	}
+
+	filtered := make([]types.Event, 0, len(events))
+	for _, event := range events {
+		if muted[strings.ToLower(event.PubKey)] {
+			continue
+		}
+		content := strings.ToLower(event.Content)
+		mutedWord := false
+		for _, word := range lowerWords {
+			if strings.Contains(content, word) {
+				mutedWord = true
+				break
+			}
+		}
+		if !mutedWord {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// filterAllTags keeps the events that have every value in tags. Relay filters
+// OR values of the same tag, so "#t:nostr,#t:bitcoin" matches either; this
+// narrows the result to events tagged with both.
+func filterAllTags(events []types.Event, tags map[string][]string) []types.Event {
+	filtered := make([]types.Event, 0, len(events))
+	for _, event := range events {
+		have := make(map[string]bool, len(event.Tags))
+		for _, tag := range event.Tags {
+			if len(tag) >= 2 {
+				have[tag[0]+":"+tag[1]] = true
+			}
+		}
+
+		matches := true
+		for name, values := range tags {
+			for _, value := range values {
+				if !have[name+":"+value] {
+					matches = false
+					break
+				}
+			}
+			if !matches {
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// PartialEventsResponse replaces the plain event array returned by HandleEvents
+// when some relays failed while others succeeded.
+type PartialEventsResponse struct {
+	Events   []types.Event `json:"events"`
+	Warnings []string      `json:"warnings"`
 }
 
 // HandleEvents handles event queries.
+// Without timing, the response is an array of events, or a PartialEventsResponse
+// with warnings when some of the queried relays failed or ephemeral kinds were
+// requested.
 // Accepts optional query params:
 // - kinds: comma-separated list of event kinds (e.g., "1,7,30023")
 // - authors: comma-separated list of pubkeys (hex or npub format)
 // - tags: comma-separated tag filters in format "#tagname:value" (e.g., "#e:abc123,#t:nostr")
-// - limit: max number of events to return (default 20, max 500)
-// - since: Unix timestamp for events created after this time
-// - until: Unix timestamp for events created before this time
+// - limit: max number of events to return (default DefaultQueryLimit or the per-kind default, capped at MaxQueryLimit)
+// - since: Unix timestamp, or signed duration relative to now (e.g. "-24h"), for events created after this time
+// - until: Unix timestamp, or signed duration relative to now (e.g. "-1h"), for events created before this time
 // - timing: if "true", returns per-relay timing data
 // - relays: comma-separated list of relay URLs to query from (only connected relays are used)
+// - group: name of a saved relay group whose relays are added to the relays list
+// - limit_scope: "newest" (default) trims the merged result to the newest limit events; "relay" limits each relay only; "global" limits the merged result, interleaving relays round-robin
+// - latest_per_author: if "true", keeps only the newest event per author; limit applies to the collapsed set
+// - outbox: if "true", queries the single author's NIP-65 write relays and returns an OutboxEventsResponse
+// - require_nip: only query connected relays whose NIP-11 info lists this NIP (combined with relays/group if given)
+// - require_all_tags: if "true", keeps only events with every tag value; filtered after fetching, so fewer than limit may be returned
+// - mute_authors: comma-separated pubkeys (hex or npub) whose events are dropped after fetching
+// - mute_words: comma-separated words; events whose content contains any of them (case-insensitive) are dropped after fetching
 func (a *API) HandleEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -278,30 +774,181 @@ func (a *API) HandleEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if params.Outbox {
+		if params.RequireNIP > 0 {
+			writeError(w, http.StatusBadRequest, "require_nip cannot be combined with outbox")
+			return
+		}
+		a.handleOutboxEvents(w, params)
+		return
+	}
+
+	if params.RequireNIP > 0 {
+		relays := a.relaysSupportingNIP(params.RequireNIP, params.Relays)
+		if len(relays) == 0 {
+			writeErrorCode(w, http.StatusBadRequest, codeNoSupportingRelays, fmt.Sprintf("no connected relay supports NIP-%02d", params.RequireNIP))
+			return
+		}
+		params.Relays = relays
+	}
+
 	includeTiming := r.URL.Query().Get("timing") == "true"
 
+	if params.GlobalLimit {
+		response, err := a.relayPool.QueryEventsGlobalLimitWithTiming(params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until, params.Relays...)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		response.Events = a.applyPostFilters(params, response.Events)
+		response.Warnings = append(params.Warnings, response.Warnings...)
+		switch {
+		case includeTiming:
+			writeJSON(w, response)
+		case len(response.Warnings) > 0:
+			writeJSON(w, PartialEventsResponse{Events: response.Events, Warnings: response.Warnings})
+		default:
+			writeJSON(w, response.Events)
+		}
+		return
+	}
+
 	if includeTiming {
 		response, err := a.relayPool.QueryEventsAdvancedWithTiming(params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until, params.Relays...)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+		response.Events = a.applyPostFilters(params, response.Events)
+		response.Warnings = append(params.Warnings, response.Warnings...)
 		writeJSON(w, response)
 		return
 	}
 
-	events, err := a.relayPool.QueryEventsAdvanced(params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until, params.Relays...)
+	events, err := a.relayPool.QueryEventsAdvancedPartial(params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until, params.Relays...)
+	events = a.applyPostFilters(params, events)
+	var partial *types.PartialError
+	if errors.As(err, &partial) {
+		writeJSON(w, PartialEventsResponse{Events: events, Warnings: append(params.Warnings, partial.Warnings()...)})
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if len(params.Warnings) > 0 {
+		writeJSON(w, PartialEventsResponse{Events: events, Warnings: params.Warnings})
+		return
+	}
 	writeJSON(w, events)
 }
 
+// ephemeralKindsWarning returns a warning naming the requested ephemeral
+// kinds (20000-29999), or "" if there are none. Relays don't store ephemeral
+// events, so stored queries never return them; they only arrive live.
+func ephemeralKindsWarning(kinds []int) string {
+	var ephemeral []string
+	for _, kind := range kinds {
+		if types.ClassifyKind(kind) == types.KindClassEphemeral {
+			ephemeral = append(ephemeral, strconv.Itoa(kind))
+		}
+	}
+	if len(ephemeral) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("kind %s is ephemeral and not stored by relays, so this query will not return it; use /api/events/stream or /api/events/subscribe for live events", strings.Join(ephemeral, ", "))
+}
+
+// relaysSupportingNIP returns the connected relays whose cached NIP-11 info
+// lists nip. If selected is non-empty, only relays in it are considered.
+func (a *API) relaysSupportingNIP(nip int, selected []string) []string {
+	var allowed map[string]bool
+	if len(selected) > 0 {
+		allowed = make(map[string]bool, len(selected))
+		for _, url := range selected {
+			if normalized, err := config.NormalizeRelayURL(url); err == nil {
+				allowed[normalized] = true
+			}
+		}
+	}
+
+	var relays []string
+	for _, relay := range a.relayPool.List() {
+		if !relay.Connected || (allowed != nil && !allowed[relay.URL]) {
+			continue
+		}
+		for _, supported := range relay.SupportedNIPs {
+			if supported == nip {
+				relays = append(relays, relay.URL)
+				break
+			}
+		}
+	}
+	sort.Strings(relays)
+	return relays
+}
+
+// latestPerAuthor keeps only the newest event per pubkey, ordered newest first
+// and truncated to limit (no truncation if limit <= 0).
+func latestPerAuthor(events []types.Event, limit int) []types.Event {
+	newest := make(map[string]types.Event)
+	for _, event := range events {
+		if current, ok := newest[event.PubKey]; !ok || event.CreatedAt > current.CreatedAt {
+			newest[event.PubKey] = event
+		}
+	}
+
+	collapsed := make([]types.Event, 0, len(newest))
+	for _, event := range newest {
+		collapsed = append(collapsed, event)
+	}
+	sort.Slice(collapsed, func(i, j int) bool {
+		if collapsed[i].CreatedAt != collapsed[j].CreatedAt {
+			return collapsed[i].CreatedAt > collapsed[j].CreatedAt
+		}
+		return collapsed[i].PubKey < collapsed[j].PubKey
+	})
+
+	if limit > 0 && len(collapsed) > limit {
+		collapsed = collapsed[:limit]
+	}
+	return collapsed
+}
+
+// newestEvents sorts events newest first and keeps at most limit of them, so
+// a query across several relays returns limit events in total rather than
+// limit per relay. Events created at the same second keep their order.
+func newestEvents(events []types.Event, limit int) []types.Event {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt > events[j].CreatedAt
+	})
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
+
+// parseTimeParam parses a since/until value. Plain integers are absolute Unix
+// timestamps; values starting with a sign are Go durations relative to now,
+// so "-24h" means 24 hours ago.
+func parseTimeParam(value string, now time.Time) (int64, error) {
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ts, nil
+	}
+	if !strings.HasPrefix(value, "-") && !strings.HasPrefix(value, "+") {
+		return 0, fmt.Errorf("invalid timestamp: %s", value)
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	return now.Add(d).Unix(), nil
+}
+
 // parseEventQueryParams parses the query parameters for event queries.
 func (a *API) parseEventQueryParams(r *http.Request) (*EventQueryParams, error) {
 	params := &EventQueryParams{
-		Limit: 20, // default
+		Limit: a.defaultQueryLimit(),
 	}
 
 	// Parse kinds (comma-separated)
@@ -324,35 +971,30 @@ func (a *API) parseEventQueryParams(r *http.Request) (*EventQueryParams, error)
 		}
 	}
 
+	if warning := ephemeralKindsWarning(params.Kinds); warning != "" {
+		params.Warnings = append(params.Warnings, warning)
+	}
+
 	// Parse authors (comma-separated, can be hex or npub)
 	authorsStr := r.URL.Query().Get("authors")
 	if authorsStr == "" {
 		// Fallback to legacy "author" parameter for backwards compatibility
 		authorsStr = r.URL.Query().Get("author")
 	}
-	if authorsStr != "" {
-		authorStrs := strings.Split(authorsStr, ",")
-		for _, as := range authorStrs {
-			as = strings.TrimSpace(as)
-			if as == "" {
-				continue
-			}
-			// Decode npub/nprofile to hex if needed
-			if strings.HasPrefix(as, "npub") || strings.HasPrefix(as, "nprofile") {
-				if a.nak == nil {
-					return nil, fmt.Errorf("nak CLI not available for decoding npub")
-				}
-				decoded, err := a.nak.Decode(as)
-				if err != nil {
-					return nil, fmt.Errorf("invalid author pubkey: %s", as)
-				}
-				if decoded.Pubkey != "" {
-					as = decoded.Pubkey
-				} else if decoded.Hex != "" {
-					as = decoded.Hex
-				}
-			}
-			params.Authors = append(params.Authors, as)
+	authors, err := a.parsePubkeyList(authorsStr)
+	if err != nil {
+		return nil, err
+	}
+	params.Authors = authors
+
+	// Parse muted authors and words, applied after fetching
+	params.MuteAuthors, err = a.parsePubkeyList(r.URL.Query().Get("mute_authors"))
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range strings.Split(r.URL.Query().Get("mute_words"), ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			params.MuteWords = append(params.MuteWords, word)
 		}
 	}
 
@@ -389,26 +1031,28 @@ func (a *API) parseEventQueryParams(r *http.Request) (*EventQueryParams, error)
 		if limit < 1 {
 			limit = 1
 		}
-		if limit > 500 {
-			limit = 500
+		if maxLimit := a.maxQueryLimit(); limit > maxLimit {
+			limit = maxLimit
 		}
 		params.Limit = limit
+	} else if kindLimit, ok := a.kindDefaultLimit(params.Kinds); ok {
+		params.Limit = kindLimit
 	}
 
-	// Parse since (Unix timestamp)
+	// Parse since (Unix timestamp or relative duration)
 	sinceStr := r.URL.Query().Get("since")
 	if sinceStr != "" {
-		since, err := strconv.ParseInt(sinceStr, 10, 64)
+		since, err := parseTimeParam(sinceStr, time.Now())
 		if err != nil {
 			return nil, fmt.Errorf("invalid since value: %s", sinceStr)
 		}
 		params.Since = since
 	}
 
-	// Parse until (Unix timestamp)
+	// Parse until (Unix timestamp or relative duration)
 	untilStr := r.URL.Query().Get("until")
 	if untilStr != "" {
-		until, err := strconv.ParseInt(untilStr, 10, 64)
+		until, err := parseTimeParam(untilStr, time.Now())
 		if err != nil {
 			return nil, fmt.Errorf("invalid until value: %s", untilStr)
 		}
@@ -427,18 +1071,125 @@ func (a *API) parseEventQueryParams(r *http.Request) (*EventQueryParams, error)
 		}
 	}
 
+	// Expand a saved relay group into its relay URLs
+	if group := strings.TrimSpace(r.URL.Query().Get("group")); group != "" {
+		groupRelays, ok := a.relayGroups.Get(group)
+		if !ok {
+			return nil, fmt.Errorf("unknown relay group: %s", group)
+		}
+		params.Relays = append(params.Relays, groupRelays...)
+	}
+
+	if nipStr := r.URL.Query().Get("require_nip"); nipStr != "" {
+		nip, err := strconv.Atoi(nipStr)
+		if err != nil || nip < 1 {
+			return nil, fmt.Errorf("invalid require_nip value: %s", nipStr)
+		}
+		params.RequireNIP = nip
+	}
+
+	params.LatestPerAuthor = r.URL.Query().Get("latest_per_author") == "true"
+	params.Outbox = r.URL.Query().Get("outbox") == "true"
+	params.RequireAllTags = r.URL.Query().Get("require_all_tags") == "true"
+	params.IncludeRaw = r.URL.Query().Get("include_raw") == "true"
+
+	// Parse limit scope
+	switch scope := r.URL.Query().Get("limit_scope"); scope {
+	case "", "newest":
+	case "relay":
+		params.PerRelayLimit = true
+	case "global":
+		params.GlobalLimit = true
+	default:
+		return nil, fmt.Errorf("invalid limit_scope value: %s", scope)
+	}
+
 	return params, nil
 }
 
+// parsePubkeyList splits a comma-separated list of pubkeys, decoding any
+// npub/nprofile entries to hex.
+func (a *API) parsePubkeyList(value string) ([]string, error) {
+	var pubkeys []string
+	for _, pk := range strings.Split(value, ",") {
+		pk = strings.TrimSpace(pk)
+		if pk == "" {
+			continue
+		}
+		// Decode npub/nprofile to hex if needed
+		if strings.HasPrefix(pk, "npub") || strings.HasPrefix(pk, "nprofile") {
+			if a.nak == nil {
+				return nil, fmt.Errorf("nak CLI not available for decoding npub")
+			}
+			decoded, err := a.nak.Decode(pk)
+			if err != nil {
+				return nil, fmt.Errorf("invalid author pubkey: %s", pk)
+			}
+			if decoded.Pubkey != "" {
+				pk = decoded.Pubkey
+			} else if decoded.Hex != "" {
+				pk = decoded.Hex
+			}
+		}
+		pubkeys = append(pubkeys, pk)
+	}
+	return pubkeys, nil
+}
+
+// kindDefaultLimit returns the configured default limit for a single-kind query.
+// Limit precedence is: explicit limit param > per-kind default > global default.
+func (a *API) kindDefaultLimit(kinds []int) (int, bool) {
+	if len(kinds) != 1 || a.cfg.KindQueryLimits == nil {
+		return 0, false
+	}
+	limit, ok := a.cfg.KindQueryLimits[kinds[0]]
+	if !ok {
+		return 0, false
+	}
+	if maxLimit := a.maxQueryLimit(); limit > maxLimit {
+		limit = maxLimit
+	}
+	return limit, true
+}
+
+// Fallback query limits, used when the config leaves them unset.
+const (
+	fallbackQueryLimit    = 20
+	fallbackMaxQueryLimit = 500
+)
+
+// defaultQueryLimit returns the configured limit for queries that don't set
+// one, capped at maxQueryLimit.
+func (a *API) defaultQueryLimit() int {
+	limit := fallbackQueryLimit
+	if a.cfg.DefaultQueryLimit > 0 {
+		limit = a.cfg.DefaultQueryLimit
+	}
+	if maxLimit := a.maxQueryLimit(); limit > maxLimit {
+		limit = maxLimit
+	}
+	return limit
+}
+
+// maxQueryLimit returns the configured cap on query limits.
+func (a *API) maxQueryLimit() int {
+	if a.cfg.MaxQueryLimit > 0 {
+		return a.cfg.MaxQueryLimit
+	}
+	return fallbackMaxQueryLimit
+}
+
 // HandleEventsAggregate queries events and returns aggregated statistics.
 // Accepts the same query params as HandleEvents:
 // - kinds: comma-separated list of event kinds
 // - authors: comma-separated list of pubkeys
 // - tags: comma-separated tag filters in format "#tagname:value"
-// - limit: max number of events to aggregate (default 100, max 500)
-// - since: Unix timestamp for events created after this time
-// - until: Unix timestamp for events created before this time
+// - limit: max number of events to aggregate (default 100, capped at MaxQueryLimit)
+// - since: Unix timestamp or signed relative duration (e.g. "-24h")
+// - until: Unix timestamp or signed relative duration (e.g. "-1h")
 // - relays: comma-separated list of relay URLs to query from
+// When the result includes kind 1063 file metadata events, the response also
+// has a file_stats section with MIME type and size distributions.
 func (a *API) HandleEventsAggregate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -452,11 +1203,11 @@ func (a *API) HandleEventsAggregate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Default limit for aggregation is higher (100 instead of 20)
-	if params.Limit == 20 {
-		limitStr := r.URL.Query().Get("limit")
-		if limitStr == "" {
-			params.Limit = 100
+	// Default limit for aggregation is higher (100 instead of the query
+	// default), unless a per-kind default already applied
+	if r.URL.Query().Get("limit") == "" {
+		if _, ok := a.kindDefaultLimit(params.Kinds); !ok {
+			params.Limit = min(100, a.maxQueryLimit())
 		}
 	}
 
@@ -470,6 +1221,31 @@ func (a *API) HandleEventsAggregate(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, aggregation)
 }
 
+// HandleEventsCount counts events matching the query.
+// Accepts the same query params as HandleEvents. Relays supporting NIP-45 report
+// an exact count; other relays are counted by fetching events up to the limit,
+// in which case the result is marked approximate.
+func (a *API) HandleEventsCount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	params, err := a.parseEventQueryParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := a.relayPool.CountEvents(params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until, params.Relays...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, result)
+}
+
 // HandleEventSubscribe handles event subscription management.
 // Accepts an optional JSON body with kinds and authors filters.
 // If body is empty or missing, defaults to empty filters (subscribes to all events).
@@ -479,6 +1255,9 @@ func (a *API) HandleEventSubscribe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !requireJSON(w, r, true) {
+		return
+	}
 	var req struct {
 		Kinds   []int    `json:"kinds"`
 		Authors []string `json:"authors"`
@@ -487,7 +1266,7 @@ func (a *API) HandleEventSubscribe(w http.ResponseWriter, r *http.Request) {
 	// Read the body to check if it's empty
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "failed to read request body")
+		writeBodyError(w, err, "failed to read request body")
 		return
 	}
 
@@ -498,16 +1277,88 @@ func (a *API) HandleEventSubscribe(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	// If body is empty, req stays with zero values (empty slices)
+	// If body is empty, req stays with zero values (empty slices)
+
+	// Start subscription
+	subID := a.relayPool.Subscribe(req.Kinds, req.Authors, func(event types.Event) {
+		if a.hub != nil {
+			a.hub.BroadcastEvent(event)
+		}
+	})
+
+	writeJSON(w, map[string]string{"subscription_id": subID})
+}
+
+// streamQueryParams are the query params HandleEventStream can filter by; the
+// live subscription supports no other filters.
+var streamQueryParams = map[string]bool{"kinds": true, "kind": true, "authors": true}
+
+// HandleEventStream streams live events as Server-Sent Events.
+// Accepts optional kinds and authors query params (same format as HandleEvents);
+// any other param is rejected rather than silently ignored.
+// Each event is sent as a JSON "data:" line; the subscription is closed when
+// the client disconnects. Events are dropped if the client can't keep up.
+func (a *API) HandleEventStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	unsupported := []string{}
+	for name := range r.URL.Query() {
+		if !streamQueryParams[name] {
+			unsupported = append(unsupported, name)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported query parameter(s) for the live stream: %s (only kinds and authors are supported)", strings.Join(unsupported, ", ")))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	params, err := a.parseEventQueryParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// Start subscription
-	subID := a.relayPool.Subscribe(req.Kinds, req.Authors, func(event types.Event) {
-		if a.hub != nil {
-			a.hub.BroadcastEvent(event)
+	events := make(chan types.Event, 64)
+	subID := a.relayPool.Subscribe(params.Kinds, params.Authors, func(event types.Event) {
+		select {
+		case events <- event:
+		default:
 		}
 	})
-
-	writeJSON(w, map[string]string{"subscription_id": subID})
+	defer a.relayPool.Unsubscribe(subID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, ": subscribed %s\n\n", subID)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
 }
 
 // HandleNIPs returns the list of supported NIPs.
@@ -519,6 +1370,29 @@ func (a *API) HandleNIPs(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, GetNIPList())
 }
 
+// HandleKinds returns all known event kinds and their labels, sorted by kind.
+func (a *API) HandleKinds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, types.KnownKinds())
+}
+
+// HandleTestList returns the available NIP tests and the parameters each
+// accepts, so the UI can render input forms.
+func (a *API) HandleTestList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if a.testRunner == nil {
+		writeJSON(w, []types.TestDescriptor{})
+		return
+	}
+	writeJSON(w, a.testRunner.DescribeTests())
+}
+
 // HandleTest handles NIP test execution.
 func (a *API) HandleTest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -537,12 +1411,18 @@ func (a *API) HandleTest(w http.ResponseWriter, r *http.Request) {
 	// Parse test parameters from body
 	var params map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && err.Error() != "EOF" {
-		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		writeBodyError(w, err, "invalid JSON body: "+err.Error())
 		return
 	}
 
-	// Run test
-	result, err := a.testRunner.RunTest(r.Context(), "nip"+nipID, params)
+	// Run test, broadcasting each step as it completes
+	var onStep func(types.TestStep)
+	if a.hub != nil {
+		onStep = func(step types.TestStep) {
+			a.hub.BroadcastTestStep("nip"+nipID, step)
+		}
+	}
+	result, err := a.testRunner.RunTestStreaming(r.Context(), "nip"+nipID, params, onStep)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -550,6 +1430,7 @@ func (a *API) HandleTest(w http.ResponseWriter, r *http.Request) {
 
 	// Store in history
 	entry := a.addTestHistory(*result)
+	a.testRuns.Add(1)
 
 	// Broadcast result
 	if a.hub != nil {
@@ -672,7 +1553,7 @@ func (a *API) HandleKeyGenerate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if a.nak == nil {
-		writeError(w, http.StatusServiceUnavailable, "nak CLI not available")
+		writeErrorCode(w, http.StatusServiceUnavailable, codeNakUnavailable, "nak CLI not available")
 		return
 	}
 
@@ -693,15 +1574,18 @@ func (a *API) HandleKeyDecode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if a.nak == nil {
-		writeError(w, http.StatusServiceUnavailable, "nak CLI not available")
+		writeErrorCode(w, http.StatusServiceUnavailable, codeNakUnavailable, "nak CLI not available")
 		return
 	}
 
+	if !requireJSON(w, r, false) {
+		return
+	}
 	var req struct {
 		Input string `json:"input"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err, "invalid request body")
 		return
 	}
 
@@ -722,16 +1606,19 @@ func (a *API) HandleKeyEncode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if a.nak == nil {
-		writeError(w, http.StatusServiceUnavailable, "nak CLI not available")
+		writeErrorCode(w, http.StatusServiceUnavailable, codeNakUnavailable, "nak CLI not available")
 		return
 	}
 
+	if !requireJSON(w, r, false) {
+		return
+	}
 	var req struct {
 		Type string `json:"type"` // npub, nsec, note, etc.
 		Hex  string `json:"hex"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err, "invalid request body")
 		return
 	}
 
@@ -745,6 +1632,8 @@ func (a *API) HandleKeyEncode(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleNak executes a raw nak command.
+// Requests are rate limited per cfg.NakRateLimit, and when
+// cfg.NakAllowedCommands is set only those subcommands may be run.
 func (a *API) HandleNak(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -752,15 +1641,29 @@ func (a *API) HandleNak(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if a.nak == nil {
-		writeError(w, http.StatusServiceUnavailable, "nak CLI not available")
+		writeErrorCode(w, http.StatusServiceUnavailable, codeNakUnavailable, "nak CLI not available")
 		return
 	}
 
+	if a.nakLimiter != nil {
+		if ok, wait := a.nakLimiter.Allow(); !ok {
+			seconds := int((wait + time.Second - 1) / time.Second)
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+	}
+
 	var req struct {
 		Args []string `json:"args"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err, "invalid request body")
+		return
+	}
+
+	if !a.nakCommandAllowed(req.Args) {
+		writeErrorCode(w, http.StatusForbidden, codeNakCommandNotAllowed, "nak command not allowed")
 		return
 	}
 
@@ -773,6 +1676,23 @@ func (a *API) HandleNak(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]string{"output": output})
 }
 
+// nakCommandAllowed reports whether args start with an allowed nak
+// subcommand. Every command is allowed when no allowlist is configured.
+func (a *API) nakCommandAllowed(args []string) bool {
+	if len(a.cfg.NakAllowedCommands) == 0 {
+		return true
+	}
+	if len(args) == 0 {
+		return false
+	}
+	for _, cmd := range a.cfg.NakAllowedCommands {
+		if args[0] == cmd {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleProfile looks up a Nostr profile by pubkey from URL path.
 // Path: /api/profile/{pubkey}
 func (a *API) HandleProfile(w http.ResponseWriter, r *http.Request) {
@@ -781,17 +1701,34 @@ func (a *API) HandleProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract pubkey from URL path: /api/profile/{pubkey}
+	// Extract pubkey from URL path: /api/profile/{pubkey}[/{resource}]
 	path := strings.TrimPrefix(r.URL.Path, "/api/profile/")
-	pubkey := strings.TrimSpace(path)
+	parts := strings.SplitN(path, "/", 2)
+	pubkey := strings.TrimSpace(parts[0])
 
 	if pubkey == "" {
 		writeError(w, http.StatusBadRequest, "pubkey is required in path")
 		return
 	}
 
+	if len(parts) == 2 && parts[1] != "" {
+		switch parts[1] {
+		case "zaps":
+			a.HandleProfileZaps(w, r)
+		case "relays":
+			a.HandleProfileRelays(w, r)
+		case "feed":
+			a.HandleProfileFeed(w, r)
+		case "history":
+			a.HandleProfileHistory(w, r)
+		default:
+			writeError(w, http.StatusNotFound, "unknown profile resource: "+parts[1])
+		}
+		return
+	}
+
 	// Delegate to the common profile lookup logic
-	a.lookupProfile(w, pubkey)
+	a.lookupProfile(w, r, pubkey)
 }
 
 // HandleProfileLookup looks up a Nostr profile by pubkey or NIP-19 identifier.
@@ -809,21 +1746,51 @@ func (a *API) HandleProfileLookup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delegate to the common profile lookup logic
-	a.lookupProfile(w, pubkey)
+	a.lookupProfile(w, r, pubkey)
 }
 
 // lookupProfile is the shared logic for looking up a profile by pubkey.
-func (a *API) lookupProfile(w http.ResponseWriter, pubkey string) {
+func (a *API) lookupProfile(w http.ResponseWriter, r *http.Request, pubkey string) {
+	pubkey, status, err := a.resolvePubkey(pubkey)
+	if err != nil {
+		writeErrorCode(w, status, pubkeyErrorCode(status), err.Error())
+		return
+	}
+
+	// Query kind 0 (profile metadata) events for this pubkey
+	events, err := a.relayPool.QueryEvents("0", pubkey, "1")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query profile: "+err.Error())
+		return
+	}
+
+	latest := types.LatestReplaceable(events)
+	if latest == nil {
+		writeErrorCode(w, http.StatusNotFound, codeProfileNotFound, "profile not found")
+		return
+	}
+
+	profile := parseProfileMetadata(pubkey, *latest)
+
+	// Verify NIP-05 if present
+	if profile.NIP05 != "" {
+		profile.NIP05Valid = verifyNIP05(profile.NIP05, pubkey)
+	}
+
+	writeCachedJSON(w, r, profile, a.cfg.HTTPCacheMaxAge)
+}
+
+// resolvePubkey converts an npub/nprofile or hex pubkey to a validated hex pubkey.
+// On failure it returns the HTTP status code to respond with.
+func (a *API) resolvePubkey(pubkey string) (string, int, error) {
 	// If input starts with "npub" or "nprofile", decode it first
 	if strings.HasPrefix(pubkey, "npub") || strings.HasPrefix(pubkey, "nprofile") {
 		if a.nak == nil {
-			writeError(w, http.StatusServiceUnavailable, "nak CLI not available for NIP-19 decoding")
-			return
+			return "", http.StatusServiceUnavailable, fmt.Errorf("nak CLI not available for NIP-19 decoding")
 		}
 		decoded, err := a.nak.Decode(pubkey)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid NIP-19 identifier: "+err.Error())
-			return
+			return "", http.StatusBadRequest, fmt.Errorf("invalid NIP-19 identifier: %s", err.Error())
 		}
 		// Extract hex pubkey from decoded result
 		if decoded.Pubkey != "" {
@@ -831,37 +1798,25 @@ func (a *API) lookupProfile(w http.ResponseWriter, pubkey string) {
 		} else if decoded.Hex != "" {
 			pubkey = decoded.Hex
 		} else {
-			writeError(w, http.StatusBadRequest, "could not extract pubkey from NIP-19 identifier")
-			return
+			return "", http.StatusBadRequest, fmt.Errorf("could not extract pubkey from NIP-19 identifier")
 		}
 	}
 
 	// Validate pubkey format (should be 64 hex characters)
 	if len(pubkey) != 64 {
-		writeError(w, http.StatusBadRequest, "pubkey must be a 64-character hex string")
-		return
+		return "", http.StatusBadRequest, fmt.Errorf("pubkey must be a 64-character hex string")
 	}
 	for _, c := range pubkey {
 		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
-			writeError(w, http.StatusBadRequest, "pubkey must be a valid hex string")
-			return
+			return "", http.StatusBadRequest, fmt.Errorf("pubkey must be a valid hex string")
 		}
 	}
+	return pubkey, http.StatusOK, nil
+}
 
-	// Query kind 0 (profile metadata) events for this pubkey
-	events, err := a.relayPool.QueryEvents("0", pubkey, "1")
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to query profile: "+err.Error())
-		return
-	}
-
-	if len(events) == 0 {
-		writeError(w, http.StatusNotFound, "profile not found")
-		return
-	}
-
-	// Parse profile metadata from event content
-	event := events[0]
+// parseProfileMetadata builds a Profile from a kind 0 metadata event.
+// Content that isn't valid JSON yields a profile with only the pubkey and timestamp set.
+func parseProfileMetadata(pubkey string, event types.Event) types.Profile {
 	profile := types.Profile{
 		PubKey:    pubkey,
 		CreatedAt: event.CreatedAt,
@@ -889,77 +1844,61 @@ func (a *API) lookupProfile(w http.ResponseWriter, pubkey string) {
 			profile.Website = website
 		}
 		if nip05, ok := metadata["nip05"].(string); ok {
-			profile.NIP05 = nip05
+			profile.NIP05 = displayNIP05(nip05)
 		}
 		if lud16, ok := metadata["lud16"].(string); ok {
 			profile.LUD16 = lud16
 		}
 	}
 
-	// Verify NIP-05 if present
-	if profile.NIP05 != "" {
-		profile.NIP05Valid = verifyNIP05(profile.NIP05, pubkey)
-	}
-
-	writeJSON(w, profile)
+	return profile
 }
 
-// verifyNIP05 verifies a NIP-05 identifier against an expected pubkey.
-// It fetches the .well-known/nostr.json file and checks if the name maps to the expected pubkey.
-func verifyNIP05(address, expectedPubkey string) bool {
-	// Parse address (user@domain)
-	parts := strings.Split(address, "@")
-	if len(parts) != 2 {
-		return false
+// splitNIP05 parses a NIP-05 address (user@domain) into its name and domain.
+// A bare domain is the root identifier and is treated as "_@domain".
+func splitNIP05(address string) (name, domain string, ok bool) {
+	if !strings.Contains(address, "@") {
+		// Bare domains must at least look like a hostname
+		if !strings.Contains(address, ".") || strings.ContainsAny(address, "/ ") {
+			return "", "", false
+		}
+		return "_", address, true
 	}
-	name := parts[0]
-	domain := parts[1]
 
-	// Build URL
-	url := fmt.Sprintf("https://%s/.well-known/nostr.json?name=%s", domain, name)
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+	parts := strings.Split(address, "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
 	}
+	return parts[0], parts[1], true
+}
 
-	// Fetch nostr.json
-	req, err := http.NewRequestWithContext(context.Background(), "GET", url, nil)
-	if err != nil {
-		return false
+// displayNIP05 returns the form of a NIP-05 address shown to users.
+// The root identifier "_@domain" is displayed as just "domain".
+func displayNIP05(address string) string {
+	name, domain, ok := splitNIP05(address)
+	if !ok {
+		return address
 	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return false
+	if name == "_" {
+		return domain
 	}
-	defer resp.Body.Close()
+	return address
+}
 
-	if resp.StatusCode != http.StatusOK {
+// verifyNIP05 verifies a NIP-05 identifier against an expected pubkey.
+// The address may be a bare domain, which verifies against the "_" name.
+// It fetches the .well-known/nostr.json file and checks if the name maps to the expected pubkey.
+func verifyNIP05(address, expectedPubkey string) bool {
+	name, domain, ok := splitNIP05(address)
+	if !ok {
 		return false
 	}
 
-	// Read and parse response
-	body, err := io.ReadAll(resp.Body)
+	names, err := fetchNIP05Names(context.Background(), domain, name)
 	if err != nil {
 		return false
 	}
-
-	var nip05Data struct {
-		Names map[string]string `json:"names"`
-	}
-	if err := json.Unmarshal(body, &nip05Data); err != nil {
-		return false
-	}
-
-	// Check if the name maps to the expected pubkey
-	pubkey, exists := nip05Data.Names[name]
-	if !exists {
-		return false
-	}
-
-	// Compare pubkeys (case-insensitive hex comparison)
-	return strings.EqualFold(pubkey, expectedPubkey)
+	return nip05Matches(names, name, expectedPubkey)
 }
 
 // HandleEventSign signs an event with a provided private key.
@@ -970,10 +1909,13 @@ func (a *API) HandleEventSign(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if a.nak == nil {
-		writeError(w, http.StatusServiceUnavailable, "nak CLI not available")
+		writeErrorCode(w, http.StatusServiceUnavailable, codeNakUnavailable, "nak CLI not available")
 		return
 	}
 
+	if !requireJSON(w, r, false) {
+		return
+	}
 	var req struct {
 		Kind       int        `json:"kind"`
 		Content    string     `json:"content"`
@@ -981,7 +1923,7 @@ func (a *API) HandleEventSign(w http.ResponseWriter, r *http.Request) {
 		PrivateKey string     `json:"privateKey"` // nsec format
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err, "invalid request body")
 		return
 	}
 
@@ -1012,13 +1954,13 @@ func (a *API) HandleEventVerify(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if a.nak == nil {
-		writeError(w, http.StatusServiceUnavailable, "nak CLI not available")
+		writeErrorCode(w, http.StatusServiceUnavailable, codeNakUnavailable, "nak CLI not available")
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "failed to read request body")
+		writeBodyError(w, err, "failed to read request body")
 		return
 	}
 
@@ -1031,7 +1973,14 @@ func (a *API) HandleEventVerify(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]interface{}{"valid": valid})
 }
 
-// HandleEventLookup looks up an event by its ID (hex or note1.../nevent1... format).
+// HandleEventLookup looks up an event by its ID (hex or note1.../nevent1... format),
+// or resolves an naddr1... pointer to the latest version of an addressable event.
+// When an nevent's event isn't on the connected relays, its relay hints are
+// tried before giving up; the event's relay field names the relay that served it.
+// Events looked up by ID are marked deleted when their author has published a
+// NIP-09 deletion request for them. If relays return differing events under
+// the requested ID, the conflicts are reported alongside a warning. With
+// include_raw=true the event's wire JSON is attached under "raw".
 func (a *API) HandleEventLookup(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -1046,46 +1995,74 @@ func (a *API) HandleEventLookup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	eventID = strings.TrimSpace(eventID)
+	includeRaw := r.URL.Query().Get("include_raw") == "true"
+
+	if strings.HasPrefix(eventID, "naddr1") {
+		a.lookupNaddr(w, eventID, includeRaw)
+		return
+	}
 
 	// If input is note1... or nevent1..., decode it to hex
+	var hints []string
 	if strings.HasPrefix(eventID, "note1") || strings.HasPrefix(eventID, "nevent1") {
 		if a.nak == nil {
-			writeError(w, http.StatusServiceUnavailable, "nak CLI not available for decoding")
+			writeErrorCode(w, http.StatusServiceUnavailable, codeNakUnavailable, "nak CLI not available for decoding")
 			return
 		}
 		decoded, err := a.nak.Decode(eventID)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to decode event ID: %v", err))
+			writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, fmt.Sprintf("failed to decode event ID: %v", err))
 			return
 		}
 		eventID = decoded.Hex
+		hints = decoded.Relays
 	}
 
 	// Validate hex format (64 characters, valid hex)
 	if len(eventID) != 64 {
-		writeError(w, http.StatusBadRequest, "event ID must be 64 hex characters")
+		writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, "event ID must be 64 hex characters")
 		return
 	}
 	for _, c := range eventID {
 		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
-			writeError(w, http.StatusBadRequest, "event ID must contain only hexadecimal characters")
+			writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, "event ID must contain only hexadecimal characters")
 			return
 		}
 	}
 
-	// Query the event by ID
-	events, err := a.relayPool.QueryEventsByIDs([]string{eventID})
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to query event: %v", err))
-		return
+	// Query the event by ID, falling back to the nevent's relay hints
+	events, conflicts, err := a.relayPool.QueryEventsByIDsWithConflicts([]string{eventID})
+	fromHint := false
+	if len(events) == 0 && len(hints) > 0 {
+		if event := a.relayPool.QueryEventFromHints(eventID, hints); event != nil {
+			events = []types.Event{*event}
+			fromHint = true
+		}
 	}
 
 	if len(events) == 0 {
-		writeError(w, http.StatusNotFound, "event not found")
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to query event: %v", err))
+			return
+		}
+		writeErrorCode(w, http.StatusNotFound, codeEventNotFound, "event not found")
 		return
 	}
 
-	writeJSON(w, events[0])
+	if includeRaw {
+		a.attachRawEvents(events[:1])
+	}
+	response := EventLookupResponse{Event: events[0], FromHint: fromHint, Conflicts: conflicts}
+	for _, conflict := range conflicts {
+		response.Warnings = append(response.Warnings, fmt.Sprintf(
+			"security: %s and %s returned different events under this ID (%s differ); the copy from %s is shown",
+			conflict.Relay, conflict.ConflictingRelay, strings.Join(conflict.Fields, ", "), conflict.Relay))
+	}
+	if deletion := a.findDeletion(events[0]); deletion != nil {
+		response.Deleted = true
+		response.Deletion = deletion
+	}
+	writeJSON(w, response)
 }
 
 // HandleEventFetchAllRelays fetches an event by ID from all connected relays,
@@ -1108,12 +2085,12 @@ func (a *API) HandleEventFetchAllRelays(w http.ResponseWriter, r *http.Request)
 	// If input is note1... or nevent1..., decode it to hex
 	if strings.HasPrefix(eventID, "note1") || strings.HasPrefix(eventID, "nevent1") {
 		if a.nak == nil {
-			writeError(w, http.StatusServiceUnavailable, "nak CLI not available for decoding")
+			writeErrorCode(w, http.StatusServiceUnavailable, codeNakUnavailable, "nak CLI not available for decoding")
 			return
 		}
 		decoded, err := a.nak.Decode(eventID)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to decode event ID: %v", err))
+			writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, fmt.Sprintf("failed to decode event ID: %v", err))
 			return
 		}
 		eventID = decoded.Hex
@@ -1121,12 +2098,12 @@ func (a *API) HandleEventFetchAllRelays(w http.ResponseWriter, r *http.Request)
 
 	// Validate hex format (64 characters, valid hex)
 	if len(eventID) != 64 {
-		writeError(w, http.StatusBadRequest, "event ID must be 64 hex characters")
+		writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, "event ID must be 64 hex characters")
 		return
 	}
 	for _, c := range eventID {
 		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
-			writeError(w, http.StatusBadRequest, "event ID must contain only hexadecimal characters")
+			writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, "event ID must contain only hexadecimal characters")
 			return
 		}
 	}
@@ -1150,7 +2127,7 @@ func (a *API) HandleBatchEventLookup(w http.ResponseWriter, r *http.Request) {
 		IDs []string `json:"ids"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err, "invalid request body")
 		return
 	}
 
@@ -1174,12 +2151,12 @@ func (a *API) HandleBatchEventLookup(w http.ResponseWriter, r *http.Request) {
 		// If input is note1... or nevent1..., decode it to hex
 		if strings.HasPrefix(id, "note1") || strings.HasPrefix(id, "nevent1") {
 			if a.nak == nil {
-				writeError(w, http.StatusServiceUnavailable, "nak CLI not available for decoding")
+				writeErrorCode(w, http.StatusServiceUnavailable, codeNakUnavailable, "nak CLI not available for decoding")
 				return
 			}
 			decoded, err := a.nak.Decode(id)
 			if err != nil {
-				writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to decode event ID '%s': %v", id, err))
+				writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, fmt.Sprintf("failed to decode event ID '%s': %v", id, err))
 				return
 			}
 			id = decoded.Hex
@@ -1187,12 +2164,12 @@ func (a *API) HandleBatchEventLookup(w http.ResponseWriter, r *http.Request) {
 
 		// Validate hex format (64 characters, valid hex)
 		if len(id) != 64 {
-			writeError(w, http.StatusBadRequest, fmt.Sprintf("event ID '%s' must be 64 hex characters", id))
+			writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, fmt.Sprintf("event ID '%s' must be 64 hex characters", id))
 			return
 		}
 		for _, c := range id {
 			if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
-				writeError(w, http.StatusBadRequest, fmt.Sprintf("event ID '%s' must contain only hexadecimal characters", id))
+				writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, fmt.Sprintf("event ID '%s' must contain only hexadecimal characters", id))
 				return
 			}
 		}
@@ -1206,19 +2183,34 @@ func (a *API) HandleBatchEventLookup(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, response)
 }
 
+// maxPublishRetries caps the retries query param of HandleEventPublish.
+const maxPublishRetries = 5
+
 // HandleEventPublish publishes a signed event to connected relays.
 // Request body can be either:
 // 1. A signed event JSON directly
 // 2. An object with "event" (signed event) and optional "relays" (array of relay URLs)
+// The optional retries query param (0-5) retries relays that fail with transient
+// errors such as timeouts or rate limits; permanent rejections are not retried.
 func (a *API) HandleEventPublish(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
+	retries := 0
+	if retriesStr := r.URL.Query().Get("retries"); retriesStr != "" {
+		n, err := strconv.Atoi(retriesStr)
+		if err != nil || n < 0 || n > maxPublishRetries {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("retries must be between 0 and %d", maxPublishRetries))
+			return
+		}
+		retries = n
+	}
+
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "failed to read request body")
+		writeBodyError(w, err, "failed to read request body")
 		return
 	}
 
@@ -1255,12 +2247,12 @@ func (a *API) HandleEventPublish(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(targetRelays) == 0 {
-		writeError(w, http.StatusBadRequest, "no connected relays")
+		writeErrorCode(w, http.StatusBadRequest, codeNoConnectedRelays, "no connected relays")
 		return
 	}
 
 	// Publish to relays using the relay pool
-	eventID, results := a.relayPool.PublishEventJSON(eventJSON, targetRelays)
+	eventID, results := a.relayPool.PublishEventJSONWithRetries(eventJSON, targetRelays, retries)
 
 	// Check if at least one relay succeeded
 	hasSuccess := false
@@ -1287,8 +2279,18 @@ func (a *API) HandleEventPublish(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// maxParticipantProfiles caps how many participant profiles are resolved per thread.
+const maxParticipantProfiles = 50
+
 // HandleThread fetches a thread for a given event ID (NIP-10).
 // Path: /api/events/thread/{eventId}
+// Accepts optional query params:
+// - profiles: if "true", resolves profile metadata for thread participants (capped)
+// - shallow: if "true", returns a types.ThreadSummary with the root event and
+// per-depth event counts instead of the full tree
+// - reply_limit: per-relay reply limit (default 100, capped at the max query limit)
+// - until: only fetch replies created at or before this time; pass the
+// response's next_until to page back through older replies
 func (a *API) HandleThread(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -1306,35 +2308,138 @@ func (a *API) HandleThread(w http.ResponseWriter, r *http.Request) {
 
 	// Validate event ID format (should be 64 hex characters)
 	if len(eventID) != 64 {
-		writeError(w, http.StatusBadRequest, "event ID must be a 64-character hex string")
+		writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, "event ID must be a 64-character hex string")
 		return
 	}
 	for _, c := range eventID {
 		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
-			writeError(w, http.StatusBadRequest, "event ID must be a valid hex string")
+			writeErrorCode(w, http.StatusBadRequest, codeInvalidEventID, "event ID must be a valid hex string")
+			return
+		}
+	}
+
+	opts, err := a.parseThreadOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if r.URL.Query().Get("shallow") == "true" {
+		summary, err := a.buildThreadSummary(eventID, opts)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to build thread: "+err.Error())
 			return
 		}
+		writeJSON(w, summary)
+		return
 	}
 
 	// Build the thread
-	thread, err := a.buildThread(eventID)
+	thread, err := a.buildThread(eventID, opts)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to build thread: "+err.Error())
 		return
 	}
 
+	if r.URL.Query().Get("profiles") == "true" {
+		a.resolveParticipantProfiles(thread)
+	}
+
 	writeJSON(w, thread)
 }
 
-// buildThread constructs a thread starting from a given event ID.
-// It fetches the target event, finds the root via "e" tags with "root" marker,
-// and then fetches all replies to build the tree structure.
-func (a *API) buildThread(eventID string) (*types.Thread, error) {
-	thread := &types.Thread{
-		TargetID: eventID,
-		Events:   []types.ThreadEvent{},
+// parseThreadOptions reads the reply_limit and until query params of a thread
+// request. reply_limit is clamped to the maximum query limit.
+func (a *API) parseThreadOptions(r *http.Request) (threadOptions, error) {
+	var opts threadOptions
+
+	if limitStr := r.URL.Query().Get("reply_limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return opts, fmt.Errorf("invalid reply_limit value: %s", limitStr)
+		}
+		if maxLimit := a.maxQueryLimit(); limit > maxLimit {
+			limit = maxLimit
+		}
+		opts.replyLimit = limit
+	}
+
+	if untilStr := r.URL.Query().Get("until"); untilStr != "" {
+		until, err := parseTimeParam(untilStr, time.Now())
+		if err != nil {
+			return opts, fmt.Errorf("invalid until value: %s", untilStr)
+		}
+		opts.until = until
+	}
+
+	return opts, nil
+}
+
+// resolveParticipantProfiles fills in profile metadata for up to maxParticipantProfiles
+// thread participants using a single kind 0 query. Participants without a profile
+// event keep only their pubkey.
+func (a *API) resolveParticipantProfiles(thread *types.Thread) {
+	if len(thread.Participants) == 0 {
+		return
+	}
+
+	count := len(thread.Participants)
+	if count > maxParticipantProfiles {
+		count = maxParticipantProfiles
+	}
+
+	pubkeys := make([]string, count)
+	for i := 0; i < count; i++ {
+		pubkeys[i] = thread.Participants[i].PubKey
+	}
+
+	events, err := a.relayPool.QueryEventsAdvanced([]int{0}, pubkeys, nil, count*2, 0, 0)
+	if err != nil {
+		return
+	}
+
+	// Keep the newest metadata event per author
+	latest := make(map[string]types.Event)
+	for _, event := range events {
+		if existing, ok := latest[event.PubKey]; !ok || types.Supersedes(event, existing) {
+			latest[event.PubKey] = event
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		pubkey := thread.Participants[i].PubKey
+		if event, ok := latest[pubkey]; ok {
+			thread.Participants[i] = parseProfileMetadata(pubkey, event)
+		}
 	}
+}
+
+// threadGraph holds the fetched events of a thread and how they link
+// together. Depths are counted from the root along reply links.
+type threadGraph struct {
+	rootID     string
+	events     map[string]types.Event
+	children   map[string][]string // parentID -> []childID
+	parents    map[string]string   // childID -> parentID
+	eventRoots map[string]string   // eventID -> rootID
+	depths     map[string]int
+	maxDepth   int
+
+	// moreReplies is set when a reply query hit its limit; nextUntil is then
+	// the oldest reply's created_at, to pass as until for the next page.
+	moreReplies bool
+	nextUntil   int64
+}
 
+// threadOptions controls how a thread's replies are fetched.
+type threadOptions struct {
+	replyLimit int   // per-relay reply limit, 0 for the pool default
+	until      int64 // only fetch replies created at or before this, 0 for no bound
+}
+
+// fetchThreadGraph fetches the target event, finds the root via "e" tags with
+// "root" marker, and then fetches all replies to link the thread together.
+func (a *API) fetchThreadGraph(eventID string, opts threadOptions) (*threadGraph, error) {
 	// Fetch the target event
 	events, err := a.relayPool.QueryEventsByIDs([]string{eventID})
 	if err != nil {
@@ -1374,90 +2479,178 @@ func (a *API) buildThread(eventID string) (*types.Thread, error) {
 	}
 
 	// Fetch replies to the root (to build the thread)
-	replies, _ := a.relayPool.QueryEventReplies(rootID)
+	replies, moreReplies := a.queryThreadReplies(rootID, targetEvent.Relay, opts)
 
 	// Also fetch replies to the target event if it's not the root
 	if eventID != rootID {
-		targetReplies, _ := a.relayPool.QueryEventReplies(eventID)
+		targetReplies, moreTargetReplies := a.queryThreadReplies(eventID, targetEvent.Relay, opts)
 		replies = append(replies, targetReplies...)
+		moreReplies = moreReplies || moreTargetReplies
 	}
 
 	// Build a map of all events
-	eventMap := make(map[string]types.Event)
-	eventMap[targetEvent.ID] = targetEvent
+	g := &threadGraph{
+		rootID:     rootID,
+		events:     make(map[string]types.Event),
+		children:   make(map[string][]string),
+		parents:    make(map[string]string),
+		eventRoots: make(map[string]string),
+		depths:     make(map[string]int),
+	}
+	g.events[targetEvent.ID] = targetEvent
 	for _, e := range ancestors {
-		eventMap[e.ID] = e
+		g.events[e.ID] = e
 	}
 	for _, e := range replies {
-		eventMap[e.ID] = e
+		g.events[e.ID] = e
+		if moreReplies && (g.nextUntil == 0 || e.CreatedAt < g.nextUntil) {
+			g.nextUntil = e.CreatedAt
+		}
 	}
+	g.moreReplies = moreReplies
+
+	a.fetchMissingParents(g.events)
+	g.link()
+	return g, nil
+}
 
-	// Build parent-child relationships
-	children := make(map[string][]string) // parentID -> []childID
-	parents := make(map[string]string)    // childID -> parentID
-	eventRoots := make(map[string]string) // eventID -> rootID
+// queryThreadReplies fetches replies to eventID, trying relayURL first since
+// the relay that served an event usually holds its replies too. It falls back
+// to all relays when relayURL is empty or the scoped query finds nothing.
+// The returned bool reports whether the reply limit was hit.
+func (a *API) queryThreadReplies(eventID, relayURL string, opts threadOptions) ([]types.Event, bool) {
+	if relayURL != "" {
+		replies, limitHit, err := a.relayPool.QueryEventReplies(eventID, opts.replyLimit, opts.until, relayURL)
+		if err == nil && len(replies) > 0 {
+			return replies, limitHit
+		}
+	}
+	replies, limitHit, _ := a.relayPool.QueryEventReplies(eventID, opts.replyLimit, opts.until)
+	return replies, limitHit
+}
 
-	for id, event := range eventMap {
-		eRoot, eReply := parseNIP10Tags(event.Tags)
-		if eRoot != "" {
-			eventRoots[id] = eRoot
-		} else {
-			eventRoots[id] = id // It's a root
+// maxParentHops bounds how many rounds of missing-parent fetches a thread
+// makes, so a long chain of unfetched ancestors can't trigger endless queries.
+const maxParentHops = 5
+
+// fetchMissingParents adds the parents of events whose parent isn't in events
+// yet, repeating for the newly fetched parents up to maxParentHops times.
+func (a *API) fetchMissingParents(events map[string]types.Event) {
+	tried := make(map[string]bool)
+	for hop := 0; hop < maxParentHops; hop++ {
+		var missing []string
+		for id, event := range events {
+			parentID := threadParentID(event.Tags)
+			if parentID == "" || parentID == id || tried[parentID] {
+				continue
+			}
+			if _, ok := events[parentID]; ok {
+				continue
+			}
+			tried[parentID] = true
+			missing = append(missing, parentID)
+		}
+		if len(missing) == 0 {
+			return
+		}
+
+		parents, err := a.relayPool.QueryEventsByIDs(missing)
+		if err != nil || len(parents) == 0 {
+			return
+		}
+		for _, e := range parents {
+			events[e.ID] = e
 		}
+	}
+}
+
+// threadParentID returns the event an event replies to: its NIP-10 reply
+// reference, or its root when it replies to the root directly.
+func threadParentID(tags [][]string) string {
+	rootID, replyID := parseNIP10Tags(tags)
+	if replyID != "" {
+		return replyID
+	}
+	return rootID
+}
 
-		// Determine parent
-		parentID := eReply
-		if parentID == "" && eRoot != "" {
-			parentID = eRoot
+// link builds the parent-child relationships and depths of the graph's events.
+func (g *threadGraph) link() {
+	for id, event := range g.events {
+		eRoot, _ := parseNIP10Tags(event.Tags)
+		if eRoot != "" {
+			g.eventRoots[id] = eRoot
+		} else {
+			g.eventRoots[id] = id // It's a root
 		}
 
-		if parentID != "" && parentID != id {
-			parents[id] = parentID
-			children[parentID] = append(children[parentID], id)
+		if parentID := threadParentID(event.Tags); parentID != "" && parentID != id {
+			g.parents[id] = parentID
+			g.children[parentID] = append(g.children[parentID], id)
 		}
 	}
 
 	// Calculate depths using BFS from root
-	depths := make(map[string]int)
-	depths[rootID] = 0
-	queue := []string{rootID}
-	maxDepth := 0
+	g.depths[g.rootID] = 0
+	queue := []string{g.rootID}
 
 	for len(queue) > 0 {
 		current := queue[0]
 		queue = queue[1:]
-		currentDepth := depths[current]
-		if currentDepth > maxDepth {
-			maxDepth = currentDepth
+		currentDepth := g.depths[current]
+		if currentDepth > g.maxDepth {
+			g.maxDepth = currentDepth
 		}
 
-		for _, childID := range children[current] {
-			if _, visited := depths[childID]; !visited {
-				depths[childID] = currentDepth + 1
+		for _, childID := range g.children[current] {
+			if _, visited := g.depths[childID]; !visited {
+				g.depths[childID] = currentDepth + 1
 				queue = append(queue, childID)
 			}
 		}
 	}
+}
+
+// orphaned reports whether an event can't be reached from the root because
+// an ancestor between them was never fetched.
+func (g *threadGraph) orphaned(id string) bool {
+	_, reachable := g.depths[id]
+	return !reachable
+}
+
+// threadEvent returns the event with its position in the thread. Orphaned
+// events have an unknown position and report depth 0.
+func (g *threadGraph) threadEvent(id string) types.ThreadEvent {
+	return types.ThreadEvent{
+		Event:      g.events[id],
+		Depth:      g.depths[id],
+		IsRoot:     id == g.rootID,
+		Orphaned:   g.orphaned(id),
+		ParentID:   g.parents[id],
+		RootID:     g.eventRoots[id],
+		ReplyCount: len(g.children[id]),
+	}
+}
+
+// buildThread constructs a thread starting from a given event ID.
+func (a *API) buildThread(eventID string, opts threadOptions) (*types.Thread, error) {
+	g, err := a.fetchThreadGraph(eventID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := &types.Thread{
+		TargetID: eventID,
+		Events:   []types.ThreadEvent{},
+	}
 
 	// Convert to ThreadEvent slice, sorted by timestamp
 	var threadEvents []types.ThreadEvent
-	for id, event := range eventMap {
-		depth := depths[id]
-		if depth == 0 && id != rootID {
-			depth = 1 // Default to 1 if not reachable from root
-		}
-
-		te := types.ThreadEvent{
-			Event:      event,
-			Depth:      depth,
-			IsRoot:     id == rootID,
-			ParentID:   parents[id],
-			RootID:     eventRoots[id],
-			ReplyCount: len(children[id]),
-		}
+	for id := range g.events {
+		te := g.threadEvent(id)
 		threadEvents = append(threadEvents, te)
 
-		if id == rootID {
+		if id == g.rootID {
 			thread.RootEvent = &te
 		}
 	}
@@ -1473,11 +2666,54 @@ func (a *API) buildThread(eventID string) (*types.Thread, error) {
 
 	thread.Events = threadEvents
 	thread.TotalSize = len(threadEvents)
-	thread.MaxDepth = maxDepth
+	thread.MaxDepth = g.maxDepth
+	thread.MoreReplies = g.moreReplies
+	thread.NextUntil = g.nextUntil
+
+	// Collect distinct participants in thread order
+	seenAuthors := make(map[string]bool)
+	for _, te := range threadEvents {
+		if te.PubKey == "" || seenAuthors[te.PubKey] {
+			continue
+		}
+		seenAuthors[te.PubKey] = true
+		thread.Participants = append(thread.Participants, types.Profile{PubKey: te.PubKey})
+	}
 
 	return thread, nil
 }
 
+// buildThreadSummary fetches a thread like buildThread but only returns the
+// root event and how many events sit at each depth, leaving out reply bodies.
+func (a *API) buildThreadSummary(eventID string, opts threadOptions) (*types.ThreadSummary, error) {
+	g, err := a.fetchThreadGraph(eventID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &types.ThreadSummary{
+		TargetID:    eventID,
+		TotalSize:   len(g.events),
+		MaxDepth:    g.maxDepth,
+		DepthCounts: make([]int, g.maxDepth+1),
+		MoreReplies: g.moreReplies,
+	}
+	if _, ok := g.events[g.rootID]; ok {
+		root := g.threadEvent(g.rootID)
+		summary.RootEvent = &root
+	}
+
+	for id := range g.events {
+		if g.orphaned(id) {
+			summary.Orphaned++
+			continue
+		}
+		summary.DepthCounts[g.depths[id]]++
+	}
+
+	return summary, nil
+}
+
 // parseNIP10Tags extracts root and reply event IDs from NIP-10 formatted tags.
 // Returns (rootID, replyID)
 func parseNIP10Tags(tags [][]string) (string, string) {
@@ -0,0 +1,63 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBearerAuth(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+	h := bearerAuthMiddleware("s3cret", ok)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"valid token", "/api/status", "Bearer s3cret", http.StatusOK},
+		{"missing header", "/api/status", "", http.StatusUnauthorized},
+		{"wrong token", "/api/status", "Bearer wrong", http.StatusUnauthorized},
+		{"token prefix", "/api/status", "Bearer s3cre", http.StatusUnauthorized},
+		{"wrong scheme", "/api/status", "Basic s3cret", http.StatusUnauthorized},
+		{"static asset stays public", "/index.html", "", http.StatusOK},
+		{"websocket stays public", "/ws", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, w.Code)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected a WWW-Authenticate header on 401")
+			}
+		})
+	}
+}
+
+func TestBearerAuth_NoTokenConfiguredLeavesAPIOpen(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+	h := bearerAuthMiddleware("", ok)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 without a configured token, got %d", w.Code)
+	}
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxBatchRequests caps how many sub-requests one /api/batch call may carry.
+const maxBatchRequests = 20
+
+// batchExcludedPaths can't be batched: nested batches, and streams that only
+// end when the client disconnects.
+var batchExcludedPaths = map[string]bool{
+	"/api/batch":         true,
+	"/api/events/stream": true,
+}
+
+// BatchRequest is one sub-request of a batch. Body is sent as the JSON
+// request body; Method defaults to GET.
+type BatchRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchResponse is the result of one sub-request. Body holds the handler's
+// JSON response, or a JSON string for responses that aren't JSON.
+type BatchResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// batchResponseWriter records a sub-request's response.
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header { return w.header }
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *batchResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// batchHandler serves POST /api/batch: it runs each sub-request against mux
+// in order and returns their statuses and bodies in the same order, saving
+// the UI a round trip per call. Sub-requests share the batch request's
+// context and request ID.
+func batchHandler(mux http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !requireJSON(w, r, false) {
+			return
+		}
+
+		var reqs []BatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			writeBodyError(w, err, "invalid request body")
+			return
+		}
+		if len(reqs) == 0 {
+			writeError(w, http.StatusBadRequest, "batch is empty")
+			return
+		}
+		if len(reqs) > maxBatchRequests {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("batch has %d requests, maximum is %d", len(reqs), maxBatchRequests))
+			return
+		}
+
+		for i, req := range reqs {
+			if err := validateBatchRequest(req); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("request %d: %v", i, err))
+				return
+			}
+		}
+
+		responses := make([]BatchResponse, len(reqs))
+		for i, req := range reqs {
+			responses[i] = runBatchRequest(mux, r, w.Header().Get(requestIDHeader), req)
+		}
+		writeJSON(w, responses)
+	}
+}
+
+// validateBatchRequest checks that a sub-request targets a batchable API path.
+func validateBatchRequest(req BatchRequest) error {
+	if !strings.HasPrefix(req.Path, "/api/") {
+		return fmt.Errorf("path must start with /api/")
+	}
+	path, _, _ := strings.Cut(req.Path, "?")
+	if batchExcludedPaths[path] {
+		return fmt.Errorf("%s cannot be batched", path)
+	}
+	return nil
+}
+
+// runBatchRequest dispatches one sub-request to mux and records its response.
+func runBatchRequest(mux http.Handler, parent *http.Request, requestID string, req BatchRequest) BatchResponse {
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	sub, err := http.NewRequestWithContext(parent.Context(), method, req.Path, bytes.NewReader(req.Body))
+	if err != nil {
+		body, _ := json.Marshal(ErrorResponse{Error: "invalid request", Code: codeBadRequest, RequestID: requestID})
+		return BatchResponse{Status: http.StatusBadRequest, Body: body}
+	}
+	if len(req.Body) > 0 {
+		sub.Header.Set("Content-Type", "application/json")
+	}
+	sub.RemoteAddr = parent.RemoteAddr
+
+	rec := &batchResponseWriter{header: http.Header{}}
+	if requestID != "" {
+		rec.header.Set(requestIDHeader, requestID)
+	}
+	mux.ServeHTTP(rec, sub)
+
+	resp := BatchResponse{Status: rec.status}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	body := bytes.TrimSpace(rec.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case json.Valid(body):
+		resp.Body = body
+	default:
+		resp.Body, _ = json.Marshal(string(body))
+	}
+	return resp
+}
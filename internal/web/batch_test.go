@@ -0,0 +1,129 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func batchTestServer() http.Handler {
+	pool := &mockRelayPool{
+		relayList: []types.RelayStatus{{URL: "wss://relay.example.com", Connected: true}},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+	return NewServer(":0", nil, api).routes()
+}
+
+func postBatch(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestBatch_StatusAndRelays(t *testing.T) {
+	w := postBatch(t, batchTestServer(), `[
+		{"method": "GET", "path": "/api/status"},
+		{"path": "/api/relays"},
+		{"method": "POST", "path": "/api/relays", "body": {"url": "not a relay"}}
+	]`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var responses []BatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&responses); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(responses))
+	}
+
+	var status map[string]interface{}
+	if responses[0].Status != http.StatusOK || json.Unmarshal(responses[0].Body, &status) != nil || status["status"] != "ok" {
+		t.Errorf("unexpected status response: %d %s", responses[0].Status, responses[0].Body)
+	}
+
+	var relays []types.RelayStatus
+	if responses[1].Status != http.StatusOK || json.Unmarshal(responses[1].Body, &relays) != nil {
+		t.Fatalf("unexpected relays response: %d %s", responses[1].Status, responses[1].Body)
+	}
+	if len(relays) != 1 || relays[0].URL != "wss://relay.example.com" {
+		t.Errorf("unexpected relays: %+v", relays)
+	}
+
+	var errResp ErrorResponse
+	if responses[2].Status != http.StatusBadRequest || json.Unmarshal(responses[2].Body, &errResp) != nil || errResp.Code != codeInvalidRelayURL {
+		t.Errorf("unexpected add relay response: %d %s", responses[2].Status, responses[2].Body)
+	}
+}
+
+func TestBatch_Rejects(t *testing.T) {
+	tooMany := make([]string, maxBatchRequests+1)
+	for i := range tooMany {
+		tooMany[i] = `{"path": "/api/status"}`
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"empty batch", `[]`},
+		{"too many requests", "[" + strings.Join(tooMany, ",") + "]"},
+		{"nested batch", `[{"method": "POST", "path": "/api/batch", "body": []}]`},
+		{"stream", `[{"path": "/api/events/stream"}]`},
+		{"non-API path", `[{"path": "/index.html"}]`},
+		{"not an array", `{"path": "/api/status"}`},
+	}
+
+	h := batchTestServer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postBatch(t, h, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBatch_MethodNotAllowed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/batch", nil)
+	w := httptest.NewRecorder()
+	batchTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
+
+func TestBatch_SharesRequestID(t *testing.T) {
+	h := requestIDMiddleware(batchTestServer())
+	req := httptest.NewRequest(http.MethodPost, "/api/batch", strings.NewReader(`[{"path": "/api/relays/stats", "method": "DELETE"}]`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestIDHeader, "batch-123")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var responses []BatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&responses); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	var errResp ErrorResponse
+	if err := json.Unmarshal(responses[0].Body, &errResp); err != nil {
+		t.Fatalf("failed to decode sub-response: %v", err)
+	}
+	if errResp.RequestID != "batch-123" {
+		t.Errorf("request_id = %q, want the batch's request ID", errResp.RequestID)
+	}
+	if responses[0].Status != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", responses[0].Status)
+	}
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+)
+
+// fallbackMaxBodyBytes caps request bodies when the config leaves
+// MaxBodyBytes unset.
+const fallbackMaxBodyBytes = 1 << 20
+
+// maxBodyBytes returns the configured cap on request body size.
+func (a *API) maxBodyBytes() int64 {
+	if a.cfg.MaxBodyBytes > 0 {
+		return a.cfg.MaxBodyBytes
+	}
+	return fallbackMaxBodyBytes
+}
+
+// maxBodyMiddleware limits every request body to limit bytes. Handlers see a
+// *http.MaxBytesError when reading past it and report it with writeBodyError.
+func maxBodyMiddleware(limit int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeBodyError responds to a failure reading or decoding the request body:
+// 413 when the body exceeded the size limit, otherwise 400 with message.
+func writeBodyError(w http.ResponseWriter, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	writeError(w, http.StatusBadRequest, message)
+}
+
+// requireJSON rejects a request whose Content-Type isn't application/json
+// with 415 Unsupported Media Type and reports whether it may proceed. When
+// allowEmpty is set, a request without a body needs no Content-Type.
+func requireJSON(w http.ResponseWriter, r *http.Request, allowEmpty bool) bool {
+	if allowEmpty && r.ContentLength == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+)
+
+func TestMaxBodyMiddleware_RejectsOversizedBodies(t *testing.T) {
+	api := NewAPI(&config.Config{MaxBodyBytes: 64}, nil, &mockRelayPool{}, nil)
+
+	handlers := map[string]http.HandlerFunc{
+		"/api/events/publish":      api.HandleEventPublish,
+		"/api/events/batch-lookup": api.HandleBatchEventLookup,
+		"/api/events/validate":     api.HandleEventValidate,
+	}
+	oversized := `{"ids": ["` + strings.Repeat("a", 256) + `"]}`
+
+	for path, handler := range handlers {
+		h := maxBodyMiddleware(api.maxBodyBytes(), handler)
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(oversized))
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusRequestEntityTooLarge, w.Code)
+		}
+	}
+}
+
+func TestMaxBodyMiddleware_AllowsBodiesWithinLimit(t *testing.T) {
+	api := NewAPI(&config.Config{MaxBodyBytes: 64}, nil, &mockRelayPool{}, nil)
+	h := maxBodyMiddleware(api.maxBodyBytes(), http.HandlerFunc(api.HandleEventValidate))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/events/validate", strings.NewReader(`{"kind": 1}`))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestMaxBodyBytes_DefaultsToOneMegabyte(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+	if got := api.maxBodyBytes(); got != 1<<20 {
+		t.Errorf("maxBodyBytes() = %d, want %d", got, 1<<20)
+	}
+}
+
+func TestRequireJSON_RejectsOtherContentTypes(t *testing.T) {
+	api := NewAPI(&config.Config{}, &mockNakClient{}, &mockRelayPool{}, nil)
+
+	handlers := map[string]http.HandlerFunc{
+		"/api/relays":           api.HandleRelays,
+		"/api/keys/decode":      api.HandleKeyDecode,
+		"/api/keys/encode":      api.HandleKeyEncode,
+		"/api/events/sign":      api.HandleEventSign,
+		"/api/events/subscribe": api.HandleEventSubscribe,
+	}
+
+	for path, handler := range handlers {
+		for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != http.StatusUnsupportedMediaType {
+				t.Errorf("%s with %q: expected status %d, got %d", path, contentType, http.StatusUnsupportedMediaType, w.Code)
+			}
+		}
+	}
+}
+
+func TestRequireJSON_AcceptsJSONContentType(t *testing.T) {
+	for _, contentType := range []string{"application/json", "application/json; charset=utf-8", "Application/JSON"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/relays", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		if !requireJSON(w, req, false) {
+			t.Errorf("expected %q to be accepted, got status %d", contentType, w.Code)
+		}
+	}
+}
+
+func TestRequireJSON_AllowsEmptyBodyWhenSupported(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/events/subscribe", nil)
+
+	if !requireJSON(httptest.NewRecorder(), req, true) {
+		t.Error("expected an empty body to be allowed without a Content-Type")
+	}
+	if requireJSON(httptest.NewRecorder(), req, false) {
+		t.Error("expected an empty body to need a Content-Type unless allowed")
+	}
+}
@@ -0,0 +1,133 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+const cacheTestPubkey = "1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"
+
+func TestHandleProfileLookup_ETagNotModified(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			{ID: "event123", Kind: 0, PubKey: cacheTestPubkey, Content: `{"name":"testuser"}`, CreatedAt: 1700000000},
+		},
+	}
+	api := NewAPI(&config.Config{HTTPCacheMaxAge: 2 * time.Minute}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/lookup?pubkey="+cacheTestPubkey, nil)
+	w := httptest.NewRecorder()
+	api.HandleProfileLookup(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag header")
+	}
+	if got := w.Header().Get("Cache-Control"); got != "max-age=120" {
+		t.Errorf("Cache-Control = %q, want max-age=120", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/profile/"+cacheTestPubkey, nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	api.HandleProfile(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body on 304, got %q", w.Body.String())
+	}
+}
+
+func TestHandleRelayInfo_ETagChangesWithContent(t *testing.T) {
+	pool := &mockRelayPool{
+		relayInfoMap: map[string]*types.RelayInfo{
+			"wss://relay.example.com": {Name: "Example Relay"},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/relays/info?url=wss://relay.example.com", nil)
+	w := httptest.NewRecorder()
+	api.HandleRelayInfo(w, req)
+
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d and %q", w.Code, etag)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache when max-age is zero", got)
+	}
+
+	pool.relayInfoMap["wss://relay.example.com"] = &types.RelayInfo{Name: "Renamed Relay"}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/relays/info?url=wss://relay.example.com", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	api.HandleRelayInfo(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d after the info changed, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("expected a new ETag after the info changed")
+	}
+}
+
+func TestCachedEndpoints_ErrorsOmitCacheHeaders(t *testing.T) {
+	api := NewAPI(&config.Config{HTTPCacheMaxAge: time.Minute}, nil, &mockRelayPool{}, nil)
+
+	tests := []struct {
+		name    string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"profile not found", "/api/profile/" + cacheTestPubkey, api.HandleProfile},
+		{"invalid pubkey", "/api/profile/lookup?pubkey=nothex", api.HandleProfileLookup},
+		{"relay info missing", "/api/relays/info?url=wss://unknown.example.com", api.HandleRelayInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			tt.handler(w, req)
+
+			if w.Code < 400 {
+				t.Fatalf("expected an error status, got %d", w.Code)
+			}
+			if w.Header().Get("ETag") != "" || w.Header().Get("Cache-Control") != "" {
+				t.Errorf("expected no cache headers on errors, got ETag=%q Cache-Control=%q",
+					w.Header().Get("ETag"), w.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{"*", true},
+		{`"xyz"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package web
+
+import (
+	"net/http"
+)
+
+// ConfigResponse is the non-sensitive runtime configuration returned by
+// HandleConfig. It is built field by field from config.Config so secrets such
+// as the probe key can never leak by being added to Config later.
+type ConfigResponse struct {
+	WebAddr       string   `json:"web_addr"`
+	DefaultRelays []string `json:"default_relays"`
+	Production    bool     `json:"production"`
+	LogLevel      string   `json:"log_level"`
+	UserAgent     string   `json:"user_agent"`
+	Origin        string   `json:"origin,omitempty"`
+
+	DefaultQueryLimit int         `json:"default_query_limit"`
+	MaxQueryLimit     int         `json:"max_query_limit"`
+	KindQueryLimits   map[int]int `json:"kind_query_limits,omitempty"`
+
+	QueryTimeoutMs      int64 `json:"query_timeout_ms"`
+	ConnectTimeoutMs    int64 `json:"connect_timeout_ms"`
+	InfoFetchTimeoutMs  int64 `json:"info_fetch_timeout_ms"`
+	DrainTimeoutMs      int64 `json:"drain_timeout_ms"`
+	KeepAliveIntervalMs int64 `json:"keepalive_interval_ms"`
+	HTTPCacheMaxAgeMs   int64 `json:"http_cache_max_age_ms"`
+	PruneAfterMs        int64 `json:"prune_after_ms"`
+
+	MaxConcurrentQueries int   `json:"max_concurrent_queries"`
+	EventCacheSize       int   `json:"event_cache_size"`
+	CacheableKinds       []int `json:"cacheable_kinds,omitempty"`
+	InfoCacheSize        int   `json:"info_cache_size"`
+	MaxTrackedKinds      int   `json:"max_tracked_kinds"`
+	MaxDiscoveredRelays  int   `json:"max_discovered_relays"`
+	MaxBodyBytes         int64 `json:"max_body_bytes"`
+	ImageProxyMaxBytes   int64 `json:"image_proxy_max_bytes"`
+
+	EOSETimeoutPenalty float64 `json:"eose_timeout_penalty"`
+
+	Features ConfigFeatures `json:"features"`
+}
+
+// ConfigFeatures reports which optional features are enabled, so the UI can
+// hide controls that would fail.
+type ConfigFeatures struct {
+	Nak                bool     `json:"nak"`
+	NakRateLimit       int      `json:"nak_rate_limit"`
+	NakAllowedCommands []string `json:"nak_allowed_commands,omitempty"`
+	CORSAllowedOrigins []string `json:"cors_allowed_origins,omitempty"`
+	BlockedRelays      []string `json:"blocked_relays,omitempty"`
+	RelayAllowlist     bool     `json:"relay_allowlist"`
+	AllowedRelays      []string `json:"allowed_relays,omitempty"`
+	WriteProbes        bool     `json:"write_probes"`
+	ReadAuth           bool     `json:"read_auth"`
+	APIAuth            bool     `json:"api_auth"`
+	PruneDeadRelays    bool     `json:"prune_dead_relays"`
+	DiscoverRelays     bool     `json:"discover_relays"`
+	ImageProxy         bool     `json:"image_proxy"`
+	EventCache         bool     `json:"event_cache"`
+	KeepAlive          bool     `json:"keepalive"`
+}
+
+// HandleConfig returns the non-sensitive parts of the runtime configuration.
+func (a *API) HandleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	cfg := a.cfg
+	defaultRelays := cfg.DefaultRelays
+	if defaultRelays == nil {
+		defaultRelays = []string{}
+	}
+
+	writeJSON(w, ConfigResponse{
+		WebAddr:       cfg.WebAddr,
+		DefaultRelays: defaultRelays,
+		Production:    cfg.Production,
+		LogLevel:      cfg.LogLevel,
+		UserAgent:     cfg.UserAgent,
+		Origin:        cfg.Origin,
+
+		DefaultQueryLimit: a.defaultQueryLimit(),
+		MaxQueryLimit:     a.maxQueryLimit(),
+		KindQueryLimits:   cfg.KindQueryLimits,
+
+		QueryTimeoutMs:      cfg.QueryTimeout.Milliseconds(),
+		ConnectTimeoutMs:    cfg.ConnectTimeout.Milliseconds(),
+		InfoFetchTimeoutMs:  cfg.InfoFetchTimeout.Milliseconds(),
+		DrainTimeoutMs:      cfg.DrainTimeout.Milliseconds(),
+		KeepAliveIntervalMs: cfg.KeepAliveInterval.Milliseconds(),
+		HTTPCacheMaxAgeMs:   cfg.HTTPCacheMaxAge.Milliseconds(),
+		PruneAfterMs:        cfg.PruneAfter.Milliseconds(),
+
+		MaxConcurrentQueries: cfg.MaxConcurrentQueries,
+		EventCacheSize:       cfg.EventCacheSize,
+		CacheableKinds:       cfg.CacheableKinds,
+		InfoCacheSize:        cfg.InfoCacheSize,
+		MaxTrackedKinds:      cfg.MaxTrackedKinds,
+		MaxDiscoveredRelays:  cfg.MaxDiscoveredRelays,
+		MaxBodyBytes:         a.maxBodyBytes(),
+		ImageProxyMaxBytes:   cfg.ImageProxyMaxBytes,
+
+		EOSETimeoutPenalty: cfg.EOSETimeoutPenalty,
+
+		Features: ConfigFeatures{
+			Nak:                cfg.HasNak(),
+			NakRateLimit:       cfg.NakRateLimit,
+			NakAllowedCommands: cfg.NakAllowedCommands,
+			CORSAllowedOrigins: cfg.CORSAllowedOrigins,
+			BlockedRelays:      cfg.BlockedRelays,
+			RelayAllowlist:     cfg.AllowlistMode,
+			AllowedRelays:      cfg.AllowedRelays,
+			WriteProbes:        cfg.ProbeTestKey != "",
+			ReadAuth:           cfg.ReadAuthEnabled(),
+			APIAuth:            cfg.APIToken != "",
+			PruneDeadRelays:    cfg.PruneDeadRelays,
+			DiscoverRelays:     cfg.DiscoverRelays,
+			ImageProxy:         cfg.ImageProxy,
+			EventCache:         cfg.EventCacheSize > 0,
+			KeepAlive:          cfg.KeepAliveInterval > 0,
+		},
+	})
+}
@@ -0,0 +1,113 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+)
+
+func TestHandleConfig_ReturnsSettings(t *testing.T) {
+	cfg := &config.Config{
+		WebAddr:           ":9090",
+		DefaultRelays:     []string{"wss://relay.example.com"},
+		DefaultQueryLimit: 25,
+		MaxQueryLimit:     200,
+		QueryTimeout:      10 * time.Second,
+		KeepAliveInterval: time.Minute,
+		EventCacheSize:    1000,
+		NakRateLimit:      30,
+		LogLevel:          "debug",
+	}
+	api := NewAPI(cfg, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+	w := httptest.NewRecorder()
+	api.HandleConfig(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp ConfigResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.WebAddr != ":9090" {
+		t.Errorf("web_addr = %q, want :9090", resp.WebAddr)
+	}
+	if len(resp.DefaultRelays) != 1 || resp.DefaultRelays[0] != "wss://relay.example.com" {
+		t.Errorf("default_relays = %v", resp.DefaultRelays)
+	}
+	if resp.DefaultQueryLimit != 25 || resp.MaxQueryLimit != 200 {
+		t.Errorf("query limits = %d/%d, want 25/200", resp.DefaultQueryLimit, resp.MaxQueryLimit)
+	}
+	if resp.QueryTimeoutMs != 10000 {
+		t.Errorf("query_timeout_ms = %d, want 10000", resp.QueryTimeoutMs)
+	}
+	if resp.LogLevel != "debug" {
+		t.Errorf("log_level = %q, want debug", resp.LogLevel)
+	}
+	if resp.Features.Nak {
+		t.Error("nak should be reported unavailable without a nak path")
+	}
+	if !resp.Features.EventCache || !resp.Features.KeepAlive {
+		t.Errorf("expected event cache and keep-alive enabled, got %+v", resp.Features)
+	}
+	if resp.Features.WriteProbes {
+		t.Error("write probes should be disabled without a probe key")
+	}
+}
+
+func TestHandleConfig_NeverExposesSecrets(t *testing.T) {
+	const secret = "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5"
+	const token = "api-token-that-must-stay-private"
+	cfg := &config.Config{
+		ProbeTestKey: secret,
+		APIToken:     token,
+	}
+	api := NewAPI(cfg, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+	w := httptest.NewRecorder()
+	api.HandleConfig(w, req)
+
+	body := w.Body.String()
+	if strings.Contains(body, secret) {
+		t.Fatalf("response leaks the probe key: %s", body)
+	}
+	if strings.Contains(body, token) {
+		t.Fatalf("response leaks the API token: %s", body)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &raw); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for key := range raw {
+		if strings.Contains(strings.ToLower(key), "key") {
+			t.Errorf("unexpected key-like field %q in response", key)
+		}
+	}
+
+	features, _ := raw["features"].(map[string]interface{})
+	if features["write_probes"] != true {
+		t.Errorf("expected write_probes true when a probe key is set, got %v", features["write_probes"])
+	}
+}
+
+func TestHandleConfig_MethodNotAllowed(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/config", nil)
+	w := httptest.NewRecorder()
+	api.HandleConfig(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
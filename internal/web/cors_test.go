@@ -0,0 +1,101 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func corsTestHandler() http.Handler {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+	return corsMiddleware([]string{"https://dash.example.com/"}, ok)
+}
+
+func TestCORS_AllowedOrigin(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	w := httptest.NewRecorder()
+
+	corsTestHandler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://dash.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the request origin", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestCORS_DisallowedOrigin(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+
+	corsTestHandler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none for a disallowed origin", got)
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	tests := []struct {
+		name       string
+		origin     string
+		wantStatus int
+		wantOrigin string
+	}{
+		{"allowed origin", "https://dash.example.com", http.StatusNoContent, "https://dash.example.com"},
+		{"disallowed origin", "https://evil.example.com", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/api/relays", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+			w := httptest.NewRecorder()
+
+			corsTestHandler().ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if tt.wantOrigin != "" {
+				if w.Header().Get("Access-Control-Allow-Methods") == "" {
+					t.Error("expected Access-Control-Allow-Methods on preflight")
+				}
+				if !strings.Contains(w.Header().Get("Access-Control-Allow-Headers"), "Content-Type") {
+					t.Errorf("Access-Control-Allow-Headers = %q, want it to include Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+				}
+			}
+		})
+	}
+}
+
+func TestCORS_NoOriginsConfiguredIsSameOriginOnly(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := corsMiddleware(nil, ok)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none by default", got)
+	}
+}
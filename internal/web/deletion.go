@@ -0,0 +1,73 @@
+package web
+
+import (
+	"github.com/keanuklestil/shirushi/internal/logging"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// EventLookupResponse is returned by HandleEventLookup. It is the event itself,
+// annotated when its author has requested its deletion (NIP-09).
+type EventLookupResponse struct {
+	types.Event
+	Deleted  bool          `json:"deleted,omitempty"`
+	Deletion *DeletionInfo `json:"deletion,omitempty"`
+	// FromHint is set when the event was only found on an nevent relay hint
+	// outside the pool; Relay then names that hint.
+	FromHint bool `json:"from_hint,omitempty"`
+	// Conflicts lists relays that returned a different event under the same
+	// ID, a sign of a faulty or malicious relay; Warnings describes them.
+	Conflicts []types.EventConflict `json:"conflicts,omitempty"`
+	Warnings  []string              `json:"warnings,omitempty"`
+}
+
+// DeletionInfo describes the kind 5 event that requested an event's deletion.
+type DeletionInfo struct {
+	EventID   string `json:"event_id"`
+	DeletedAt int64  `json:"deleted_at"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// findDeletion looks for a kind 5 deletion request by the event's author that
+// references the event. Only the author may delete an event, so deletions by
+// anyone else are ignored. Returns nil if none was found or the query failed.
+func (a *API) findDeletion(event types.Event) *DeletionInfo {
+	tags := map[string][]string{"e": {event.ID}}
+	deletions, err := a.relayPool.QueryEventsAdvanced([]int{5}, []string{event.PubKey}, tags, 10, 0, 0)
+	if err != nil {
+		logging.Warnf("[Web] Deletion check failed for %s: %v", event.ID, err)
+		return nil
+	}
+
+	var matched []types.Event
+	for _, deletion := range deletions {
+		if deletion.Kind == 5 && deletion.PubKey == event.PubKey && referencesEvent(deletion, event.ID) {
+			matched = append(matched, deletion)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	// Report the earliest request, since the event was deleted from then on
+	earliest := matched[0]
+	for _, deletion := range matched[1:] {
+		if deletion.CreatedAt < earliest.CreatedAt {
+			earliest = deletion
+		}
+	}
+	return &DeletionInfo{
+		EventID:   earliest.ID,
+		DeletedAt: earliest.CreatedAt,
+		Reason:    earliest.Content,
+	}
+}
+
+// referencesEvent reports whether event has an e tag pointing at id.
+func referencesEvent(event types.Event, id string) bool {
+	for _, tag := range event.Tags {
+		if len(tag) >= 2 && tag[0] == "e" && tag[1] == id {
+			return true
+		}
+	}
+	return false
+}
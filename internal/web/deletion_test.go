@@ -0,0 +1,111 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+const (
+	deletedEventID = "d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1d1"
+	deletionAuthor = "a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1"
+)
+
+func lookupWithDeletions(t *testing.T, deletions []types.Event) EventLookupResponse {
+	t.Helper()
+	pool := &mockRelayPool{
+		eventsByID: map[string]types.Event{
+			deletedEventID: {ID: deletedEventID, Kind: 1, PubKey: deletionAuthor, Content: "oops", CreatedAt: 1700000000},
+		},
+		events: deletions,
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/lookup?id="+deletedEventID, nil)
+	w := httptest.NewRecorder()
+	api.HandleEventLookup(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response EventLookupResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.ID != deletedEventID {
+		t.Errorf("expected event %s, got %s", deletedEventID, response.ID)
+	}
+	return response
+}
+
+func TestHandleEventLookup_MarksDeletedEvent(t *testing.T) {
+	response := lookupWithDeletions(t, []types.Event{
+		{ID: "del-late", Kind: 5, PubKey: deletionAuthor, CreatedAt: 1700002000, Tags: [][]string{{"e", deletedEventID}}, Content: "again"},
+		{ID: "del", Kind: 5, PubKey: deletionAuthor, CreatedAt: 1700001000, Tags: [][]string{{"e", deletedEventID}, {"k", "1"}}, Content: "posted by mistake"},
+	})
+
+	if !response.Deleted {
+		t.Fatal("expected event to be marked deleted")
+	}
+	if response.Deletion == nil {
+		t.Fatal("expected deletion details")
+	}
+	if response.Deletion.EventID != "del" || response.Deletion.DeletedAt != 1700001000 {
+		t.Errorf("expected earliest deletion 'del' at 1700001000, got %+v", response.Deletion)
+	}
+	if response.Deletion.Reason != "posted by mistake" {
+		t.Errorf("expected reason 'posted by mistake', got %q", response.Deletion.Reason)
+	}
+}
+
+func TestHandleEventLookup_IgnoresDeletionByOtherAuthor(t *testing.T) {
+	response := lookupWithDeletions(t, []types.Event{
+		{ID: "del", Kind: 5, PubKey: "b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2", CreatedAt: 1700001000, Tags: [][]string{{"e", deletedEventID}}},
+	})
+
+	if response.Deleted || response.Deletion != nil {
+		t.Errorf("expected deletion by another author to be ignored, got %+v", response.Deletion)
+	}
+}
+
+func TestHandleEventLookup_IgnoresUnrelatedDeletion(t *testing.T) {
+	response := lookupWithDeletions(t, []types.Event{
+		{ID: "del", Kind: 5, PubKey: deletionAuthor, CreatedAt: 1700001000, Tags: [][]string{{"e", "other"}}},
+	})
+
+	if response.Deleted {
+		t.Error("expected deletion of another event to be ignored")
+	}
+}
+
+func TestHandleEventLookup_NotDeletedOmitsFields(t *testing.T) {
+	pool := &mockRelayPool{
+		eventsByID: map[string]types.Event{
+			deletedEventID: {ID: deletedEventID, Kind: 1, PubKey: deletionAuthor, CreatedAt: 1700000000},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/lookup?id="+deletedEventID, nil)
+	w := httptest.NewRecorder()
+	api.HandleEventLookup(w, req)
+
+	var raw map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&raw); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if _, ok := raw["deleted"]; ok {
+		t.Error("expected deleted to be omitted")
+	}
+	if _, ok := raw["deletion"]; ok {
+		t.Error("expected deletion to be omitted")
+	}
+	if raw["id"] != deletedEventID {
+		t.Errorf("expected event fields at the top level, got %v", raw)
+	}
+}
@@ -0,0 +1,72 @@
+package web
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// DiagnosticsResponse is the health report returned by HandleDiagnostics.
+// Healthy is true when at least one relay is connected.
+type DiagnosticsResponse struct {
+	Healthy       bool                   `json:"healthy"`
+	Nak           NakDiagnostics         `json:"nak"`
+	Pool          *types.PoolDiagnostics `json:"pool,omitempty"`
+	Goroutines    int                    `json:"goroutines"`
+	UptimeSeconds int64                  `json:"uptime_seconds"`
+	Timestamp     int64                  `json:"timestamp"`
+}
+
+// NakDiagnostics reports whether the nak CLI is usable.
+type NakDiagnostics struct {
+	Available bool   `json:"available"`
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HandleDiagnostics returns a detailed health report for monitoring and
+// probes. Unlike HandleStatus it responds 503 when no relay is connected,
+// with the same report as the body.
+func (a *API) HandleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	report := DiagnosticsResponse{
+		Nak:           a.nakDiagnostics(),
+		Goroutines:    runtime.NumGoroutine(),
+		UptimeSeconds: int64(time.Since(a.startedAt).Seconds()),
+		Timestamp:     time.Now().Unix(),
+	}
+	if a.relayPool != nil {
+		report.Pool = a.relayPool.Diagnostics()
+		report.Healthy = report.Pool.ConnectedRelays > 0
+	}
+
+	if !report.Healthy {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, report)
+}
+
+// nakDiagnostics checks that nak is configured and answers a version query.
+func (a *API) nakDiagnostics() NakDiagnostics {
+	if !a.cfg.HasNak() || a.nak == nil {
+		return NakDiagnostics{Error: "nak CLI not found"}
+	}
+
+	diag := NakDiagnostics{Path: a.cfg.NakPath}
+	version, err := a.nak.Version()
+	if err != nil {
+		diag.Error = err.Error()
+		return diag
+	}
+	diag.Available = true
+	diag.Version = version
+	return diag
+}
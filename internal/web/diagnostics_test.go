@@ -0,0 +1,104 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func TestHandleDiagnostics_Healthy(t *testing.T) {
+	pool := &mockRelayPool{
+		diagnostics: &types.PoolDiagnostics{
+			Relays:              3,
+			ConnectedRelays:     2,
+			ActiveSubscriptions: 1,
+			ActiveQueries:       2,
+			EventCacheSize:      10,
+			EventCacheCapacity:  1000,
+			InfoCacheSize:       3,
+			InfoCacheCapacity:   500,
+		},
+	}
+	nakClient := &mockNakClient{version: "nak 0.7.0"}
+	api := NewAPI(&config.Config{NakPath: "/usr/bin/nak"}, nakClient, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/diagnostics", nil)
+	w := httptest.NewRecorder()
+	api.HandleDiagnostics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var report DiagnosticsResponse
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if !report.Healthy {
+		t.Error("expected healthy with connected relays")
+	}
+	if !report.Nak.Available || report.Nak.Version != "nak 0.7.0" || report.Nak.Path != "/usr/bin/nak" {
+		t.Errorf("unexpected nak diagnostics: %+v", report.Nak)
+	}
+	if report.Pool == nil || *report.Pool != *pool.diagnostics {
+		t.Errorf("pool diagnostics = %+v, want %+v", report.Pool, pool.diagnostics)
+	}
+	if report.Goroutines <= 0 {
+		t.Errorf("goroutines = %d, want a positive count", report.Goroutines)
+	}
+	if report.UptimeSeconds < 0 || report.Timestamp == 0 {
+		t.Errorf("unexpected uptime %d / timestamp %d", report.UptimeSeconds, report.Timestamp)
+	}
+}
+
+func TestHandleDiagnostics_Unhealthy(t *testing.T) {
+	pool := &mockRelayPool{diagnostics: &types.PoolDiagnostics{Relays: 2}}
+	nakClient := &mockNakClient{versionErr: errors.New("exit status 1")}
+	api := NewAPI(&config.Config{NakPath: "/usr/bin/nak"}, nakClient, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/diagnostics", nil)
+	w := httptest.NewRecorder()
+	api.HandleDiagnostics(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", w.Code)
+	}
+	var report DiagnosticsResponse
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Healthy {
+		t.Error("expected unhealthy with no connected relays")
+	}
+	if report.Nak.Available || report.Nak.Error == "" {
+		t.Errorf("expected nak to be reported unavailable with an error, got %+v", report.Nak)
+	}
+}
+
+func TestHandleDiagnostics_WithoutNak(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/diagnostics", nil)
+	w := httptest.NewRecorder()
+	api.HandleDiagnostics(w, req)
+
+	var report DiagnosticsResponse
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Nak.Available || report.Nak.Error != "nak CLI not found" {
+		t.Errorf("unexpected nak diagnostics: %+v", report.Nak)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/diagnostics", nil)
+	w = httptest.NewRecorder()
+	api.HandleDiagnostics(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
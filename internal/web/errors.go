@@ -0,0 +1,112 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// Error codes sent in the "code" field of error responses. Clients should
+// branch on these rather than on the human-readable message.
+const (
+	// Generic codes, derived from the HTTP status by writeError.
+	codeBadRequest           = "bad_request"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeMethodNotAllowed     = "method_not_allowed"
+	codeRequestTooLarge      = "request_too_large"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeRateLimited          = "rate_limited"
+	codeInternal             = "internal_error"
+	codeUnavailable          = "service_unavailable"
+	codeBadGateway           = "bad_gateway"
+
+	// Specific codes, passed explicitly to writeErrorCode.
+	codeNakUnavailable       = "nak_unavailable"
+	codeNakCommandNotAllowed = "nak_command_not_allowed"
+	codeInvalidPubkey        = "invalid_pubkey"
+	codeInvalidEventID       = "invalid_event_id"
+	codeInvalidRelayURL      = "invalid_relay_url"
+	codeRelayNotFound        = "relay_not_found"
+	codeRelayBlocked         = "relay_blocked"
+	codeRelayNotAllowed      = "relay_not_allowed"
+	codeEventNotFound        = "event_not_found"
+	codeProfileNotFound      = "profile_not_found"
+	codeNoConnectedRelays    = "no_connected_relays"
+	codeNoSupportingRelays   = "no_supporting_relays"
+	codeImageProxyDisabled   = "image_proxy_disabled"
+	codeImageTooLarge        = "image_too_large"
+)
+
+// ErrorResponse is the JSON body of every error response.
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// writeErrorCode writes an error response with an explicit error code. The
+// request ID set by requestIDMiddleware is included so errors can be matched
+// to logs.
+func writeErrorCode(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:     message,
+		Code:      code,
+		RequestID: w.Header().Get(requestIDHeader),
+	})
+}
+
+// statusErrorCode returns the generic error code for an HTTP status.
+func statusErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return codeBadRequest
+	case http.StatusUnauthorized:
+		return codeUnauthorized
+	case http.StatusForbidden:
+		return codeForbidden
+	case http.StatusNotFound:
+		return codeNotFound
+	case http.StatusMethodNotAllowed:
+		return codeMethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return codeRequestTooLarge
+	case http.StatusUnsupportedMediaType:
+		return codeUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return codeRateLimited
+	case http.StatusServiceUnavailable:
+		return codeUnavailable
+	case http.StatusBadGateway:
+		return codeBadGateway
+	default:
+		return codeInternal
+	}
+}
+
+// pubkeyErrorCode returns the code for a resolvePubkey failure, which is
+// either a missing nak (503) or a malformed pubkey.
+func pubkeyErrorCode(status int) string {
+	if status == http.StatusServiceUnavailable {
+		return codeNakUnavailable
+	}
+	return codeInvalidPubkey
+}
+
+// writeRelayURLError writes the error for a relay URL the pool refused: 403
+// for relays blocked or outside the allowlist, 400 for malformed URLs.
+func writeRelayURLError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, types.ErrRelayBlocked):
+		writeErrorCode(w, http.StatusForbidden, codeRelayBlocked, err.Error())
+	case errors.Is(err, types.ErrRelayNotAllowed):
+		writeErrorCode(w, http.StatusForbidden, codeRelayNotAllowed, err.Error())
+	default:
+		writeErrorCode(w, http.StatusBadRequest, codeInvalidRelayURL, err.Error())
+	}
+}
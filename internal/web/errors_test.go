@@ -0,0 +1,59 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func TestHandlers_EmitErrorCodes(t *testing.T) {
+	pool := &mockRelayPool{
+		relayList: []types.RelayStatus{{URL: "wss://relay.example.com"}},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+	missingID := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		method     string
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{"method not allowed", api.HandleRelayStats, http.MethodPost, "/api/relays/stats", http.StatusMethodNotAllowed, codeMethodNotAllowed},
+		{"nak unavailable", api.HandleKeyGenerate, http.MethodPost, "/api/keys/generate", http.StatusServiceUnavailable, codeNakUnavailable},
+		{"invalid pubkey", api.HandleProfile, http.MethodGet, "/api/profile/not-a-pubkey", http.StatusBadRequest, codeInvalidPubkey},
+		{"invalid event id", api.HandleEventLookup, http.MethodGet, "/api/events/lookup?id=xyz", http.StatusBadRequest, codeInvalidEventID},
+		{"event not found", api.HandleEventLookup, http.MethodGet, "/api/events/lookup?id=" + missingID, http.StatusNotFound, codeEventNotFound},
+		{"relay not found", api.HandleRelayReconnect, http.MethodPost, "/api/relays/reconnect?url=wss://other.example.com", http.StatusNotFound, codeRelayNotFound},
+		{"invalid relay url", api.HandleRelayReconnect, http.MethodPost, "/api/relays/reconnect?url=https://relay.example.com", http.StatusBadRequest, codeInvalidRelayURL},
+		{"generic bad request", api.HandleRelayReconnect, http.MethodPost, "/api/relays/reconnect", http.StatusBadRequest, codeBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			w := httptest.NewRecorder()
+			tt.handler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode error body: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+			}
+			if resp.Error == "" {
+				t.Error("expected the human-readable error message to be kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/logging"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// exportFlushEvery is how many events are written between flushes while
+// exporting, so large exports start reaching the client before the whole
+// file is encoded.
+const exportFlushEvery = 100
+
+// HandleEventsExport downloads query results as a file.
+// Accepts the same query params as HandleEvents (except outbox and timing) plus:
+// - format: "jsonl" (one event JSON object per line, the default) or "csv"
+// CSV columns are id, pubkey, kind, created_at, content and tags, where tags
+// summarizes each tag as its values joined by ":" and separates tags with ";".
+//
+// Only the encoding is incremental: the query result is collected and
+// post-filtered in memory first, since deduplication and newest-first
+// trimming need the whole merged set, and is then written out with periodic
+// flushes. Memory use is therefore bounded by the query limit, not streamed.
+func (a *API) HandleEventsExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	if format != "jsonl" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "invalid format value: must be jsonl or csv")
+		return
+	}
+
+	params, err := a.parseEventQueryParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.Outbox {
+		writeError(w, http.StatusBadRequest, "outbox is not supported for export")
+		return
+	}
+
+	events, err := a.queryEventsForExport(params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("events-%d.%s", time.Now().Unix(), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		writeEventsCSV(w, events)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	writeEventsJSONL(w, events)
+}
+
+// queryEventsForExport runs the query described by params. Failures on some
+// relays are logged rather than returned, since the export has no room for
+// warnings.
+func (a *API) queryEventsForExport(params *EventQueryParams) ([]types.Event, error) {
+	var events []types.Event
+	if params.GlobalLimit {
+		response, err := a.relayPool.QueryEventsGlobalLimitWithTiming(params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until, params.Relays...)
+		if err != nil {
+			return nil, err
+		}
+		events = response.Events
+	} else {
+		var err error
+		events, err = a.relayPool.QueryEventsAdvancedPartial(params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until, params.Relays...)
+		var partial *types.PartialError
+		if errors.As(err, &partial) {
+			logging.Warnf("[Web] Export continuing without failed relays: %v", partial)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.applyPostFilters(params, events), nil
+}
+
+// writeEventsJSONL writes one event JSON object per line.
+func writeEventsJSONL(w http.ResponseWriter, events []types.Event) {
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	for i, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return
+		}
+		if flusher != nil && (i+1)%exportFlushEvery == 0 {
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEventsCSV writes events as CSV rows with a header row.
+func writeEventsCSV(w http.ResponseWriter, events []types.Event) {
+	cw := csv.NewWriter(w)
+	flusher, _ := w.(http.Flusher)
+
+	cw.Write([]string{"id", "pubkey", "kind", "created_at", "content", "tags"})
+	for i, event := range events {
+		err := cw.Write([]string{
+			event.ID,
+			event.PubKey,
+			strconv.Itoa(event.Kind),
+			strconv.FormatInt(event.CreatedAt, 10),
+			event.Content,
+			tagSummary(event.Tags),
+		})
+		if err != nil {
+			return
+		}
+		if (i+1)%exportFlushEvery == 0 {
+			cw.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+	cw.Flush()
+}
+
+// tagSummary joins each tag's values with ":" and the tags with ";".
+func tagSummary(tags [][]string) string {
+	parts := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		parts = append(parts, strings.Join(tag, ":"))
+	}
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func exportTestEvents() []types.Event {
+	return []types.Event{
+		{
+			ID:        "event1",
+			PubKey:    "pubkey1",
+			Kind:      1,
+			CreatedAt: 1700000000,
+			Content:   "hello, world\nsecond line with \"quotes\"",
+			Tags:      [][]string{{"t", "nostr"}, {"p", "pubkey2", "wss://relay.example.com"}},
+		},
+		{
+			ID:        "event2",
+			PubKey:    "pubkey2",
+			Kind:      7,
+			CreatedAt: 1700000100,
+			Content:   "+",
+			Tags:      [][]string{},
+		},
+	}
+}
+
+func TestHandleEventsExport_JSONL(t *testing.T) {
+	pool := &mockRelayPool{events: exportTestEvents()}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/export?kinds=1,7&format=jsonl", nil)
+	w := httptest.NewRecorder()
+	api.HandleEventsExport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment; filename=\"events-") || !strings.HasSuffix(cd, ".jsonl\"") {
+		t.Errorf("unexpected Content-Disposition %q", cd)
+	}
+
+	var lines []types.Event
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var event types.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line is not a JSON event: %v", err)
+		}
+		lines = append(lines, event)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	// Export uses the same newest-first order as /api/events.
+	if lines[0].ID != "event2" || lines[1].ID != "event1" {
+		t.Errorf("expected newest first, got %s, %s", lines[0].ID, lines[1].ID)
+	}
+	if lines[1].Content != exportTestEvents()[0].Content {
+		t.Errorf("content did not round-trip: %q", lines[1].Content)
+	}
+}
+
+func TestHandleEventsExport_CSV(t *testing.T) {
+	pool := &mockRelayPool{events: exportTestEvents()}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/export?format=csv", nil)
+	w := httptest.NewRecorder()
+	api.HandleEventsExport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasSuffix(cd, ".csv\"") {
+		t.Errorf("unexpected Content-Disposition %q", cd)
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %d records", len(records))
+	}
+
+	wantHeader := []string{"id", "pubkey", "kind", "created_at", "content", "tags"}
+	for i, col := range wantHeader {
+		if records[0][i] != col {
+			t.Errorf("header[%d] = %q, want %q", i, records[0][i], col)
+		}
+	}
+
+	if records[1][0] != "event2" {
+		t.Errorf("expected the newest event first, got %v", records[1])
+	}
+	if records[1][5] != "" {
+		t.Errorf("expected empty tag summary, got %q", records[1][5])
+	}
+
+	row := records[2]
+	if row[0] != "event1" || row[2] != "1" || row[3] != "1700000000" {
+		t.Errorf("unexpected row %v", row)
+	}
+	if row[4] != exportTestEvents()[0].Content {
+		t.Errorf("content with commas and newlines did not round-trip: %q", row[4])
+	}
+	if row[5] != "t:nostr;p:pubkey2:wss://relay.example.com" {
+		t.Errorf("tags = %q", row[5])
+	}
+}
+
+func TestHandleEventsExport_InvalidFormat(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/export?format=xml", nil)
+	w := httptest.NewRecorder()
+	api.HandleEventsExport(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/keanuklestil/shirushi/internal/logging"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+const (
+	// defaultFeedLimit is the number of notes returned when no limit is given.
+	defaultFeedLimit = 20
+	// maxFeedLimit caps the number of notes a feed request may ask for.
+	maxFeedLimit = 100
+)
+
+// HandleProfileFeed returns a profile's metadata and its latest notes in one
+// response, querying both in parallel.
+// Path: /api/profile/{pubkey}/feed
+// Accepts an optional limit query param (default 20, capped at 100). When
+// the profile metadata can't be found but notes can, the notes are returned
+// with an empty profile and profile_found set to false.
+func (a *API) HandleProfileFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/profile/")
+	path = strings.TrimSuffix(path, "/feed")
+	if strings.TrimSpace(path) == "" {
+		writeError(w, http.StatusBadRequest, "pubkey is required in path")
+		return
+	}
+
+	pubkey, status, err := a.resolvePubkey(strings.TrimSpace(path))
+	if err != nil {
+		writeErrorCode(w, status, pubkeyErrorCode(status), err.Error())
+		return
+	}
+
+	limit := defaultFeedLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit value")
+			return
+		}
+		limit = min(l, maxFeedLimit)
+	}
+
+	var wg sync.WaitGroup
+	var profile *types.Profile
+	var notes []types.Event
+	var profileErr, notesErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		var events []types.Event
+		events, profileErr = a.relayPool.QueryEvents("0", pubkey, "1")
+		if latest := types.LatestReplaceable(events); latest != nil {
+			p := parseProfileMetadata(pubkey, *latest)
+			if p.NIP05 != "" {
+				p.NIP05Valid = verifyNIP05(p.NIP05, pubkey)
+			}
+			profile = &p
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		notes, notesErr = a.relayPool.QueryEventsAdvanced([]int{1}, []string{pubkey}, nil, limit, 0, 0)
+	}()
+	wg.Wait()
+
+	if notesErr != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query notes: "+notesErr.Error())
+		return
+	}
+	if profileErr != nil {
+		logging.Warnf("[Web] Feed for %s continuing without profile: %v", pubkey, profileErr)
+	}
+	if profile == nil && len(notes) == 0 {
+		if profileErr != nil {
+			writeError(w, http.StatusInternalServerError, "failed to query profile: "+profileErr.Error())
+			return
+		}
+		writeErrorCode(w, http.StatusNotFound, codeProfileNotFound, "profile not found")
+		return
+	}
+
+	if notes == nil {
+		notes = []types.Event{}
+	}
+	feed := types.ProfileFeed{
+		Profile:      types.Profile{PubKey: pubkey},
+		ProfileFound: profile != nil,
+		Notes:        newestEvents(notes, limit),
+	}
+	if profile != nil {
+		feed.Profile = *profile
+	}
+	writeJSON(w, feed)
+}
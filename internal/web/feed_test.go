@@ -0,0 +1,125 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+const feedPubkey = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+
+func TestHandleProfileFeed_CombinesProfileAndNotes(t *testing.T) {
+	pool := &mockRelayPool{
+		kindEvents: map[string][]types.Event{
+			"0": {{ID: "meta", PubKey: feedPubkey, Kind: 0, CreatedAt: 100, Content: `{"name":"alice"}`}},
+		},
+		events: []types.Event{
+			{ID: "older", PubKey: feedPubkey, Kind: 1, CreatedAt: 200, Content: "first"},
+			{ID: "newer", PubKey: feedPubkey, Kind: 1, CreatedAt: 300, Content: "second"},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/"+feedPubkey+"/feed?limit=5", nil)
+	w := httptest.NewRecorder()
+	api.HandleProfile(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var feed types.ProfileFeed
+	if err := json.NewDecoder(w.Body).Decode(&feed); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !feed.ProfileFound || feed.Profile.Name != "alice" || feed.Profile.PubKey != feedPubkey {
+		t.Errorf("unexpected profile: found=%v %+v", feed.ProfileFound, feed.Profile)
+	}
+	if len(feed.Notes) != 2 || feed.Notes[0].ID != "newer" || feed.Notes[1].ID != "older" {
+		t.Errorf("expected notes newest first, got %+v", feed.Notes)
+	}
+	if pool.lastLimit != 5 {
+		t.Errorf("notes queried with limit %d, want 5", pool.lastLimit)
+	}
+	if len(pool.lastAuthors) != 1 || pool.lastAuthors[0] != feedPubkey {
+		t.Errorf("notes queried for authors %v, want the profile pubkey", pool.lastAuthors)
+	}
+}
+
+func TestHandleProfileFeed_NotesWithoutProfile(t *testing.T) {
+	pool := &mockRelayPool{
+		kindEvents: map[string][]types.Event{},
+		events:     []types.Event{{ID: "note", PubKey: feedPubkey, Kind: 1, CreatedAt: 200}},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/"+feedPubkey+"/feed", nil)
+	w := httptest.NewRecorder()
+	api.HandleProfileFeed(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var feed types.ProfileFeed
+	if err := json.NewDecoder(w.Body).Decode(&feed); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if feed.ProfileFound {
+		t.Error("expected profile_found to be false")
+	}
+	if feed.Profile.PubKey != feedPubkey || feed.Profile.Name != "" {
+		t.Errorf("expected an empty profile with only the pubkey, got %+v", feed.Profile)
+	}
+	if len(feed.Notes) != 1 {
+		t.Errorf("expected 1 note, got %d", len(feed.Notes))
+	}
+	if pool.lastLimit != defaultFeedLimit {
+		t.Errorf("notes queried with limit %d, want default %d", pool.lastLimit, defaultFeedLimit)
+	}
+}
+
+func TestHandleProfileFeed_CapsLimit(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{{ID: "note", PubKey: feedPubkey, Kind: 1, CreatedAt: 200}},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/"+feedPubkey+"/feed?limit=1000", nil)
+	w := httptest.NewRecorder()
+	api.HandleProfileFeed(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if pool.lastLimit != maxFeedLimit {
+		t.Errorf("notes queried with limit %d, want cap %d", pool.lastLimit, maxFeedLimit)
+	}
+}
+
+func TestHandleProfileFeed_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"nothing found", "/api/profile/" + feedPubkey + "/feed", http.StatusNotFound},
+		{"invalid limit", "/api/profile/" + feedPubkey + "/feed?limit=abc", http.StatusBadRequest},
+		{"invalid pubkey", "/api/profile/xyz/feed", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			w := httptest.NewRecorder()
+			api.HandleProfileFeed(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func largeJSONHandler() http.Handler {
+	body := `{"data":"` + strings.Repeat("a", 4096) + `"}`
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+}
+
+func TestGzipMiddleware_CompressesLargeResponses(t *testing.T) {
+	handler := gzipMiddleware(largeJSONHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/monitoring/history", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("response is not gzip: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	if len(body) != 4096+len(`{"data":""}`) {
+		t.Errorf("decompressed body has %d bytes", len(body))
+	}
+}
+
+func TestGzipMiddleware_PlainWithoutAcceptEncoding(t *testing.T) {
+	handler := gzipMiddleware(largeJSONHandler())
+
+	for _, acceptEncoding := range []string{"", "deflate", "gzip;q=0"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/monitoring/history", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want none", acceptEncoding, got)
+		}
+		if !strings.HasPrefix(w.Body.String(), `{"data":"aaa`) {
+			t.Errorf("Accept-Encoding %q: expected a plain JSON body", acceptEncoding)
+		}
+	}
+}
+
+func TestGzipMiddleware_SmallResponsesUncompressed(t *testing.T) {
+	handler := gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not found")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none for a small body", got)
+	}
+	if !strings.Contains(w.Body.String(), "not found") {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+}
+
+func TestGzipMiddleware_SkipsStreams(t *testing.T) {
+	handler := gzipMiddleware(largeJSONHandler())
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+	}{
+		{"event stream path", "/api/events/stream", "", ""},
+		{"websocket path", "/ws", "", ""},
+		{"upgrade header", "/api/other", "Upgrade", "websocket"},
+		{"sse accept", "/api/other", "Accept", "text/event-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+		})
+	}
+}
@@ -4,18 +4,76 @@ package web
 import (
 	"encoding/json"
 	"log"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/keanuklestil/shirushi/internal/logging"
 	"github.com/keanuklestil/shirushi/internal/types"
 )
 
+// clientSendBuffer is how many messages may queue for a client before it is
+// considered too slow and dropped.
+const clientSendBuffer = 256
+
 // Client represents a connected WebSocket client.
 type Client struct {
 	hub  *Hub
 	conn *websocket.Conn
 	send chan []byte
+
+	// filter limits which live events the client receives; nil means all.
+	filter   *eventFilter
+	filterMu sync.RWMutex
+}
+
+// eventFilter is the kinds/authors filter a client sets with a
+// subscribe_events message. An empty field matches every event.
+type eventFilter struct {
+	Kinds   []int    `json:"kinds"`
+	Authors []string `json:"authors"`
+}
+
+// matches reports whether event passes the filter.
+func (f *eventFilter) matches(event types.Event) bool {
+	if len(f.Kinds) > 0 && !slices.Contains(f.Kinds, event.Kind) {
+		return false
+	}
+	if len(f.Authors) > 0 && !slices.Contains(f.Authors, event.PubKey) {
+		return false
+	}
+	return true
+}
+
+// setFilter replaces the client's event filter. A filter with no kinds and
+// no authors clears it.
+func (c *Client) setFilter(f *eventFilter) {
+	if f != nil && len(f.Kinds) == 0 && len(f.Authors) == 0 {
+		f = nil
+	}
+	c.filterMu.Lock()
+	c.filter = f
+	c.filterMu.Unlock()
+}
+
+// filterEvents returns the events the client is subscribed to.
+func (c *Client) filterEvents(events []types.Event) []types.Event {
+	c.filterMu.RLock()
+	f := c.filter
+	c.filterMu.RUnlock()
+
+	if f == nil {
+		return events
+	}
+	matched := make([]types.Event, 0, len(events))
+	for _, event := range events {
+		if f.matches(event) {
+			matched = append(matched, event)
+		}
+	}
+	return matched
 }
 
 // Hub maintains the set of active clients and broadcasts messages.
@@ -35,6 +93,9 @@ type Hub struct {
 	eventTicker     *time.Ticker
 	maxEventsPerSec int
 	stopChan        chan struct{}
+
+	// droppedClients counts clients evicted because their send buffer filled.
+	droppedClients atomic.Int64
 }
 
 // NewHub creates a new Hub.
@@ -83,28 +144,18 @@ func (h *Hub) Run() {
 
 		case message := <-h.broadcast:
 			// Collect clients that fail to receive the message
-			var deadClients []*Client
+			var slowClients []*Client
 			h.mu.RLock()
 			for client := range h.clients {
 				select {
 				case client.send <- message:
 				default:
-					deadClients = append(deadClients, client)
+					slowClients = append(slowClients, client)
 				}
 			}
 			h.mu.RUnlock()
 
-			// Remove dead clients with proper write lock
-			if len(deadClients) > 0 {
-				h.mu.Lock()
-				for _, client := range deadClients {
-					if _, ok := h.clients[client]; ok {
-						delete(h.clients, client)
-						close(client.send)
-					}
-				}
-				h.mu.Unlock()
-			}
+			h.dropClients(slowClients)
 
 		case <-h.eventTicker.C:
 			h.flushEventBuffer()
@@ -112,6 +163,32 @@ func (h *Hub) Run() {
 	}
 }
 
+// dropClients closes and unregisters clients whose send buffer is full, so
+// one stuck connection never blocks delivery to the others.
+func (h *Hub) dropClients(clients []*Client) {
+	if len(clients) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, client := range clients {
+		if _, ok := h.clients[client]; ok {
+			delete(h.clients, client)
+			close(client.send)
+			total := h.droppedClients.Add(1)
+			logging.Warnf("[Hub] Dropped slow client (%d remaining, %d dropped total)", len(h.clients), total)
+		}
+	}
+}
+
+// DroppedClientCount returns how many clients have been dropped for not
+// keeping up with broadcasts.
+func (h *Hub) DroppedClientCount() int64 {
+	return h.droppedClients.Load()
+}
+
 // Stop gracefully stops the hub.
 func (h *Hub) Stop() {
 	close(h.stopChan)
@@ -140,17 +217,54 @@ func (h *Hub) flushEventBuffer() {
 	}
 	h.eventMu.Unlock()
 
-	// Send events as a batch message
 	if len(eventsToSend) > 0 {
-		h.Broadcast(Message{
-			Type: "events_batch",
-			Data: eventsToSend,
-		})
+		h.sendEventsBatch(eventsToSend)
 	}
 }
 
-// HandleClientMessage processes incoming messages from clients
-func (h *Hub) HandleClientMessage(data []byte) {
+// sendEventsBatch sends each client the events matching its filter as a
+// single events_batch message. Clients with nothing to receive are skipped;
+// clients whose send buffer is full are dropped.
+func (h *Hub) sendEventsBatch(events []types.Event) {
+	var unfiltered []byte
+	var slowClients []*Client
+	defer func() { h.dropClients(slowClients) }()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		matched := client.filterEvents(events)
+		if len(matched) == 0 {
+			continue
+		}
+
+		var data []byte
+		if len(matched) == len(events) && unfiltered != nil {
+			data = unfiltered
+		} else {
+			var err error
+			data, err = json.Marshal(Message{Type: "events_batch", Data: matched})
+			if err != nil {
+				log.Printf("[Hub] Error marshaling message: %v", err)
+				return
+			}
+			if len(matched) == len(events) {
+				unfiltered = data
+			}
+		}
+
+		select {
+		case client.send <- data:
+		default:
+			slowClients = append(slowClients, client)
+		}
+	}
+}
+
+// HandleClientMessage processes incoming messages from a client. The client
+// may be nil when the message has no sender to apply per-client state to.
+func (h *Hub) HandleClientMessage(client *Client, data []byte) {
 	var msg struct {
 		Type string          `json:"type"`
 		Data json.RawMessage `json:"data"`
@@ -163,8 +277,16 @@ func (h *Hub) HandleClientMessage(data []byte) {
 
 	switch msg.Type {
 	case "subscribe_events":
-		// Handle event subscription requests
-		log.Printf("[Hub] Event subscription request")
+		var filter eventFilter
+		if len(msg.Data) > 0 {
+			if err := json.Unmarshal(msg.Data, &filter); err != nil {
+				logging.Warnf("[Hub] Invalid event subscription: %v", err)
+				return
+			}
+		}
+		if client != nil {
+			client.setFilter(&filter)
+		}
 	case "ping":
 		// Handle ping
 	default:
@@ -203,7 +325,8 @@ func (h *Hub) Broadcast(msg Message) {
 	}
 }
 
-// BroadcastEvent buffers an event for rate-limited broadcast to all clients.
+// BroadcastEvent buffers an event for rate-limited broadcast to clients whose
+// subscription filter matches it.
 // Duplicate events (by ID) are ignored to prevent sending the same event multiple times.
 func (h *Hub) BroadcastEvent(event types.Event) {
 	h.eventMu.Lock()
@@ -255,6 +378,14 @@ func (h *Hub) BroadcastRelayInfo(url string, info *types.RelayInfo) {
 	})
 }
 
+// BroadcastRelayPruned tells all clients a relay was pruned from the pool.
+func (h *Hub) BroadcastRelayPruned(pruned types.PrunedRelay) {
+	h.Broadcast(Message{
+		Type: "relay_pruned",
+		Data: pruned,
+	})
+}
+
 // BroadcastTestResult sends a test result to all clients.
 func (h *Hub) BroadcastTestResult(result types.TestResult) {
 	h.Broadcast(Message{
@@ -263,6 +394,17 @@ func (h *Hub) BroadcastTestResult(result types.TestResult) {
 	})
 }
 
+// BroadcastTestStep sends a single test step to all clients while a test is running.
+func (h *Hub) BroadcastTestStep(nipID string, step types.TestStep) {
+	h.Broadcast(Message{
+		Type: "test_step",
+		Data: map[string]interface{}{
+			"nip_id": nipID,
+			"step":   step,
+		},
+	})
+}
+
 // BroadcastMonitoringUpdate sends monitoring data to all clients.
 func (h *Hub) BroadcastMonitoringUpdate(data types.MonitoringData) {
 	h.Broadcast(Message{
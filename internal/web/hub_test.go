@@ -916,7 +916,7 @@ func TestHub_HandleClientMessage_UnknownType(t *testing.T) {
 
 	// Test with unknown message type - should not panic
 	data := []byte(`{"type":"unknown","data":{}}`)
-	hub.HandleClientMessage(data)
+	hub.HandleClientMessage(nil, data)
 }
 
 func TestHub_HandleClientMessage_InvalidJSON(t *testing.T) {
@@ -924,7 +924,7 @@ func TestHub_HandleClientMessage_InvalidJSON(t *testing.T) {
 
 	// Test with invalid JSON - should not panic
 	data := []byte(`invalid json`)
-	hub.HandleClientMessage(data)
+	hub.HandleClientMessage(nil, data)
 }
 
 func TestHub_HandleClientMessage_SubscribeEvents(t *testing.T) {
@@ -932,7 +932,7 @@ func TestHub_HandleClientMessage_SubscribeEvents(t *testing.T) {
 
 	// Test subscribe_events message type
 	data := []byte(`{"type":"subscribe_events","data":{"kinds":[1]}}`)
-	hub.HandleClientMessage(data)
+	hub.HandleClientMessage(nil, data)
 }
 
 func TestHub_HandleClientMessage_Ping(t *testing.T) {
@@ -940,7 +940,7 @@ func TestHub_HandleClientMessage_Ping(t *testing.T) {
 
 	// Test ping message type
 	data := []byte(`{"type":"ping","data":{}}`)
-	hub.HandleClientMessage(data)
+	hub.HandleClientMessage(nil, data)
 }
 
 func TestGetNIPList_ValidCategories(t *testing.T) {
@@ -1549,3 +1549,187 @@ func TestHub_SeenEventIDs_MapCleanupConcurrent(t *testing.T) {
 		t.Errorf("expected seenEventIDs to be cleaned up, got %d entries", seenCount)
 	}
 }
+
+// receivedEventIDs drains a client's send channel and returns the IDs of
+// events delivered in events_batch messages.
+func receivedEventIDs(t *testing.T, c *Client) []string {
+	t.Helper()
+	var ids []string
+	for {
+		select {
+		case data := <-c.send:
+			var msg struct {
+				Type string        `json:"type"`
+				Data []types.Event `json:"data"`
+			}
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatalf("failed to decode message: %v", err)
+			}
+			if msg.Type != "events_batch" {
+				t.Fatalf("expected events_batch, got %s", msg.Type)
+			}
+			for _, e := range msg.Data {
+				ids = append(ids, e.ID)
+			}
+		default:
+			return ids
+		}
+	}
+}
+
+func TestHub_SubscribeEvents_FiltersByKind(t *testing.T) {
+	hub := NewHub()
+	hub.maxEventsPerSec = 100
+
+	filtered := &Client{hub: hub, send: make(chan []byte, 16)}
+	unfiltered := &Client{hub: hub, send: make(chan []byte, 16)}
+	hub.clients[filtered] = true
+	hub.clients[unfiltered] = true
+
+	hub.HandleClientMessage(filtered, []byte(`{"type":"subscribe_events","data":{"kinds":[1]}}`))
+
+	hub.BroadcastEvent(types.Event{ID: "note", Kind: 1, PubKey: "alice"})
+	hub.BroadcastEvent(types.Event{ID: "reaction", Kind: 7, PubKey: "alice"})
+	hub.flushEventBuffer()
+
+	got := receivedEventIDs(t, filtered)
+	if len(got) != 1 || got[0] != "note" {
+		t.Errorf("filtered client received %v, want [note]", got)
+	}
+
+	got = receivedEventIDs(t, unfiltered)
+	if len(got) != 2 {
+		t.Errorf("unfiltered client received %v, want both events", got)
+	}
+}
+
+func TestHub_SubscribeEvents_FiltersByAuthor(t *testing.T) {
+	hub := NewHub()
+	hub.maxEventsPerSec = 100
+
+	client := &Client{hub: hub, send: make(chan []byte, 16)}
+	hub.clients[client] = true
+
+	hub.HandleClientMessage(client, []byte(`{"type":"subscribe_events","data":{"kinds":[1,7],"authors":["bob"]}}`))
+
+	hub.BroadcastEvent(types.Event{ID: "alice-note", Kind: 1, PubKey: "alice"})
+	hub.BroadcastEvent(types.Event{ID: "bob-note", Kind: 1, PubKey: "bob"})
+	hub.BroadcastEvent(types.Event{ID: "bob-repost", Kind: 6, PubKey: "bob"})
+	hub.flushEventBuffer()
+
+	got := receivedEventIDs(t, client)
+	if len(got) != 1 || got[0] != "bob-note" {
+		t.Errorf("client received %v, want [bob-note]", got)
+	}
+}
+
+func TestHub_SubscribeEvents_NoMatchSendsNothing(t *testing.T) {
+	hub := NewHub()
+
+	client := &Client{hub: hub, send: make(chan []byte, 16)}
+	hub.clients[client] = true
+
+	hub.HandleClientMessage(client, []byte(`{"type":"subscribe_events","data":{"kinds":[30023]}}`))
+	hub.BroadcastEvent(types.Event{ID: "note", Kind: 1})
+	hub.flushEventBuffer()
+
+	if len(client.send) != 0 {
+		t.Errorf("expected no messages, got %d", len(client.send))
+	}
+}
+
+func TestHub_SubscribeEvents_EmptyFilterClears(t *testing.T) {
+	hub := NewHub()
+	hub.maxEventsPerSec = 100
+
+	client := &Client{hub: hub, send: make(chan []byte, 16)}
+	hub.clients[client] = true
+
+	hub.HandleClientMessage(client, []byte(`{"type":"subscribe_events","data":{"kinds":[1]}}`))
+	hub.HandleClientMessage(client, []byte(`{"type":"subscribe_events","data":{}}`))
+
+	hub.BroadcastEvent(types.Event{ID: "reaction", Kind: 7})
+	hub.flushEventBuffer()
+
+	got := receivedEventIDs(t, client)
+	if len(got) != 1 {
+		t.Errorf("client received %v, want [reaction]", got)
+	}
+}
+
+func TestHub_SlowClientEvictedWithoutBlockingOthers(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	// The slow client never drains its buffer
+	slow := &Client{hub: hub, send: make(chan []byte, 2)}
+	fast := &Client{hub: hub, send: make(chan []byte, clientSendBuffer)}
+	hub.mu.Lock()
+	hub.clients[slow] = true
+	hub.clients[fast] = true
+	hub.mu.Unlock()
+
+	received := make(chan int)
+	go func() {
+		n := 0
+		for range fast.send {
+			n++
+			if n == 10 {
+				received <- n
+			}
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		hub.Broadcast(Message{Type: "test", Data: i})
+	}
+
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("fast client did not receive all broadcasts")
+	}
+
+	hub.mu.RLock()
+	_, slowRegistered := hub.clients[slow]
+	_, fastRegistered := hub.clients[fast]
+	hub.mu.RUnlock()
+
+	if slowRegistered {
+		t.Error("expected slow client to be evicted")
+	}
+	if !fastRegistered {
+		t.Error("expected fast client to stay registered")
+	}
+	if got := hub.DroppedClientCount(); got != 1 {
+		t.Errorf("DroppedClientCount() = %d, want 1", got)
+	}
+
+	// The slow client's channel is closed so its writer shuts down
+	for range slow.send {
+	}
+}
+
+func TestHub_SendEventsBatch_DropsSlowClient(t *testing.T) {
+	hub := NewHub()
+
+	slow := &Client{hub: hub, send: make(chan []byte, 1)}
+	slow.send <- []byte("filler")
+	fast := &Client{hub: hub, send: make(chan []byte, 4)}
+	hub.clients[slow] = true
+	hub.clients[fast] = true
+
+	hub.BroadcastEvent(types.Event{ID: "note", Kind: 1})
+	hub.flushEventBuffer()
+
+	if got := receivedEventIDs(t, fast); len(got) != 1 {
+		t.Errorf("fast client received %v, want [note]", got)
+	}
+	if _, ok := hub.clients[slow]; ok {
+		t.Error("expected slow client to be evicted")
+	}
+	if got := hub.DroppedClientCount(); got != 1 {
+		t.Errorf("DroppedClientCount() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,210 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Image proxy limits. Fetched images are cached for imageCacheTTL, up to
+// imageCacheEntries images; fallbackImageMaxBytes applies when the config
+// leaves ImageProxyMaxBytes unset.
+const (
+	fallbackImageMaxBytes = 5 << 20
+	imageFetchTimeout     = 10 * time.Second
+	imageCacheTTL         = 10 * time.Minute
+	imageCacheEntries     = 200
+)
+
+// errImageTooLarge is returned when a remote image exceeds the size limit.
+var errImageTooLarge = errors.New("image too large")
+
+// errNotImage is returned when a remote response isn't a supported image.
+var errNotImage = errors.New("not an image")
+
+// imageProxy fetches remote images server-side so the dashboard doesn't
+// leak the viewer's IP to arbitrary hosts, and caches them briefly.
+type imageProxy struct {
+	client   *http.Client
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*cachedImage
+}
+
+// cachedImage is a fetched image kept until expires.
+type cachedImage struct {
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// newImageProxy creates a proxy serving images up to maxBytes. Its client
+// refuses to connect to loopback, private and link-local addresses so the
+// proxy can't be used to reach the local network.
+func newImageProxy(maxBytes int64) *imageProxy {
+	if maxBytes <= 0 {
+		maxBytes = fallbackImageMaxBytes
+	}
+	dialer := &net.Dialer{
+		Timeout: imageFetchTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("refusing to fetch from non-public address %s", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &imageProxy{
+		client:   &http.Client{Timeout: imageFetchTimeout, Transport: transport},
+		maxBytes: maxBytes,
+		entries:  make(map[string]*cachedImage),
+	}
+}
+
+// publicIP reports whether ip is a globally routable unicast address.
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsMulticast()
+}
+
+// get returns the image at rawURL from the cache or fetches it.
+func (p *imageProxy) get(ctx context.Context, rawURL string) (*cachedImage, error) {
+	now := time.Now()
+
+	p.mu.Lock()
+	if img, ok := p.entries[rawURL]; ok && now.Before(img.expires) {
+		p.mu.Unlock()
+		return img, nil
+	}
+	p.mu.Unlock()
+
+	img, err := p.fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	img.expires = now.Add(imageCacheTTL)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.entries) >= imageCacheEntries {
+		p.evictLocked(now)
+	}
+	p.entries[rawURL] = img
+	return img, nil
+}
+
+// evictLocked drops expired images, or the one expiring soonest if none has.
+func (p *imageProxy) evictLocked(now time.Time) {
+	var soonest string
+	for url, img := range p.entries {
+		if !now.Before(img.expires) {
+			delete(p.entries, url)
+			continue
+		}
+		if soonest == "" || img.expires.Before(p.entries[soonest].expires) {
+			soonest = url
+		}
+	}
+	if len(p.entries) >= imageCacheEntries && soonest != "" {
+		delete(p.entries, soonest)
+	}
+}
+
+// fetch downloads an image, enforcing the size limit and requiring an
+// image/* content type other than SVG, which can carry scripts.
+func (p *imageProxy) fetch(ctx context.Context, rawURL string) (*cachedImage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote returned %s", resp.Status)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "image/") || mediaType == "image/svg+xml" {
+		return nil, errNotImage
+	}
+
+	if resp.ContentLength > p.maxBytes {
+		return nil, errImageTooLarge
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, p.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > p.maxBytes {
+		return nil, errImageTooLarge
+	}
+
+	return &cachedImage{contentType: mediaType, body: body}, nil
+}
+
+// HandleImageProxy serves GET /api/proxy/image?url=...: it fetches a remote
+// image (such as a profile picture) server-side and returns it. Only
+// http(s) URLs and image types are accepted. The proxy must be enabled in
+// the config.
+func (a *API) HandleImageProxy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if a.imageProxy == nil {
+		writeErrorCode(w, http.StatusNotFound, codeImageProxyDisabled, "image proxy is disabled")
+		return
+	}
+
+	rawURL := r.URL.Query().Get("url")
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		writeError(w, http.StatusBadRequest, "url must be an http or https URL")
+		return
+	}
+
+	img, err := a.imageProxy.get(r.Context(), u.String())
+	switch {
+	case errors.Is(err, errNotImage):
+		writeError(w, http.StatusUnsupportedMediaType, "remote resource is not a supported image")
+		return
+	case errors.Is(err, errImageTooLarge):
+		writeErrorCode(w, http.StatusBadGateway, codeImageTooLarge, fmt.Sprintf("image exceeds %d bytes", a.imageProxy.maxBytes))
+		return
+	case err != nil:
+		writeError(w, http.StatusBadGateway, "failed to fetch image: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", img.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(img.body)))
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(imageCacheTTL.Seconds())))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Write(img.body)
+}
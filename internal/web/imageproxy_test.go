@@ -0,0 +1,146 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+)
+
+// pngBytes is the start of a PNG file; the proxy doesn't decode images.
+var pngBytes = []byte("\x89PNG\r\n\x1a\n-image-data-")
+
+func imageServer(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/avatar.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(pngBytes)
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(bytes.Repeat([]byte{0}, 2048))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		case "/logo.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte("<svg></svg>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func imageProxyAPI(server *httptest.Server, maxBytes int64) *API {
+	api := NewAPI(&config.Config{ImageProxy: true, ImageProxyMaxBytes: maxBytes}, nil, &mockRelayPool{}, nil)
+	// The test server listens on loopback, which the proxy's own client refuses.
+	api.imageProxy.client = server.Client()
+	return api
+}
+
+func proxyImage(api *API, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/proxy/image?url="+url.QueryEscape(target), nil)
+	w := httptest.NewRecorder()
+	api.HandleImageProxy(w, req)
+	return w
+}
+
+func TestHandleImageProxy_ServesAndCachesImage(t *testing.T) {
+	var hits atomic.Int32
+	server := imageServer(t, &hits)
+	api := imageProxyAPI(server, 1024)
+
+	for i := 0; i < 2; i++ {
+		w := proxyImage(api, server.URL+"/avatar.png")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); got != "image/png" {
+			t.Errorf("Content-Type = %q, want image/png", got)
+		}
+		if !bytes.Equal(w.Body.Bytes(), pngBytes) {
+			t.Errorf("unexpected body %q", w.Body.Bytes())
+		}
+		if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Error("expected X-Content-Type-Options: nosniff")
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected the second request to be served from cache, remote saw %d requests", hits.Load())
+	}
+}
+
+func TestHandleImageProxy_Rejects(t *testing.T) {
+	var hits atomic.Int32
+	server := imageServer(t, &hits)
+	api := imageProxyAPI(server, 1024)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{"oversized response", server.URL + "/huge.png", http.StatusBadGateway, codeImageTooLarge},
+		{"non-image content type", server.URL + "/page.html", http.StatusUnsupportedMediaType, codeUnsupportedMediaType},
+		{"svg", server.URL + "/logo.svg", http.StatusUnsupportedMediaType, codeUnsupportedMediaType},
+		{"remote error", server.URL + "/missing.png", http.StatusBadGateway, codeBadGateway},
+		{"non-http scheme", "file:///etc/passwd", http.StatusBadRequest, codeBadRequest},
+		{"missing url", "", http.StatusBadRequest, codeBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := proxyImage(api, tt.target)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode error: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandleImageProxy_RefusesLocalAddresses(t *testing.T) {
+	var hits atomic.Int32
+	server := imageServer(t, &hits)
+	api := NewAPI(&config.Config{ImageProxy: true}, nil, &mockRelayPool{}, nil)
+
+	w := proxyImage(api, server.URL+"/avatar.png")
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected status 502, got %d: %s", w.Code, w.Body.String())
+	}
+	if hits.Load() != 0 {
+		t.Errorf("expected no request to reach the loopback server, got %d", hits.Load())
+	}
+}
+
+func TestHandleImageProxy_Disabled(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	w := proxyImage(api, "https://example.com/avatar.png")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	if resp.Code != codeImageProxyDisabled {
+		t.Errorf("code = %q, want %q", resp.Code, codeImageProxyDisabled)
+	}
+}
@@ -0,0 +1,316 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// httpLatencyBuckets are the upper bounds, in seconds, of the request
+// latency histogram buckets.
+var httpLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// httpMetrics is a registry of HTTP request counts, status codes and latency
+// histograms per route pattern. It is safe for concurrent use.
+type httpMetrics struct {
+	mu     sync.Mutex
+	routes map[string]*routeMetrics
+}
+
+// routeMetrics holds the request metrics of a single route.
+type routeMetrics struct {
+	statuses map[int]int64
+	buckets  []int64 // requests per latency bucket, with a final +Inf bucket
+	count    int64
+	sum      time.Duration
+	max      time.Duration
+}
+
+func newHTTPMetrics() *httpMetrics {
+	return &httpMetrics{routes: make(map[string]*routeMetrics)}
+}
+
+// record counts one request to route that was answered with status after
+// taking elapsed.
+func (m *httpMetrics) record(route string, status int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rm, exists := m.routes[route]
+	if !exists {
+		rm = &routeMetrics{
+			statuses: make(map[int]int64),
+			buckets:  make([]int64, len(httpLatencyBuckets)+1),
+		}
+		m.routes[route] = rm
+	}
+	rm.statuses[status]++
+	rm.buckets[sort.SearchFloat64s(httpLatencyBuckets, elapsed.Seconds())]++
+	rm.count++
+	rm.sum += elapsed
+	rm.max = max(rm.max, elapsed)
+}
+
+// HTTPRouteStats summarizes the requests served by one route pattern.
+// LatencyBuckets are cumulative, as in a Prometheus histogram.
+type HTTPRouteStats struct {
+	Route          string              `json:"route"`
+	Requests       int64               `json:"requests"`
+	Statuses       map[int]int64       `json:"statuses"`
+	TotalLatencyMs float64             `json:"total_latency_ms"`
+	AvgLatencyMs   float64             `json:"avg_latency_ms"`
+	MaxLatencyMs   float64             `json:"max_latency_ms"`
+	LatencyBuckets []HTTPLatencyBucket `json:"latency_buckets"`
+}
+
+// HTTPLatencyBucket counts the requests that took at most LeMs milliseconds.
+// The last bucket has no bound and counts every request.
+type HTTPLatencyBucket struct {
+	LeMs  float64 `json:"le_ms,omitempty"`
+	Count int64   `json:"count"`
+}
+
+// HTTPStatsResponse is the body of HandleHTTPStats.
+type HTTPStatsResponse struct {
+	Routes    []HTTPRouteStats `json:"routes"`
+	Timestamp int64            `json:"timestamp"`
+}
+
+// snapshot returns the stats of every route, sorted by route.
+func (m *httpMetrics) snapshot() []HTTPRouteStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]HTTPRouteStats, 0, len(m.routes))
+	for route, rm := range m.routes {
+		statuses := make(map[int]int64, len(rm.statuses))
+		for status, n := range rm.statuses {
+			statuses[status] = n
+		}
+		buckets := make([]HTTPLatencyBucket, len(rm.buckets))
+		var cumulative int64
+		for i, n := range rm.buckets {
+			cumulative += n
+			buckets[i].Count = cumulative
+			if i < len(httpLatencyBuckets) {
+				buckets[i].LeMs = httpLatencyBuckets[i] * 1000
+			}
+		}
+		stats = append(stats, HTTPRouteStats{
+			Route:          route,
+			Requests:       rm.count,
+			Statuses:       statuses,
+			TotalLatencyMs: float64(rm.sum.Microseconds()) / 1000,
+			AvgLatencyMs:   float64(rm.sum.Microseconds()) / 1000 / float64(rm.count),
+			MaxLatencyMs:   float64(rm.max.Microseconds()) / 1000,
+			LatencyBuckets: buckets,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+	return stats
+}
+
+// metricsMiddleware records the status and latency of every request in
+// metrics, labelled with the mux pattern that serves it so per-ID paths
+// don't create a label each.
+func metricsMiddleware(metrics *httpMetrics, mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		metrics.record(route, rec.status, time.Since(start))
+	})
+}
+
+// statusRecorder remembers the status code written through it. It passes
+// Flush and Hijack through so streaming and WebSocket handlers still work.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	if rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// metricsWriter builds a Prometheus text exposition.
+type metricsWriter struct {
+	strings.Builder
+}
+
+// family starts a metric family with its HELP and TYPE lines.
+func (m *metricsWriter) family(name, typ, help string) {
+	fmt.Fprintf(m, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one sample; labels alternate names and values.
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	m.WriteString(name)
+	if len(labels) > 0 {
+		m.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.WriteByte(',')
+			}
+			fmt.Fprintf(m, `%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		m.WriteByte('}')
+	}
+	m.WriteByte(' ')
+	m.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	m.WriteByte('\n')
+}
+
+// labelEscaper escapes backslashes, quotes and newlines in label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// HandleHTTPStats returns request counts, status codes and latency per
+// route, for finding slow endpoints without a Prometheus server.
+func (a *API) HandleHTTPStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, HTTPStatsResponse{Routes: a.httpMetrics.snapshot(), Timestamp: time.Now().Unix()})
+}
+
+// HandleMetrics serves relay, subscription, test and HTTP request metrics in
+// the Prometheus text format.
+func (a *API) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var m metricsWriter
+
+	if a.relayPool != nil {
+		relays := a.relayPool.List()
+		connected := 0
+		for _, relay := range relays {
+			if relay.Connected {
+				connected++
+			}
+		}
+		m.family("shirushi_relays", "gauge", "Relays in the pool.")
+		m.sample("shirushi_relays", float64(len(relays)))
+		m.family("shirushi_relays_connected", "gauge", "Relays in the pool that are connected.")
+		m.sample("shirushi_relays_connected", float64(connected))
+
+		m.family("shirushi_relay_up", "gauge", "Whether each relay is connected (1) or not (0).")
+		for _, relay := range relays {
+			up := 0.0
+			if relay.Connected {
+				up = 1
+			}
+			m.sample("shirushi_relay_up", up, "relay", relay.URL)
+		}
+		m.family("shirushi_relay_latency_milliseconds", "gauge", "Latest measured latency of each relay.")
+		for _, relay := range relays {
+			m.sample("shirushi_relay_latency_milliseconds", float64(relay.Latency), "relay", relay.URL)
+		}
+
+		stats := a.relayPool.Stats()
+		urls := make([]string, 0, len(stats))
+		for url := range stats {
+			urls = append(urls, url)
+		}
+		sort.Strings(urls)
+		m.family("shirushi_relay_events_received_total", "counter", "Events received from each relay.")
+		for _, url := range urls {
+			m.sample("shirushi_relay_events_received_total", float64(stats[url].TotalEvents), "relay", url)
+		}
+
+		var totalEvents int64
+		if data := a.relayPool.MonitoringData(); data != nil {
+			totalEvents = data.TotalEvents
+		}
+		m.family("shirushi_events_received_total", "counter", "Events received from all relays.")
+		m.sample("shirushi_events_received_total", float64(totalEvents))
+
+		diagnostics := a.relayPool.Diagnostics()
+		m.family("shirushi_active_subscriptions", "gauge", "Live event subscriptions.")
+		m.sample("shirushi_active_subscriptions", float64(diagnostics.ActiveSubscriptions))
+		m.family("shirushi_active_queries", "gauge", "In-flight relay queries and subscriptions.")
+		m.sample("shirushi_active_queries", float64(diagnostics.ActiveQueries))
+	}
+
+	m.family("shirushi_test_runs_total", "counter", "NIP test runs completed.")
+	m.sample("shirushi_test_runs_total", float64(a.testRuns.Load()))
+
+	routes := a.httpMetrics.snapshot()
+	m.family("shirushi_http_requests_total", "counter", "HTTP requests by route and status code.")
+	for _, route := range routes {
+		statuses := make([]int, 0, len(route.Statuses))
+		for status := range route.Statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			m.sample("shirushi_http_requests_total", float64(route.Statuses[status]), "handler", route.Route, "code", strconv.Itoa(status))
+		}
+	}
+	m.family("shirushi_http_request_duration_seconds", "histogram", "HTTP request latency by route.")
+	for _, route := range routes {
+		for i, bucket := range route.LatencyBuckets {
+			le := "+Inf"
+			if i < len(httpLatencyBuckets) {
+				le = strconv.FormatFloat(httpLatencyBuckets[i], 'g', -1, 64)
+			}
+			m.sample("shirushi_http_request_duration_seconds_bucket", float64(bucket.Count), "handler", route.Route, "le", le)
+		}
+		m.sample("shirushi_http_request_duration_seconds_sum", route.TotalLatencyMs/1000, "handler", route.Route)
+		m.sample("shirushi_http_request_duration_seconds_count", float64(route.Requests), "handler", route.Route)
+	}
+
+	w.Header().Set("Content-Type", metricsContentType)
+	w.Write([]byte(m.String()))
+}
@@ -0,0 +1,140 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func TestHandleMetrics(t *testing.T) {
+	pool := &mockRelayPool{
+		relayList: []types.RelayStatus{
+			{URL: "wss://relay.one.example", Connected: true, Latency: 42},
+			{URL: "wss://relay.two.example", Connected: false},
+		},
+		monitoringData: &types.MonitoringData{TotalEvents: 7},
+		diagnostics:    &types.PoolDiagnostics{ActiveSubscriptions: 2},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+	api.testRuns.Add(3)
+	api.httpMetrics.record("/api/status", http.StatusOK, 30*time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	api.HandleMetrics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", ct)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE shirushi_relays_connected gauge\n",
+		"shirushi_relays_connected 1\n",
+		"# TYPE shirushi_relay_latency_milliseconds gauge\n",
+		`shirushi_relay_latency_milliseconds{relay="wss://relay.one.example"} 42` + "\n",
+		`shirushi_relay_up{relay="wss://relay.two.example"} 0` + "\n",
+		"# TYPE shirushi_events_received_total counter\n",
+		"shirushi_events_received_total 7\n",
+		"# TYPE shirushi_active_subscriptions gauge\n",
+		"shirushi_active_subscriptions 2\n",
+		"# TYPE shirushi_test_runs_total counter\n",
+		"shirushi_test_runs_total 3\n",
+		"# TYPE shirushi_http_requests_total counter\n",
+		`shirushi_http_requests_total{handler="/api/status",code="200"} 1` + "\n",
+		"# TYPE shirushi_http_request_duration_seconds histogram\n",
+		`shirushi_http_request_duration_seconds_bucket{handler="/api/status",le="0.025"} 0` + "\n",
+		`shirushi_http_request_duration_seconds_bucket{handler="/api/status",le="0.05"} 1` + "\n",
+		`shirushi_http_request_duration_seconds_bucket{handler="/api/status",le="+Inf"} 1` + "\n",
+		`shirushi_http_request_duration_seconds_count{handler="/api/status"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsMiddlewareCountsByRouteAndStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/profile/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/api/missing", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not found")
+	})
+	metrics := newHTTPMetrics()
+	handler := metricsMiddleware(metrics, mux, mux)
+
+	for _, path := range []string{"/api/profile/aa", "/api/profile/bb", "/api/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	routes := metrics.snapshot()
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %+v", routes)
+	}
+	missing, profile := routes[0], routes[1]
+	if missing.Route != "/api/missing" || missing.Requests != 1 || missing.Statuses[404] != 1 {
+		t.Errorf("missing route = %+v, want one 404", missing)
+	}
+	if profile.Route != "/api/profile/" || profile.Requests != 2 || profile.Statuses[200] != 2 {
+		t.Errorf("profile route = %+v, want two 200s", profile)
+	}
+	if last := profile.LatencyBuckets[len(profile.LatencyBuckets)-1]; last.Count != 2 {
+		t.Errorf("+Inf bucket = %d, want every request", last.Count)
+	}
+}
+
+func TestHandleHTTPStats(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+	api.httpMetrics.record("/api/thread/", http.StatusOK, 2*time.Second)
+	api.httpMetrics.record("/api/thread/", http.StatusOK, 4*time.Second)
+	api.httpMetrics.record("/api/thread/", http.StatusInternalServerError, 3*time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats/http", nil)
+	w := httptest.NewRecorder()
+	api.HandleHTTPStats(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp HTTPStatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Routes) != 1 {
+		t.Fatalf("expected 1 route, got %+v", resp.Routes)
+	}
+	route := resp.Routes[0]
+	if route.Requests != 3 || route.Statuses[200] != 2 || route.Statuses[500] != 1 {
+		t.Errorf("route = %+v, want 3 requests: two 200s and one 500", route)
+	}
+	if route.MaxLatencyMs != 4000 || route.AvgLatencyMs != 2001 {
+		t.Errorf("latency avg = %v max = %v, want 2001 and 4000", route.AvgLatencyMs, route.MaxLatencyMs)
+	}
+	for _, bucket := range route.LatencyBuckets {
+		if bucket.LeMs == 5 && bucket.Count != 1 {
+			t.Errorf("5ms bucket = %d, want 1", bucket.Count)
+		}
+		if bucket.LeMs == 2500 && bucket.Count != 2 {
+			t.Errorf("2.5s bucket = %d, want 2", bucket.Count)
+		}
+	}
+}
+
+func TestMetricsLabelEscaping(t *testing.T) {
+	var m metricsWriter
+	m.sample("x", 1, "relay", "wss://a\"b\\c\nd")
+	if want := `x{relay="wss://a\"b\\c\nd"} 1` + "\n"; m.String() != want {
+		t.Errorf("sample = %q, want %q", m.String(), want)
+	}
+}
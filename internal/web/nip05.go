@@ -0,0 +1,157 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NIP-05 verification limits: at most maxNIP05Batch addresses per bulk
+// request, fetching at most maxNIP05Fetches domains at once.
+const (
+	nip05Timeout     = 5 * time.Second
+	maxNIP05Batch    = 500
+	maxNIP05Fetches  = 8
+	maxNIP05DocBytes = 1 << 20
+)
+
+// nip05Client fetches nostr.json documents.
+var nip05Client = &http.Client{Timeout: nip05Timeout}
+
+// nip05URL returns the nostr.json URL for domain, asking for a single name
+// unless name is empty. Tests point it at a local server.
+var nip05URL = func(domain, name string) string {
+	u := "https://" + domain + "/.well-known/nostr.json"
+	if name != "" {
+		u += "?name=" + url.QueryEscape(name)
+	}
+	return u
+}
+
+// fetchNIP05Names fetches domain's nostr.json and returns its name to pubkey
+// map. With an empty name the whole document is requested.
+func fetchNIP05Names(ctx context.Context, domain, name string) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nip05URL(domain, name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := nip05Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", domain, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxNIP05DocBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Names map[string]string `json:"names"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return doc.Names, nil
+}
+
+// nip05Matches reports whether names maps name to pubkey, comparing the hex
+// pubkeys case-insensitively.
+func nip05Matches(names map[string]string, name, pubkey string) bool {
+	listed, ok := names[name]
+	return ok && strings.EqualFold(listed, pubkey)
+}
+
+// NIP05VerifyRequest is one address to check in a bulk NIP-05 verification.
+type NIP05VerifyRequest struct {
+	PubKey string `json:"pubkey"`
+	NIP05  string `json:"nip05"`
+}
+
+// HandleNIP05Verify verifies a list of {pubkey, nip05} pairs, such as the
+// profiles in a follow list, and returns a map of address to validity.
+// Each domain's nostr.json is fetched once, however many of its names are
+// listed; invalid addresses and unreachable domains are reported invalid.
+func (a *API) HandleNIP05Verify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !requireJSON(w, r, false) {
+		return
+	}
+
+	var entries []NIP05VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&entries); err != nil {
+		writeBodyError(w, err, "invalid request body")
+		return
+	}
+	if len(entries) > maxNIP05Batch {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("batch has %d addresses, maximum is %d", len(entries), maxNIP05Batch))
+		return
+	}
+
+	writeJSON(w, verifyNIP05Batch(r.Context(), entries))
+}
+
+// verifyNIP05Batch verifies entries, fetching each domain's document once
+// with bounded concurrency.
+func verifyNIP05Batch(ctx context.Context, entries []NIP05VerifyRequest) map[string]bool {
+	results := make(map[string]bool, len(entries))
+	byDomain := make(map[string][]NIP05VerifyRequest)
+	for _, entry := range entries {
+		results[entry.NIP05] = false
+		if _, domain, ok := splitNIP05(entry.NIP05); ok && entry.PubKey != "" {
+			domain = strings.ToLower(domain)
+			byDomain[domain] = append(byDomain[domain], entry)
+		}
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxNIP05Fetches)
+	)
+	for domain, domainEntries := range byDomain {
+		wg.Add(1)
+		go func(domain string, domainEntries []NIP05VerifyRequest) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// A single name can be requested directly; servers that build
+			// nostr.json per request may not list every name otherwise.
+			var only string
+			if len(domainEntries) == 1 {
+				only, _, _ = splitNIP05(domainEntries[0].NIP05)
+			}
+			names, err := fetchNIP05Names(ctx, domain, only)
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, entry := range domainEntries {
+				name, _, _ := splitNIP05(entry.NIP05)
+				if nip05Matches(names, name, entry.PubKey) {
+					results[entry.NIP05] = true
+				}
+			}
+		}(domain, domainEntries)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,139 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+)
+
+const (
+	alicePubkey = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	bobPubkey   = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	carolPubkey = "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+)
+
+// nip05Server serves a nostr.json per domain under /{domain}/ and counts
+// requests per domain. nip05URL is pointed at it for the test's duration.
+func nip05Server(t *testing.T, docs map[string]map[string]string) map[string]int {
+	t.Helper()
+	var mu sync.Mutex
+	hits := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		domain := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/.well-known/nostr.json")
+		mu.Lock()
+		hits[domain]++
+		mu.Unlock()
+
+		names, ok := docs[domain]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"names": names})
+	}))
+	t.Cleanup(server.Close)
+
+	original := nip05URL
+	nip05URL = func(domain, name string) string {
+		u := server.URL + "/" + domain + "/.well-known/nostr.json"
+		if name != "" {
+			u += "?name=" + name
+		}
+		return u
+	}
+	t.Cleanup(func() { nip05URL = original })
+
+	return hits
+}
+
+func TestHandleNIP05Verify_FetchesEachDomainOnce(t *testing.T) {
+	hits := nip05Server(t, map[string]map[string]string{
+		"example.com": {"alice": alicePubkey, "bob": bobPubkey, "_": carolPubkey},
+		"other.com":   {"carol": carolPubkey},
+	})
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	body := `[
+		{"pubkey": "` + alicePubkey + `", "nip05": "alice@example.com"},
+		{"pubkey": "` + strings.ToUpper(bobPubkey) + `", "nip05": "bob@example.com"},
+		{"pubkey": "` + alicePubkey + `", "nip05": "mallory@example.com"},
+		{"pubkey": "` + carolPubkey + `", "nip05": "example.com"},
+		{"pubkey": "` + alicePubkey + `", "nip05": "carol@other.com"},
+		{"pubkey": "` + alicePubkey + `", "nip05": "alice@missing.com"},
+		{"pubkey": "` + alicePubkey + `", "nip05": "not an address"}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "/api/nip05/verify", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	api.HandleNIP05Verify(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var results map[string]bool
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	want := map[string]bool{
+		"alice@example.com":   true,
+		"bob@example.com":     true,
+		"mallory@example.com": false,
+		"example.com":         true,
+		"carol@other.com":     false,
+		"alice@missing.com":   false,
+		"not an address":      false,
+	}
+	for address, valid := range want {
+		if got, ok := results[address]; !ok || got != valid {
+			t.Errorf("%s: got %v (present %v), want %v", address, got, ok, valid)
+		}
+	}
+
+	if hits["example.com"] != 1 {
+		t.Errorf("expected one fetch for example.com, got %d", hits["example.com"])
+	}
+	if hits["other.com"] != 1 || hits["missing.com"] != 1 {
+		t.Errorf("expected one fetch per other domain, got %v", hits)
+	}
+}
+
+func TestHandleNIP05Verify_Rejects(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	tooMany := make([]NIP05VerifyRequest, maxNIP05Batch+1)
+	for i := range tooMany {
+		tooMany[i] = NIP05VerifyRequest{PubKey: alicePubkey, NIP05: "alice@example.com"}
+	}
+	tooManyBody, _ := json.Marshal(tooMany)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+	}{
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"invalid body", http.MethodPost, `{"pubkey": "x"}`, http.StatusBadRequest},
+		{"too many addresses", http.MethodPost, string(tooManyBody), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/nip05/verify", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			api.HandleNIP05Verify(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
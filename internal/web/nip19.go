@@ -0,0 +1,230 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/keanuklestil/shirushi/internal/nak"
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// DecodeFetchResponse is returned by HandleDecodeAndFetch. Exactly one of
+// Event or Profile is set, depending on the entity type.
+type DecodeFetchResponse struct {
+	Type    string         `json:"type"`
+	Decoded *nak.Decoded   `json:"decoded"`
+	Event   *types.Event   `json:"event,omitempty"`
+	Profile *types.Profile `json:"profile,omitempty"`
+}
+
+// decodeNIP19Native decodes a NIP-19 entity with go-nostr, without needing
+// the nak CLI. The result uses the same shape as nak.Decode.
+func decodeNIP19Native(input string) (*nak.Decoded, error) {
+	input = strings.TrimPrefix(strings.TrimSpace(input), "nostr:")
+
+	prefix, value, err := nip19.Decode(input)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &nak.Decoded{Type: prefix}
+	switch v := value.(type) {
+	case string:
+		decoded.Hex = v
+		if prefix == "note" {
+			decoded.ID = v
+		}
+	case nostr.EventPointer:
+		decoded.ID = v.ID
+		decoded.Hex = v.ID
+		decoded.Relays = v.Relays
+		decoded.Author = v.Author
+		decoded.Kind = v.Kind
+	case nostr.ProfilePointer:
+		decoded.Pubkey = v.PublicKey
+		decoded.Hex = v.PublicKey
+		decoded.Relays = v.Relays
+	case nostr.EntityPointer:
+		decoded.Pubkey = v.PublicKey
+		decoded.Kind = v.Kind
+		decoded.Identifier = v.Identifier
+		decoded.Relays = v.Relays
+	default:
+		return nil, fmt.Errorf("unsupported NIP-19 entity %q", prefix)
+	}
+
+	return decoded, nil
+}
+
+// HandleDecodeAndFetch decodes any NIP-19 entity and fetches what it points to,
+// using the entity's relay hints in addition to the connected relays.
+// - note/nevent: the event
+// - naddr: the latest version of the addressable event
+// - npub/nprofile: the profile metadata
+// Query param: input (required), with or without a "nostr:" prefix.
+func (a *API) HandleDecodeAndFetch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	input := strings.TrimSpace(r.URL.Query().Get("input"))
+	if input == "" {
+		writeError(w, http.StatusBadRequest, "input query parameter is required")
+		return
+	}
+
+	decoded, err := decodeNIP19Native(input)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid NIP-19 identifier: "+err.Error())
+		return
+	}
+
+	response := DecodeFetchResponse{Type: decoded.Type, Decoded: decoded}
+
+	switch decoded.Type {
+	case "note", "nevent":
+		events, err := a.relayPool.QueryEventsFromRelays(decoded.Relays, []string{decoded.ID}, nil, nil, nil, 1)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to query event: "+err.Error())
+			return
+		}
+		if len(events) == 0 {
+			writeErrorCode(w, http.StatusNotFound, codeEventNotFound, "event not found")
+			return
+		}
+		response.Event = &events[0]
+
+	case "naddr":
+		latest, err := a.fetchAddressable(decoded.Kind, decoded.Pubkey, decoded.Identifier, decoded.Relays)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to query event: "+err.Error())
+			return
+		}
+		if latest == nil {
+			writeErrorCode(w, http.StatusNotFound, codeEventNotFound, "event not found")
+			return
+		}
+		response.Event = latest
+
+	case "npub", "nprofile":
+		events, err := a.relayPool.QueryEventsFromRelays(decoded.Relays, nil, []int{0}, []string{decoded.Hex}, nil, 10)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to query profile: "+err.Error())
+			return
+		}
+		latest := types.LatestReplaceable(events)
+		if latest == nil {
+			writeErrorCode(w, http.StatusNotFound, codeProfileNotFound, "profile not found")
+			return
+		}
+		profile := parseProfileMetadata(decoded.Hex, *latest)
+		response.Profile = &profile
+
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("cannot fetch data for %s entities", decoded.Type))
+		return
+	}
+
+	writeJSON(w, response)
+}
+
+// ConvertResponse is returned by HandleConvert. Hex is always set; the bech32
+// forms that apply to the input are set alongside it. For an nsec, Pubkey and
+// Npub hold the matching public key.
+type ConvertResponse struct {
+	Type      string `json:"type"`
+	Hex       string `json:"hex"`
+	Npub      string `json:"npub,omitempty"`
+	Nsec      string `json:"nsec,omitempty"`
+	Note      string `json:"note,omitempty"`
+	Pubkey    string `json:"pubkey,omitempty"`
+	Ambiguous bool   `json:"ambiguous,omitempty"`
+	Notice    string `json:"notice,omitempty"`
+}
+
+// convertIdentifier detects whether value is an npub, nsec, note or 32-byte
+// hex string and returns its equivalent representations. Bare hex could be a
+// public key or an event ID, so both npub and note are returned and the
+// response is marked ambiguous; it is never encoded as an nsec.
+func convertIdentifier(value string) (*ConvertResponse, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "nostr:")
+
+	if hex := strings.ToLower(value); nostr.IsValid32ByteHex(hex) {
+		npub, _ := nip19.EncodePublicKey(hex)
+		note, _ := nip19.EncodeNote(hex)
+		return &ConvertResponse{
+			Type:      "hex",
+			Hex:       hex,
+			Npub:      npub,
+			Note:      note,
+			Ambiguous: true,
+			Notice:    "hex may be a public key (npub) or an event ID (note); pick the form that matches its use",
+		}, nil
+	}
+
+	prefix, decoded, err := nip19.Decode(value)
+	if err != nil {
+		return nil, err
+	}
+	hex, ok := decoded.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %s entities; use /api/decode-fetch", prefix)
+	}
+
+	response := &ConvertResponse{Type: prefix, Hex: hex}
+	switch prefix {
+	case "npub":
+		response.Npub = value
+	case "note":
+		response.Note = value
+	case "nsec":
+		pubkey, err := nostr.GetPublicKey(hex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		response.Nsec = value
+		response.Pubkey = pubkey
+		response.Npub, _ = nip19.EncodePublicKey(pubkey)
+	default:
+		return nil, fmt.Errorf("cannot convert %s entities", prefix)
+	}
+	return response, nil
+}
+
+// HandleConvert flips an identifier between its hex and bech32 forms without
+// needing the nak CLI.
+// Body: {"value": "npub1..." | "nsec1..." | "note1..." | "<64-char hex>"}
+func (a *API) HandleConvert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if !requireJSON(w, r, false) {
+		return
+	}
+	var req struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err, "invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Value) == "" {
+		writeError(w, http.StatusBadRequest, "value is required")
+		return
+	}
+
+	response, err := convertIdentifier(req.Value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid identifier: "+err.Error())
+		return
+	}
+
+	writeJSON(w, response)
+}
@@ -0,0 +1,281 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+const (
+	decodeEventID = "e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1e1"
+	decodeAuthor  = "a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0"
+)
+
+func TestDecodeNIP19Native(t *testing.T) {
+	nevent, _ := nip19.EncodeEvent(decodeEventID, []string{"wss://hint.example.com"}, decodeAuthor)
+	naddr, _ := nip19.EncodeEntity(decodeAuthor, 30023, "my-article", []string{"wss://hint.example.com"})
+	npub, _ := nip19.EncodePublicKey(decodeAuthor)
+
+	decoded, err := decodeNIP19Native(nevent)
+	if err != nil {
+		t.Fatalf("failed to decode nevent: %v", err)
+	}
+	if decoded.Type != "nevent" || decoded.ID != decodeEventID || decoded.Author != decodeAuthor {
+		t.Errorf("unexpected nevent decoding: %+v", decoded)
+	}
+	if len(decoded.Relays) != 1 || decoded.Relays[0] != "wss://hint.example.com" {
+		t.Errorf("expected relay hint, got %v", decoded.Relays)
+	}
+
+	decoded, err = decodeNIP19Native("nostr:" + naddr)
+	if err != nil {
+		t.Fatalf("failed to decode naddr: %v", err)
+	}
+	if decoded.Type != "naddr" || decoded.Kind != 30023 || decoded.Identifier != "my-article" || decoded.Pubkey != decodeAuthor {
+		t.Errorf("unexpected naddr decoding: %+v", decoded)
+	}
+
+	decoded, err = decodeNIP19Native(npub)
+	if err != nil {
+		t.Fatalf("failed to decode npub: %v", err)
+	}
+	if decoded.Type != "npub" || decoded.Hex != decodeAuthor {
+		t.Errorf("unexpected npub decoding: %+v", decoded)
+	}
+
+	if _, err := decodeNIP19Native("nevent1invalid"); err == nil {
+		t.Error("expected error for invalid input")
+	}
+}
+
+func TestHandleDecodeAndFetch_Nevent(t *testing.T) {
+	pool := &mockRelayPool{
+		eventsByID: map[string]types.Event{
+			decodeEventID: {ID: decodeEventID, Kind: 1, PubKey: decodeAuthor, Content: "hello"},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	nevent, _ := nip19.EncodeEvent(decodeEventID, []string{"wss://hint.example.com"}, decodeAuthor)
+	req := httptest.NewRequest(http.MethodGet, "/api/decode-fetch?input="+nevent, nil)
+	w := httptest.NewRecorder()
+
+	api.HandleDecodeAndFetch(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response DecodeFetchResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Type != "nevent" {
+		t.Errorf("expected type nevent, got %s", response.Type)
+	}
+	if response.Event == nil || response.Event.ID != decodeEventID {
+		t.Fatalf("expected event %s, got %+v", decodeEventID, response.Event)
+	}
+	if response.Profile != nil {
+		t.Error("expected no profile for nevent input")
+	}
+	if len(pool.lastHintRelays) != 1 || pool.lastHintRelays[0] != "wss://hint.example.com" {
+		t.Errorf("expected relay hints to be used, got %v", pool.lastHintRelays)
+	}
+}
+
+func TestHandleDecodeAndFetch_NaddrReturnsLatest(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			{ID: "old", Kind: 30023, PubKey: decodeAuthor, CreatedAt: 1700000000, Tags: [][]string{{"d", "my-article"}}},
+			{ID: "new", Kind: 30023, PubKey: decodeAuthor, CreatedAt: 1700005000, Tags: [][]string{{"d", "my-article"}}},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	naddr, _ := nip19.EncodeEntity(decodeAuthor, 30023, "my-article", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/decode-fetch?input="+naddr, nil)
+	w := httptest.NewRecorder()
+
+	api.HandleDecodeAndFetch(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response DecodeFetchResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Event == nil || response.Event.ID != "new" {
+		t.Fatalf("expected latest version 'new', got %+v", response.Event)
+	}
+	if d := pool.lastTags["d"]; len(d) != 1 || d[0] != "my-article" {
+		t.Errorf("expected d tag filter my-article, got %v", pool.lastTags)
+	}
+	if len(pool.lastAuthors) != 1 || pool.lastAuthors[0] != decodeAuthor {
+		t.Errorf("expected author filter %s, got %v", decodeAuthor, pool.lastAuthors)
+	}
+}
+
+func TestHandleDecodeAndFetch_Npub(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			{ID: "meta", Kind: 0, PubKey: decodeAuthor, CreatedAt: 1700000000, Content: `{"name":"alice"}`},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	npub, _ := nip19.EncodePublicKey(decodeAuthor)
+	req := httptest.NewRequest(http.MethodGet, "/api/decode-fetch?input="+npub, nil)
+	w := httptest.NewRecorder()
+
+	api.HandleDecodeAndFetch(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response DecodeFetchResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Profile == nil || response.Profile.Name != "alice" {
+		t.Errorf("expected profile alice, got %+v", response.Profile)
+	}
+}
+
+func TestHandleDecodeAndFetch_NotFound(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	nevent, _ := nip19.EncodeEvent(decodeEventID, nil, "")
+	req := httptest.NewRequest(http.MethodGet, "/api/decode-fetch?input="+nevent, nil)
+	w := httptest.NewRecorder()
+
+	api.HandleDecodeAndFetch(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleDecodeAndFetch_RejectsNsec(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	nsec, _ := nip19.EncodePrivateKey(decodeEventID)
+	req := httptest.NewRequest(http.MethodGet, "/api/decode-fetch?input="+nsec, nil)
+	w := httptest.NewRecorder()
+
+	api.HandleDecodeAndFetch(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandleDecodeAndFetch_MissingInput(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/decode-fetch", nil)
+	w := httptest.NewRecorder()
+
+	api.HandleDecodeAndFetch(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func postConvert(t *testing.T, value string) (*httptest.ResponseRecorder, ConvertResponse) {
+	t.Helper()
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	body, _ := json.Marshal(map[string]string{"value": value})
+	req := httptest.NewRequest(http.MethodPost, "/api/convert", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	api.HandleConvert(w, req)
+
+	var resp ConvertResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return w, resp
+}
+
+func TestHandleConvert_NpubToHex(t *testing.T) {
+	npub, _ := nip19.EncodePublicKey(decodeAuthor)
+
+	w, resp := postConvert(t, npub)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Type != "npub" || resp.Hex != decodeAuthor || resp.Npub != npub {
+		t.Errorf("unexpected conversion: %+v", resp)
+	}
+	if resp.Ambiguous || resp.Note != "" {
+		t.Errorf("npub conversion should not be ambiguous: %+v", resp)
+	}
+}
+
+func TestHandleConvert_HexIsAmbiguous(t *testing.T) {
+	npub, _ := nip19.EncodePublicKey(decodeAuthor)
+	note, _ := nip19.EncodeNote(decodeAuthor)
+
+	w, resp := postConvert(t, strings.ToUpper(decodeAuthor))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Type != "hex" || resp.Hex != decodeAuthor {
+		t.Errorf("unexpected conversion: %+v", resp)
+	}
+	if resp.Npub != npub || resp.Note != note {
+		t.Errorf("expected npub %s and note %s, got %+v", npub, note, resp)
+	}
+	if !resp.Ambiguous || resp.Notice == "" {
+		t.Errorf("expected hex to be flagged ambiguous: %+v", resp)
+	}
+	if resp.Nsec != "" {
+		t.Errorf("hex must not be encoded as an nsec: %+v", resp)
+	}
+}
+
+func TestHandleConvert_NsecToHex(t *testing.T) {
+	sk := nostr.GeneratePrivateKey()
+	pk, _ := nostr.GetPublicKey(sk)
+	nsec, _ := nip19.EncodePrivateKey(sk)
+	npub, _ := nip19.EncodePublicKey(pk)
+
+	w, resp := postConvert(t, nsec)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Type != "nsec" || resp.Hex != sk || resp.Nsec != nsec {
+		t.Errorf("unexpected conversion: %+v", resp)
+	}
+	if resp.Pubkey != pk || resp.Npub != npub {
+		t.Errorf("expected derived pubkey %s / %s, got %+v", pk, npub, resp)
+	}
+}
+
+func TestHandleConvert_Invalid(t *testing.T) {
+	nevent, _ := nip19.EncodeEvent(decodeEventID, nil, "")
+
+	for _, value := range []string{"", "npub1invalid", "abcd", nevent} {
+		if w, _ := postConvert(t, value); w.Code != http.StatusBadRequest {
+			t.Errorf("value %q: expected status 400, got %d", value, w.Code)
+		}
+	}
+}
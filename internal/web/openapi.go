@@ -0,0 +1,23 @@
+package web
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec is the hand-maintained OpenAPI 3 description of the REST API.
+// It is embedded so it ships with, and is versioned alongside, the handlers
+// it documents; update it when an endpoint's parameters or response change.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// HandleOpenAPI serves the OpenAPI description of the REST API.
+func (a *API) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+)
+
+func TestOpenAPISpec_ValidAndCoversCorePaths(t *testing.T) {
+	var spec struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want a 3.x version", spec.OpenAPI)
+	}
+
+	for _, path := range []string{"/api/events", "/api/profile/{pubkey}", "/api/relays/info"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("spec is missing path %s", path)
+		}
+	}
+
+	// Every documented path must be served by a registered route rather than
+	// falling through to the dashboard.
+	mux := NewServer(":0", nil, NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)).routes()
+	for path := range spec.Paths {
+		concrete := strings.ReplaceAll(path, "{pubkey}", "abc")
+		req := httptest.NewRequest(http.MethodGet, concrete, nil)
+		if _, pattern := mux.Handler(req); pattern == "" || pattern == "/" {
+			t.Errorf("documented path %s has no registered route", path)
+		}
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	h := NewServer(":0", nil, NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)).routes()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if !json.Valid(w.Body.Bytes()) {
+		t.Error("response body is not valid JSON")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/openapi.json", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for POST, got %d", w.Code)
+	}
+}
@@ -0,0 +1,139 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+const (
+	// defaultProfileHistoryLimit is the number of versions returned when no
+	// limit is given.
+	defaultProfileHistoryLimit = 20
+	// maxProfileHistoryLimit caps the number of versions a request may ask for.
+	maxProfileHistoryLimit = 100
+)
+
+// profileHistoryNotice explains why a profile history may be incomplete.
+const profileHistoryNotice = "best effort: kind 0 is replaceable, so most relays keep only the latest version; older versions appear only where relays retained them"
+
+// HandleProfileHistory returns the kind 0 metadata versions relays still hold
+// for a pubkey, oldest first, with the fields each version changed.
+// Path: /api/profile/{pubkey}/history
+// Accepts an optional limit query param (default 20, capped at 100); the
+// newest versions are kept.
+func (a *API) HandleProfileHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/profile/")
+	path = strings.TrimSuffix(path, "/history")
+	if strings.TrimSpace(path) == "" {
+		writeError(w, http.StatusBadRequest, "pubkey is required in path")
+		return
+	}
+
+	pubkey, status, err := a.resolvePubkey(strings.TrimSpace(path))
+	if err != nil {
+		writeErrorCode(w, status, pubkeyErrorCode(status), err.Error())
+		return
+	}
+
+	limit := defaultProfileHistoryLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit value")
+			return
+		}
+		limit = min(l, maxProfileHistoryLimit)
+	}
+
+	events, err := a.relayPool.QueryEventsAdvanced([]int{0}, []string{pubkey}, nil, limit, 0, 0)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query profile: "+err.Error())
+		return
+	}
+
+	versions := profileVersions(pubkey, events, limit)
+	if len(versions) == 0 {
+		writeErrorCode(w, http.StatusNotFound, codeProfileNotFound, "profile not found")
+		return
+	}
+
+	writeJSON(w, types.ProfileHistory{
+		PubKey:     pubkey,
+		Versions:   versions,
+		BestEffort: true,
+		Notice:     profileHistoryNotice,
+	})
+}
+
+// profileVersions parses the pubkey's kind 0 events into versions, oldest
+// first, keeping the newest limit. Each version lists the fields that differ
+// from the version before it.
+func profileVersions(pubkey string, events []types.Event, limit int) []types.ProfileVersion {
+	seen := make(map[string]bool)
+	var metadata []types.Event
+	for _, event := range events {
+		if event.Kind != 0 || event.PubKey != pubkey || seen[event.ID] {
+			continue
+		}
+		seen[event.ID] = true
+		metadata = append(metadata, event)
+	}
+	sort.Slice(metadata, func(i, j int) bool {
+		if metadata[i].CreatedAt != metadata[j].CreatedAt {
+			return metadata[i].CreatedAt < metadata[j].CreatedAt
+		}
+		return metadata[i].ID < metadata[j].ID
+	})
+	if len(metadata) > limit {
+		metadata = metadata[len(metadata)-limit:]
+	}
+
+	versions := make([]types.ProfileVersion, 0, len(metadata))
+	for i, event := range metadata {
+		version := types.ProfileVersion{
+			EventID:   event.ID,
+			CreatedAt: event.CreatedAt,
+			Profile:   parseProfileMetadata(pubkey, event),
+		}
+		if i > 0 {
+			version.Changed = changedProfileFields(versions[i-1].Profile, version.Profile)
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// changedProfileFields lists the metadata fields that differ between two
+// versions of a profile, using their JSON names.
+func changedProfileFields(before, after types.Profile) []string {
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"name", before.Name, after.Name},
+		{"display_name", before.DisplayName, after.DisplayName},
+		{"about", before.About, after.About},
+		{"picture", before.Picture, after.Picture},
+		{"banner", before.Banner, after.Banner},
+		{"website", before.Website, after.Website},
+		{"nip05", before.NIP05, after.NIP05},
+		{"lud16", before.LUD16, after.LUD16},
+	}
+
+	changed := []string{}
+	for _, field := range fields {
+		if field.before != field.after {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
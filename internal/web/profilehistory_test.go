@@ -0,0 +1,115 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+func profileHistoryEvents() []types.Event {
+	return []types.Event{
+		{ID: "v3", PubKey: feedPubkey, Kind: 0, CreatedAt: 300, Content: `{"name":"alice","picture":"https://img.example.com/new.png","nip05":"alice@example.com"}`},
+		{ID: "v1", PubKey: feedPubkey, Kind: 0, CreatedAt: 100, Content: `{"name":"al","picture":"https://img.example.com/old.png"}`},
+		{ID: "v2", PubKey: feedPubkey, Kind: 0, CreatedAt: 200, Content: `{"name":"alice","picture":"https://img.example.com/old.png"}`},
+		{ID: "v1", PubKey: feedPubkey, Kind: 0, CreatedAt: 100, Content: `{"name":"al","picture":"https://img.example.com/old.png"}`},
+		{ID: "other", PubKey: "someone-else", Kind: 0, CreatedAt: 250, Content: `{"name":"mallory"}`},
+	}
+}
+
+func TestHandleProfileHistory_ListsVersionsOldestFirst(t *testing.T) {
+	pool := &mockRelayPool{events: profileHistoryEvents()}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/"+feedPubkey+"/history", nil)
+	w := httptest.NewRecorder()
+	api.HandleProfile(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var history types.ProfileHistory
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if history.PubKey != feedPubkey || !history.BestEffort || history.Notice == "" {
+		t.Errorf("expected a best-effort history for the pubkey, got %+v", history)
+	}
+	if len(history.Versions) != 3 {
+		t.Fatalf("expected 3 versions, got %+v", history.Versions)
+	}
+
+	want := []struct {
+		id      string
+		name    string
+		changed []string
+	}{
+		{"v1", "al", nil},
+		{"v2", "alice", []string{"name"}},
+		{"v3", "alice", []string{"picture", "nip05"}},
+	}
+	for i, w := range want {
+		got := history.Versions[i]
+		if got.EventID != w.id || got.Profile.Name != w.name {
+			t.Errorf("versions[%d] = %s %q, want %s %q", i, got.EventID, got.Profile.Name, w.id, w.name)
+		}
+		if !reflect.DeepEqual(got.Changed, w.changed) {
+			t.Errorf("versions[%d].Changed = %v, want %v", i, got.Changed, w.changed)
+		}
+	}
+	if pool.lastLimit != defaultProfileHistoryLimit {
+		t.Errorf("queried with limit %d, want %d", pool.lastLimit, defaultProfileHistoryLimit)
+	}
+}
+
+func TestHandleProfileHistory_LimitKeepsNewest(t *testing.T) {
+	pool := &mockRelayPool{events: profileHistoryEvents()}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/"+feedPubkey+"/history?limit=2", nil)
+	w := httptest.NewRecorder()
+	api.HandleProfileHistory(w, req)
+
+	var history types.ProfileHistory
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(history.Versions) != 2 || history.Versions[0].EventID != "v2" || history.Versions[1].EventID != "v3" {
+		t.Errorf("expected the 2 newest versions, got %+v", history.Versions)
+	}
+	if history.Versions[0].Changed != nil {
+		t.Errorf("expected no changes on the oldest returned version, got %v", history.Versions[0].Changed)
+	}
+}
+
+func TestHandleProfileHistory_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		events     []types.Event
+		wantStatus int
+	}{
+		{"no versions", "/api/profile/" + feedPubkey + "/history", nil, http.StatusNotFound},
+		{"invalid limit", "/api/profile/" + feedPubkey + "/history?limit=0", profileHistoryEvents(), http.StatusBadRequest},
+		{"invalid pubkey", "/api/profile/not-a-pubkey/history", profileHistoryEvents(), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := NewAPI(&config.Config{}, nil, &mockRelayPool{events: tt.events}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			api.HandleProfileHistory(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
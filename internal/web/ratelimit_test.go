@@ -0,0 +1,47 @@
+package web
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_LimitsAndRefills(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := newTokenBucket(2)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := b.Allow(); !ok {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+
+	ok, wait := b.Allow()
+	if ok {
+		t.Fatal("third request should be rejected")
+	}
+	if wait != 30*time.Second {
+		t.Errorf("wait = %v, want 30s", wait)
+	}
+
+	now = now.Add(30 * time.Second)
+	if ok, _ := b.Allow(); !ok {
+		t.Error("request should be allowed after refill")
+	}
+}
+
+func TestTokenBucket_CapsAtCapacity(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := newTokenBucket(1)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	now = now.Add(time.Hour)
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("first request should be allowed")
+	}
+	if ok, _ := b.Allow(); ok {
+		t.Error("idle time should not accumulate more than capacity")
+	}
+}
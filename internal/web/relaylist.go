@@ -0,0 +1,160 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+// HandleProfileRelays returns a profile's NIP-65 relay list (kind 10002),
+// split into read-only, write-only and read+write relays.
+// Path: /api/profile/{pubkey}/relays
+func (a *API) HandleProfileRelays(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/profile/")
+	path = strings.TrimSuffix(path, "/relays")
+	if strings.TrimSpace(path) == "" {
+		writeError(w, http.StatusBadRequest, "pubkey is required in path")
+		return
+	}
+
+	pubkey, status, err := a.resolvePubkey(strings.TrimSpace(path))
+	if err != nil {
+		writeErrorCode(w, status, pubkeyErrorCode(status), err.Error())
+		return
+	}
+
+	list, err := a.fetchRelayList(pubkey)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query relay list: "+err.Error())
+		return
+	}
+	if list == nil {
+		writeError(w, http.StatusNotFound, "relay list not found")
+		return
+	}
+
+	writeJSON(w, list)
+}
+
+// fetchRelayList fetches and parses the newest kind 10002 relay list published
+// by pubkey. Returns nil if none was found.
+func (a *API) fetchRelayList(pubkey string) (*types.RelayList, error) {
+	events, err := a.relayPool.QueryEventsAdvanced([]int{10002}, []string{pubkey}, nil, 5, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []types.Event
+	for _, event := range events {
+		if event.Kind == 10002 && event.PubKey == pubkey {
+			lists = append(lists, event)
+		}
+	}
+
+	latest := types.LatestReplaceable(lists)
+	if latest == nil {
+		return nil, nil
+	}
+	return parseRelayList(latest), nil
+}
+
+// OutboxEventsResponse is the HandleEvents response for outbox queries. It
+// adds the author's write relays that were queried, which is empty when the
+// author has no relay list and the default relays were used instead.
+type OutboxEventsResponse struct {
+	*types.EventsQueryResponse
+	OutboxRelays []string `json:"outbox_relays"`
+}
+
+// handleOutboxEvents serves HandleEvents queries with outbox=true: events by a
+// single author are fetched from the write relays in the author's NIP-65 relay
+// list rather than from the pool, falling back to the pool if there is no list.
+func (a *API) handleOutboxEvents(w http.ResponseWriter, params *EventQueryParams) {
+	if len(params.Authors) != 1 {
+		writeError(w, http.StatusBadRequest, "outbox requires exactly one author")
+		return
+	}
+
+	list, err := a.fetchRelayList(params.Authors[0])
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query relay list: "+err.Error())
+		return
+	}
+
+	outboxRelays := []string{}
+	if list != nil {
+		outboxRelays = append(outboxRelays, list.Write...)
+		outboxRelays = append(outboxRelays, list.Both...)
+	}
+
+	var response *types.EventsQueryResponse
+	if len(outboxRelays) > 0 {
+		response, err = a.relayPool.QueryEventsOnRelaysWithTiming(outboxRelays, params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until)
+	} else {
+		response, err = a.relayPool.QueryEventsAdvancedWithTiming(params.Kinds, params.Authors, params.Tags, params.Limit, params.Since, params.Until, params.Relays...)
+		if err == nil {
+			response.Warnings = append(response.Warnings, "author has no relay list; queried the default relays")
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Events = a.applyPostFilters(params, response.Events)
+	response.Warnings = append(params.Warnings, response.Warnings...)
+
+	writeJSON(w, OutboxEventsResponse{EventsQueryResponse: response, OutboxRelays: outboxRelays})
+}
+
+// parseRelayList parses the "r" tags of a kind 10002 event. A tag with a
+// "read" or "write" marker is limited to that use; a tag without a marker
+// means the relay is used for both. Invalid URLs and unknown markers are
+// skipped, and each relay is listed once.
+func parseRelayList(event *types.Event) *types.RelayList {
+	list := &types.RelayList{
+		PubKey:    event.PubKey,
+		Read:      []string{},
+		Write:     []string{},
+		Both:      []string{},
+		CreatedAt: event.CreatedAt,
+		EventID:   event.ID,
+	}
+
+	seen := make(map[string]bool)
+	for _, tag := range event.Tags {
+		if len(tag) < 2 || tag[0] != "r" {
+			continue
+		}
+		url, err := config.NormalizeRelayURL(tag[1])
+		if err != nil || seen[url] {
+			continue
+		}
+
+		marker := ""
+		if len(tag) >= 3 {
+			marker = tag[2]
+		}
+
+		switch marker {
+		case "":
+			list.Both = append(list.Both, url)
+		case "read":
+			list.Read = append(list.Read, url)
+		case "write":
+			list.Write = append(list.Write, url)
+		default:
+			continue
+		}
+		seen[url] = true
+	}
+
+	return list
+}
@@ -0,0 +1,182 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+const relayListOwner = "dddd444444444444444444444444444444444444444444444444444444444444"
+
+func TestParseRelayList(t *testing.T) {
+	event := &types.Event{
+		ID:        "list1",
+		Kind:      10002,
+		PubKey:    relayListOwner,
+		CreatedAt: 1700000000,
+		Tags: [][]string{
+			{"r", "wss://both.example.com"},
+			{"r", "wss://inbox.example.com", "read"},
+			{"r", "wss://outbox.example.com/", "write"},
+			{"r", "wss://both.example.com", "read"}, // duplicate, first entry wins
+			{"r", "https://not-a-relay.example.com"},
+			{"r", "wss://odd.example.com", "sometimes"},
+			{"p", relayListOwner},
+		},
+	}
+
+	list := parseRelayList(event)
+
+	assertRelays := func(name string, got, want []string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s[%d] = %s, want %s", name, i, got[i], want[i])
+			}
+		}
+	}
+
+	assertRelays("both", list.Both, []string{"wss://both.example.com"})
+	assertRelays("read", list.Read, []string{"wss://inbox.example.com"})
+	assertRelays("write", list.Write, []string{"wss://outbox.example.com"})
+
+	if list.PubKey != relayListOwner || list.EventID != "list1" || list.CreatedAt != 1700000000 {
+		t.Errorf("unexpected list metadata: %+v", list)
+	}
+}
+
+func TestHandleProfileRelays_UsesLatestList(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			{ID: "old", Kind: 10002, PubKey: relayListOwner, CreatedAt: 1700000000, Tags: [][]string{{"r", "wss://old.example.com"}}},
+			{ID: "new", Kind: 10002, PubKey: relayListOwner, CreatedAt: 1700005000, Tags: [][]string{{"r", "wss://new.example.com", "write"}}},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/"+relayListOwner+"/relays", nil)
+	w := httptest.NewRecorder()
+
+	api.HandleProfile(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var list types.RelayList
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if list.EventID != "new" {
+		t.Errorf("expected latest relay list, got %s", list.EventID)
+	}
+	if len(list.Write) != 1 || list.Write[0] != "wss://new.example.com" || len(list.Both) != 0 {
+		t.Errorf("unexpected relay list: %+v", list)
+	}
+	if len(pool.lastAuthors) != 1 || pool.lastAuthors[0] != relayListOwner {
+		t.Errorf("expected author filter %s, got %v", relayListOwner, pool.lastAuthors)
+	}
+}
+
+func TestHandleProfileRelays_NotFound(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/"+relayListOwner+"/relays", nil)
+	w := httptest.NewRecorder()
+
+	api.HandleProfile(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleEvents_OutboxUsesAuthorWriteRelays(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			{ID: "list", Kind: 10002, PubKey: relayListOwner, CreatedAt: 1700000000, Tags: [][]string{
+				{"r", "wss://inbox.example.com", "read"},
+				{"r", "wss://outbox.example.com", "write"},
+				{"r", "wss://both.example.com"},
+			}},
+			{ID: "note", Kind: 1, PubKey: relayListOwner, CreatedAt: 1700000100},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events?kinds=1&authors="+relayListOwner+"&outbox=true", nil)
+	w := httptest.NewRecorder()
+
+	api.HandleEvents(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response OutboxEventsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	want := []string{"wss://outbox.example.com", "wss://both.example.com"}
+	if len(response.OutboxRelays) != len(want) || response.OutboxRelays[0] != want[0] || response.OutboxRelays[1] != want[1] {
+		t.Errorf("expected outbox relays %v, got %v", want, response.OutboxRelays)
+	}
+	if len(pool.lastOutboxRelays) != len(want) {
+		t.Errorf("expected outbox relays to be queried, got %v", pool.lastOutboxRelays)
+	}
+	if response.EventsQueryResponse == nil || len(response.Events) == 0 {
+		t.Error("expected events in response")
+	}
+}
+
+func TestHandleEvents_OutboxFallsBackWithoutRelayList(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{{ID: "note", Kind: 1, PubKey: relayListOwner, CreatedAt: 1700000100}},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events?authors="+relayListOwner+"&outbox=true", nil)
+	w := httptest.NewRecorder()
+
+	api.HandleEvents(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response OutboxEventsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.OutboxRelays) != 0 {
+		t.Errorf("expected no outbox relays, got %v", response.OutboxRelays)
+	}
+	if pool.lastOutboxRelays != nil {
+		t.Errorf("expected default pool to be queried, got outbox query to %v", pool.lastOutboxRelays)
+	}
+	if len(response.Warnings) != 1 {
+		t.Errorf("expected a fallback warning, got %v", response.Warnings)
+	}
+}
+
+func TestHandleEvents_OutboxRequiresSingleAuthor(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events?kinds=1&outbox=true", nil)
+	w := httptest.NewRecorder()
+
+	api.HandleEvents(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
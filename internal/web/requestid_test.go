@@ -0,0 +1,71 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestID_RoundTripsIncomingHeader(t *testing.T) {
+	var seen string
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestIDFrom(r.Context())
+		writeError(w, http.StatusBadRequest, "bad input")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+	req.Header.Set("X-Request-ID", "trace-abc-123")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Request-ID"); got != "trace-abc-123" {
+		t.Errorf("X-Request-ID response header = %q, want the incoming ID", got)
+	}
+	if seen != "trace-abc-123" {
+		t.Errorf("request ID in context = %q, want the incoming ID", seen)
+	}
+
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode error body: %v", err)
+	}
+	if body["request_id"] != "trace-abc-123" || body["error"] != "bad input" {
+		t.Errorf("unexpected error body: %v", body)
+	}
+}
+
+func TestRequestID_GeneratesIDWhenMissingOrInvalid(t *testing.T) {
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"status": "ok"})
+	}))
+
+	for _, incoming := range []string{"", "has spaces in it", string(make([]byte, maxRequestIDLength+1))} {
+		req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+		if incoming != "" {
+			req.Header.Set("X-Request-ID", incoming)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if got := w.Header().Get("X-Request-ID"); !uuidPattern.MatchString(got) {
+			t.Errorf("incoming %q: X-Request-ID = %q, want a generated UUID", incoming, got)
+		}
+	}
+}
+
+func TestWriteError_WithoutRequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(w, http.StatusNotFound, "not found")
+
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode error body: %v", err)
+	}
+	if _, ok := body["request_id"]; ok {
+		t.Errorf("expected no request_id outside the middleware, got %v", body)
+	}
+}
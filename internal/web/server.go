@@ -5,11 +5,11 @@ import (
 	"embed"
 	"encoding/json"
 	"io/fs"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/keanuklestil/shirushi/internal/logging"
 	"github.com/keanuklestil/shirushi/internal/types"
 )
 
@@ -55,6 +55,9 @@ func NewServer(addr string, staticFS fs.FS, api *API) *Server {
 		api.relayPool.SetOnRelayInfo(func(url string, info *types.RelayInfo) {
 			hub.BroadcastRelayInfo(url, info)
 		})
+
+		// Tell clients when a dead relay is pruned from the pool
+		api.relayPool.SetOnRelayPruned(hub.BroadcastRelayPruned)
 	}
 
 	return &Server{
@@ -69,40 +72,79 @@ func NewServer(addr string, staticFS fs.FS, api *API) *Server {
 func (s *Server) Start() error {
 	go s.hub.Run()
 
+	logging.Infof("[Web] Starting server at http://%s", s.addr)
+	mux := s.routes()
+	handler := bearerAuthMiddleware(s.api.cfg.APIToken, gzipMiddleware(maxBodyMiddleware(s.api.maxBodyBytes(), mux)))
+	handler = corsMiddleware(s.api.cfg.CORSAllowedOrigins, handler)
+	handler = metricsMiddleware(s.api.httpMetrics, mux, handler)
+	return http.ListenAndServe(s.addr, requestIDMiddleware(handler))
+}
+
+// routes builds the mux serving the API, the WebSocket and the dashboard.
+func (s *Server) routes() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// API routes
 	mux.HandleFunc("/api/status", s.api.HandleStatus)
+	mux.HandleFunc("/api/config", s.api.HandleConfig)
+	mux.HandleFunc("/api/diagnostics", s.api.HandleDiagnostics)
+	mux.HandleFunc("/api/stats/http", s.api.HandleHTTPStats)
 	mux.HandleFunc("/api/relays", s.api.HandleRelays)
 	mux.HandleFunc("/api/relays/stats", s.api.HandleRelayStats)
 	mux.HandleFunc("/api/relays/presets", s.api.HandleRelayPresets)
 	mux.HandleFunc("/api/relays/info", s.api.HandleRelayInfo)
+	mux.HandleFunc("/api/relays/info/diff", s.api.HandleRelayInfoDiff)
+	mux.HandleFunc("/api/relays/events", s.api.HandleRelayEvents)
+	mux.HandleFunc("/api/relays/reconnect", s.api.HandleRelayReconnect)
+	mux.HandleFunc("/api/relays/pruned", s.api.HandleRelaysPruned)
+	mux.HandleFunc("/api/relays/history", s.api.HandleRelayHistory)
+	mux.HandleFunc("/api/relays/discovered", s.api.HandleRelaysDiscovered)
+	mux.HandleFunc("/api/relays/test", s.api.HandleRelayTest)
+	mux.HandleFunc("/api/relays/capabilities", s.api.HandleRelayCapabilities)
+	mux.HandleFunc("/api/relays/groups", s.api.HandleRelayGroups)
 	mux.HandleFunc("/api/monitoring/history", s.api.HandleMonitoringHistory)
 	mux.HandleFunc("/api/monitoring/health", s.api.HandleMonitoringHealth)
+	mux.HandleFunc("/api/monitoring/kinds", s.api.HandleMonitoringKinds)
 	mux.HandleFunc("/api/events", s.api.HandleEvents)
 	mux.HandleFunc("/api/events/thread/", s.api.HandleThread)
 	mux.HandleFunc("/api/events/subscribe", s.api.HandleEventSubscribe)
+	mux.HandleFunc("/api/events/stream", s.api.HandleEventStream)
 	mux.HandleFunc("/api/nips", s.api.HandleNIPs)
+	mux.HandleFunc("/api/kinds", s.api.HandleKinds)
+	mux.HandleFunc("/api/openapi.json", s.api.HandleOpenAPI)
 	mux.HandleFunc("/api/test/history/", s.api.HandleTestHistoryEntry)
 	mux.HandleFunc("/api/test/history", s.api.HandleTestHistory)
+	mux.HandleFunc("/api/test", s.api.HandleTestList)
 	mux.HandleFunc("/api/test/", s.api.HandleTest)
 	mux.HandleFunc("/api/keys/generate", s.api.HandleKeyGenerate)
 	mux.HandleFunc("/api/keys/decode", s.api.HandleKeyDecode)
 	mux.HandleFunc("/api/keys/encode", s.api.HandleKeyEncode)
+	mux.HandleFunc("/api/decode-fetch", s.api.HandleDecodeAndFetch)
+	mux.HandleFunc("/api/convert", s.api.HandleConvert)
 	mux.HandleFunc("/api/nak", s.api.HandleNak)
 	mux.HandleFunc("/api/profile/lookup", s.api.HandleProfileLookup)
+	mux.HandleFunc("/api/nip05/verify", s.api.HandleNIP05Verify)
 	mux.HandleFunc("/api/profile/", s.api.HandleProfile)
 	mux.HandleFunc("/api/events/sign", s.api.HandleEventSign)
 	mux.HandleFunc("/api/events/verify", s.api.HandleEventVerify)
+	mux.HandleFunc("/api/events/validate", s.api.HandleEventValidate)
 	mux.HandleFunc("/api/events/publish", s.api.HandleEventPublish)
 	mux.HandleFunc("/api/events/lookup", s.api.HandleEventLookup)
+	mux.HandleFunc("/api/events/addr", s.api.HandleAddressableEvent)
 	mux.HandleFunc("/api/events/fetch-all-relays", s.api.HandleEventFetchAllRelays)
 	mux.HandleFunc("/api/events/batch-lookup", s.api.HandleBatchEventLookup)
 	mux.HandleFunc("/api/events/aggregate", s.api.HandleEventsAggregate)
+	mux.HandleFunc("/api/events/count", s.api.HandleEventsCount)
+	mux.HandleFunc("/api/events/export", s.api.HandleEventsExport)
+	mux.HandleFunc("/api/proxy/image", s.api.HandleImageProxy)
+	mux.Handle("/api/batch", batchHandler(mux))
 
 	// WebSocket
 	mux.HandleFunc("/ws", s.handleWebSocket)
 
+	// Prometheus metrics
+	mux.HandleFunc("/metrics", s.api.HandleMetrics)
+
 	// Static files
 	if s.staticFS != nil {
 		mux.Handle("/", http.FileServer(http.FS(s.staticFS)))
@@ -110,8 +152,7 @@ func (s *Server) Start() error {
 		mux.HandleFunc("/", s.handleIndex)
 	}
 
-	log.Printf("[Web] Starting server at http://%s", s.addr)
-	return http.ListenAndServe(s.addr, mux)
+	return mux
 }
 
 // Hub returns the WebSocket hub for broadcasting
@@ -123,14 +164,14 @@ func (s *Server) Hub() *Hub {
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("[Web] WebSocket upgrade error: %v", err)
+		logging.Warnf("[Web] WebSocket upgrade error: %v", err)
 		return
 	}
 
 	client := &Client{
 		hub:  s.hub,
 		conn: conn,
-		send: make(chan []byte, 256),
+		send: make(chan []byte, clientSendBuffer),
 	}
 
 	s.hub.register <- client
@@ -163,12 +204,12 @@ func (c *Client) readPump() {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("[Web] WebSocket error: %v", err)
+				logging.Warnf("[Web] WebSocket error: %v", err)
 			}
 			break
 		}
 
-		c.hub.HandleClientMessage(message)
+		c.hub.HandleClientMessage(c, message)
 	}
 }
 
@@ -220,11 +261,9 @@ func writeJSON(w http.ResponseWriter, v interface{}) {
 	json.NewEncoder(w).Encode(v)
 }
 
-// writeError writes an error response
+// writeError writes an error response with the generic code for status.
 func writeError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	writeErrorCode(w, status, statusErrorCode(status), message)
 }
 
 var EmbeddedFS embed.FS
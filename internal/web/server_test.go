@@ -177,3 +177,48 @@ func TestNewServer_NilRelayPool(t *testing.T) {
 		t.Error("expected hub to be initialized")
 	}
 }
+
+func TestNewServer_PrunedCallbackBroadcastsRelayPruned(t *testing.T) {
+	pool := &mockRelayPool{}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	server := NewServer(":0", nil, api)
+	go server.hub.Run()
+
+	if pool.prunedCallback == nil {
+		t.Fatal("expected SetOnRelayPruned to be called")
+	}
+
+	testClient := &Client{
+		hub:  server.hub,
+		send: make(chan []byte, 10),
+	}
+	server.hub.register <- testClient
+
+	// Drain the initial "init" message sent on client connection
+	select {
+	case <-testClient.send:
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	pool.prunedCallback(types.PrunedRelay{URL: "wss://dead.relay", Error: "connection refused"})
+
+	select {
+	case data := <-testClient.send:
+		var msg struct {
+			Type string            `json:"type"`
+			Data types.PrunedRelay `json:"data"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("failed to unmarshal message: %v", err)
+		}
+		if msg.Type != "relay_pruned" {
+			t.Errorf("expected message type 'relay_pruned', got %q", msg.Type)
+		}
+		if msg.Data.URL != "wss://dead.relay" {
+			t.Errorf("expected URL 'wss://dead.relay', got %q", msg.Data.URL)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("timeout waiting for relay_pruned broadcast")
+	}
+}
@@ -0,0 +1,189 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// Validation finding severities.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// maxFutureSkew is how far in the future created_at may be before it is flagged.
+const maxFutureSkew = 15 * time.Minute
+
+// ValidationFinding is a single structural issue found in an event.
+type ValidationFinding struct {
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// EventValidationResponse is returned by HandleEventValidate. Valid is false
+// when any finding has error severity; warnings alone keep an event valid.
+type EventValidationResponse struct {
+	Valid    bool                `json:"valid"`
+	Findings []ValidationFinding `json:"findings"`
+}
+
+// HandleEventValidate lints an event for NIP-01 structural problems such as
+// missing fields, malformed hex, bad tags or a mismatched ID. It runs
+// entirely offline and does not need nak.
+func (a *API) HandleEventValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBodyError(w, err, "failed to read request body")
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		writeError(w, http.StatusBadRequest, "event must be a JSON object")
+		return
+	}
+
+	findings := validateEvent(fields, time.Now())
+	writeJSON(w, EventValidationResponse{Valid: !hasErrors(findings), Findings: findings})
+}
+
+// validateEvent checks the raw fields of an event against NIP-01. The ID and
+// signature are only checked once every field is structurally sound.
+func validateEvent(fields map[string]json.RawMessage, now time.Time) []ValidationFinding {
+	findings := make([]ValidationFinding, 0)
+	add := func(field, severity, format string, args ...interface{}) {
+		findings = append(findings, ValidationFinding{
+			Field:    field,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	var ev nostr.Event
+
+	for _, field := range []string{"id", "pubkey", "created_at", "kind", "tags", "content", "sig"} {
+		if _, ok := fields[field]; !ok {
+			add(field, severityError, "missing required field")
+		}
+	}
+
+	checkHex := func(field string, length int, dest *string) {
+		raw, ok := fields[field]
+		if !ok {
+			return
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			add(field, severityError, "must be a string")
+			return
+		}
+		switch {
+		case len(value) != length:
+			add(field, severityError, "must be %d hex characters, got %d", length, len(value))
+		case !isLowerHex(value):
+			add(field, severityError, "must be lowercase hex")
+		default:
+			*dest = value
+		}
+	}
+	checkHex("id", 64, &ev.ID)
+	checkHex("pubkey", 64, &ev.PubKey)
+	checkHex("sig", 128, &ev.Sig)
+
+	if raw, ok := fields["created_at"]; ok {
+		var createdAt float64
+		if err := json.Unmarshal(raw, &createdAt); err != nil || createdAt != math.Trunc(createdAt) {
+			add("created_at", severityError, "must be an integer Unix timestamp")
+		} else if createdAt < 0 {
+			add("created_at", severityError, "must not be negative")
+		} else {
+			ev.CreatedAt = nostr.Timestamp(createdAt)
+			if time.Unix(int64(createdAt), 0).After(now.Add(maxFutureSkew)) {
+				add("created_at", severityWarning, "is in the future")
+			}
+		}
+	}
+
+	if raw, ok := fields["kind"]; ok {
+		var kind float64
+		if err := json.Unmarshal(raw, &kind); err != nil || kind != math.Trunc(kind) {
+			add("kind", severityError, "must be an integer")
+		} else if kind < 0 || kind > 65535 {
+			add("kind", severityError, "must be between 0 and 65535")
+		} else {
+			ev.Kind = int(kind)
+		}
+	}
+
+	if raw, ok := fields["tags"]; ok {
+		var tags []json.RawMessage
+		if err := json.Unmarshal(raw, &tags); err != nil || tags == nil {
+			add("tags", severityError, "must be an array of arrays of strings")
+		} else {
+			ev.Tags = make(nostr.Tags, 0, len(tags))
+			for i, rawTag := range tags {
+				var tag []string
+				if err := json.Unmarshal(rawTag, &tag); err != nil || tag == nil {
+					add(fmt.Sprintf("tags[%d]", i), severityError, "must be an array of strings")
+					continue
+				}
+				if len(tag) == 0 {
+					add(fmt.Sprintf("tags[%d]", i), severityWarning, "is empty")
+				}
+				ev.Tags = append(ev.Tags, tag)
+			}
+		}
+	}
+
+	if raw, ok := fields["content"]; ok {
+		if err := json.Unmarshal(raw, &ev.Content); err != nil {
+			add("content", severityError, "must be a string")
+		}
+	}
+
+	if hasErrors(findings) {
+		return findings
+	}
+
+	if ev.GetID() != ev.ID {
+		add("id", severityError, "does not match the hash of the event")
+		return findings
+	}
+	if ok, err := ev.CheckSignature(); err != nil || !ok {
+		add("sig", severityError, "signature does not verify")
+	}
+
+	return findings
+}
+
+// hasErrors reports whether any finding has error severity.
+func hasErrors(findings []ValidationFinding) bool {
+	for _, f := range findings {
+		if f.Severity == severityError {
+			return true
+		}
+	}
+	return false
+}
+
+// isLowerHex reports whether s contains only lowercase hex digits.
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func postValidate(t *testing.T, body string) (int, EventValidationResponse) {
+	t.Helper()
+	api := NewAPI(&config.Config{}, nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/events/validate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	api.HandleEventValidate(w, req)
+
+	var resp EventValidationResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestHandleEventValidate_CleanEvent(t *testing.T) {
+	ev := nostr.Event{
+		Kind:      1,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{{"t", "nostr"}},
+		Content:   "hello",
+	}
+	if err := ev.Sign(nostr.GeneratePrivateKey()); err != nil {
+		t.Fatalf("failed to sign event: %v", err)
+	}
+	body, _ := json.Marshal(ev)
+
+	code, resp := postValidate(t, string(body))
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if !resp.Valid || len(resp.Findings) != 0 {
+		t.Errorf("expected a valid event with no findings, got %+v", resp)
+	}
+}
+
+func TestHandleEventValidate_ReportsDefects(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	body := `{
+		"id": "ABCDEF",
+		"pubkey": "` + strings.Repeat("A", 64) + `",
+		"created_at": ` + strconv.FormatInt(future, 10) + `,
+		"kind": 70000,
+		"tags": [["p", "x"], ["e", 5], []],
+		"content": "hi"
+	}`
+
+	code, resp := postValidate(t, body)
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if resp.Valid {
+		t.Error("expected the event to be invalid")
+	}
+
+	want := map[string]string{
+		"id":         severityError,
+		"pubkey":     severityError,
+		"sig":        severityError,
+		"created_at": severityWarning,
+		"kind":       severityError,
+		"tags[1]":    severityError,
+		"tags[2]":    severityWarning,
+	}
+	got := make(map[string]string)
+	for _, f := range resp.Findings {
+		got[f.Field] = f.Severity
+	}
+	for field, severity := range want {
+		if got[field] != severity {
+			t.Errorf("finding for %s = %q, want %q (findings: %+v)", field, got[field], severity, resp.Findings)
+		}
+	}
+}
+
+func TestHandleEventValidate_TamperedContent(t *testing.T) {
+	ev := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Tags: nostr.Tags{}, Content: "original"}
+	if err := ev.Sign(nostr.GeneratePrivateKey()); err != nil {
+		t.Fatalf("failed to sign event: %v", err)
+	}
+	ev.Content = "tampered"
+	body, _ := json.Marshal(ev)
+
+	_, resp := postValidate(t, string(body))
+	if resp.Valid || len(resp.Findings) != 1 || resp.Findings[0].Field != "id" {
+		t.Errorf("expected a single id mismatch finding, got %+v", resp)
+	}
+}
+
+func TestHandleEventValidate_InvalidJSON(t *testing.T) {
+	if code, _ := postValidate(t, `["not", "an", "object"]`); code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, code)
+	}
+}
@@ -0,0 +1,240 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+const (
+	// defaultZapLimit is the number of zap receipts fetched when no limit is given.
+	defaultZapLimit = 100
+	// maxRecentZaps is the number of most recent zaps included in the stats.
+	maxRecentZaps = 10
+)
+
+// HandleProfileZaps returns NIP-57 zap statistics for a profile.
+// Path: /api/profile/{pubkey}/zaps
+// Accepts an optional limit query param (default 100, capped at MaxQueryLimit) for the
+// number of zap receipts to aggregate.
+func (a *API) HandleProfileZaps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/profile/")
+	path = strings.TrimSuffix(path, "/zaps")
+	if strings.TrimSpace(path) == "" {
+		writeError(w, http.StatusBadRequest, "pubkey is required in path")
+		return
+	}
+
+	pubkey, status, err := a.resolvePubkey(strings.TrimSpace(path))
+	if err != nil {
+		writeErrorCode(w, status, pubkeyErrorCode(status), err.Error())
+		return
+	}
+
+	limit := defaultZapLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit value")
+			return
+		}
+		if maxLimit := a.maxQueryLimit(); l > maxLimit {
+			l = maxLimit
+		}
+		limit = l
+	}
+
+	// Query kind 9735 (zap receipt) events tagging this pubkey
+	tags := map[string][]string{"p": {pubkey}}
+	events, err := a.relayPool.QueryEventsAdvanced([]int{9735}, nil, tags, limit, 0, 0)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query zaps: "+err.Error())
+		return
+	}
+
+	writeJSON(w, buildZapStats(pubkey, events))
+}
+
+// buildZapStats aggregates zap receipts into ZapStats. Receipts whose amount
+// cannot be determined are skipped and counted in Skipped.
+func buildZapStats(pubkey string, events []types.Event) *types.ZapStats {
+	stats := &types.ZapStats{
+		PubKey:      pubkey,
+		RecentZaps:  []types.ZapEvent{},
+		LastUpdated: time.Now().Unix(),
+	}
+
+	seen := make(map[string]bool)
+	var zaps []types.ZapEvent
+	for _, event := range events {
+		if seen[event.ID] {
+			continue
+		}
+		seen[event.ID] = true
+
+		zap, err := parseZapReceipt(event)
+		if err != nil {
+			stats.Skipped++
+			continue
+		}
+		zaps = append(zaps, zap)
+
+		stats.TotalZaps++
+		stats.TotalSats += zap.Amount
+		if zap.Amount > stats.TopZap {
+			stats.TopZap = zap.Amount
+		}
+	}
+
+	if stats.TotalZaps > 0 {
+		stats.AvgSats = stats.TotalSats / int64(stats.TotalZaps)
+	}
+
+	sort.Slice(zaps, func(i, j int) bool {
+		return zaps[i].CreatedAt > zaps[j].CreatedAt
+	})
+	if len(zaps) > maxRecentZaps {
+		zaps = zaps[:maxRecentZaps]
+	}
+	stats.RecentZaps = append(stats.RecentZaps, zaps...)
+
+	return stats
+}
+
+// parseZapReceipt extracts a ZapEvent from a kind 9735 zap receipt.
+// The amount is taken from the bolt11 invoice, falling back to the amount
+// tag of the embedded zap request for invoices without an amount.
+func parseZapReceipt(event types.Event) (types.ZapEvent, error) {
+	zap := types.ZapEvent{
+		EventID:   event.ID,
+		CreatedAt: event.CreatedAt,
+	}
+
+	var bolt11, description string
+	for _, tag := range event.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "bolt11":
+			bolt11 = tag[1]
+		case "description":
+			description = tag[1]
+		case "p":
+			zap.Receiver = tag[1]
+		case "P":
+			zap.Sender = tag[1]
+		}
+	}
+
+	if bolt11 == "" {
+		return zap, fmt.Errorf("missing bolt11 tag")
+	}
+
+	// The description tag holds the JSON-encoded zap request (kind 9734)
+	var requestAmount int64
+	if description != "" {
+		var request struct {
+			PubKey  string     `json:"pubkey"`
+			Content string     `json:"content"`
+			Tags    [][]string `json:"tags"`
+		}
+		if err := json.Unmarshal([]byte(description), &request); err != nil {
+			return zap, fmt.Errorf("invalid zap request description: %w", err)
+		}
+		if request.PubKey != "" {
+			zap.Sender = request.PubKey
+		}
+		zap.Content = request.Content
+		for _, tag := range request.Tags {
+			if len(tag) >= 2 && tag[0] == "amount" {
+				if msats, err := strconv.ParseInt(tag[1], 10, 64); err == nil && msats > 0 {
+					requestAmount = msats / 1000
+				}
+			}
+		}
+	}
+
+	amount, err := parseBolt11Amount(bolt11)
+	if err != nil {
+		return zap, err
+	}
+	if amount == 0 {
+		amount = requestAmount
+	}
+	if amount <= 0 {
+		return zap, fmt.Errorf("zap amount not specified")
+	}
+	zap.Amount = amount
+
+	return zap, nil
+}
+
+// parseBolt11Amount returns the amount in sats encoded in a BOLT-11 invoice's
+// human-readable part (e.g. "lnbc2500u1..." is 250000 sats). Invoices without
+// an amount return 0. Sub-satoshi amounts are rounded down.
+func parseBolt11Amount(invoice string) (int64, error) {
+	invoice = strings.ToLower(strings.TrimSpace(invoice))
+	invoice = strings.TrimPrefix(invoice, "lightning:")
+	if !strings.HasPrefix(invoice, "ln") {
+		return 0, fmt.Errorf("invalid bolt11 invoice: missing ln prefix")
+	}
+
+	// The human-readable part ends at the last "1" separator
+	sep := strings.LastIndex(invoice, "1")
+	if sep < 0 {
+		return 0, fmt.Errorf("invalid bolt11 invoice: missing separator")
+	}
+	hrp := invoice[2:sep]
+
+	// Skip the currency prefix (bc, tb, bcrt, ...)
+	i := 0
+	for i < len(hrp) && hrp[i] >= 'a' && hrp[i] <= 'z' {
+		i++
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid bolt11 invoice: missing currency prefix")
+	}
+	amountPart := hrp[i:]
+	if amountPart == "" {
+		return 0, nil
+	}
+
+	multiplier := byte(0)
+	if last := amountPart[len(amountPart)-1]; last < '0' || last > '9' {
+		multiplier = last
+		amountPart = amountPart[:len(amountPart)-1]
+	}
+
+	value, err := strconv.ParseInt(amountPart, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid bolt11 amount %q", amountPart)
+	}
+
+	// Convert from BTC (scaled by the multiplier) to sats
+	switch multiplier {
+	case 0:
+		return value * 100_000_000, nil
+	case 'm':
+		return value * 100_000, nil
+	case 'u':
+		return value * 100, nil
+	case 'n':
+		return value / 10, nil
+	case 'p':
+		return value / 10_000, nil
+	default:
+		return 0, fmt.Errorf("invalid bolt11 multiplier %q", string(multiplier))
+	}
+}
@@ -0,0 +1,182 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keanuklestil/shirushi/internal/config"
+	"github.com/keanuklestil/shirushi/internal/types"
+)
+
+const (
+	zapRecipient = "aaaa111111111111111111111111111111111111111111111111111111111111"
+	zapSender    = "bbbb222222222222222222222222222222222222222222222222222222222222"
+)
+
+// sampleZapReceipt builds a kind 9735 zap receipt with the given invoice.
+func sampleZapReceipt(id, bolt11 string, createdAt int64) types.Event {
+	request := `{"kind":9734,"pubkey":"` + zapSender + `","content":"great post","tags":[["p","` + zapRecipient + `"],["amount","21000"]]}`
+	return types.Event{
+		ID:        id,
+		Kind:      9735,
+		PubKey:    "cccc333333333333333333333333333333333333333333333333333333333333",
+		CreatedAt: createdAt,
+		Tags: [][]string{
+			{"p", zapRecipient},
+			{"bolt11", bolt11},
+			{"description", request},
+		},
+	}
+}
+
+func TestParseBolt11Amount(t *testing.T) {
+	testCases := []struct {
+		invoice string
+		want    int64
+		wantErr bool
+	}{
+		{"lnbc2500u1pvjluezpp5qqqsyqcyq5rqwzqf", 250000, false},
+		{"lnbc20m1pvjluezpp5qqqsyqcyq5rqwzqf", 2000000, false},
+		{"lnbc1000n1pvjluezpp5qqqsyqcyq5rqwzqf", 100, false},
+		{"lnbc210000p1pvjluezpp5qqqsyqcyq5rqwzqf", 21, false},
+		{"lntb1u1pvjluezpp5qqqsyqcyq5rqwzqf", 100, false},
+		{"LNBC10U1PVJLUEZPP5QQQSYQCYQ5RQWZQF", 1000, false},
+		{"lnbc1pvjluezpp5qqqsyqcyq5rqwzqf", 0, false},
+		{"notaninvoice", 0, true},
+		{"lnbc25x1pvjluezpp5qqqsyqcyq5rqwzqf", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.invoice, func(t *testing.T) {
+			got, err := parseBolt11Amount(tc.invoice)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q, got amount %d", tc.invoice, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("parseBolt11Amount(%q) = %d, want %d", tc.invoice, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseZapReceipt_FallsBackToRequestAmount(t *testing.T) {
+	// Invoice without an amount uses the zap request's amount tag (in millisats)
+	zap, err := parseZapReceipt(sampleZapReceipt("zap1", "lnbc1pvjluezpp5qqqsyqcyq5rqwzqf", 1700000000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if zap.Amount != 21 {
+		t.Errorf("expected amount 21, got %d", zap.Amount)
+	}
+	if zap.Sender != zapSender {
+		t.Errorf("expected sender %s, got %s", zapSender, zap.Sender)
+	}
+	if zap.Receiver != zapRecipient {
+		t.Errorf("expected receiver %s, got %s", zapRecipient, zap.Receiver)
+	}
+	if zap.Content != "great post" {
+		t.Errorf("expected content 'great post', got %q", zap.Content)
+	}
+}
+
+func TestBuildZapStats(t *testing.T) {
+	events := []types.Event{
+		sampleZapReceipt("zap1", "lnbc10u1pvjluezpp5qqqsyqcyq5rqwzqf", 1700000000),  // 1000 sats
+		sampleZapReceipt("zap2", "lnbc50u1pvjluezpp5qqqsyqcyq5rqwzqf", 1700000200),  // 5000 sats
+		sampleZapReceipt("zap3", "lnbc210n1pvjluezpp5qqqsyqcyq5rqwzqf", 1700000100), // 21 sats
+		{ID: "bad1", Kind: 9735, Tags: [][]string{{"p", zapRecipient}}},             // missing bolt11
+		{ID: "bad2", Kind: 9735, Tags: [][]string{{"bolt11", "garbage"}}},           // malformed invoice
+	}
+
+	stats := buildZapStats(zapRecipient, events)
+
+	if stats.TotalZaps != 3 {
+		t.Errorf("expected 3 zaps, got %d", stats.TotalZaps)
+	}
+	if stats.TotalSats != 6021 {
+		t.Errorf("expected 6021 total sats, got %d", stats.TotalSats)
+	}
+	if stats.AvgSats != 2007 {
+		t.Errorf("expected 2007 avg sats, got %d", stats.AvgSats)
+	}
+	if stats.TopZap != 5000 {
+		t.Errorf("expected top zap 5000, got %d", stats.TopZap)
+	}
+	if stats.Skipped != 2 {
+		t.Errorf("expected 2 skipped receipts, got %d", stats.Skipped)
+	}
+	if len(stats.RecentZaps) != 3 || stats.RecentZaps[0].EventID != "zap2" {
+		t.Errorf("expected recent zaps sorted newest first, got %+v", stats.RecentZaps)
+	}
+}
+
+func TestHandleProfileZaps_Success(t *testing.T) {
+	pool := &mockRelayPool{
+		events: []types.Event{
+			sampleZapReceipt("zap1", "lnbc10u1pvjluezpp5qqqsyqcyq5rqwzqf", 1700000000),
+			{ID: "bad1", Kind: 9735},
+		},
+	}
+	api := NewAPI(&config.Config{}, nil, pool, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/"+zapRecipient+"/zaps", nil)
+	w := httptest.NewRecorder()
+
+	api.HandleProfile(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var stats types.ZapStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if stats.PubKey != zapRecipient {
+		t.Errorf("expected pubkey %s, got %s", zapRecipient, stats.PubKey)
+	}
+	if stats.TotalZaps != 1 || stats.TotalSats != 1000 {
+		t.Errorf("expected 1 zap of 1000 sats, got %d zaps totalling %d", stats.TotalZaps, stats.TotalSats)
+	}
+	if stats.Skipped != 1 {
+		t.Errorf("expected 1 skipped receipt, got %d", stats.Skipped)
+	}
+	if pool.lastLimit != defaultZapLimit {
+		t.Errorf("expected default limit %d, got %d", defaultZapLimit, pool.lastLimit)
+	}
+}
+
+func TestHandleProfileZaps_InvalidPubkey(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/notahexkey/zaps", nil)
+	w := httptest.NewRecorder()
+
+	api.HandleProfile(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandleProfile_UnknownResource(t *testing.T) {
+	api := NewAPI(&config.Config{}, nil, &mockRelayPool{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/"+zapRecipient+"/unknown", nil)
+	w := httptest.NewRecorder()
+
+	api.HandleProfile(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
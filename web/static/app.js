@@ -14,6 +14,9 @@ class Shirushi {
 
         this.currentProfile = null;
 
+        // Set from /api/config; profile images go through the proxy when on
+        this.imageProxyEnabled = false;
+
         // Monitoring state
         this.monitoringData = null;
         this.healthScoreHistory = {};
@@ -589,6 +592,9 @@ class Shirushi {
             case 'relay_info':
                 this.updateRelayInfo(data.data);
                 break;
+            case 'relay_pruned':
+                this.handleRelayPruned(data.data);
+                break;
             case 'test_result':
                 this.showTestResult(data.data);
                 break;
@@ -746,7 +752,28 @@ class Shirushi {
     }
 
     async loadInitialData() {
-        await this.loadRelays();
+        await Promise.all([this.loadRelays(), this.loadFeatures()]);
+    }
+
+    async loadFeatures() {
+        try {
+            const response = await fetch('/api/config');
+            const config = await response.json();
+            this.imageProxyEnabled = !!(config.features && config.features.image_proxy);
+        } catch (error) {
+            console.error('Failed to load config:', error);
+        }
+    }
+
+    /**
+     * Return the URL to load a remote image from: the server-side proxy
+     * when it is enabled, so the viewer's IP isn't sent to the image host.
+     */
+    imageURL(url) {
+        if (!this.imageProxyEnabled || !/^https?:\/\//i.test(url)) {
+            return url;
+        }
+        return `/api/proxy/image?url=${encodeURIComponent(url)}`;
     }
 
     async loadRelays() {
@@ -1319,6 +1346,11 @@ class Shirushi {
         }
     }
 
+    handleRelayPruned(pruned) {
+        this.toastWarning('Relay removed', `${pruned.url} kept failing to connect and was pruned`);
+        this.loadRelays();
+    }
+
     updateRelayInfo(data) {
         const relay = this.relays.find(r => r.url === data.url);
         if (relay) {
@@ -1441,7 +1473,7 @@ class Shirushi {
         // Set banner
         const banner = document.getElementById('profile-banner');
         if (profile.banner) {
-            banner.style.backgroundImage = `url(${profile.banner})`;
+            banner.style.backgroundImage = `url(${this.imageURL(profile.banner)})`;
         } else {
             banner.style.backgroundImage = '';
         }
@@ -1449,7 +1481,7 @@ class Shirushi {
         // Set avatar
         const avatar = document.getElementById('profile-avatar');
         if (profile.picture) {
-            avatar.style.backgroundImage = `url(${profile.picture})`;
+            avatar.style.backgroundImage = `url(${this.imageURL(profile.picture)})`;
         } else {
             avatar.style.backgroundImage = '';
             avatar.textContent = (profile.name || profile.pubkey || '?')[0].toUpperCase();
@@ -7918,6 +7950,7 @@ class Shirushi {
             <div class="thread-info">
                 <span>Max depth: ${thread.max_depth}</span>
                 <span>Events: ${thread.total_size}</span>
+                ${thread.more_replies ? '<span>More replies available</span>' : ''}
             </div>
         `;
 